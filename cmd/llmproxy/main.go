@@ -1,24 +1,33 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-logr/logr"
 	"github.com/spf13/cobra"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
-	"github.com/shengyanli1982/gs"
 	"github.com/shengyanli1982/law"
 	"github.com/shengyanli1982/llmproxy-go/internal/config"
 	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/shengyanli1982/llmproxy-go/internal/logging"
 	"github.com/shengyanli1982/llmproxy-go/internal/server"
 	"github.com/shengyanli1982/orbit/utils/log"
 )
 
-// Version 通过 ldflags 在编译时设置
-var Version = constants.DefaultVersion
+// 构建信息通过 ldflags 在编译时设置
+var (
+	Version   = constants.DefaultVersion
+	GitCommit = constants.DefaultBuildValue
+	BuildTime = constants.DefaultBuildValue
+)
 
 const ASCII_LOGO = `
 ██╗     ██╗     ███╗   ███╗██████╗ ██████╗  ██████╗ ██╗  ██╗██╗   ██╗
@@ -47,6 +56,7 @@ const ASCII_LOGO = `
 type ServiceContext struct {
 	logger      *logr.Logger      // 日志记录器
 	asyncWriter *law.WriteAsyncer // 异步写入器
+	logLevel    zap.AtomicLevel   // 运行时日志级别
 	config      *config.Config    // 服务配置
 	configMgr   *config.Manager   // 配置管理器
 	proxyServer *server.Server    // 代理服务器
@@ -58,41 +68,43 @@ func isReleaseMode(releaseMode bool) bool {
 	return releaseMode || gin.Mode() == gin.ReleaseMode
 }
 
-// initLogger 初始化日志系统
+// initLogger 初始化日志系统，返回的日志级别可通过管理接口在运行时调整
 // releaseMode: 是否为发布模式
 // jsonOutput: 是否输出 JSON 格式日志
-func initLogger(releaseMode, jsonOutput bool) (*logr.Logger, *law.WriteAsyncer) {
+func initLogger(releaseMode, jsonOutput bool) (*logr.Logger, *law.WriteAsyncer, zap.AtomicLevel) {
 	var (
 		logger      *logr.Logger
 		asyncWriter *law.WriteAsyncer
 	)
 
+	level := logging.NewAtomicLevel(isReleaseMode(releaseMode))
+
 	// 在发布模式下使用异步写入器
 	if isReleaseMode(releaseMode) {
 		asyncWriter = law.NewWriteAsyncer(os.Stdout, law.DefaultConfig())
 		if jsonOutput {
 			// JSON 格式输出使用 ZapLogger
-			logger = log.NewZapLogger(zapcore.AddSync(asyncWriter), releaseMode).GetLogrLogger()
+			logger = logging.NewZapLogger(zapcore.AddSync(asyncWriter), level)
 		} else {
 			// 普通格式输出使用 LogrLogger
-			logger = log.NewLogrLogger(asyncWriter, releaseMode).GetLogrLogger()
+			logger = logging.WithLevel(log.NewLogrLogger(asyncWriter, releaseMode).GetLogrLogger(), level)
 		}
-		return logger, asyncWriter
+		return logger, asyncWriter, level
 	}
 
 	// 开发模式直接使用标准输出
-	logger = log.NewLogrLogger(os.Stdout, releaseMode).GetLogrLogger()
-	return logger, nil
+	logger = logging.WithLevel(log.NewLogrLogger(os.Stdout, releaseMode).GetLogrLogger(), level)
+	return logger, nil, level
 }
 
 // initConfig 初始化配置管理器
-// configPath: 配置文件路径
+// configPath: 配置文件路径、配置目录或以逗号分隔的多个配置文件路径
 func initConfig(configPath string) (*config.Manager, *config.Config, error) {
 	configManager, err := config.NewManager()
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create configuration manager: %w", err)
 	}
-	if err := configManager.LoadFromFile(configPath); err != nil {
+	if err := configManager.LoadFromPath(configPath); err != nil {
 		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
@@ -101,21 +113,64 @@ func initConfig(configPath string) (*config.Manager, *config.Config, error) {
 }
 
 // setupGracefulShutdown 设置优雅关闭机制
+// SIGTERM 先停止接收新请求并等待进行中的请求完成（最长 shutdownTimeout），再停止服务；
+// SIGINT/SIGQUIT 立即停止服务；关闭过程中再次收到信号时强制退出
 // ctx: 服务上下文
 // releaseMode: 是否为发布模式
 func setupGracefulShutdown(ctx *ServiceContext, releaseMode bool) {
-	// 创建服务器终止信号
-	serverSignal := gs.NewTerminateSignal()
-	serverSignal.RegisterCancelHandles(ctx.proxyServer.Stop)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	defer signal.Stop(signals)
+
+	sig := <-signals
+	ctx.logger.Info("Received shutdown signal", "signal", sig.String())
+
+	// 在独立协程中关闭服务，以便继续响应第二次信号
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		if sig == syscall.SIGTERM {
+			timeout := time.Duration(ctx.config.HTTPServer.ShutdownTimeout) * time.Millisecond
+			drainCtx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			ctx.proxyServer.Drain(drainCtx)
+		}
 
-	// 创建写入器终止信号
-	writerSignal := gs.NewTerminateSignal()
-	if isReleaseMode(releaseMode) && ctx.asyncWriter != nil {
-		writerSignal.RegisterCancelHandles(ctx.asyncWriter.Stop)
+		ctx.proxyServer.Stop()
+	}()
+
+	select {
+	case <-done:
+		stopAsyncWriter(ctx, releaseMode)
+	case sig = <-signals:
+		ctx.logger.Info("Received second shutdown signal, forcing exit", "signal", sig.String())
+		stopAsyncWriter(ctx, releaseMode)
+		os.Exit(constants.ExitFailure)
 	}
+}
 
-	// 等待所有终止信号完成
-	gs.WaitForSync(serverSignal, writerSignal)
+// setupConfigReload 收到 SIGHUP 时重新加载配置文件并应用转发服务 TLS 证书的变更，包含需要重启的变更或失败时继续使用当前配置
+// ctx: 服务上下文
+func setupConfigReload(ctx *ServiceContext) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		for range signals {
+			ctx.logger.Info("Received reload signal", "signal", syscall.SIGHUP.String())
+			_ = ctx.proxyServer.Reload(ctx.configMgr)
+		}
+	}()
+}
+
+// stopAsyncWriter 停止异步写入器，确保缓冲的日志全部输出
+// ctx: 服务上下文
+// releaseMode: 是否为发布模式
+func stopAsyncWriter(ctx *ServiceContext, releaseMode bool) {
+	if isReleaseMode(releaseMode) && ctx.asyncWriter != nil {
+		ctx.asyncWriter.Stop()
+	}
 }
 
 func main() {
@@ -167,7 +222,7 @@ Repository: https://github.com/shengyanli1982/llmproxy-go`,
 			ctx := &ServiceContext{}
 
 			// 初始化日志系统
-			ctx.logger, ctx.asyncWriter = initLogger(releaseMode, jsonOutput)
+			ctx.logger, ctx.asyncWriter, ctx.logLevel = initLogger(releaseMode, jsonOutput)
 
 			// 加载服务配置
 			var err error
@@ -177,6 +232,7 @@ Repository: https://github.com/shengyanli1982/llmproxy-go`,
 				return err
 			}
 
+			configLoadedAt := time.Now()
 			ctx.logger.Info("Configuration loaded successfully", "path", ctx.configMgr.GetConfigPath())
 
 			// 输出 ASCII 标志（只有在配置加载成功后才显示）
@@ -184,11 +240,20 @@ Repository: https://github.com/shengyanli1982/llmproxy-go`,
 
 			// 创建代理服务器
 			ctx.proxyServer = server.NewServer(!releaseMode, ctx.logger, &ctx.config.HTTPServer, ctx.config)
+			ctx.proxyServer.SetBuildInfo(server.BuildInfo{Version: Version, GitCommit: GitCommit, BuildTime: BuildTime})
+			ctx.proxyServer.SetConfigInfo(server.ConfigInfo{Path: ctx.configMgr.GetConfigPath(), LoadedAt: configLoadedAt})
+			ctx.proxyServer.SetLogLevel(ctx.logLevel)
 
 			// 启动代理服务
 			ctx.proxyServer.Start()
 			ctx.logger.Info("LLMProxy started successfully")
 
+			// 输出生效的路由拓扑，便于启动后立即发现配置错误
+			ctx.proxyServer.LogTopology()
+
+			// 设置配置重新加载
+			setupConfigReload(ctx)
+
 			// 设置优雅关闭机制
 			setupGracefulShutdown(ctx, releaseMode)
 
@@ -198,7 +263,7 @@ Repository: https://github.com/shengyanli1982/llmproxy-go`,
 	}
 
 	// 注册命令行参数
-	cmd.Flags().StringVarP(&configPath, constants.FlagConfig, constants.FlagConfigShort, constants.DefaultConfigPath, "Path to configuration file")
+	cmd.Flags().StringVarP(&configPath, constants.FlagConfig, constants.FlagConfigShort, constants.DefaultConfigPath, "Path to configuration file, directory of *.yaml files, or comma-separated list of files merged in order")
 	cmd.Flags().BoolVarP(&jsonOutput, constants.FlagJSON, constants.FlagJSONShort, false, "Enable JSON format logging output (only effective in release mode)")
 	cmd.Flags().BoolVarP(&releaseMode, constants.FlagRelease, constants.FlagReleaseShort, false, "Enable release mode for performance optimizations and async logging")
 
@@ -0,0 +1,114 @@
+// Package activation 获取由进程管理器（如 systemd socket activation）传递给进程的监听套接字
+//
+// 转发服务与套接字的对应规则：
+//   - LLMPROXY_FORWARD_FD 中 name=fd 的 name 与转发服务名称一致时使用该文件描述符，
+//     未指定名称的 fd 适用于所有转发服务，仅适合只有一个转发服务使用继承套接字的场景
+//   - LISTEN_FDS 传递的套接字从文件描述符 3 开始依次排列，LISTEN_FDNAMES 中的名称
+//     （即 socket 单元的 FileDescriptorName）与转发服务名称一致时使用对应的套接字；
+//     没有名称匹配且只传递了一个套接字时，使用该套接字
+//
+// LISTEN_PID 与当前进程不一致时忽略 LISTEN_FDS，LLMPROXY_FORWARD_FD 优先于 LISTEN_FDS
+package activation
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+var (
+	// files 缓存已打开的继承文件描述符
+	// os.File 被回收时会关闭文件描述符，因此每个文件描述符只创建一次并一直持有，服务器重启时从同一套接字重新创建监听器
+	files   = make(map[int]*os.File)
+	filesMu sync.Mutex
+)
+
+// Lookup 查找转发服务对应的继承套接字文件描述符
+// name: 转发服务名称
+func Lookup(name string) (int, bool) {
+	if value := os.Getenv(constants.EnvForwardFD); value != "" {
+		if fd, ok := lookupForwardFD(value, name); ok {
+			return fd, true
+		}
+	}
+	return lookupListenFDs(name)
+}
+
+// lookupForwardFD 从 LLMPROXY_FORWARD_FD 中查找转发服务对应的文件描述符，名称匹配的条目优先
+func lookupForwardFD(value, name string) (int, bool) {
+	fallback := -1
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		itemName, itemFD, named := strings.Cut(item, "=")
+		if !named {
+			itemName, itemFD = "", item
+		}
+
+		fd, err := strconv.Atoi(strings.TrimSpace(itemFD))
+		if err != nil || fd < 0 {
+			continue
+		}
+		if !named {
+			if fallback < 0 {
+				fallback = fd
+			}
+			continue
+		}
+		if strings.TrimSpace(itemName) == name {
+			return fd, true
+		}
+	}
+	return fallback, fallback >= 0
+}
+
+// lookupListenFDs 从 systemd socket activation 传递的套接字中查找转发服务对应的文件描述符
+func lookupListenFDs(name string) (int, bool) {
+	if pid, err := strconv.Atoi(os.Getenv(constants.EnvListenPID)); err != nil || pid != os.Getpid() {
+		return 0, false
+	}
+	count, err := strconv.Atoi(os.Getenv(constants.EnvListenFDs))
+	if err != nil || count <= 0 {
+		return 0, false
+	}
+
+	if names := os.Getenv(constants.EnvListenFDNames); names != "" {
+		for i, fdName := range strings.Split(names, ":") {
+			if i < count && fdName == name {
+				return constants.ListenFDsStart + i, true
+			}
+		}
+	}
+	if count == 1 {
+		return constants.ListenFDsStart, true
+	}
+	return 0, false
+}
+
+// Listener 从转发服务对应的继承套接字创建监听器，每次调用返回新的监听器，关闭监听器不影响继承的套接字
+// 未找到对应的套接字时返回 false
+// name: 转发服务名称
+func Listener(name string) (net.Listener, bool, error) {
+	fd, ok := Lookup(name)
+	if !ok {
+		return nil, false, nil
+	}
+
+	filesMu.Lock()
+	file, exists := files[fd]
+	if !exists {
+		file = os.NewFile(uintptr(fd), fmt.Sprintf("listen-fd-%d", fd))
+		files[fd] = file
+	}
+	filesMu.Unlock()
+
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to use inherited socket fd %d: %w", fd, err)
+	}
+	return listener, true, nil
+}
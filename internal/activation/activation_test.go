@@ -0,0 +1,96 @@
+package activation
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setEnv 设置测试使用的套接字继承环境变量
+func setEnv(t *testing.T, forwardFD, listenFDs, listenPID, listenFDNames string) {
+	t.Helper()
+	t.Setenv(constants.EnvForwardFD, forwardFD)
+	t.Setenv(constants.EnvListenFDs, listenFDs)
+	t.Setenv(constants.EnvListenPID, listenPID)
+	t.Setenv(constants.EnvListenFDNames, listenFDNames)
+}
+
+func TestLookup(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+
+	tests := []struct {
+		name          string
+		forwardFD     string
+		listenFDs     string
+		listenPID     string
+		listenFDNames string
+		forward       string
+		wantFD        int
+		wantOK        bool
+	}{
+		{name: "nothing inherited", forward: "api"},
+		{name: "forward fd by name", forwardFD: "chat=7, api=8", forward: "api", wantFD: 8, wantOK: true},
+		{name: "unnamed forward fd", forwardFD: "9", forward: "api", wantFD: 9, wantOK: true},
+		{name: "named forward fd preferred over unnamed", forwardFD: "9,api=8", forward: "api", wantFD: 8, wantOK: true},
+		{name: "invalid forward fd ignored", forwardFD: "api=x", forward: "api"},
+		{name: "listen fds by name", listenFDs: "2", listenPID: pid, listenFDNames: "chat:api", forward: "api", wantFD: 4, wantOK: true},
+		{name: "single listen fd without matching name", listenFDs: "1", listenPID: pid, listenFDNames: "llmproxy.socket", forward: "api", wantFD: 3, wantOK: true},
+		{name: "multiple listen fds without matching name", listenFDs: "2", listenPID: pid, listenFDNames: "a:b", forward: "api"},
+		{name: "listen fds for another process", listenFDs: "1", listenPID: "1", forward: "api"},
+		{name: "forward fd preferred over listen fds", forwardFD: "api=8", listenFDs: "1", listenPID: pid, forward: "api", wantFD: 8, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.forwardFD, tt.listenFDs, tt.listenPID, tt.listenFDNames)
+
+			fd, ok := Lookup(tt.forward)
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.Equal(t, tt.wantFD, fd)
+			}
+		})
+	}
+}
+
+func TestListener(t *testing.T) {
+	socket, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer socket.Close()
+	file, err := socket.(*net.TCPListener).File()
+	require.NoError(t, err)
+	defer file.Close()
+
+	setEnv(t, "api="+strconv.Itoa(int(file.Fd())), "", "", "")
+
+	// 每次调用返回新的监听器，关闭后可以从同一套接字重新创建
+	for i := 0; i < 2; i++ {
+		listener, ok, err := Listener("api")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, socket.Addr().String(), listener.Addr().String())
+
+		server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})}
+		go func() { _ = server.Serve(listener) }()
+
+		resp, err := http.Get("http://" + listener.Addr().String())
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		require.NoError(t, server.Close())
+	}
+
+	// 未找到对应的套接字
+	listener, ok, err := Listener("chat")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Nil(t, listener)
+}
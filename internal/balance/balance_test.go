@@ -2,9 +2,14 @@ package balance
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/sony/gobreaker"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -55,6 +60,113 @@ func TestWeightedRoundRobinBalancer(t *testing.T) {
 	assert.Greater(t, selections["upstream2"], selections["upstream3"])
 }
 
+func TestWeightedRoundRobinBalancer_Smooth(t *testing.T) {
+	upstreams := []Upstream{
+		{Name: "A", Weight: 5},
+		{Name: "B", Weight: 1},
+		{Name: "C", Weight: 1},
+	}
+
+	balancer := NewWeightedRRBalancer()
+	ctx := context.Background()
+
+	// 平滑加权轮询的选择序列是交错且确定的
+	expected := []string{"A", "A", "B", "A", "C", "A", "A"}
+	for round := 0; round < 3; round++ {
+		for i, name := range expected {
+			upstream, err := balancer.Select(ctx, upstreams)
+			require.NoError(t, err)
+			assert.Equal(t, name, upstream.Name, "round %d selection %d", round, i)
+		}
+	}
+}
+
+func TestWeightedRoundRobinBalancer_MaxConsecutive(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []int
+	}{
+		{name: "5-1-1", weights: []int{5, 1, 1}},
+		{name: "3-1", weights: []int{3, 1}},
+		{name: "4-2-1", weights: []int{4, 2, 1}},
+		{name: "6-3-1", weights: []int{6, 3, 1}},
+		{name: "equal", weights: []int{1, 1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstreams := make([]Upstream, len(tt.weights))
+			totalWeight := 0
+			for i, weight := range tt.weights {
+				upstreams[i] = Upstream{Name: fmt.Sprintf("upstream%d", i), Weight: weight}
+				totalWeight += weight
+			}
+
+			balancer := NewWeightedRRBalancer()
+			ctx := context.Background()
+
+			// 一个完整周期内，连续选中同一上游的次数不超过 ceil(自身权重 / 其余权重之和)
+			counts := make(map[string]int)
+			last, run := "", 0
+			for i := 0; i < totalWeight; i++ {
+				upstream, err := balancer.Select(ctx, upstreams)
+				require.NoError(t, err)
+				counts[upstream.Name]++
+
+				if upstream.Name == last {
+					run++
+				} else {
+					last, run = upstream.Name, 1
+				}
+
+				weight := upstream.Weight
+				maxRun := int(math.Ceil(float64(weight) / float64(totalWeight-weight)))
+				assert.LessOrEqual(t, run, maxRun, "upstream %s selected %d times in a row", upstream.Name, run)
+			}
+
+			// 每个周期内选择次数与权重严格一致
+			for _, upstream := range upstreams {
+				assert.Equal(t, upstream.Weight, counts[upstream.Name])
+			}
+		})
+	}
+}
+
+func TestWeightedRoundRobinBalancer_Concurrent(t *testing.T) {
+	upstreams := []Upstream{
+		{Name: "A", Weight: 5},
+		{Name: "B", Weight: 1},
+		{Name: "C", Weight: 1},
+	}
+
+	balancer := NewWeightedRRBalancer()
+	ctx := context.Background()
+
+	// 并发选择完整周期后，选择次数仍与权重严格成比例
+	const workers, cycles = 8, 50
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < cycles*7; i++ {
+				upstream, err := balancer.Select(ctx, upstreams)
+				assert.NoError(t, err)
+				mu.Lock()
+				counts[upstream.Name]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, workers*cycles*5, counts["A"])
+	assert.Equal(t, workers*cycles, counts["B"])
+	assert.Equal(t, workers*cycles, counts["C"])
+}
+
 func TestRandomBalancer(t *testing.T) {
 	upstreams := []Upstream{
 		{Name: "upstream1", URL: "http://example1.com", Weight: 1},
@@ -79,6 +191,391 @@ func TestRandomBalancer(t *testing.T) {
 	assert.True(t, selectedUpstreams["upstream3"])
 }
 
+func TestRandomBalancer_Seed(t *testing.T) {
+	upstreams := []Upstream{
+		{Name: "upstream1", URL: "http://example1.com", Weight: 1},
+		{Name: "upstream2", URL: "http://example2.com", Weight: 1},
+		{Name: "upstream3", URL: "http://example3.com", Weight: 1},
+	}
+	ctx := context.Background()
+
+	// sequence 记录负载均衡器的选择序列
+	sequence := func(balancer LoadBalancer) []string {
+		names := make([]string, 0, 50)
+		for i := 0; i < 50; i++ {
+			upstream, err := balancer.Select(ctx, upstreams)
+			require.NoError(t, err)
+			names = append(names, upstream.Name)
+		}
+		return names
+	}
+
+	// 相同种子产生相同的选择序列
+	assert.Equal(t, sequence(NewRandomBalancerWithSeed(42)), sequence(NewRandomBalancerWithSeed(42)))
+	assert.NotEqual(t, sequence(NewRandomBalancerWithSeed(42)), sequence(NewRandomBalancerWithSeed(7)))
+
+	// 工厂使用配置的种子创建负载均衡器
+	factory := NewFactory()
+	first, err := factory.Create(&config.BalanceConfig{Strategy: "random", Seed: 42})
+	require.NoError(t, err)
+	second, err := factory.Create(&config.BalanceConfig{Strategy: "random", Seed: 42})
+	require.NoError(t, err)
+	assert.Equal(t, sequence(first), sequence(second))
+
+	// iphash 无法获取客户端 IP 时的降级随机选择同样可以复现
+	first, err = factory.Create(&config.BalanceConfig{Strategy: "iphash", Seed: 42})
+	require.NoError(t, err)
+	second, err = factory.Create(&config.BalanceConfig{Strategy: "iphash", Seed: 42})
+	require.NoError(t, err)
+	assert.Equal(t, sequence(first), sequence(second))
+}
+
+func TestFailoverBalancer(t *testing.T) {
+	primaryBreaker := &stateBreaker{state: gobreaker.StateClosed}
+	secondaryBreaker := &stateBreaker{state: gobreaker.StateClosed}
+	tertiaryBreaker := &stateBreaker{state: gobreaker.StateClosed}
+	upstreams := []Upstream{
+		{Name: "primary", URL: "http://primary.com", Weight: 1, Breaker: primaryBreaker},
+		{Name: "secondary", URL: "http://secondary.com", Weight: 1, Breaker: secondaryBreaker},
+		{Name: "tertiary", URL: "http://tertiary.com", Weight: 1, Breaker: tertiaryBreaker},
+	}
+
+	balancer := NewFailoverBalancer()
+	ctx := context.Background()
+
+	selectName := func() string {
+		upstream, err := balancer.Select(ctx, upstreams)
+		require.NoError(t, err)
+		return upstream.Name
+	}
+
+	// 主上游健康时始终选择主上游
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, "primary", selectName())
+	}
+
+	// 主上游熔断时切换到下一个
+	primaryBreaker.state = gobreaker.StateOpen
+	assert.Equal(t, "secondary", selectName())
+
+	// 半开状态允许探测请求，切回主上游
+	primaryBreaker.state = gobreaker.StateHalfOpen
+	assert.Equal(t, "primary", selectName())
+	primaryBreaker.state = gobreaker.StateClosed
+
+	// 健康状态只由熔断器决定，UpdateHealth 不影响选择
+	balancer.UpdateHealth("primary", false)
+	assert.Equal(t, "primary", selectName())
+
+	// 前两个上游均熔断时选择第三个
+	primaryBreaker.state = gobreaker.StateOpen
+	secondaryBreaker.state = gobreaker.StateOpen
+	assert.Equal(t, "tertiary", selectName())
+
+	// 所有上游均熔断时返回错误
+	tertiaryBreaker.state = gobreaker.StateOpen
+	_, err := balancer.Select(ctx, upstreams)
+	assert.ErrorIs(t, err, ErrAllUpstreamsUnhealthy)
+}
+
+func TestBalancers_Standby(t *testing.T) {
+	primaryBreaker := &stateBreaker{state: gobreaker.StateClosed}
+	secondaryBreaker := &stateBreaker{state: gobreaker.StateClosed}
+	upstreams := []Upstream{
+		{Name: "standby", Weight: 0, Standby: true},
+		{Name: "primary", Weight: 3, Breaker: primaryBreaker},
+		{Name: "secondary", Weight: 1, Breaker: secondaryBreaker},
+	}
+
+	balancers := map[string]LoadBalancer{
+		"weighted_roundrobin": NewWeightedRRBalancer(),
+		"roundrobin":          NewRRBalancer(),
+		"random":              NewRandomBalancer(),
+		"iphash":              NewIPHashBalancer(),
+		"failover":            NewFailoverBalancer(),
+		"canary":              NewCanaryBalancer(),
+	}
+	ctx := WithClientIP(context.Background(), "192.168.1.100")
+
+	for name, balancer := range balancers {
+		t.Run(name, func(t *testing.T) {
+			selectNames := func() map[string]int {
+				counts := make(map[string]int)
+				for i := 0; i < 20; i++ {
+					upstream, err := balancer.Select(ctx, upstreams)
+					require.NoError(t, err)
+					counts[upstream.Name]++
+				}
+				return counts
+			}
+
+			// 非备用上游可用时从不选择备用上游
+			primaryBreaker.state = gobreaker.StateClosed
+			secondaryBreaker.state = gobreaker.StateClosed
+			assert.Zero(t, selectNames()["standby"])
+
+			// 部分非备用上游熔断时仍不选择备用上游
+			primaryBreaker.state = gobreaker.StateOpen
+			assert.Zero(t, selectNames()["standby"])
+
+			// 所有非备用上游均熔断时仅选择备用上游
+			secondaryBreaker.state = gobreaker.StateOpen
+			assert.Equal(t, 20, selectNames()["standby"])
+
+			// 非备用上游恢复后不再选择备用上游
+			secondaryBreaker.state = gobreaker.StateHalfOpen
+			assert.Zero(t, selectNames()["standby"])
+		})
+	}
+}
+
+func TestBalancers_FailurePenalty(t *testing.T) {
+	balancers := map[string]LoadBalancer{
+		"weighted_roundrobin": NewWeightedRRBalancer(),
+		"roundrobin":          NewRRBalancer(),
+		"random":              NewRandomBalancer(),
+		"iphash":              NewIPHashBalancer(),
+		"failover":            NewFailoverBalancer(),
+		"canary":              NewCanaryBalancer(),
+	}
+	ctx := WithClientIP(context.Background(), "192.168.1.100")
+
+	for name, balancer := range balancers {
+		t.Run(name, func(t *testing.T) {
+			upstreams := []Upstream{
+				{Name: "primary", Weight: 1, Penalty: NewFailurePenalty(50 * time.Millisecond)},
+				{Name: "secondary", Weight: 1, Penalty: NewFailurePenalty(50 * time.Millisecond)},
+			}
+			selectNames := func() map[string]int {
+				counts := make(map[string]int)
+				for i := 0; i < 20; i++ {
+					upstream, err := balancer.Select(ctx, upstreams)
+					require.NoError(t, err)
+					counts[upstream.Name]++
+				}
+				return counts
+			}
+
+			// 惩罚期内跳过失败的上游
+			upstreams[0].PenalizeFailure()
+			assert.Equal(t, 20, selectNames()["secondary"])
+
+			// 所有上游均处于惩罚期时仍可选择
+			upstreams[1].PenalizeFailure()
+			counts := selectNames()
+			assert.Equal(t, 20, counts["primary"]+counts["secondary"])
+
+			// 惩罚期结束后恢复参与选择
+			time.Sleep(60 * time.Millisecond)
+			upstreams[1].PenalizeFailure()
+			assert.Equal(t, 20, selectNames()["primary"])
+		})
+	}
+}
+
+func TestActiveUpstreams_PenaltyWithBreaker(t *testing.T) {
+	penalizedBreaker := &stateBreaker{state: gobreaker.StateClosed}
+	openBreaker := &stateBreaker{state: gobreaker.StateOpen}
+	upstreams := []Upstream{
+		{Name: "penalized", Weight: 1, Breaker: penalizedBreaker, Penalty: NewFailurePenalty(time.Minute)},
+		{Name: "open", Weight: 1, Breaker: openBreaker, Penalty: NewFailurePenalty(time.Minute)},
+	}
+	upstreams[0].PenalizeFailure()
+
+	// 未处于惩罚期的上游均已熔断时保留处于惩罚期的健康上游
+	active := activeUpstreams(upstreams)
+	assert.Len(t, active, 2)
+
+	upstream, err := NewFailoverBalancer().Select(context.Background(), upstreams)
+	require.NoError(t, err)
+	assert.Equal(t, "penalized", upstream.Name)
+
+	// 处于惩罚期的非备用上游仍健康时不切换到备用上游
+	standbyUpstreams := []Upstream{
+		{Name: "primary", Weight: 1, Breaker: penalizedBreaker, Penalty: NewFailurePenalty(time.Minute)},
+		{Name: "secondary", Weight: 1, Breaker: &stateBreaker{state: gobreaker.StateClosed}, Penalty: NewFailurePenalty(time.Minute)},
+		{Name: "standby", Weight: 1, Standby: true},
+	}
+	standbyUpstreams[0].PenalizeFailure()
+	standbyUpstreams[1].PenalizeFailure()
+
+	active = activeUpstreams(standbyUpstreams)
+	require.Len(t, active, 2)
+	for _, upstream := range active {
+		assert.False(t, upstream.Standby)
+	}
+}
+
+func TestBalancers_AllUpstreamsUnhealthy(t *testing.T) {
+	primaryBreaker := &stateBreaker{state: gobreaker.StateOpen}
+	secondaryBreaker := &stateBreaker{state: gobreaker.StateOpen}
+	upstreams := []Upstream{
+		{Name: "primary", Weight: 1, Breaker: primaryBreaker},
+		{Name: "secondary", Weight: 1, Breaker: secondaryBreaker, TrafficPercent: 10},
+	}
+
+	balancers := map[string]LoadBalancer{
+		"weighted_roundrobin": NewWeightedRRBalancer(),
+		"roundrobin":          NewRRBalancer(),
+		"random":              NewRandomBalancer(),
+		"iphash":              NewIPHashBalancer(),
+		"failover":            NewFailoverBalancer(),
+		"canary":              NewCanaryBalancer(),
+	}
+	ctx := WithClientIP(context.Background(), "192.168.1.100")
+
+	for name, balancer := range balancers {
+		t.Run(name, func(t *testing.T) {
+			// 所有上游均熔断时返回独立的错误
+			primaryBreaker.state = gobreaker.StateOpen
+			secondaryBreaker.state = gobreaker.StateOpen
+			_, err := balancer.Select(ctx, upstreams)
+			assert.ErrorIs(t, err, ErrAllUpstreamsUnhealthy)
+
+			// 任一上游半开时恢复选择
+			secondaryBreaker.state = gobreaker.StateHalfOpen
+			_, err = balancer.Select(ctx, upstreams)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCanaryBalancer(t *testing.T) {
+	ctx := context.Background()
+
+	countSelections := func(balancer LoadBalancer, upstreams []Upstream, total int) map[string]int {
+		counts := make(map[string]int)
+		for i := 0; i < total; i++ {
+			upstream, err := balancer.Select(ctx, upstreams)
+			require.NoError(t, err)
+			counts[upstream.Name]++
+		}
+		return counts
+	}
+
+	t.Run("canary receives its traffic percent", func(t *testing.T) {
+		upstreams := []Upstream{
+			{Name: "stable-a", Weight: 3},
+			{Name: "stable-b", Weight: 1},
+			{Name: "canary", Weight: 1, TrafficPercent: 5},
+		}
+
+		balancer := NewCanaryBalancer()
+		assert.Equal(t, "canary", balancer.Type())
+
+		// 金丝雀上游恰好获得 5% 的流量，剩余流量按权重 3:1 分配
+		counts := countSelections(balancer, upstreams, 10000)
+		assert.InDelta(t, 500, counts["canary"], 50)
+		assert.InDelta(t, 7125, counts["stable-a"], 100)
+		assert.InDelta(t, 2375, counts["stable-b"], 100)
+	})
+
+	t.Run("canary selections are interleaved", func(t *testing.T) {
+		upstreams := []Upstream{
+			{Name: "stable", Weight: 1},
+			{Name: "canary", Weight: 1, TrafficPercent: 20},
+		}
+
+		// 每 5 个请求中恰好有 1 个分配给金丝雀上游
+		balancer := NewCanaryBalancer()
+		for round := 0; round < 10; round++ {
+			assert.Equal(t, 1, countSelections(balancer, upstreams, 5)["canary"])
+		}
+	})
+
+	t.Run("without stable upstreams", func(t *testing.T) {
+		upstreams := []Upstream{
+			{Name: "canary-a", TrafficPercent: 30},
+			{Name: "canary-b", TrafficPercent: 10},
+		}
+
+		// 没有非金丝雀上游时按百分比比例在金丝雀上游之间分配
+		counts := countSelections(NewCanaryBalancer(), upstreams, 400)
+		assert.Equal(t, 300, counts["canary-a"])
+		assert.Equal(t, 100, counts["canary-b"])
+	})
+
+	t.Run("without canary upstreams", func(t *testing.T) {
+		upstreams := []Upstream{
+			{Name: "stable-a", Weight: 2},
+			{Name: "stable-b", Weight: 1},
+		}
+
+		counts := countSelections(NewCanaryBalancer(), upstreams, 300)
+		assert.Equal(t, 200, counts["stable-a"])
+		assert.Equal(t, 100, counts["stable-b"])
+	})
+}
+
+func TestWeightedLeastRequestBalancer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("distributes by weight when requests complete", func(t *testing.T) {
+		upstreams := []Upstream{
+			{Name: "heavy", Weight: 3},
+			{Name: "light", Weight: 1},
+		}
+
+		balancer := NewWeightedLeastRequestBalancerWithSeed(42)
+		assert.Equal(t, "weighted_least_request", balancer.Type())
+		tracker, ok := balancer.(LoadBalancerWithRequestTracking)
+		require.True(t, ok)
+
+		// 保持 4 个进行中的请求，每完成一个再发起一个，稳定后流量按权重 3:1 分配
+		inFlight := make([]string, 0, 4)
+		counts := make(map[string]int)
+		for i := 0; i < 4000; i++ {
+			if len(inFlight) == 4 {
+				tracker.Release(inFlight[0])
+				inFlight = inFlight[1:]
+			}
+			upstream, err := balancer.Select(ctx, upstreams)
+			require.NoError(t, err)
+			inFlight = append(inFlight, upstream.Name)
+			counts[upstream.Name]++
+		}
+		assert.InDelta(t, 3000, counts["heavy"], 100)
+		assert.InDelta(t, 1000, counts["light"], 100)
+	})
+
+	t.Run("avoids upstreams with in-flight load", func(t *testing.T) {
+		upstreams := []Upstream{
+			{Name: "a", Weight: 2},
+			{Name: "b", Weight: 1},
+			{Name: "c", Weight: 1},
+		}
+
+		balancer := NewWeightedLeastRequestBalancerWithSeed(7).(*WeightedLeastRequestBalancer)
+
+		// 模拟 a 上积压了大量慢请求，新请求应转向 b 和 c
+		// 仅当两次随机抽取都是 a 时才会选择 a，约占 1/9
+		for i := 0; i < 20; i++ {
+			balancer.inFlight["a"]++
+		}
+		counts := make(map[string]int)
+		for i := 0; i < 300; i++ {
+			upstream, err := balancer.Select(ctx, upstreams)
+			require.NoError(t, err)
+			counts[upstream.Name]++
+			balancer.Release(upstream.Name)
+		}
+		assert.Less(t, counts["a"], 60)
+		assert.InDelta(t, counts["b"], counts["c"], 60)
+
+		// 积压的请求全部完成后不再保留计数
+		for i := 0; i < 20; i++ {
+			balancer.Release("a")
+		}
+		assert.Zero(t, balancer.inFlight["a"])
+	})
+
+	t.Run("release without select", func(t *testing.T) {
+		balancer := NewWeightedLeastRequestBalancer().(*WeightedLeastRequestBalancer)
+		balancer.Release("unknown")
+		assert.Empty(t, balancer.inFlight)
+	})
+}
+
 func TestClientIPContext(t *testing.T) {
 	ctx := context.Background()
 
@@ -190,12 +687,36 @@ func TestFactory_Create(t *testing.T) {
 			wantError: false,
 		},
 
+		{
+			name:      "iphash with affinity",
+			config:    &config.BalanceConfig{Strategy: "iphash", AffinityTTL: 60000},
+			wantType:  "iphash",
+			wantError: false,
+		},
 		{
 			name:      "iphash",
 			config:    &config.BalanceConfig{Strategy: "iphash"},
 			wantType:  "iphash",
 			wantError: false,
 		},
+		{
+			name:      "failover",
+			config:    &config.BalanceConfig{Strategy: "failover"},
+			wantType:  "failover",
+			wantError: false,
+		},
+		{
+			name:      "canary",
+			config:    &config.BalanceConfig{Strategy: "canary"},
+			wantType:  "canary",
+			wantError: false,
+		},
+		{
+			name:      "weighted_least_request",
+			config:    &config.BalanceConfig{Strategy: "weighted_least_request", Seed: 42},
+			wantType:  "weighted_least_request",
+			wantError: false,
+		},
 		{
 			name:      "unknown strategy",
 			config:    &config.BalanceConfig{Strategy: "unknown"},
@@ -232,6 +753,9 @@ func TestEmptyUpstreams(t *testing.T) {
 		NewWeightedRRBalancer(),
 		NewRandomBalancer(),
 		NewIPHashBalancer(),
+		NewFailoverBalancer(),
+		NewCanaryBalancer(),
+		NewWeightedLeastRequestBalancer(),
 	}
 
 	ctx := context.Background()
@@ -374,6 +898,9 @@ func TestUpdateHealthMethods(t *testing.T) {
 		NewWeightedRRBalancer(),
 		NewRandomBalancer(),
 		NewIPHashBalancer(),
+		NewFailoverBalancer(),
+		NewCanaryBalancer(),
+		NewWeightedLeastRequestBalancer(),
 	}
 
 	for _, balancer := range balancers {
@@ -391,6 +918,9 @@ func TestUpdateLatencyMethods(t *testing.T) {
 		NewWeightedRRBalancer(),
 		NewRandomBalancer(),
 		NewIPHashBalancer(),
+		NewFailoverBalancer(),
+		NewCanaryBalancer(),
+		NewWeightedLeastRequestBalancer(),
 	}
 
 	for _, balancer := range balancers {
@@ -409,6 +939,9 @@ func TestBalancersWithConfigDefaults(t *testing.T) {
 		"weighted_roundrobin",
 		"random",
 		"iphash",
+		"failover",
+		"canary",
+		"weighted_least_request",
 	}
 
 	factory := NewFactory()
@@ -424,3 +957,117 @@ func TestBalancersWithConfigDefaults(t *testing.T) {
 		})
 	}
 }
+
+// stateBreaker 代表返回固定状态的测试熔断器
+type stateBreaker struct {
+	state gobreaker.State
+}
+
+func (b *stateBreaker) Execute(req func() (interface{}, error)) (interface{}, error) { return req() }
+func (b *stateBreaker) Name() string                                                 { return "test" }
+func (b *stateBreaker) State() gobreaker.State                                       { return b.state }
+
+// findRemappedIP 查找在加入新上游后哈希映射发生变化的客户端 IP
+func findRemappedIP(t *testing.T, before, after []Upstream) (string, string) {
+	t.Helper()
+
+	for i := 0; i < 1000; i++ {
+		ctx := WithClientIP(context.Background(), fmt.Sprintf("10.0.%d.%d", i/256, i%256))
+		oldUpstream, err := NewIPHashBalancer().Select(ctx, before)
+		require.NoError(t, err)
+		newUpstream, err := NewIPHashBalancer().Select(ctx, after)
+		require.NoError(t, err)
+		if oldUpstream.Name != newUpstream.Name {
+			clientIP, _ := GetClientIP(ctx)
+			return clientIP, oldUpstream.Name
+		}
+	}
+	t.Fatal("no client IP remapped after adding an upstream")
+	return "", ""
+}
+
+func TestIPHashBalancer_Affinity(t *testing.T) {
+	before := []Upstream{
+		{Name: "upstream1", URL: "http://example1.com", Weight: 1},
+		{Name: "upstream2", URL: "http://example2.com", Weight: 1},
+	}
+	after := append(append([]Upstream{}, before...), Upstream{Name: "upstream3", URL: "http://example3.com", Weight: 1})
+
+	clientIP, original := findRemappedIP(t, before, after)
+	ctx := WithClientIP(context.Background(), clientIP)
+
+	t.Run("sticks across upstream added", func(t *testing.T) {
+		balancer := NewIPHashBalancerWithAffinity(time.Minute)
+
+		upstream, err := balancer.Select(ctx, before)
+		require.NoError(t, err)
+		assert.Equal(t, original, upstream.Name)
+
+		upstream, err = balancer.Select(ctx, after)
+		require.NoError(t, err)
+		assert.Equal(t, original, upstream.Name, "affinity should survive ring changes")
+
+		// 未启用会话亲和时映射随哈希环变化
+		plain := NewIPHashBalancer()
+		_, err = plain.Select(ctx, before)
+		require.NoError(t, err)
+		upstream, err = plain.Select(ctx, after)
+		require.NoError(t, err)
+		assert.NotEqual(t, original, upstream.Name)
+	})
+
+	t.Run("expired affinity is evicted", func(t *testing.T) {
+		balancer := NewIPHashBalancerWithAffinity(20 * time.Millisecond)
+
+		_, err := balancer.Select(ctx, before)
+		require.NoError(t, err)
+		time.Sleep(30 * time.Millisecond)
+
+		upstream, err := balancer.Select(ctx, after)
+		require.NoError(t, err)
+		assert.NotEqual(t, original, upstream.Name)
+	})
+
+	t.Run("unhealthy upstream is evicted", func(t *testing.T) {
+		balancer := NewIPHashBalancerWithAffinity(time.Minute)
+		_, err := balancer.Select(ctx, before)
+		require.NoError(t, err)
+
+		balancer.UpdateHealth(original, false)
+		upstream, err := balancer.Select(ctx, after)
+		require.NoError(t, err)
+		assert.NotEqual(t, original, upstream.Name)
+	})
+
+	t.Run("open breaker is evicted", func(t *testing.T) {
+		balancer := NewIPHashBalancerWithAffinity(time.Minute)
+		_, err := balancer.Select(ctx, before)
+		require.NoError(t, err)
+
+		opened := append([]Upstream{}, after...)
+		for i := range opened {
+			if opened[i].Name == original {
+				opened[i].Breaker = &stateBreaker{state: gobreaker.StateOpen}
+			}
+		}
+		upstream, err := balancer.Select(ctx, opened)
+		require.NoError(t, err)
+		assert.NotEqual(t, original, upstream.Name)
+	})
+
+	t.Run("removed upstream is evicted", func(t *testing.T) {
+		balancer := NewIPHashBalancerWithAffinity(time.Minute)
+		_, err := balancer.Select(ctx, before)
+		require.NoError(t, err)
+
+		var remaining []Upstream
+		for _, upstream := range after {
+			if upstream.Name != original {
+				remaining = append(remaining, upstream)
+			}
+		}
+		upstream, err := balancer.Select(ctx, remaining)
+		require.NoError(t, err)
+		assert.NotEqual(t, original, upstream.Name)
+	})
+}
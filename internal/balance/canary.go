@@ -0,0 +1,104 @@
+package balance
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// remainderSlot 代表分配给非金丝雀上游的剩余流量，上游名称不能为空，不会与之冲突
+const remainderSlot = ""
+
+// CanaryBalancer 实现金丝雀负载均衡算法
+// 配置了流量百分比的上游（金丝雀上游）按百分比固定分配流量，剩余流量在其余上游之间按权重分配
+// 两级选择均使用平滑加权轮询，例如金丝雀上游占 5% 时，每 100 个请求中恰好有 5 个分配给它且交错分布
+type CanaryBalancer struct {
+	splitter LoadBalancer // 按流量百分比在金丝雀上游和剩余流量之间选择
+	weighted LoadBalancer // 剩余流量在非金丝雀上游之间按权重选择
+}
+
+// NewCanaryBalancer 创建新的金丝雀负载均衡器实例
+func NewCanaryBalancer() LoadBalancer {
+	return &CanaryBalancer{
+		splitter: NewWeightedRRBalancer(),
+		weighted: NewWeightedRRBalancer(),
+	}
+}
+
+// Select 按流量百分比选择金丝雀上游，未选中时在其余上游之间按权重选择
+// 没有非金丝雀上游时流量全部在金丝雀上游之间按百分比分配
+// ctx: 上下文信息
+// upstreams: 可用的上游服务列表
+func (b *CanaryBalancer) Select(ctx context.Context, upstreams []Upstream) (Upstream, error) {
+	if err := checkUpstreams(upstreams); err != nil {
+		return Upstream{}, err
+	}
+	upstreams = activeUpstreams(upstreams)
+
+	var canaries, stable []Upstream
+	totalPercent := 0
+	for _, upstream := range upstreams {
+		if upstream.TrafficPercent > 0 {
+			canaries = append(canaries, upstream)
+			totalPercent += upstream.TrafficPercent
+		} else {
+			stable = append(stable, upstream)
+		}
+	}
+	if len(canaries) == 0 {
+		return b.selectStable(ctx, stable)
+	}
+
+	// 每个金丝雀上游以流量百分比作为权重，剩余流量作为一个整体参与选择
+	slots := make([]Upstream, 0, len(canaries)+1)
+	for _, canary := range canaries {
+		slots = append(slots, Upstream{Name: canary.Name, Weight: canary.TrafficPercent})
+	}
+	if remainder := 100 - totalPercent; remainder > 0 && len(stable) > 0 {
+		slots = append(slots, Upstream{Name: remainderSlot, Weight: remainder})
+	}
+
+	slot, err := b.splitter.Select(ctx, slots)
+	if err != nil {
+		return Upstream{}, err
+	}
+	if slot.Name == remainderSlot {
+		return b.selectStable(ctx, stable)
+	}
+	for _, canary := range canaries {
+		if canary.Name == slot.Name {
+			return canary, nil
+		}
+	}
+	return canaries[0], nil
+}
+
+// selectStable 在非金丝雀上游之间按权重选择
+// 上游组整体已通过可用性检查，非金丝雀上游全部熔断时仍返回其中之一，由熔断器拒绝请求，保持金丝雀流量比例不变
+func (b *CanaryBalancer) selectStable(ctx context.Context, stable []Upstream) (Upstream, error) {
+	upstream, err := b.weighted.Select(ctx, stable)
+	if errors.Is(err, ErrAllUpstreamsUnhealthy) {
+		return stable[0], nil
+	}
+	return upstream, err
+}
+
+// UpdateHealth 更新健康状态（金丝雀算法不需要此信息）
+// upstreamName: 上游服务名称
+// healthy: 健康状态
+func (b *CanaryBalancer) UpdateHealth(upstreamName string, healthy bool) {
+	// 金丝雀算法不需要健康状态信息，此方法为空实现
+}
+
+// UpdateLatency 更新延迟信息（金丝雀算法不需要此信息）
+// upstreamName: 上游服务名称
+// latency: 响应延迟
+func (b *CanaryBalancer) UpdateLatency(upstreamName string, latency int64) {
+	// 金丝雀算法不需要延迟信息，此方法为空实现
+}
+
+// Type 获取负载均衡器类型
+func (b *CanaryBalancer) Type() string {
+	return constants.BalanceCanary
+}
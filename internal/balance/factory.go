@@ -3,6 +3,7 @@ package balance
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/shengyanli1982/llmproxy-go/internal/config"
 	"github.com/shengyanli1982/llmproxy-go/internal/constants"
@@ -39,9 +40,27 @@ func (f *BalanceFactory) Create(config *config.BalanceConfig) (LoadBalancer, err
 	case constants.BalanceWeightedRoundRobin:
 		return NewWeightedRRBalancer(), nil
 	case constants.BalanceRandom:
+		if config.Seed != 0 {
+			return NewRandomBalancerWithSeed(config.Seed), nil
+		}
 		return NewRandomBalancer(), nil
 	case constants.BalanceIPHash:
+		if config.Seed != 0 {
+			return NewIPHashBalancerWithSeed(time.Duration(config.AffinityTTL)*time.Millisecond, config.Seed), nil
+		}
+		if config.AffinityTTL > 0 {
+			return NewIPHashBalancerWithAffinity(time.Duration(config.AffinityTTL) * time.Millisecond), nil
+		}
 		return NewIPHashBalancer(), nil
+	case constants.BalanceFailover:
+		return NewFailoverBalancer(), nil
+	case constants.BalanceCanary:
+		return NewCanaryBalancer(), nil
+	case constants.BalanceWeightedLeastRequest:
+		if config.Seed != 0 {
+			return NewWeightedLeastRequestBalancerWithSeed(config.Seed), nil
+		}
+		return NewWeightedLeastRequestBalancer(), nil
 	default:
 		return nil, fmt.Errorf("%w: %s", ErrUnknownStrategy, strategy)
 	}
@@ -0,0 +1,55 @@
+package balance
+
+import (
+	"context"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// FailoverBalancer 实现主备故障转移负载均衡算法
+// 始终按配置顺序选择第一个健康的上游服务，健康状态由熔断器决定，主上游熔断时切换到下一个，
+// 熔断器进入半开状态后自动切回主上游
+type FailoverBalancer struct{}
+
+// NewFailoverBalancer 创建新的故障转移负载均衡器实例
+func NewFailoverBalancer() LoadBalancer {
+	return &FailoverBalancer{}
+}
+
+// Select 选择配置顺序中第一个熔断器未开启的上游服务
+// 所有上游均已熔断时返回 ErrAllUpstreamsUnhealthy
+// ctx: 上下文信息
+// upstreams: 可用的上游服务列表
+func (b *FailoverBalancer) Select(ctx context.Context, upstreams []Upstream) (Upstream, error) {
+	if err := checkUpstreams(upstreams); err != nil {
+		return Upstream{}, err
+	}
+	upstreams = activeUpstreams(upstreams)
+
+	for _, upstream := range upstreams {
+		if isUpstreamHealthy(upstream) {
+			return upstream, nil
+		}
+	}
+
+	return Upstream{}, ErrAllUpstreamsUnhealthy
+}
+
+// UpdateHealth 更新健康状态（故障转移算法直接读取熔断器状态，不需要此信息）
+// upstreamName: 上游服务名称
+// healthy: 健康状态
+func (b *FailoverBalancer) UpdateHealth(upstreamName string, healthy bool) {
+	// 故障转移算法由熔断器状态驱动，此方法为空实现
+}
+
+// UpdateLatency 更新延迟信息（故障转移算法不需要此信息）
+// upstreamName: 上游服务名称
+// latency: 响应延迟
+func (b *FailoverBalancer) UpdateLatency(upstreamName string, latency int64) {
+	// 故障转移算法不需要延迟信息，此方法为空实现
+}
+
+// Type 获取负载均衡器类型
+func (b *FailoverBalancer) Type() string {
+	return constants.BalanceFailover
+}
@@ -22,23 +22,29 @@ var clientIPContextKey = clientIPKey{}
 
 // 负载均衡相关错误定义
 var (
-	ErrNoAvailableUpstream = errors.New(constants.ErrMsgNoAvailableUpstream)
-	ErrUnknownStrategy     = errors.New(constants.ErrMsgUnknownStrategy)
-	ErrNilUpstreams        = errors.New(constants.ErrMsgNilUpstreams)
-	ErrEmptyUpstreams      = errors.New(constants.ErrMsgEmptyUpstreams)
+	ErrNoAvailableUpstream   = errors.New(constants.ErrMsgNoAvailableUpstream)
+	ErrUnknownStrategy       = errors.New(constants.ErrMsgUnknownStrategy)
+	ErrNilUpstreams          = errors.New(constants.ErrMsgNilUpstreams)
+	ErrEmptyUpstreams        = errors.New(constants.ErrMsgEmptyUpstreams)
+	ErrAllUpstreamsUnhealthy = errors.New(constants.ErrMsgAllUpstreamsUnhealthy)
 )
 
 // Upstream 代表一个上游服务实例
 type Upstream struct {
-	Name   string                 // 上游服务名称
-	URL    string                 // 上游服务 URL
-	Weight int                    // 权重（用于加权轮询）
-	Config *config.UpstreamConfig // 上游服务配置
+	Name    string                 // 上游服务名称
+	URL     string                 // 上游服务 URL
+	Weight  int                    // 权重（用于加权轮询）
+	Standby bool                   // 备用上游，仅在所有非备用上游均不可用时参与选择
+	Config  *config.UpstreamConfig // 上游服务配置
+
+	// TrafficPercent 固定流量百分比（用于金丝雀策略），0 表示按权重分配剩余流量
+	TrafficPercent int
 
 	// 预初始化的组件实例，避免重复创建
 	Authenticator auth.Authenticator         // 认证器（缓存）
 	Breaker       breaker.CircuitBreaker     // 熔断器
 	RateLimiter   *ratelimit.UpstreamLimiter // 限流器
+	Penalty       *FailurePenalty            // 失败惩罚状态，未配置时为 nil
 }
 
 // ApplyAuth 应用认证到HTTP请求
@@ -59,6 +65,14 @@ func (u *Upstream) CheckRateLimit() bool {
 	return true
 }
 
+// PenalizeFailure 标记上游请求失败，使其进入失败惩罚期
+// 如果未配置失败惩罚，则跳过（默认行为）
+func (u *Upstream) PenalizeFailure() {
+	if u.Penalty != nil {
+		u.Penalty.Penalize()
+	}
+}
+
 // ExecuteWithBreaker 通过熔断器执行HTTP请求
 // 如果熔断器未初始化，则直接执行请求函数
 func (u *Upstream) ExecuteWithBreaker(fn func() (*http.Response, error)) (*http.Response, error) {
@@ -74,9 +88,54 @@ func (u *Upstream) ExecuteWithBreaker(fn func() (*http.Response, error)) (*http.
 	return fn()
 }
 
+// checkUpstreams 检查上游服务列表是否可供选择
+// 列表为 nil 或为空时返回 ErrNilUpstreams、ErrEmptyUpstreams，所有上游的熔断器均处于开启状态时返回 ErrAllUpstreamsUnhealthy
+func checkUpstreams(upstreams []Upstream) error {
+	if upstreams == nil {
+		return ErrNilUpstreams
+	}
+	if len(upstreams) == 0 {
+		return ErrEmptyUpstreams
+	}
+	for _, upstream := range upstreams {
+		if isUpstreamHealthy(upstream) {
+			return nil
+		}
+	}
+	return ErrAllUpstreamsUnhealthy
+}
+
+// activeUpstreams 获取参与选择的上游服务列表
+// 存在可用的非备用上游时排除备用上游，所有非备用上游均不可用时仅在备用上游中选择
+// 备用上游的判断基于熔断器状态而不受失败惩罚影响，处于失败惩罚期的上游仅在没有其他可用上游时参与选择
+func activeUpstreams(upstreams []Upstream) []Upstream {
+	hasStandby := false
+	primaryAvailable := false
+	for _, upstream := range upstreams {
+		if upstream.Standby {
+			hasStandby = true
+		} else if isUpstreamHealthy(upstream) {
+			primaryAvailable = true
+		}
+	}
+	if !hasStandby {
+		return unpenalizedUpstreams(upstreams)
+	}
+
+	// 非备用上游可用时仅保留非备用上游，否则仅保留备用上游
+	active := make([]Upstream, 0, len(upstreams))
+	for _, upstream := range upstreams {
+		if upstream.Standby != primaryAvailable {
+			active = append(active, upstream)
+		}
+	}
+	return unpenalizedUpstreams(active)
+}
+
 // LoadBalancer 代表负载均衡器接口，定义选择上游服务的行为
 type LoadBalancer interface {
 	// Select 根据负载均衡策略选择一个上游服务
+	// 备用上游仅在所有非备用上游均不可用时参与选择，所有上游均不可用时返回 ErrAllUpstreamsUnhealthy
 	// ctx: 上下文信息
 	// upstreams: 可用的上游服务列表
 	Select(ctx context.Context, upstreams []Upstream) (Upstream, error)
@@ -106,6 +165,16 @@ type LoadBalancerWithBreaker interface {
 	GetBreaker(upstreamName string) (breaker.CircuitBreaker, bool)
 }
 
+// LoadBalancerWithRequestTracking 扩展负载均衡器接口，支持跟踪进行中的请求
+// Select 选中的上游计为一个进行中的请求，请求完成后调用方必须调用 Release
+type LoadBalancerWithRequestTracking interface {
+	LoadBalancer
+
+	// Release 标记选中的上游上的一个请求已完成
+	// upstreamName: 上游服务名称
+	Release(upstreamName string)
+}
+
 // LoadBalancerFactory 代表负载均衡器工厂接口
 type LoadBalancerFactory interface {
 	// Create 根据配置创建负载均衡器
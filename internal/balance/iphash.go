@@ -2,23 +2,38 @@ package balance
 
 import (
 	"context"
-	"crypto/rand"
 	"fmt"
-	"math/big"
+	"math/rand"
 	"sync"
+	"time"
 
 	"github.com/buraksezer/consistent"
 	"github.com/cespare/xxhash/v2"
 	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/sony/gobreaker"
 )
 
 // IPHashBalancer 实现基于客户端 IP 的一致性哈希负载均衡算法
 // 使用一致性哈希环确保相同客户端 IP 总是路由到相同的上游服务
 // 支持虚拟节点机制以提高负载分布的均匀性
+// 配置会话亲和时，记住每个客户端 IP 最近选择的上游并在 TTL 内优先复用，
+// 即使上游增减导致哈希环变化也不会迁移，只要该上游仍然存在且健康
 type IPHashBalancer struct {
 	mu        sync.RWMutex           // 读写锁，保护并发访问
 	ring      *consistent.Consistent // 一致性哈希环
 	upstreams map[string]Upstream    // 上游服务映射，key 为服务名称
+	rng       *rand.Rand             // 无法获取客户端 IP 时降级随机选择使用的随机数生成器，受 mu 保护
+
+	// 会话亲和
+	affinityTTL   time.Duration            // 亲和记录有效期，为 0 时禁用会话亲和
+	affinity      map[string]affinityEntry // 亲和记录，key 为客户端 IP
+	lastSweepTime time.Time                // 上次清理过期亲和记录的时间
+}
+
+// affinityEntry 代表客户端 IP 的亲和记录
+type affinityEntry struct {
+	upstream  string    // 最近选择的上游服务名称
+	expiresAt time.Time // 过期时间，每次复用时顺延
 }
 
 // hasher 实现 consistent.Hasher 接口，使用 xxhash 算法
@@ -43,18 +58,37 @@ func NewIPHashBalancer() LoadBalancer {
 	return &IPHashBalancer{
 		ring:      nil, // 延迟初始化，在第一次添加成员时创建
 		upstreams: make(map[string]Upstream),
+		rng:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// NewIPHashBalancerWithAffinity 创建带会话亲和的基于 IP 的一致性哈希负载均衡器实例
+// affinityTTL: 亲和记录有效期，为 0 时等同于 NewIPHashBalancer
+func NewIPHashBalancerWithAffinity(affinityTTL time.Duration) LoadBalancer {
+	return NewIPHashBalancerWithSeed(affinityTTL, time.Now().UnixNano())
+}
+
+// NewIPHashBalancerWithSeed 创建降级随机选择使用指定随机种子的基于 IP 的一致性哈希负载均衡器实例
+// affinityTTL: 亲和记录有效期，为 0 时禁用会话亲和
+// seed: 随机种子
+func NewIPHashBalancerWithSeed(affinityTTL time.Duration, seed int64) LoadBalancer {
+	return &IPHashBalancer{
+		ring:          nil,
+		upstreams:     make(map[string]Upstream),
+		rng:           rand.New(rand.NewSource(seed)),
+		affinityTTL:   affinityTTL,
+		affinity:      make(map[string]affinityEntry),
+		lastSweepTime: time.Now(),
 	}
 }
 
 // Select 使用一致性哈希算法根据客户端 IP 选择上游服务
-// 相同的客户端 IP 总是会被路由到相同的上游服务
+// 相同的客户端 IP 总是会被路由到相同的上游服务，启用会话亲和时优先复用亲和记录中的上游
 func (b *IPHashBalancer) Select(ctx context.Context, upstreams []Upstream) (Upstream, error) {
-	if upstreams == nil {
-		return Upstream{}, ErrNilUpstreams
-	}
-	if len(upstreams) == 0 {
-		return Upstream{}, ErrEmptyUpstreams
+	if err := checkUpstreams(upstreams); err != nil {
+		return Upstream{}, err
 	}
+	upstreams = activeUpstreams(upstreams)
 
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -71,6 +105,11 @@ func (b *IPHashBalancer) Select(ctx context.Context, upstreams []Upstream) (Upst
 		return b.selectRandomUpstream(upstreams), nil
 	}
 
+	// 优先复用会话亲和记录中的上游服务
+	if upstream, ok := b.selectAffinity(clientIP); ok {
+		return upstream, nil
+	}
+
 	// 使用一致性哈希选择上游服务
 	member := b.ring.LocateKey([]byte(clientIP))
 	if member == nil {
@@ -81,6 +120,7 @@ func (b *IPHashBalancer) Select(ctx context.Context, upstreams []Upstream) (Upst
 	// 根据成员名称查找对应的上游服务
 	upstreamName := member.String()
 	if upstream, exists := b.upstreams[upstreamName]; exists {
+		b.rememberAffinity(clientIP, upstreamName)
 		return upstream, nil
 	}
 
@@ -88,6 +128,63 @@ func (b *IPHashBalancer) Select(ctx context.Context, upstreams []Upstream) (Upst
 	return b.selectRandomUpstream(upstreams), nil
 }
 
+// selectAffinity 查找客户端 IP 的有效亲和记录，调用方必须持有写锁
+// 亲和记录过期、上游已移除或上游熔断器处于开启状态时删除记录并返回 false
+func (b *IPHashBalancer) selectAffinity(clientIP string) (Upstream, bool) {
+	if b.affinityTTL <= 0 {
+		return Upstream{}, false
+	}
+
+	now := time.Now()
+	b.sweepAffinity(now)
+
+	entry, exists := b.affinity[clientIP]
+	if !exists {
+		return Upstream{}, false
+	}
+
+	upstream, ok := b.upstreams[entry.upstream]
+	if !ok || !now.Before(entry.expiresAt) || !isUpstreamHealthy(upstream) {
+		delete(b.affinity, clientIP)
+		return Upstream{}, false
+	}
+
+	// 复用时顺延有效期，保持进行中会话的亲和
+	entry.expiresAt = now.Add(b.affinityTTL)
+	b.affinity[clientIP] = entry
+	return upstream, true
+}
+
+// rememberAffinity 记录客户端 IP 选择的上游服务，调用方必须持有写锁
+func (b *IPHashBalancer) rememberAffinity(clientIP, upstreamName string) {
+	if b.affinityTTL <= 0 {
+		return
+	}
+	b.affinity[clientIP] = affinityEntry{
+		upstream:  upstreamName,
+		expiresAt: time.Now().Add(b.affinityTTL),
+	}
+}
+
+// sweepAffinity 清理过期的亲和记录，每个 TTL 周期最多执行一次，调用方必须持有写锁
+func (b *IPHashBalancer) sweepAffinity(now time.Time) {
+	if now.Sub(b.lastSweepTime) < b.affinityTTL {
+		return
+	}
+	b.lastSweepTime = now
+
+	for clientIP, entry := range b.affinity {
+		if !now.Before(entry.expiresAt) {
+			delete(b.affinity, clientIP)
+		}
+	}
+}
+
+// isUpstreamHealthy 判断上游服务是否健康，熔断器处于开启状态时视为不健康
+func isUpstreamHealthy(upstream Upstream) bool {
+	return upstream.Breaker == nil || upstream.Breaker.State() != gobreaker.StateOpen
+}
+
 // updateRing 更新一致性哈希环中的节点
 func (b *IPHashBalancer) updateRing(upstreams []Upstream) error {
 	// 如果哈希环还未初始化，先创建它
@@ -137,27 +234,29 @@ func (b *IPHashBalancer) updateRing(upstreams []Upstream) error {
 	return nil
 }
 
-// selectRandomUpstream 随机选择一个上游服务作为降级策略
+// selectRandomUpstream 随机选择一个上游服务作为降级策略，调用方必须持有写锁
 func (b *IPHashBalancer) selectRandomUpstream(upstreams []Upstream) Upstream {
 	if len(upstreams) == 1 {
 		return upstreams[0]
 	}
-
-	// 使用加密安全的随机数生成器
-	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(upstreams))))
-	if err != nil {
-		// 如果随机数生成失败，返回第一个上游服务
-		return upstreams[0]
-	}
-
-	return upstreams[n.Int64()]
+	return upstreams[b.rng.Intn(len(upstreams))]
 }
 
 // UpdateHealth 更新上游服务的健康状态
-// IPHash 负载均衡器不需要维护健康状态，提供空实现以满足接口要求
+// IPHash 算法本身不依赖健康状态，上游不健康时仅清除指向该上游的会话亲和记录
 func (b *IPHashBalancer) UpdateHealth(upstreamName string, healthy bool) {
-	// IPHash 算法不依赖健康状态，此方法为空实现
-	// 健康检查应该在更上层的组件中处理
+	if healthy || b.affinityTTL <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for clientIP, entry := range b.affinity {
+		if entry.upstream == upstreamName {
+			delete(b.affinity, clientIP)
+		}
+	}
 }
 
 // UpdateLatency 更新上游服务的响应延迟
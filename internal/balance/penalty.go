@@ -0,0 +1,59 @@
+package balance
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// FailurePenalty 代表上游失败后的短暂惩罚状态
+// 与熔断器不同，单次失败即生效，惩罚期内负载均衡器优先选择其他上游，到期后自动恢复
+type FailurePenalty struct {
+	duration time.Duration
+	until    atomic.Int64 // 惩罚截止时间（UnixNano），0 表示未处于惩罚期
+}
+
+// NewFailurePenalty 创建新的失败惩罚状态
+func NewFailurePenalty(duration time.Duration) *FailurePenalty {
+	return &FailurePenalty{duration: duration}
+}
+
+// Penalize 从当前时间开始进入惩罚期
+func (p *FailurePenalty) Penalize() {
+	p.until.Store(time.Now().Add(p.duration).UnixNano())
+}
+
+// Active 判断当前是否处于惩罚期
+func (p *FailurePenalty) Active() bool {
+	until := p.until.Load()
+	return until != 0 && time.Now().UnixNano() < until
+}
+
+// isUpstreamPenalized 判断上游是否处于失败惩罚期
+func isUpstreamPenalized(upstream Upstream) bool {
+	return upstream.Penalty != nil && upstream.Penalty.Active()
+}
+
+// unpenalizedUpstreams 排除处于失败惩罚期的上游服务
+// 仅当存在未处于惩罚期且熔断器未开启的上游时才排除，惩罚仅降低优先级而不会导致无可用上游可选
+func unpenalizedUpstreams(upstreams []Upstream) []Upstream {
+	penalized := 0
+	healthyUnpenalized := false
+	for _, upstream := range upstreams {
+		if isUpstreamPenalized(upstream) {
+			penalized++
+		} else if isUpstreamHealthy(upstream) {
+			healthyUnpenalized = true
+		}
+	}
+	if penalized == 0 || !healthyUnpenalized {
+		return upstreams
+	}
+
+	active := make([]Upstream, 0, len(upstreams)-penalized)
+	for _, upstream := range upstreams {
+		if !isUpstreamPenalized(upstream) {
+			active = append(active, upstream)
+		}
+	}
+	return active
+}
@@ -2,7 +2,8 @@ package balance
 
 import (
 	"context"
-	"sync/atomic"
+	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/shengyanli1982/llmproxy-go/internal/constants"
@@ -11,13 +12,27 @@ import (
 // RandomBalancer 实现随机负载均衡算法
 // 随机选择上游服务，适用于服务性能相近的场景
 type RandomBalancer struct {
-	seed uint64 // 原子操作的随机种子
+	mu  sync.Mutex // 互斥锁，rand.Rand 不支持并发访问
+	rng *rand.Rand // 随机数生成器
 }
 
-// NewRandomBalancer 创建新的随机负载均衡器实例
+// NewRandomBalancer 创建新的随机负载均衡器实例，使用当前时间作为随机种子
 func NewRandomBalancer() LoadBalancer {
+	return NewRandomBalancerWithSource(rand.NewSource(time.Now().UnixNano()))
+}
+
+// NewRandomBalancerWithSeed 创建使用指定随机种子的随机负载均衡器实例
+// 相同种子的负载均衡器对相同的上游列表产生相同的选择序列，便于测试和多副本保持一致的路由
+// seed: 随机种子
+func NewRandomBalancerWithSeed(seed int64) LoadBalancer {
+	return NewRandomBalancerWithSource(rand.NewSource(seed))
+}
+
+// NewRandomBalancerWithSource 创建使用指定随机数源的随机负载均衡器实例
+// source: 随机数源，由负载均衡器串行访问
+func NewRandomBalancerWithSource(source rand.Source) LoadBalancer {
 	return &RandomBalancer{
-		seed: uint64(time.Now().UnixNano()),
+		rng: rand.New(source),
 	}
 }
 
@@ -25,17 +40,14 @@ func NewRandomBalancer() LoadBalancer {
 // ctx: 上下文信息
 // upstreams: 可用的上游服务列表
 func (b *RandomBalancer) Select(ctx context.Context, upstreams []Upstream) (Upstream, error) {
-	if upstreams == nil {
-		return Upstream{}, ErrNilUpstreams
-	}
-	if len(upstreams) == 0 {
-		return Upstream{}, ErrEmptyUpstreams
+	if err := checkUpstreams(upstreams); err != nil {
+		return Upstream{}, err
 	}
+	upstreams = activeUpstreams(upstreams)
 
-	// 使用原子操作生成随机数，避免锁竞争
-	// 简单的线性同余生成器，适合快速随机选择
-	seed := atomic.AddUint64(&b.seed, 1)
-	index := int(seed % uint64(len(upstreams)))
+	b.mu.Lock()
+	index := b.rng.Intn(len(upstreams))
+	b.mu.Unlock()
 
 	selected := upstreams[index]
 
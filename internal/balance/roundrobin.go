@@ -24,12 +24,10 @@ func NewRRBalancer() LoadBalancer {
 // ctx: 上下文信息
 // upstreams: 可用的上游服务列表
 func (b *RRBalancer) Select(ctx context.Context, upstreams []Upstream) (Upstream, error) {
-	if upstreams == nil {
-		return Upstream{}, ErrNilUpstreams
-	}
-	if len(upstreams) == 0 {
-		return Upstream{}, ErrEmptyUpstreams
+	if err := checkUpstreams(upstreams); err != nil {
+		return Upstream{}, err
 	}
+	upstreams = activeUpstreams(upstreams)
 
 	// 使用原子操作获取下一个索引
 	idx := atomic.AddUint64(&b.index, 1) - 1
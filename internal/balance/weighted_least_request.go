@@ -0,0 +1,109 @@
+package balance
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// WeightedLeastRequestBalancer 实现加权最少请求负载均衡算法（Envoy 风格）
+// 每次随机抽取若干上游，选择进行中请求数与权重之比最小的上游，
+// 适用于上游处理时长差异较大的场景，慢上游上积压的请求会使流量自动转向其他上游
+type WeightedLeastRequestBalancer struct {
+	mu       sync.Mutex       // 互斥锁，保护随机数生成器和进行中请求计数
+	rng      *rand.Rand       // 随机数生成器
+	inFlight map[string]int64 // 每个上游进行中的请求数
+}
+
+// NewWeightedLeastRequestBalancer 创建新的加权最少请求负载均衡器实例，使用当前时间作为随机种子
+func NewWeightedLeastRequestBalancer() LoadBalancer {
+	return NewWeightedLeastRequestBalancerWithSeed(time.Now().UnixNano())
+}
+
+// NewWeightedLeastRequestBalancerWithSeed 创建使用指定随机种子的加权最少请求负载均衡器实例
+// seed: 随机种子
+func NewWeightedLeastRequestBalancerWithSeed(seed int64) LoadBalancer {
+	return &WeightedLeastRequestBalancer{
+		rng:      rand.New(rand.NewSource(seed)),
+		inFlight: make(map[string]int64),
+	}
+}
+
+// Select 在随机抽取的上游中选择进行中请求数与权重之比最小的上游，并将其进行中请求数加一
+// 上游数量不超过抽取数量时在全部上游中选择；权重不大于 0 的上游按权重 1 处理
+// ctx: 上下文信息
+// upstreams: 可用的上游服务列表
+func (b *WeightedLeastRequestBalancer) Select(ctx context.Context, upstreams []Upstream) (Upstream, error) {
+	if err := checkUpstreams(upstreams); err != nil {
+		return Upstream{}, err
+	}
+	upstreams = activeUpstreams(upstreams)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var candidates []Upstream
+	if len(upstreams) <= constants.DefaultLeastRequestChoiceCount {
+		candidates = upstreams
+	} else {
+		candidates = make([]Upstream, constants.DefaultLeastRequestChoiceCount)
+		for i := range candidates {
+			candidates[i] = upstreams[b.rng.Intn(len(upstreams))]
+		}
+	}
+
+	// 比较 (进行中请求数 + 1) / 权重，计入本次请求使空闲上游之间按权重区分
+	selected := candidates[0]
+	for _, candidate := range candidates[1:] {
+		if b.load(candidate) < b.load(selected) {
+			selected = candidate
+		}
+	}
+	b.inFlight[selected.Name]++
+
+	return selected, nil
+}
+
+// Release 将上游的进行中请求数减一
+// upstreamName: 上游服务名称
+func (b *WeightedLeastRequestBalancer) Release(upstreamName string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.inFlight[upstreamName] <= 1 {
+		delete(b.inFlight, upstreamName)
+		return
+	}
+	b.inFlight[upstreamName]--
+}
+
+// load 计算上游的加权负载，调用方必须持有锁
+func (b *WeightedLeastRequestBalancer) load(upstream Upstream) float64 {
+	weight := upstream.Weight
+	if weight <= 0 {
+		weight = 1
+	}
+	return float64(b.inFlight[upstream.Name]+1) / float64(weight)
+}
+
+// UpdateHealth 更新健康状态（加权最少请求算法不需要此信息）
+// upstreamName: 上游服务名称
+// healthy: 健康状态
+func (b *WeightedLeastRequestBalancer) UpdateHealth(upstreamName string, healthy bool) {
+	// 加权最少请求算法不需要健康状态信息，此方法为空实现
+}
+
+// UpdateLatency 更新延迟信息（加权最少请求算法不需要此信息）
+// upstreamName: 上游服务名称
+// latency: 响应延迟
+func (b *WeightedLeastRequestBalancer) UpdateLatency(upstreamName string, latency int64) {
+	// 加权最少请求算法不需要延迟信息，此方法为空实现
+}
+
+// Type 获取负载均衡器类型
+func (b *WeightedLeastRequestBalancer) Type() string {
+	return constants.BalanceWeightedLeastRequest
+}
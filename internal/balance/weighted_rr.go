@@ -3,89 +3,68 @@ package balance
 import (
 	"context"
 	"sync"
-	"sync/atomic"
 
 	"github.com/shengyanli1982/llmproxy-go/internal/constants"
 )
 
-// WeightedRRBalancer 实现加权轮询负载均衡算法
-// 根据上游服务的权重进行选择，权重越高被选中的概率越大
+// WeightedRRBalancer 实现平滑加权轮询负载均衡算法（nginx SWRR）
+// 根据上游服务的权重进行选择，权重越高被选中的次数越多，且选择结果交错分布
+// 例如权重 {5,1,1} 的选择序列为 A,A,B,A,C,A,A，而不是 A,A,A,A,A,B,C
 type WeightedRRBalancer struct {
-	weights sync.Map // 使用 sync.Map 存储 string -> *int64，支持原子操作
+	mu             sync.Mutex
+	currentWeights map[string]int64 // 上游名称 -> 当前权重
 }
 
 // NewWeightedRRBalancer 创建新的加权轮询负载均衡器实例
 func NewWeightedRRBalancer() LoadBalancer {
-	return &WeightedRRBalancer{}
+	return &WeightedRRBalancer{
+		currentWeights: make(map[string]int64),
+	}
 }
 
-// Select 使用加权轮询算法选择上游服务
+// Select 使用平滑加权轮询算法选择上游服务
+// 每次选择时所有上游的当前权重增加其配置权重，选中当前权重最大者（相同时取靠前者），
+// 再将选中上游的当前权重减去总权重。整个过程在锁内完成，保证并发下选择序列仍然平滑
 // ctx: 上下文信息
 // upstreams: 可用的上游服务列表
 func (b *WeightedRRBalancer) Select(ctx context.Context, upstreams []Upstream) (Upstream, error) {
-	if upstreams == nil {
-		return Upstream{}, ErrNilUpstreams
-	}
-	if len(upstreams) == 0 {
-		return Upstream{}, ErrEmptyUpstreams
+	if err := checkUpstreams(upstreams); err != nil {
+		return Upstream{}, err
 	}
+	upstreams = activeUpstreams(upstreams)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
 
-	// 使用原子操作优化权重计算，无需显式锁
 	var totalWeight int64
-	var selected Upstream
-	var maxCurrentWeight int64 = -1
+	selectedIndex := -1
+	var maxCurrentWeight int64
 
-	for _, upstream := range upstreams {
+	for i, upstream := range upstreams {
 		weight := int64(upstream.Weight)
 		if weight <= 0 {
 			weight = 1 // 默认权重为1
 		}
 		totalWeight += weight
 
-		// 获取或创建原子权重值
-		weightPtr := b.getOrCreateAtomicWeight(upstream.Name)
+		currentWeight := b.currentWeights[upstream.Name] + weight
+		b.currentWeights[upstream.Name] = currentWeight
 
-		// 原子操作增加当前权重
-		newWeight := atomic.AddInt64(weightPtr, weight)
-
-		// 选择当前权重最大的服务
-		if newWeight > maxCurrentWeight {
-			maxCurrentWeight = newWeight
-			selected = upstream
+		// 选择当前权重最大的服务，严格大于保证相同权重时顺序确定
+		if selectedIndex < 0 || currentWeight > maxCurrentWeight {
+			maxCurrentWeight = currentWeight
+			selectedIndex = i
 		}
 	}
 
-	// 原子操作减少选中服务的当前权重
-	if weightPtr := b.getAtomicWeight(selected.Name); weightPtr != nil {
-		atomic.AddInt64(weightPtr, -totalWeight)
-	}
+	selected := upstreams[selectedIndex]
+	b.currentWeights[selected.Name] -= totalWeight
 
 	// 注意：负载均衡器的选择日志将在调用方记录
-	// 加权轮询算法的权重信息已记录在选择决策中
 
 	return selected, nil
 }
 
-// getOrCreateAtomicWeight 获取或创建原子权重值
-func (b *WeightedRRBalancer) getOrCreateAtomicWeight(name string) *int64 {
-	if value, ok := b.weights.Load(name); ok {
-		return value.(*int64)
-	}
-
-	// 创建新的原子权重值
-	newWeight := new(int64)
-	actual, _ := b.weights.LoadOrStore(name, newWeight)
-	return actual.(*int64)
-}
-
-// getAtomicWeight 获取原子权重值
-func (b *WeightedRRBalancer) getAtomicWeight(name string) *int64 {
-	if value, ok := b.weights.Load(name); ok {
-		return value.(*int64)
-	}
-	return nil
-}
-
 // UpdateHealth 更新健康状态（加权轮询算法不需要此信息）
 // upstreamName: 上游服务名称
 // healthy: 健康状态
@@ -0,0 +1,67 @@
+package breaker
+
+import (
+	"sync/atomic"
+
+	"github.com/sony/gobreaker"
+)
+
+// ProbeBreaker 代表使用合成探测请求恢复的熔断器
+// 半开状态下不放行用户请求，而是由探测函数发送请求决定是否闭合：
+// 连续探测成功达到半开状态最大请求数后闭合，任一探测失败则重新开启
+// 半开状态对外报告为开启状态，使负载均衡器在探测成功前跳过该上游
+type ProbeBreaker struct {
+	CircuitBreaker
+	probe   func() error
+	probing atomic.Bool
+}
+
+// NewProbeBreaker 创建使用合成探测请求恢复的熔断器
+// cb: 被包装的熔断器
+// probe: 探测函数，返回错误表示探测失败
+func NewProbeBreaker(cb CircuitBreaker, probe func() error) *ProbeBreaker {
+	return &ProbeBreaker{
+		CircuitBreaker: cb,
+		probe:          probe,
+	}
+}
+
+// Execute 执行受保护的操作，半开状态下拒绝用户请求并启动探测
+func (b *ProbeBreaker) Execute(req func() (interface{}, error)) (interface{}, error) {
+	if b.CircuitBreaker.State() == gobreaker.StateHalfOpen {
+		b.startProbe()
+		return nil, gobreaker.ErrOpenState
+	}
+	return b.CircuitBreaker.Execute(req)
+}
+
+// State 获取当前状态，半开状态下启动探测并报告为开启状态
+func (b *ProbeBreaker) State() gobreaker.State {
+	state := b.CircuitBreaker.State()
+	if state == gobreaker.StateHalfOpen {
+		b.startProbe()
+		return gobreaker.StateOpen
+	}
+	return state
+}
+
+// startProbe 启动后台探测，同一时刻只运行一个探测
+func (b *ProbeBreaker) startProbe() {
+	if !b.probing.CompareAndSwap(false, true) {
+		return
+	}
+	go b.runProbes()
+}
+
+// runProbes 在半开状态下依次发送探测请求，直到熔断器闭合或重新开启
+func (b *ProbeBreaker) runProbes() {
+	defer b.probing.Store(false)
+
+	for b.CircuitBreaker.State() == gobreaker.StateHalfOpen {
+		if _, err := b.CircuitBreaker.Execute(func() (interface{}, error) {
+			return nil, b.probe()
+		}); err != nil {
+			return
+		}
+	}
+}
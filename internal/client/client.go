@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -33,6 +34,10 @@ type httpClient struct {
 	config       *config.HTTPClientConfig
 	closed       bool
 
+	// 按上游缓存的自定义 TLS 客户端
+	tlsMu      sync.Mutex
+	tlsClients map[string]*http.Client
+
 	// 依赖的模块
 	authFactory    auth.AuthenticatorFactory
 	headerOperator headers.HeaderOperator
@@ -62,8 +67,9 @@ func NewHTTPClient(cfg *config.HTTPClientConfig) (HTTPClient, error) {
 
 	// 创建HTTP客户端
 	client := &http.Client{
-		Transport: pool.GetTransport(),
-		Timeout:   time.Duration(requestTimeout) * time.Millisecond,
+		Transport:     pool.GetTransport(),
+		Timeout:       time.Duration(requestTimeout) * time.Millisecond,
+		CheckRedirect: newCheckRedirect(cfg.FollowRedirects),
 	}
 
 	// 设置代理
@@ -78,6 +84,7 @@ func NewHTTPClient(cfg *config.HTTPClientConfig) (HTTPClient, error) {
 		proxyHandler:   proxyHandler,
 		config:         cfg,
 		closed:         false,
+		tlsClients:     make(map[string]*http.Client),
 		authFactory:    auth.NewFactory(),
 		headerOperator: headers.NewOperator(),
 		logger:         logr.Discard(), // 默认使用丢弃日志记录器
@@ -120,12 +127,14 @@ func (c *httpClient) Do(req *http.Request, upstream *balance.Upstream) (*http.Re
 		"upstream", upstream.Name,
 		"preparation_duration_ms", time.Since(startTime).Milliseconds())
 
-	// 执行请求
+	// 执行请求，跟踪请求使用的连接，响应体读取完毕或关闭后连接计为空闲
 	execStartTime := time.Now()
-	resp, err := c.client.Do(req)
+	tracedReq, lease := c.pool.conns.trackRequest(req)
+	resp, err := c.clientFor(upstream.Name, req.URL.Host).Do(tracedReq)
 	execDuration := time.Since(execStartTime)
 
 	if err != nil {
+		lease.release()
 		c.logger.Error(err, "HTTP request execution failed",
 			"upstream", upstream.Name,
 			"target_url", req.URL.String(),
@@ -139,9 +148,23 @@ func (c *httpClient) Do(req *http.Request, upstream *balance.Upstream) (*http.Re
 		"content_length", resp.ContentLength,
 		"execution_duration_ms", execDuration.Milliseconds())
 
+	resp.Body = &leasedBody{ReadCloser: resp.Body, lease: lease}
 	return resp, nil
 }
 
+// ConnectionStats 获取到上游主机的连接数，使用自定义 TLS 配置的上游同样计入
+// 同一上游组内地址相同的上游共享连接
+func (c *httpClient) ConnectionStats(upstream *balance.Upstream) ConnectionStats {
+	if upstream == nil {
+		return ConnectionStats{}
+	}
+	u, err := url.Parse(upstream.URL)
+	if err != nil {
+		return ConnectionStats{}
+	}
+	return c.pool.ConnectionStats(hostKey(u))
+}
+
 // prepareRequest 准备HTTP请求，设置目标URL和认证信息
 // 注意：此方法会修改传入的http.Request，调用者需要确保并发安全
 func (c *httpClient) prepareRequest(req *http.Request, upstream *balance.Upstream) error {
@@ -159,32 +182,18 @@ func (c *httpClient) prepareRequest(req *http.Request, upstream *balance.Upstrea
 
 	originalURL := req.URL.String()
 
-	// 解析upstream URL，处理不带scheme的情况
+	// 优先使用加载配置时缓存的解析结果，未缓存时（如未经过配置加载的上游）按相同规则解析
 	var upstreamURL *url.URL
-	var err error
-
-	// 首先尝试直接解析
-	upstreamURL, err = url.Parse(upstream.URL)
-	if err != nil {
-		// 如果解析失败，可能是因为缺少scheme，尝试添加http://前缀
-		upstreamURL, err = url.Parse(constants.DefaultScheme + upstream.URL)
+	if upstream.Config != nil {
+		upstreamURL = upstream.Config.ParsedURL()
+	}
+	if upstreamURL == nil {
+		var err error
+		upstreamURL, err = config.NormalizeUpstreamURL(upstream.URL)
 		if err != nil {
 			c.logger.Error(err, "Invalid upstream URL", "upstream", upstream.Name, "url", upstream.URL)
 			return fmt.Errorf("invalid upstream URL '%s': %w", upstream.URL, err)
 		}
-	} else if upstreamURL.Scheme == "" {
-		// 如果解析成功但没有scheme，添加http://前缀重新解析
-		upstreamURL, err = url.Parse(constants.DefaultScheme + upstream.URL)
-		if err != nil {
-			c.logger.Error(err, "Invalid upstream URL after adding scheme", "upstream", upstream.Name, "url", upstream.URL)
-			return fmt.Errorf("invalid upstream URL '%s': %w", upstream.URL, err)
-		}
-	}
-
-	// 验证URL必须包含host
-	if upstreamURL.Host == "" {
-		c.logger.Error(nil, "Upstream URL must include host", "upstream", upstream.Name, "url", upstream.URL)
-		return fmt.Errorf("upstream URL must include host: %s", upstream.URL)
 	}
 
 	// 设置目标URL的scheme和host
@@ -204,7 +213,7 @@ func (c *httpClient) prepareRequest(req *http.Request, upstream *balance.Upstrea
 	//      upstream配置：https://ark.cn-beijing.volces.com
 	//      最终转发：https://ark.cn-beijing.volces.com/api/v3/chat/completions
 
-	if upstreamURL.Path != "" && upstreamURL.Path != "/" {
+	if upstreamURL.Path != "" {
 		// 如果upstream URL包含具体路径，使用upstream的路径（支持完整端点配置）
 		req.URL.Path = upstreamURL.Path
 		// 同时保留upstream URL的查询参数和片段
@@ -219,6 +228,21 @@ func (c *httpClient) prepareRequest(req *http.Request, upstream *balance.Upstrea
 	// 注意：当upstream URL是基础URL时，我们不需要修改req.URL.Path、RawQuery、Fragment
 	// 它们保持用户请求的原始值，实现了"基础URL + 用户路径"的拼接机制
 
+	// 为配置了 TLS 的上游准备独立的传输层
+	if upstream.Config != nil && upstream.Config.TLS != nil {
+		if _, err := c.tlsClientFor(upstream.Name, req.URL.Host, upstream.Config.TLS); err != nil {
+			c.logger.Error(err, "Failed to prepare upstream TLS transport", "upstream", upstream.Name)
+			return fmt.Errorf("failed to prepare TLS transport: %w", err)
+		}
+	}
+
+	// 移除配置的客户端头部，在应用认证和头部操作之前执行，不影响上游自身的认证头部
+	if upstream.Config != nil {
+		for _, name := range upstream.Config.StripHeaders {
+			req.Header.Del(name)
+		}
+	}
+
 	// 应用认证（使用缓存的认证器）
 	if upstream.Authenticator != nil {
 		c.logger.Info("Applying authentication", "upstream", upstream.Name, "auth_type", upstream.Authenticator.Type())
@@ -239,6 +263,25 @@ func (c *httpClient) prepareRequest(req *http.Request, upstream *balance.Upstrea
 		}
 	}
 
+	// 对 JSON 请求体执行上游配置的转换操作
+	if upstream.Config != nil && len(upstream.Config.RequestTransform) > 0 {
+		if err := c.transformRequestBody(req, upstream); err != nil {
+			c.logger.Error(err, "Failed to read request body for transform", "upstream", upstream.Name)
+			return fmt.Errorf("failed to transform request body: %w", err)
+		}
+	}
+
+	// 覆盖客户端的Accept-Encoding，例如使用identity获取未压缩的响应，便于转换或解析响应体
+	if upstream.Config != nil && upstream.Config.RequestAcceptEncoding != "" {
+		req.Header.Set(constants.HeaderAcceptEncoding, upstream.Config.RequestAcceptEncoding)
+	}
+
+	// 应用上游指定的User-Agent，覆盖客户端级别的默认值；保留客户端头部时不覆盖客户端提供的值
+	if upstream.Config != nil && upstream.Config.UserAgent != "" &&
+		(!c.config.PreserveClientHeaders || req.Header.Get(constants.HeaderUserAgent) == "") {
+		req.Header.Set(constants.HeaderUserAgent, upstream.Config.UserAgent)
+	}
+
 	// 设置默认头部
 	c.setDefaultHeaders(req)
 
@@ -252,26 +295,39 @@ func (c *httpClient) prepareRequest(req *http.Request, upstream *balance.Upstrea
 }
 
 // setDefaultHeaders 设置默认HTTP头部
+// User-Agent 和 X-Forwarded-Host 仅在缺失时设置；Connection 默认按 KeepAlive 配置覆盖
+// 开启 PreserveClientHeaders 时 Connection 也仅在缺失时设置，且不注入 X-Forwarded-Host
 func (c *httpClient) setDefaultHeaders(req *http.Request) {
 	// 设置User-Agent
 	if req.Header.Get(constants.HeaderUserAgent) == "" {
 		req.Header.Set(constants.HeaderUserAgent, constants.UserAgent)
 	}
 
-	// 设置Connection头部
-	// 如果KeepAlive为0，表示禁用Keep-Alive
-	if c.config.KeepAlive == 0 {
-		req.Header.Set(constants.HeaderConnection, constants.ConnectionClose)
-	} else {
-		req.Header.Set(constants.HeaderConnection, constants.ConnectionKeepAlive)
+	if c.config.PreserveClientHeaders {
+		if req.Header.Get(constants.HeaderConnection) == "" {
+			req.Header.Set(constants.HeaderConnection, c.connectionHeader())
+		}
+		return
 	}
 
+	// 设置Connection头部
+	req.Header.Set(constants.HeaderConnection, c.connectionHeader())
+
 	// 保持原始Host头部用于代理
 	if req.Header.Get(constants.HeaderXForwardedHost) == "" && req.Host != "" {
 		req.Header.Set(constants.HeaderXForwardedHost, req.Host)
 	}
 }
 
+// connectionHeader 获取 Connection 头部的默认值
+func (c *httpClient) connectionHeader() string {
+	// 如果KeepAlive为0，表示禁用Keep-Alive
+	if c.config.KeepAlive == 0 {
+		return constants.ConnectionClose
+	}
+	return constants.ConnectionKeepAlive
+}
+
 // Close 关闭客户端并清理资源
 func (c *httpClient) Close() error {
 	if c.closed {
@@ -280,6 +336,9 @@ func (c *httpClient) Close() error {
 
 	c.closed = true
 
+	// 关闭自定义 TLS 传输层
+	c.closeTLSClients()
+
 	// 关闭连接池
 	if c.pool != nil {
 		return c.pool.Close()
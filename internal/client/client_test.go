@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -14,8 +16,10 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/auth"
 	"github.com/shengyanli1982/llmproxy-go/internal/balance"
 	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -446,6 +450,31 @@ func TestHTTPClient_ConnectionPoolBehavior(t *testing.T) {
 	})
 }
 
+func TestConnectionPool_TCPKeepAlive(t *testing.T) {
+	t.Run("default tcp keepalive", func(t *testing.T) {
+		dialer := newDialer(createMinimalConfig())
+		assert.Equal(t, time.Duration(constants.DefaultTCPKeepAlive)*time.Millisecond, dialer.KeepAlive)
+	})
+
+	t.Run("custom tcp keepalive", func(t *testing.T) {
+		cfg := createMinimalConfig()
+		cfg.TCPKeepAlive = 15000
+		cfg.Timeout = &config.TimeoutConfig{Connect: 2000}
+
+		dialer := newDialer(cfg)
+		assert.Equal(t, 15*time.Second, dialer.KeepAlive)
+		assert.Equal(t, 2*time.Second, dialer.Timeout)
+	})
+
+	t.Run("independent of http keepalive", func(t *testing.T) {
+		cfg := createMinimalConfig()
+		cfg.KeepAlive = 0
+
+		dialer := newDialer(cfg)
+		assert.Equal(t, time.Duration(constants.DefaultTCPKeepAlive)*time.Millisecond, dialer.KeepAlive)
+	})
+}
+
 func TestHTTPClient_KeepAliveBehavior(t *testing.T) {
 	factory := NewFactory()
 
@@ -603,6 +632,194 @@ func TestHTTPClient_HeaderHandling(t *testing.T) {
 		forwardedHost := resp.Header.Get("Echo-X-Forwarded-Host")
 		assert.Equal(t, "original-host.com", forwardedHost)
 	})
+
+	t.Run("strip headers", func(t *testing.T) {
+		server := createHeaderTestServer()
+		defer server.Close()
+
+		cfg := createMinimalConfig()
+
+		client, err := factory.Create(cfg)
+		require.NoError(t, err)
+		defer client.Close()
+
+		authenticator, err := auth.NewBearerAuthenticator("upstream-token")
+		require.NoError(t, err)
+
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer client-token")
+		req.Header.Set("X-Internal-User", "alice")
+		req.Header.Set("X-Keep", "kept")
+		upstream := createTestUpstream(server.URL)
+		upstream.Authenticator = authenticator
+		upstream.Config = &config.UpstreamConfig{
+			Name:         upstream.Name,
+			URL:          server.URL,
+			StripHeaders: []string{"authorization", "X-Internal-User"},
+		}
+
+		resp, err := client.Do(req, upstream)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		// 配置的头部被移除，上游自身的认证在移除之后应用
+		assert.Empty(t, resp.Header.Get("Echo-X-Internal-User"))
+		assert.Equal(t, "kept", resp.Header.Get("Echo-X-Keep"))
+		assert.Equal(t, "Bearer upstream-token", resp.Header.Get("Echo-Authorization"))
+	})
+
+	t.Run("upstream user agent", func(t *testing.T) {
+		server := createHeaderTestServer()
+		defer server.Close()
+
+		cfg := createMinimalConfig()
+
+		client, err := factory.Create(cfg)
+		require.NoError(t, err)
+		defer client.Close()
+
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("User-Agent", "client-agent/1.0")
+		upstream := createTestUpstream(server.URL)
+		upstream.Config = &config.UpstreamConfig{
+			Name:      upstream.Name,
+			URL:       server.URL,
+			UserAgent: "upstream-agent/2.0",
+		}
+
+		resp, err := client.Do(req, upstream)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, "upstream-agent/2.0", resp.Header.Get("Echo-User-Agent"))
+	})
+
+	t.Run("upstream accept encoding", func(t *testing.T) {
+		server := createHeaderTestServer()
+		defer server.Close()
+
+		client, err := factory.Create(createMinimalConfig())
+		require.NoError(t, err)
+		defer client.Close()
+
+		send := func(acceptEncoding string) string {
+			req, _ := http.NewRequest("GET", "/test", nil)
+			req.Header.Set("Accept-Encoding", "gzip, br")
+			upstream := createTestUpstream(server.URL)
+			upstream.Config = &config.UpstreamConfig{
+				Name:                  upstream.Name,
+				URL:                   server.URL,
+				RequestAcceptEncoding: acceptEncoding,
+			}
+
+			resp, err := client.Do(req, upstream)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			return resp.Header.Get("Echo-Accept-Encoding")
+		}
+
+		assert.Equal(t, "identity", send("identity"))
+		assert.Equal(t, "gzip, br", send(""))
+	})
+
+	t.Run("preserve client headers", func(t *testing.T) {
+		server := createHeaderTestServer()
+		defer server.Close()
+
+		upstream := createTestUpstream(server.URL)
+		upstream.Config = &config.UpstreamConfig{
+			Name:      upstream.Name,
+			URL:       server.URL,
+			UserAgent: "upstream-agent/2.0",
+		}
+
+		send := func(preserve bool, clientHeaders map[string]string) http.Header {
+			cfg := createMinimalConfig()
+			cfg.PreserveClientHeaders = preserve
+
+			client, err := factory.Create(cfg)
+			require.NoError(t, err)
+			defer client.Close()
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			req.Host = "original-host.com"
+			for name, value := range clientHeaders {
+				req.Header.Set(name, value)
+			}
+
+			resp, err := client.Do(req, upstream)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			return resp.Header
+		}
+
+		clientHeaders := map[string]string{
+			"User-Agent": "client-agent/1.0",
+			"Connection": "close",
+		}
+
+		// 默认模式：上游 User-Agent 和 KeepAlive 对应的 Connection 覆盖客户端的值，并注入 X-Forwarded-Host
+		echoed := send(false, clientHeaders)
+		assert.Equal(t, "upstream-agent/2.0", echoed.Get("Echo-User-Agent"))
+		assert.Equal(t, "keep-alive", echoed.Get("Echo-Connection"))
+		assert.Equal(t, "original-host.com", echoed.Get("Echo-X-Forwarded-Host"))
+
+		// 保留模式：客户端提供的值保持不变，且不注入 X-Forwarded-Host
+		echoed = send(true, clientHeaders)
+		assert.Equal(t, "client-agent/1.0", echoed.Get("Echo-User-Agent"))
+		assert.Equal(t, "close", echoed.Get("Echo-Connection"))
+		assert.Empty(t, echoed.Get("Echo-X-Forwarded-Host"))
+
+		// 保留模式：客户端未提供时仍补充默认值
+		echoed = send(true, map[string]string{"User-Agent": ""})
+		assert.Equal(t, "upstream-agent/2.0", echoed.Get("Echo-User-Agent"))
+		assert.Equal(t, "keep-alive", echoed.Get("Echo-Connection"))
+	})
+
+	t.Run("request body transform", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Echo-Content-Length", strconv.FormatInt(r.ContentLength, 10))
+			_, _ = w.Write(body)
+		}))
+		defer server.Close()
+
+		cfg := createMinimalConfig()
+
+		client, err := factory.Create(cfg)
+		require.NoError(t, err)
+		defer client.Close()
+
+		upstream := createTestUpstream(server.URL)
+		upstream.Config = &config.UpstreamConfig{
+			Name: upstream.Name,
+			URL:  server.URL,
+			RequestTransform: []config.BodyTransformConfig{
+				{Op: "rename", Field: "messages", To: "contents"},
+				{Op: "default", Field: "max_tokens", Value: 256},
+			},
+		}
+
+		send := func(contentType, body string) (string, string) {
+			req, _ := http.NewRequest("POST", "/test", strings.NewReader(body))
+			req.Header.Set("Content-Type", contentType)
+			resp, err := client.Do(req, upstream)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			echoed, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			return string(echoed), resp.Header.Get("Echo-Content-Length")
+		}
+
+		// JSON 请求体按配置转换，Content-Length 随之更新
+		echoed, contentLength := send("application/json", `{"messages": []}`)
+		assert.JSONEq(t, `{"contents": [], "max_tokens": 256}`, echoed)
+		assert.Equal(t, strconv.Itoa(len(echoed)), contentLength)
+
+		// 非 JSON 请求体原样转发
+		echoed, _ = send("text/plain", `{"messages": []}`)
+		assert.Equal(t, `{"messages": []}`, echoed)
+	})
 }
 
 func TestHTTPClient_URLHandling(t *testing.T) {
@@ -721,6 +938,36 @@ func TestHTTPClient_URLSplitAndJoin(t *testing.T) {
 		assert.Equal(t, "path join test", string(body))
 	})
 
+	t.Run("normalized upstream config", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/v3/chat/completions", r.URL.Path)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		// 经过 SetDefaults 的上游使用缓存的解析结果，末尾斜杠和缺省协议已被规范化
+		manager, err := config.NewManager()
+		require.NoError(t, err)
+		globalConfig := &config.Config{Upstreams: []config.UpstreamConfig{
+			{Name: "trailing-slash", URL: server.URL + "/"},
+			{Name: "scheme-less", URL: strings.TrimPrefix(server.URL, "http://")},
+		}}
+		manager.SetDefaults(globalConfig)
+
+		for i := range globalConfig.Upstreams {
+			upstreamConfig := &globalConfig.Upstreams[i]
+			require.NotNil(t, upstreamConfig.ParsedURL())
+
+			req, _ := http.NewRequest("POST", "/api/v3/chat/completions", strings.NewReader(`{}`))
+			upstream := &balance.Upstream{Name: upstreamConfig.Name, URL: upstreamConfig.URL, Config: upstreamConfig}
+
+			resp, err := client.Do(req, upstream)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+	})
+
 	t.Run("complete endpoint URL overrides user path", func(t *testing.T) {
 		// 测试upstream URL包含完整路径的情况
 		// upstream配置：https://api.example.com/v1/completions
@@ -1616,3 +1863,71 @@ func TestHTTPClient_ProxyRetryIntegration(t *testing.T) {
 		}
 	})
 }
+
+func TestHTTPClient_Warmup(t *testing.T) {
+	factory := NewFactory()
+
+	t.Run("pre-establishes idle connections", func(t *testing.T) {
+		var newConns, headRequests atomic.Int32
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				headRequests.Add(1)
+				// 保持连接占用，确保并发预热请求各自建立新连接
+				time.Sleep(50 * time.Millisecond)
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				newConns.Add(1)
+			}
+		}
+		server.Start()
+		defer server.Close()
+
+		client, err := factory.Create(createConnectConfig(100, 10, 100))
+		require.NoError(t, err)
+		defer client.Close()
+
+		upstream := createTestUpstream(server.URL)
+		require.NoError(t, client.Warmup(context.Background(), upstream, 3))
+		assert.Equal(t, int32(3), headRequests.Load())
+		assert.Equal(t, int32(3), newConns.Load())
+
+		// 后续请求复用预热的连接
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+				resp, err := client.Do(req, upstream)
+				if assert.NoError(t, err) {
+					resp.Body.Close()
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(3), newConns.Load())
+	})
+
+	t.Run("unreachable upstream", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		serverURL := server.URL
+		server.Close()
+
+		client, err := factory.Create(createMinimalConfig())
+		require.NoError(t, err)
+		defer client.Close()
+
+		assert.Error(t, client.Warmup(context.Background(), createTestUpstream(serverURL), 2))
+	})
+
+	t.Run("zero connections", func(t *testing.T) {
+		client, err := factory.Create(createMinimalConfig())
+		require.NoError(t, err)
+		defer client.Close()
+
+		assert.NoError(t, client.Warmup(context.Background(), createTestUpstream("http://127.0.0.1:1"), 0))
+	})
+}
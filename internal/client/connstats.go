@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// ConnectionStats 代表到上游主机的连接池使用情况
+type ConnectionStats struct {
+	Active int `json:"active"` // 正在处理请求的连接数
+	Idle   int `json:"idle"`   // 池中空闲的连接数
+}
+
+// connTracker 统计连接池中每个上游主机的活跃和空闲连接数
+// http.Transport 不提供连接池的实时状态，因此包装拨号函数跟踪连接的关闭，并通过 httptrace 跟踪连接的使用
+type connTracker struct {
+	mu    sync.Mutex
+	hosts map[string]*ConnectionStats // 按上游主机（host:port）统计的连接数
+}
+
+// newConnTracker 创建连接统计器
+func newConnTracker() *connTracker {
+	return &connTracker{hosts: make(map[string]*ConnectionStats)}
+}
+
+// trackedConn 代表被统计的上游连接，首次被请求使用时归属到请求的上游主机
+// 通过 HTTP 代理转发时拨号地址为代理地址，因此使用请求的上游主机而不是拨号地址
+type trackedConn struct {
+	net.Conn
+	tracker  *connTracker
+	host     string // 连接归属的上游主机，尚未被使用时为空
+	inflight int    // 使用该连接的进行中请求数，HTTP/2 连接可同时处理多个请求
+	closed   bool
+}
+
+// Close 关闭连接并从统计中移除
+func (c *trackedConn) Close() error {
+	c.tracker.mu.Lock()
+	if !c.closed && c.host != "" {
+		stats := c.tracker.hosts[c.host]
+		if c.inflight > 0 {
+			stats.Active--
+		} else {
+			stats.Idle--
+		}
+	}
+	c.closed = true
+	c.tracker.mu.Unlock()
+
+	return c.Conn.Close()
+}
+
+// DialContext 包装拨号函数，返回被统计的连接
+func (t *connTracker) DialContext(dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &trackedConn{Conn: conn, tracker: t}, nil
+	}
+}
+
+// acquire 记录请求开始使用连接，连接不是由统计器创建时返回 nil
+func (t *connTracker) acquire(conn net.Conn, host string) *trackedConn {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	tracked, ok := conn.(*trackedConn)
+	if !ok {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tracked.closed {
+		return nil
+	}
+	if tracked.host == "" {
+		tracked.host = host
+		if _, exists := t.hosts[host]; !exists {
+			t.hosts[host] = &ConnectionStats{}
+		}
+		t.hosts[host].Idle++
+	}
+
+	stats := t.hosts[tracked.host]
+	if tracked.inflight == 0 {
+		stats.Idle--
+		stats.Active++
+	}
+	tracked.inflight++
+	return tracked
+}
+
+// release 记录请求结束使用连接，连接上没有进行中的请求时计为空闲
+func (t *connTracker) release(tracked *trackedConn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tracked.closed || tracked.inflight == 0 {
+		return
+	}
+
+	tracked.inflight--
+	if tracked.inflight == 0 {
+		stats := t.hosts[tracked.host]
+		stats.Active--
+		stats.Idle++
+	}
+}
+
+// Stats 获取到指定上游主机的连接数
+// host: 上游主机，格式为 host:port
+func (t *connTracker) Stats(host string) ConnectionStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if stats, ok := t.hosts[host]; ok {
+		return *stats
+	}
+	return ConnectionStats{}
+}
+
+// connLease 代表一次请求对连接的使用，Transport 重试请求时可能先后获取多个连接
+type connLease struct {
+	mu      sync.Mutex
+	tracker *connTracker
+	host    string
+	conn    *trackedConn
+}
+
+// trace 返回在获取连接时记录连接使用的 httptrace.ClientTrace
+func (l *connLease) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.conn != nil {
+				l.tracker.release(l.conn)
+			}
+			l.conn = l.tracker.acquire(info.Conn, l.host)
+		},
+	}
+}
+
+// release 结束对连接的使用，可重复调用
+func (l *connLease) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn != nil {
+		l.tracker.release(l.conn)
+		l.conn = nil
+	}
+}
+
+// leasedBody 包装响应体，响应体读取完毕或关闭时结束对连接的使用
+type leasedBody struct {
+	io.ReadCloser
+	lease *connLease
+}
+
+// Read 读取响应体，读取到末尾时结束对连接的使用
+func (b *leasedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.lease.release()
+	}
+	return n, err
+}
+
+// Close 关闭响应体并结束对连接的使用
+func (b *leasedBody) Close() error {
+	b.lease.release()
+	return b.ReadCloser.Close()
+}
+
+// trackRequest 为请求附加连接使用跟踪，返回的 connLease 需要在响应体关闭或请求失败时释放
+func (t *connTracker) trackRequest(req *http.Request) (*http.Request, *connLease) {
+	lease := &connLease{tracker: t, host: hostKey(req.URL)}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), lease.trace())), lease
+}
+
+// hostKey 获取 URL 对应的上游主机，省略端口时使用协议的默认端口
+func hostKey(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == constants.ProtocolHTTPS {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
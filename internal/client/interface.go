@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/go-logr/logr"
@@ -13,6 +14,15 @@ type HTTPClient interface {
 	// Do 执行HTTP请求到指定上游服务
 	Do(req *http.Request, upstream *balance.Upstream) (*http.Response, error)
 
+	// Warmup 预先建立到指定上游的空闲连接
+	Warmup(ctx context.Context, upstream *balance.Upstream, connections int) error
+
+	// Probe 向上游发送探测请求，请求失败或上游返回 5xx 时返回错误
+	Probe(ctx context.Context, upstream *balance.Upstream, path string) error
+
+	// ConnectionStats 获取到上游主机的活跃和空闲连接数
+	ConnectionStats(upstream *balance.Upstream) ConnectionStats
+
 	// Close 关闭客户端并清理资源
 	Close() error
 
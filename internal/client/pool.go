@@ -4,16 +4,18 @@ import (
 	"crypto/tls"
 	"net"
 	"net/http"
-	"net/url"
 	"time"
 
 	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
 )
 
 // ConnectionPool 连接池管理器
 type ConnectionPool struct {
 	transport *http.Transport
 	config    *config.HTTPClientConfig
+	dnsCache  *dnsCache
+	conns     *connTracker
 }
 
 // NewConnectionPool 创建新的连接池实例
@@ -22,21 +24,21 @@ func NewConnectionPool(cfg *config.HTTPClientConfig) *ConnectionPool {
 	transport := &http.Transport{
 		// TLS配置
 		TLSHandshakeTimeout: 30 * time.Second,
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: false,
-		},
+		TLSClientConfig:     newClientTLSConfig(cfg.TLS),
 
 		// Keep-Alive配置 - 如果KeepAlive为0，禁用Keep-Alive
 		DisableKeepAlives: cfg.KeepAlive == 0,
 
 		// 拨号配置
-		DialContext: (&net.Dialer{
-			KeepAlive: time.Duration(cfg.KeepAlive) * time.Millisecond,
-		}).DialContext,
+		DialContext: newDialer(cfg).DialContext,
+	}
 
-		// 期望继续超时
-		ExpectContinueTimeout: 1 * time.Second,
+	// 期望继续超时，请求带有 Expect: 100-continue 头部时等待上游响应的时间，超时后直接发送请求体
+	expectContinueTimeout := cfg.ExpectContinueTimeout
+	if expectContinueTimeout <= 0 {
+		expectContinueTimeout = constants.DefaultExpectContinueTimeout
 	}
+	transport.ExpectContinueTimeout = time.Duration(expectContinueTimeout) * time.Millisecond
 
 	// 设置连接池配置
 	if cfg.Connect != nil {
@@ -47,12 +49,6 @@ func NewConnectionPool(cfg *config.HTTPClientConfig) *ConnectionPool {
 
 	// 设置超时配置
 	if cfg.Timeout != nil {
-		if cfg.Timeout.Connect > 0 {
-			transport.DialContext = (&net.Dialer{
-				Timeout:   time.Duration(cfg.Timeout.Connect) * time.Millisecond,
-				KeepAlive: time.Duration(cfg.KeepAlive) * time.Millisecond,
-			}).DialContext
-		}
 		if cfg.Timeout.Request > 0 {
 			transport.ResponseHeaderTimeout = time.Duration(cfg.Timeout.Request) * time.Millisecond
 		}
@@ -61,17 +57,72 @@ func NewConnectionPool(cfg *config.HTTPClientConfig) *ConnectionPool {
 		}
 	}
 
-	// 配置代理
+	// 配置 DNS 解析缓存，定期重新解析上游主机名，解析结果变化时关闭指向旧地址的空闲连接
+	dnsCacheTTL := cfg.DNSCacheTTL
+	if dnsCacheTTL <= 0 {
+		dnsCacheTTL = constants.DefaultDNSCacheTTL
+	}
+	cache := newDNSCache(time.Duration(dnsCacheTTL) * time.Millisecond)
+	cache.onChange = transport.CloseIdleConnections
+	transport.DialContext = cache.DialContext(transport.DialContext)
+
+	// 配置代理，SOCKS5 代理通过包装拨号函数实现，连接代理服务器时同样使用 DNS 解析缓存
 	if cfg.Proxy != nil {
-		if proxyURL, err := url.Parse(cfg.Proxy.URL); err == nil {
-			transport.Proxy = http.ProxyURL(proxyURL)
+		proxyHandler := NewProxyHandler(cfg.Proxy)
+		if proxyHandler.IsEnabled() {
+			transport.Proxy = proxyHandler.GetProxyFunc()
+			if proxyHandler.IsSOCKS5() {
+				transport.DialContext = proxyHandler.DialContext(transport.DialContext)
+			}
 		}
 	}
 
+	// 统计每个上游主机的活跃和空闲连接数，包装在最外层以统计 Transport 实际使用的连接
+	conns := newConnTracker()
+	transport.DialContext = conns.DialContext(transport.DialContext)
+
 	return &ConnectionPool{
 		transport: transport,
 		config:    cfg,
+		dnsCache:  cache,
+		conns:     conns,
+	}
+}
+
+// newClientTLSConfig 根据 HTTP 客户端的 TLS 限制创建 tls.Config，未配置的项保留 Go 的安全默认值
+// 版本和加密套件在加载配置时已验证，此处忽略解析错误
+func newClientTLSConfig(cfg *config.ClientTLSConfig) *tls.Config {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: false,
+	}
+	if cfg == nil {
+		return tlsConfig
+	}
+
+	if minVersion, err := config.ParseTLSVersion(cfg.MinVersion); err == nil {
+		tlsConfig.MinVersion = minVersion
+	}
+	if cipherSuites, err := config.ParseCipherSuites(cfg.CipherSuites); err == nil {
+		tlsConfig.CipherSuites = cipherSuites
+	}
+	return tlsConfig
+}
+
+// newDialer 创建上游连接的拨号器
+// TCP keepalive 让操作系统在空闲的池化连接上发送探测包，避免连接被中间 NAT 静默丢弃
+func newDialer(cfg *config.HTTPClientConfig) *net.Dialer {
+	tcpKeepAlive := cfg.TCPKeepAlive
+	if tcpKeepAlive <= 0 {
+		tcpKeepAlive = constants.DefaultTCPKeepAlive
 	}
+
+	dialer := &net.Dialer{
+		KeepAlive: time.Duration(tcpKeepAlive) * time.Millisecond,
+	}
+	if cfg.Timeout != nil && cfg.Timeout.Connect > 0 {
+		dialer.Timeout = time.Duration(cfg.Timeout.Connect) * time.Millisecond
+	}
+	return dialer
 }
 
 // GetTransport 获取HTTP传输层
@@ -79,6 +130,12 @@ func (p *ConnectionPool) GetTransport() *http.Transport {
 	return p.transport
 }
 
+// ConnectionStats 获取连接池到指定上游主机的连接数
+// host: 上游主机，格式为 host:port
+func (p *ConnectionPool) ConnectionStats(host string) ConnectionStats {
+	return p.conns.Stats(host)
+}
+
 // Close 关闭连接池
 func (p *ConnectionPool) Close() error {
 	p.transport.CloseIdleConnections()
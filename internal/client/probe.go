@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/balance"
+)
+
+// Probe 向上游发送 GET 探测请求，用于熔断器半开状态下判断上游是否恢复
+// 探测路径相对于上游主机，不使用上游 URL 中配置的路径；认证和头部操作与普通请求一致
+// 请求执行失败或上游返回 5xx 时返回错误
+func (c *httpClient) Probe(ctx context.Context, upstream *balance.Upstream, path string) error {
+	if c.closed {
+		return ErrClientClosed
+	}
+	if upstream == nil {
+		return ErrNilUpstream
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	if err := c.prepareRequest(req, upstream); err != nil {
+		return fmt.Errorf("failed to prepare probe request: %w", err)
+	}
+	req.URL.Path = path
+	req.URL.RawQuery = ""
+	req.URL.Fragment = ""
+
+	startTime := time.Now()
+	resp, err := c.clientFor(upstream.Name, req.URL.Host).Do(req)
+	if err != nil {
+		c.logger.Error(err, "Upstream probe failed",
+			"upstream", upstream.Name,
+			"target_url", req.URL.String(),
+			"duration_ms", time.Since(startTime).Milliseconds())
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	c.logger.Info("Upstream probe completed",
+		"upstream", upstream.Name,
+		"status_code", resp.StatusCode,
+		"duration_ms", time.Since(startTime).Milliseconds())
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("upstream probe returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -1,10 +1,15 @@
 package client
 
 import (
+	"context"
+	"net"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"golang.org/x/net/proxy"
 )
 
 // ProxyHandler 代理处理器
@@ -59,16 +64,68 @@ func NewProxyHandlerFromURL(proxyURL string) *ProxyHandler {
 
 // GetProxyFunc 获取代理函数
 // 优先使用配置的代理，如果没有配置则使用环境变量
+// SOCKS5 代理通过 DialContext 建立连接，此时不再使用 HTTP 代理
 func (p *ProxyHandler) GetProxyFunc() func(*http.Request) (*url.URL, error) {
 	if !p.enabled {
 		return http.ProxyFromEnvironment // 使用环境变量中的代理设置
 	}
 
+	if p.IsSOCKS5() {
+		return func(req *http.Request) (*url.URL, error) {
+			return nil, nil
+		}
+	}
+
 	return func(req *http.Request) (*url.URL, error) {
 		return p.proxyURL, nil
 	}
 }
 
+// IsSOCKS5 检查是否使用 SOCKS5 代理
+func (p *ProxyHandler) IsSOCKS5() bool {
+	if !p.enabled || p.proxyURL == nil {
+		return false
+	}
+	scheme := strings.ToLower(p.proxyURL.Scheme)
+	return scheme == constants.ProtocolSOCKS5 || scheme == constants.ProtocolSOCKS5H
+}
+
+// DialContext 包装拨号函数，经由 SOCKS5 代理建立连接
+// forward: 连接代理服务器使用的拨号函数
+// 代理 URL 中的用户名和密码用于 SOCKS5 认证，目标主机名由代理服务器解析
+func (p *ProxyHandler) DialContext(forward dialFunc) dialFunc {
+	var auth *proxy.Auth
+	if p.proxyURL.User != nil {
+		password, _ := p.proxyURL.User.Password()
+		auth = &proxy.Auth{
+			User:     p.proxyURL.User.Username(),
+			Password: password,
+		}
+	}
+
+	dialer, err := proxy.SOCKS5("tcp", p.proxyURL.Host, auth, forwardDialer(forward))
+	if err != nil {
+		return func(ctx context.Context, network, address string) (net.Conn, error) {
+			return nil, err
+		}
+	}
+
+	return dialer.(proxy.ContextDialer).DialContext
+}
+
+// forwardDialer 将拨号函数适配为 proxy.Dialer 和 proxy.ContextDialer
+type forwardDialer dialFunc
+
+// Dial 建立网络连接
+func (d forwardDialer) Dial(network, address string) (net.Conn, error) {
+	return d(context.Background(), network, address)
+}
+
+// DialContext 使用上下文建立网络连接
+func (d forwardDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return d(ctx, network, address)
+}
+
 // IsEnabled 检查是否启用代理
 func (p *ProxyHandler) IsEnabled() bool {
 	return p.enabled
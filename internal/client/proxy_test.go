@@ -0,0 +1,241 @@
+package client
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSOCKS5Server 代表最小化的 SOCKS5 测试代理，支持无认证和用户名密码认证的 CONNECT 命令
+type testSOCKS5Server struct {
+	listener net.Listener
+	user     string
+	password string
+	connects atomic.Int64
+	targets  chan string
+}
+
+// newTestSOCKS5Server 创建 SOCKS5 测试代理，user 为空时不要求认证
+func newTestSOCKS5Server(t *testing.T, user, password string) *testSOCKS5Server {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &testSOCKS5Server{
+		listener: listener,
+		user:     user,
+		password: password,
+		targets:  make(chan string, 16),
+	}
+	go server.serve()
+	t.Cleanup(func() { _ = listener.Close() })
+	return server
+}
+
+func (s *testSOCKS5Server) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *testSOCKS5Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *testSOCKS5Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	// 协商认证方式
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return
+	}
+
+	if s.user == "" {
+		_, _ = conn.Write([]byte{0x05, 0x00})
+	} else {
+		_, _ = conn.Write([]byte{0x05, 0x02})
+		if !s.authenticate(conn) {
+			return
+		}
+	}
+
+	// 读取 CONNECT 请求
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil || request[1] != 0x01 {
+		return
+	}
+
+	var host string
+	switch request[3] {
+	case 0x01:
+		ip := make([]byte, net.IPv4len)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return
+		}
+		host = net.IP(ip).String()
+	case 0x03:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return
+		}
+		name := make([]byte, length[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return
+		}
+		host = string(name)
+	default:
+		return
+	}
+
+	portBytes := make([]byte, 2)
+	if _, err := io.ReadFull(conn, portBytes); err != nil {
+		return
+	}
+	target := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(portBytes))))
+
+	upstream, err := net.Dial("tcp", target)
+	if err != nil {
+		_, _ = conn.Write([]byte{0x05, 0x05, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+
+	s.connects.Add(1)
+	s.targets <- target
+	_, _ = conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+
+	go func() { _, _ = io.Copy(upstream, conn) }()
+	_, _ = io.Copy(conn, upstream)
+}
+
+// authenticate 执行用户名密码认证
+func (s *testSOCKS5Server) authenticate(conn net.Conn) bool {
+	version := make([]byte, 2)
+	if _, err := io.ReadFull(conn, version); err != nil {
+		return false
+	}
+	user := make([]byte, version[1])
+	if _, err := io.ReadFull(conn, user); err != nil {
+		return false
+	}
+	length := make([]byte, 1)
+	if _, err := io.ReadFull(conn, length); err != nil {
+		return false
+	}
+	password := make([]byte, length[0])
+	if _, err := io.ReadFull(conn, password); err != nil {
+		return false
+	}
+
+	if string(user) != s.user || string(password) != s.password {
+		_, _ = conn.Write([]byte{0x01, 0x01})
+		return false
+	}
+	_, _ = conn.Write([]byte{0x01, 0x00})
+	return true
+}
+
+func TestProxyHandler_SOCKS5(t *testing.T) {
+	tests := []struct {
+		url       string
+		wantSOCKS bool
+	}{
+		{url: "socks5://127.0.0.1:1080", wantSOCKS: true},
+		{url: "SOCKS5H://127.0.0.1:1080", wantSOCKS: true},
+		{url: "http://127.0.0.1:8080", wantSOCKS: false},
+		{url: "", wantSOCKS: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			handler := NewProxyHandler(&config.ProxyConfig{URL: tt.url})
+			assert.Equal(t, tt.wantSOCKS, handler.IsSOCKS5())
+
+			if tt.wantSOCKS {
+				// SOCKS5 代理不经过 HTTP 代理
+				proxyURL, err := handler.GetProxyFunc()(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+				assert.NoError(t, err)
+				assert.Nil(t, proxyURL)
+			}
+		})
+	}
+}
+
+func TestConnectionPool_SOCKS5Proxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("via socks5"))
+	}))
+	defer upstream.Close()
+
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		proxyURL func(addr string) string
+		wantErr  bool
+	}{
+		{
+			name:     "no auth",
+			proxyURL: func(addr string) string { return "socks5://" + addr },
+		},
+		{
+			name:     "with credentials",
+			user:     "user",
+			password: "secret",
+			proxyURL: func(addr string) string { return "socks5h://user:secret@" + addr },
+		},
+		{
+			name:     "wrong credentials",
+			user:     "user",
+			password: "secret",
+			proxyURL: func(addr string) string { return "socks5://user:wrong@" + addr },
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			socks := newTestSOCKS5Server(t, tt.user, tt.password)
+
+			cfg := createMinimalConfig()
+			cfg.Proxy = &config.ProxyConfig{URL: tt.proxyURL(socks.addr())}
+			pool := NewConnectionPool(cfg)
+			defer pool.Close()
+
+			client := &http.Client{Transport: pool.GetTransport()}
+			resp, err := client.Get(upstream.URL)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, int64(0), socks.connects.Load())
+				return
+			}
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "via socks5", string(body))
+			assert.Equal(t, int64(1), socks.connects.Load())
+			assert.Equal(t, upstream.Listener.Addr().String(), <-socks.targets)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// newCheckRedirect 创建上游请求的重定向策略
+// 不跟随重定向时直接返回 3xx 响应，由转发服务原样返回客户端；
+// 跟随重定向时，目标主机（含端口）与原始请求不同则移除凭据头部（Authorization、X-Api-Key 等），避免上游凭据泄露给重定向目标
+// followRedirects: 是否跟随重定向
+func newCheckRedirect(followRedirects bool) func(req *http.Request, via []*http.Request) error {
+	if !followRedirects {
+		return func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) >= constants.DefaultMaxRedirects {
+			return fmt.Errorf("stopped after %d redirects", constants.DefaultMaxRedirects)
+		}
+		// 每次重定向的头部均复制自原始请求，因此与原始请求的主机比较
+		if !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+			for _, name := range constants.CredentialHeaders {
+				req.Header.Del(name)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/auth"
+	"github.com/shengyanli1982/llmproxy-go/internal/balance"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClient_Redirects(t *testing.T) {
+	// 重定向目标服务器，记录收到的凭据头部
+	var targetAuth, targetAPIKey string
+	var targetHit bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		targetHit = true
+		targetAuth = r.Header.Get("Authorization")
+		targetAPIKey = r.Header.Get("X-Api-Key")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	var sameHostAuth, sameHostAPIKey string
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/moved":
+			http.Redirect(w, r, "/v1/new", http.StatusFound)
+		case "/v1/new":
+			sameHostAuth = r.Header.Get("Authorization")
+			sameHostAPIKey = r.Header.Get("X-Api-Key")
+			w.WriteHeader(http.StatusOK)
+		case "/v1/loop":
+			http.Redirect(w, r, "/v1/loop", http.StatusFound)
+		default:
+			http.Redirect(w, r, target.URL+"/v1/elsewhere", http.StatusTemporaryRedirect)
+		}
+	}))
+	defer origin.Close()
+
+	upstreamConfig := &config.UpstreamConfig{
+		Name: "redirecting",
+		URL:  origin.URL,
+		Auth: &config.AuthConfig{Type: "bearer", Token: "sk-secret"},
+	}
+	authenticator, err := auth.CreateFromConfig(upstreamConfig)
+	require.NoError(t, err)
+	upstream := &balance.Upstream{
+		Name:          "redirecting",
+		URL:           origin.URL,
+		Config:        upstreamConfig,
+		Authenticator: authenticator,
+	}
+
+	newClient := func(t *testing.T, followRedirects bool) HTTPClient {
+		cfg := createMinimalConfig()
+		cfg.FollowRedirects = followRedirects
+		client, err := NewHTTPClient(cfg)
+		require.NoError(t, err)
+		t.Cleanup(func() { client.Close() })
+		return client
+	}
+
+	do := func(t *testing.T, client HTTPClient, path string) (*http.Response, error) {
+		targetHit, targetAuth, targetAPIKey, sameHostAuth, sameHostAPIKey = false, "", "", "", ""
+		req, err := http.NewRequest(http.MethodPost, "http://localhost"+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Api-Key", "sk-api-key")
+		resp, err := client.Do(req, upstream)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+
+	t.Run("redirect returned unchanged by default", func(t *testing.T) {
+		resp, err := do(t, newClient(t, false), "/v1/chat/completions")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		assert.Equal(t, target.URL+"/v1/elsewhere", resp.Header.Get("Location"))
+		assert.False(t, targetHit)
+	})
+
+	t.Run("follow same host keeps authorization", func(t *testing.T) {
+		resp, err := do(t, newClient(t, true), "/v1/moved")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "Bearer sk-secret", sameHostAuth)
+		assert.Equal(t, "sk-api-key", sameHostAPIKey)
+	})
+
+	t.Run("follow cross host strips credentials", func(t *testing.T) {
+		resp, err := do(t, newClient(t, true), "/v1/chat/completions")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.True(t, targetHit)
+		assert.Empty(t, targetAuth)
+		assert.Empty(t, targetAPIKey)
+	})
+
+	t.Run("follow stops after max redirects", func(t *testing.T) {
+		_, err := do(t, newClient(t, true), "/v1/loop")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "stopped after 10 redirects")
+	})
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// dialFunc 代表建立网络连接的函数
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// hostResolver 代表主机名解析器，便于测试替换
+type hostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// dnsCacheEntry 代表单个主机名的解析结果
+type dnsCacheEntry struct {
+	addrs     []string
+	expiresAt time.Time
+	next      atomic.Uint64 // 轮询选择地址的计数器
+}
+
+// dnsCache 代表带 TTL 的 DNS 解析缓存
+// 缓存过期后在下一次拨号时重新解析，使长期运行的连接池能够跟随上游 DNS 变更；
+// 同一主机名的多个 A 记录之间轮询选择，拨号失败时依次尝试其余地址
+type dnsCache struct {
+	mu       sync.Mutex
+	entries  map[string]*dnsCacheEntry
+	ttl      time.Duration
+	resolver hostResolver
+	onChange func() // 解析结果发生变化时的回调，用于关闭仍指向旧地址的空闲连接
+}
+
+// newDNSCache 创建新的 DNS 解析缓存实例
+// ttl: 解析结果缓存时间
+func newDNSCache(ttl time.Duration) *dnsCache {
+	return &dnsCache{
+		entries:  make(map[string]*dnsCacheEntry),
+		ttl:      ttl,
+		resolver: net.DefaultResolver,
+	}
+}
+
+// DialContext 包装拨号函数，拨号前使用缓存解析主机名
+// 配置代理时 Transport 拨号的是代理地址，同样受益于缓存
+func (d *dnsCache) DialContext(dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, address)
+		}
+
+		addrs, start, err := d.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var dialErr error
+		for i := range addrs {
+			addr := addrs[(start+i)%len(addrs)]
+			conn, err := dial(ctx, network, net.JoinHostPort(addr, port))
+			if err == nil {
+				return conn, nil
+			}
+			dialErr = err
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return nil, dialErr
+	}
+}
+
+// lookup 获取主机名的解析地址和本次轮询的起始下标，缓存过期时重新解析
+// 重新解析失败时继续使用过期的地址，避免 DNS 短暂故障影响请求
+func (d *dnsCache) lookup(ctx context.Context, host string) ([]string, int, error) {
+	now := time.Now()
+
+	d.mu.Lock()
+	entry, ok := d.entries[host]
+	d.mu.Unlock()
+
+	if !ok || !now.Before(entry.expiresAt) {
+		addrs, err := d.resolver.LookupHost(ctx, host)
+		if err == nil && len(addrs) == 0 {
+			err = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		switch {
+		case err == nil:
+			entry = d.store(host, addrs, now)
+		case !ok:
+			return nil, 0, err
+		case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+			return nil, 0, err
+		}
+	}
+
+	start := int(entry.next.Add(1)-1) % len(entry.addrs)
+	return entry.addrs, start, nil
+}
+
+// store 保存主机名的解析结果，解析地址发生变化时触发回调
+func (d *dnsCache) store(host string, addrs []string, now time.Time) *dnsCacheEntry {
+	d.mu.Lock()
+	previous, ok := d.entries[host]
+	entry := &dnsCacheEntry{addrs: addrs, expiresAt: now.Add(d.ttl)}
+	if ok {
+		entry.next.Store(previous.next.Load())
+	}
+	d.entries[host] = entry
+	onChange := d.onChange
+	d.mu.Unlock()
+
+	if ok && onChange != nil && !sameAddrs(previous.addrs, addrs) {
+		onChange()
+	}
+	return entry
+}
+
+// sameAddrs 判断两组解析地址是否相同（忽略顺序）
+func sameAddrs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[string]struct{}, len(a))
+	for _, addr := range a {
+		set[addr] = struct{}{}
+	}
+	for _, addr := range b {
+		if _, ok := set[addr]; !ok {
+			return false
+		}
+	}
+	return true
+}
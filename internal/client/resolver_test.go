@@ -0,0 +1,225 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResolver 代表可控制返回结果的测试解析器
+type fakeResolver struct {
+	mu      sync.Mutex
+	addrs   []string
+	err     error
+	lookups int
+}
+
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lookups++
+	return r.addrs, r.err
+}
+
+func (r *fakeResolver) set(addrs []string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addrs = addrs
+	r.err = err
+}
+
+func (r *fakeResolver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lookups
+}
+
+// recordingDial 记录拨号地址，failing 中的地址拨号失败
+func recordingDial(dialed *[]string, failing map[string]bool) dialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		*dialed = append(*dialed, address)
+		if failing[address] {
+			return nil, errors.New("connection refused")
+		}
+		client, server := net.Pipe()
+		_ = server.Close()
+		return client, nil
+	}
+}
+
+func TestDNSCache_CachesWithinTTL(t *testing.T) {
+	resolver := &fakeResolver{addrs: []string{"10.0.0.1"}}
+	cache := newDNSCache(50 * time.Millisecond)
+	cache.resolver = resolver
+
+	var dialed []string
+	dial := cache.DialContext(recordingDial(&dialed, nil))
+
+	for i := 0; i < 3; i++ {
+		conn, err := dial(context.Background(), "tcp", "api.example.com:443")
+		require.NoError(t, err)
+		_ = conn.Close()
+	}
+	assert.Equal(t, 1, resolver.count())
+	assert.Equal(t, []string{"10.0.0.1:443", "10.0.0.1:443", "10.0.0.1:443"}, dialed)
+
+	// 缓存过期后重新解析，使用新的地址
+	resolver.set([]string{"10.0.0.2"}, nil)
+	time.Sleep(60 * time.Millisecond)
+
+	conn, err := dial(context.Background(), "tcp", "api.example.com:443")
+	require.NoError(t, err)
+	_ = conn.Close()
+	assert.Equal(t, 2, resolver.count())
+	assert.Equal(t, "10.0.0.2:443", dialed[len(dialed)-1])
+}
+
+func TestDNSCache_RoundRobinAndFailover(t *testing.T) {
+	resolver := &fakeResolver{addrs: []string{"10.0.0.1", "10.0.0.2"}}
+	cache := newDNSCache(time.Minute)
+	cache.resolver = resolver
+
+	t.Run("round robin", func(t *testing.T) {
+		var dialed []string
+		dial := cache.DialContext(recordingDial(&dialed, nil))
+		for i := 0; i < 4; i++ {
+			conn, err := dial(context.Background(), "tcp", "rr.example.com:80")
+			require.NoError(t, err)
+			_ = conn.Close()
+		}
+		assert.Equal(t, []string{"10.0.0.1:80", "10.0.0.2:80", "10.0.0.1:80", "10.0.0.2:80"}, dialed)
+	})
+
+	t.Run("failover", func(t *testing.T) {
+		var dialed []string
+		dial := cache.DialContext(recordingDial(&dialed, map[string]bool{"10.0.0.1:80": true}))
+		for i := 0; i < 2; i++ {
+			conn, err := dial(context.Background(), "tcp", "failover.example.com:80")
+			require.NoError(t, err)
+			_ = conn.Close()
+		}
+		assert.Equal(t, []string{"10.0.0.1:80", "10.0.0.2:80", "10.0.0.2:80"}, dialed)
+	})
+
+	t.Run("all addresses fail", func(t *testing.T) {
+		var dialed []string
+		dial := cache.DialContext(recordingDial(&dialed, map[string]bool{"10.0.0.1:80": true, "10.0.0.2:80": true}))
+		_, err := dial(context.Background(), "tcp", "down.example.com:80")
+		assert.Error(t, err)
+		assert.Len(t, dialed, 2)
+	})
+}
+
+func TestDNSCache_LookupErrors(t *testing.T) {
+	resolver := &fakeResolver{addrs: []string{"10.0.0.1"}}
+	cache := newDNSCache(20 * time.Millisecond)
+	cache.resolver = resolver
+
+	var dialed []string
+	dial := cache.DialContext(recordingDial(&dialed, nil))
+
+	// IP 地址直接拨号，不经过解析
+	conn, err := dial(context.Background(), "tcp", "127.0.0.1:8080")
+	require.NoError(t, err)
+	_ = conn.Close()
+	assert.Equal(t, 0, resolver.count())
+
+	conn, err = dial(context.Background(), "tcp", "api.example.com:443")
+	require.NoError(t, err)
+	_ = conn.Close()
+
+	// 重新解析失败时继续使用过期的地址
+	resolver.set(nil, errors.New("temporary failure"))
+	time.Sleep(30 * time.Millisecond)
+	conn, err = dial(context.Background(), "tcp", "api.example.com:443")
+	require.NoError(t, err)
+	_ = conn.Close()
+	assert.Equal(t, "10.0.0.1:443", dialed[len(dialed)-1])
+
+	// 从未解析成功的主机名返回错误
+	_, err = dial(context.Background(), "tcp", "unknown.example.com:443")
+	assert.Error(t, err)
+
+	// 解析结果为空时返回错误
+	resolver.set([]string{}, nil)
+	_, err = dial(context.Background(), "tcp", "empty.example.com:443")
+	assert.Error(t, err)
+}
+
+func TestDNSCache_OnChange(t *testing.T) {
+	resolver := &fakeResolver{addrs: []string{"10.0.0.1", "10.0.0.2"}}
+	cache := newDNSCache(10 * time.Millisecond)
+	cache.resolver = resolver
+
+	changes := 0
+	cache.onChange = func() { changes++ }
+
+	var dialed []string
+	dial := cache.DialContext(recordingDial(&dialed, nil))
+	redial := func() {
+		time.Sleep(15 * time.Millisecond)
+		conn, err := dial(context.Background(), "tcp", "api.example.com:443")
+		require.NoError(t, err)
+		_ = conn.Close()
+	}
+
+	redial()
+	assert.Equal(t, 0, changes, "first resolution is not a change")
+
+	// 相同地址集合（顺序不同）不触发回调
+	resolver.set([]string{"10.0.0.2", "10.0.0.1"}, nil)
+	redial()
+	assert.Equal(t, 0, changes)
+
+	resolver.set([]string{"10.0.0.3"}, nil)
+	redial()
+	assert.Equal(t, 1, changes)
+}
+
+func TestConnectionPool_DNSCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	t.Run("default ttl", func(t *testing.T) {
+		pool := NewConnectionPool(createMinimalConfig())
+		defer pool.Close()
+		assert.Equal(t, 30*time.Second, pool.dnsCache.ttl)
+	})
+
+	t.Run("configured ttl", func(t *testing.T) {
+		cfg := createMinimalConfig()
+		cfg.DNSCacheTTL = 5000
+		pool := NewConnectionPool(cfg)
+		defer pool.Close()
+		assert.Equal(t, 5*time.Second, pool.dnsCache.ttl)
+	})
+
+	t.Run("requests resolve through cache", func(t *testing.T) {
+		pool := NewConnectionPool(createMinimalConfig())
+		defer pool.Close()
+
+		resolver := &fakeResolver{addrs: []string{"127.0.0.1"}}
+		pool.dnsCache.resolver = resolver
+
+		client := &http.Client{Transport: pool.GetTransport()}
+		resp, err := client.Get("http://upstream.test:" + port + "/")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, 1, resolver.count())
+	})
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+)
+
+// TLS 相关错误定义
+var (
+	ErrInvalidCACert = errors.New("no valid certificates found in CA file")
+)
+
+// NewTLSConfig 根据上游 TLS 配置创建 tls.Config
+// CA 证书会追加到系统根证书之后，系统根证书不可用时仅使用配置的 CA
+func NewTLSConfig(cfg *config.TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CACertFile != "" {
+		pemData, err := os.ReadFile(cfg.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file '%s': %w", cfg.CACertFile, err)
+		}
+
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil || rootCAs == nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(pemData) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCACert, cfg.CACertFile)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	return tlsConfig, nil
+}
+
+// tlsClientKey 生成自定义 TLS 客户端的缓存键
+// 同一主机可能被多个 TLS 配置不同的上游引用，因此同时使用上游名称和主机区分
+func tlsClientKey(upstreamName, host string) string {
+	return upstreamName + "@" + host
+}
+
+// tlsClientFor 获取上游的自定义 TLS 客户端，不存在时基于连接池传输层懒加载创建
+// upstreamName: 上游名称
+// host: 上游 URL 主机（含端口）
+// tlsCfg: 上游 TLS 配置
+func (c *httpClient) tlsClientFor(upstreamName, host string, tlsCfg *config.TLSConfig) (*http.Client, error) {
+	key := tlsClientKey(upstreamName, host)
+
+	c.tlsMu.Lock()
+	defer c.tlsMu.Unlock()
+
+	if client, ok := c.tlsClients[key]; ok {
+		return client, nil
+	}
+
+	tlsConfig, err := NewTLSConfig(tlsCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 复制共享传输层，保留连接池、超时与代理设置，仅替换 TLS 配置，并沿用 HTTP 客户端的版本和加密套件限制
+	transport := c.pool.GetTransport().Clone()
+	if base := transport.TLSClientConfig; base != nil {
+		tlsConfig.MinVersion = base.MinVersion
+		tlsConfig.CipherSuites = base.CipherSuites
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	client := &http.Client{
+		Transport:     transport,
+		Timeout:       c.client.Timeout,
+		CheckRedirect: c.client.CheckRedirect,
+	}
+	c.tlsClients[key] = client
+
+	c.logger.Info("Created upstream TLS transport",
+		"upstream", upstreamName,
+		"host", host,
+		"server_name", tlsCfg.ServerName,
+		"custom_ca", tlsCfg.CACertFile != "",
+		"insecure_skip_verify", tlsCfg.InsecureSkipVerify)
+
+	return client, nil
+}
+
+// clientFor 获取执行请求使用的 HTTP 客户端，未配置自定义 TLS 的上游使用共享客户端
+func (c *httpClient) clientFor(upstreamName, host string) *http.Client {
+	c.tlsMu.Lock()
+	defer c.tlsMu.Unlock()
+
+	if client, ok := c.tlsClients[tlsClientKey(upstreamName, host)]; ok {
+		return client
+	}
+	return c.client
+}
+
+// closeTLSClients 关闭所有自定义 TLS 传输层的空闲连接
+func (c *httpClient) closeTLSClients() {
+	c.tlsMu.Lock()
+	defer c.tlsMu.Unlock()
+
+	for key, client := range c.tlsClients {
+		if transport, ok := client.Transport.(*http.Transport); ok {
+			transport.CloseIdleConnections()
+		}
+		delete(c.tlsClients, key)
+	}
+}
@@ -0,0 +1,259 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/balance"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTLSServer 创建使用独立自签名 CA 的 TLS 测试服务器，返回服务器和 CA 证书文件路径
+func newTestTLSServer(t *testing.T, dnsName string, body string) (*httptest.Server, string) {
+	t.Helper()
+	return newTestTLSServerWithVersions(t, dnsName, body, 0, 0)
+}
+
+// newTestTLSServerWithVersions 创建限定 TLS 版本范围的 TLS 测试服务器，版本为 0 时使用默认值
+func newTestTLSServerWithVersions(t *testing.T, dnsName string, body string, minVersion, maxVersion uint16) (*httptest.Server, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: dnsName},
+		DNSNames:              []string{dnsName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, certPEM, 0o600))
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body))
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: minVersion, MaxVersion: maxVersion}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server, caFile
+}
+
+func TestHTTPClient_PerUpstreamTLS(t *testing.T) {
+	serverA, caFileA := newTestTLSServer(t, "gateway-a.internal", "from-a")
+	serverB, caFileB := newTestTLSServer(t, "gateway-b.internal", "from-b")
+
+	client, err := NewHTTPClient(createMinimalConfig())
+	require.NoError(t, err)
+	defer client.Close()
+
+	newUpstream := func(name, url string, tlsCfg *config.TLSConfig) *balance.Upstream {
+		return &balance.Upstream{
+			Name: name,
+			URL:  url,
+			Config: &config.UpstreamConfig{
+				Name: name,
+				URL:  url,
+				TLS:  tlsCfg,
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		upstream *balance.Upstream
+		wantErr  bool
+		wantBody string
+	}{
+		{
+			name:     "gateway a with its own CA and SNI",
+			upstream: newUpstream("gateway-a", serverA.URL, &config.TLSConfig{CACertFile: caFileA, ServerName: "gateway-a.internal"}),
+			wantBody: "from-a",
+		},
+		{
+			name:     "gateway b with its own CA and SNI",
+			upstream: newUpstream("gateway-b", serverB.URL, &config.TLSConfig{CACertFile: caFileB, ServerName: "gateway-b.internal"}),
+			wantBody: "from-b",
+		},
+		{
+			name:     "insecure skip verify",
+			upstream: newUpstream("gateway-insecure", serverA.URL+"/insecure", &config.TLSConfig{InsecureSkipVerify: true}),
+			wantBody: "from-a",
+		},
+		{
+			name:     "no TLS config uses system roots",
+			upstream: newUpstream("gateway-default", serverB.URL+"/default", nil),
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "http://localhost/v1/test", nil)
+			require.NoError(t, err)
+
+			resp, err := client.Do(req, tt.upstream)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			buf := make([]byte, 64)
+			n, _ := resp.Body.Read(buf)
+			assert.Equal(t, tt.wantBody, string(buf[:n]))
+		})
+	}
+}
+
+func TestHTTPClient_PerUpstreamTLS_WrongCA(t *testing.T) {
+	serverA, _ := newTestTLSServer(t, "gateway-a.internal", "from-a")
+	_, caFileB := newTestTLSServer(t, "gateway-b.internal", "from-b")
+
+	client, err := NewHTTPClient(createMinimalConfig())
+	require.NoError(t, err)
+	defer client.Close()
+
+	// 使用 B 的 CA 访问 A，证书校验应失败
+	upstream := &balance.Upstream{
+		Name: "gateway-a",
+		URL:  serverA.URL,
+		Config: &config.UpstreamConfig{
+			Name: "gateway-a",
+			URL:  serverA.URL,
+			TLS:  &config.TLSConfig{CACertFile: caFileB, ServerName: "gateway-a.internal"},
+		},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/v1/test", nil)
+	require.NoError(t, err)
+
+	_, err = client.Do(req, upstream)
+	assert.Error(t, err)
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	t.Run("server name and insecure flag", func(t *testing.T) {
+		tlsConfig, err := NewTLSConfig(&config.TLSConfig{ServerName: "gateway.internal", InsecureSkipVerify: true})
+		require.NoError(t, err)
+		assert.Equal(t, "gateway.internal", tlsConfig.ServerName)
+		assert.True(t, tlsConfig.InsecureSkipVerify)
+		assert.Nil(t, tlsConfig.RootCAs)
+	})
+
+	t.Run("missing CA file", func(t *testing.T) {
+		_, err := NewTLSConfig(&config.TLSConfig{CACertFile: filepath.Join(t.TempDir(), "missing.pem")})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid CA file", func(t *testing.T) {
+		caFile := filepath.Join(t.TempDir(), "invalid.pem")
+		require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0o600))
+
+		_, err := NewTLSConfig(&config.TLSConfig{CACertFile: caFile})
+		assert.ErrorIs(t, err, ErrInvalidCACert)
+	})
+}
+
+func TestHTTPClient_MinTLSVersion(t *testing.T) {
+	tls11Server, tls11CA := newTestTLSServerWithVersions(t, "legacy.internal", "from-legacy", tls.VersionTLS10, tls.VersionTLS11)
+	tls12Server, tls12CA := newTestTLSServerWithVersions(t, "modern.internal", "from-modern", 0, tls.VersionTLS12)
+
+	tests := []struct {
+		name       string
+		minVersion string
+		serverURL  string
+		caFile     string
+		serverName string
+		wantErr    bool
+	}{
+		{name: "TLS 1.1 server rejected by min 1.2", minVersion: "1.2", serverURL: tls11Server.URL, caFile: tls11CA, serverName: "legacy.internal", wantErr: true},
+		{name: "TLS 1.2 server accepted by min 1.2", minVersion: "1.2", serverURL: tls12Server.URL, caFile: tls12CA, serverName: "modern.internal"},
+		{name: "TLS 1.2 server rejected by min 1.3", minVersion: "1.3", serverURL: tls12Server.URL, caFile: tls12CA, serverName: "modern.internal", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := createMinimalConfig()
+			cfg.TLS = &config.ClientTLSConfig{MinVersion: tt.minVersion}
+			client, err := NewHTTPClient(cfg)
+			require.NoError(t, err)
+			defer client.Close()
+
+			upstream := &balance.Upstream{
+				Name: tt.serverName,
+				URL:  tt.serverURL,
+				Config: &config.UpstreamConfig{
+					Name: tt.serverName,
+					URL:  tt.serverURL,
+					TLS:  &config.TLSConfig{CACertFile: tt.caFile, ServerName: tt.serverName},
+				},
+			}
+
+			req, err := http.NewRequest(http.MethodGet, "http://localhost/v1/test", nil)
+			require.NoError(t, err)
+
+			resp, err := client.Do(req, upstream)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "protocol version")
+				return
+			}
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		})
+	}
+}
+
+func TestConnectionPool_ClientTLS(t *testing.T) {
+	pool := NewConnectionPool(createMinimalConfig())
+	defer pool.Close()
+	assert.Zero(t, pool.GetTransport().TLSClientConfig.MinVersion)
+	assert.Nil(t, pool.GetTransport().TLSClientConfig.CipherSuites)
+
+	cfg := createMinimalConfig()
+	cfg.TLS = &config.ClientTLSConfig{
+		MinVersion:   "1.2",
+		CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+	}
+	pool = NewConnectionPool(cfg)
+	defer pool.Close()
+	assert.Equal(t, uint16(tls.VersionTLS12), pool.GetTransport().TLSClientConfig.MinVersion)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, pool.GetTransport().TLSClientConfig.CipherSuites)
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/balance"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/shengyanli1982/llmproxy-go/internal/transform"
+)
+
+// transformRequestBody 对 JSON 请求体执行上游配置的转换操作
+// 流式转发（不可重放）、已编码或非 JSON 的请求体保持不变，转换失败时原样转发
+func (c *httpClient) transformRequestBody(req *http.Request, upstream *balance.Upstream) error {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody == nil {
+		return nil
+	}
+	if req.Header.Get(constants.HeaderContentEncoding) != "" || !transform.IsJSONContentType(req.Header.Get(constants.HeaderContentType)) {
+		return nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	_ = req.Body.Close()
+
+	transformed, err := transform.Apply(body, upstream.Config.RequestTransform)
+	if err != nil {
+		c.logger.Error(err, "Failed to transform request body, forwarding unchanged", "upstream", upstream.Name)
+		transformed = body
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(transformed))
+	req.ContentLength = int64(len(transformed))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(transformed)), nil
+	}
+	return nil
+}
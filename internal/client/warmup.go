@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/balance"
+)
+
+// Warmup 并发向上游发送 HEAD 请求，使连接池中预先保留指定数量的空闲连接
+// 上游对 HEAD 请求返回的状态码不影响预热结果，只要连接建立成功即可复用
+// ctx: 控制预热的最长时间
+// upstream: 目标上游服务
+// connections: 预热的连接数
+func (c *httpClient) Warmup(ctx context.Context, upstream *balance.Upstream, connections int) error {
+	if c.closed {
+		return ErrClientClosed
+	}
+	if upstream == nil {
+		return ErrNilUpstream
+	}
+	if connections <= 0 {
+		return nil
+	}
+
+	startTime := time.Now()
+	errs := make([]error, connections)
+
+	var wg sync.WaitGroup
+	for i := 0; i < connections; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = c.warmupConnection(ctx, upstream)
+		}(i)
+	}
+	wg.Wait()
+
+	err := errors.Join(errs...)
+	if err != nil {
+		c.logger.Error(err, "Connection warmup failed",
+			"upstream", upstream.Name,
+			"connections", connections,
+			"duration_ms", time.Since(startTime).Milliseconds())
+		return err
+	}
+
+	c.logger.Info("Connection warmup completed",
+		"upstream", upstream.Name,
+		"connections", connections,
+		"duration_ms", time.Since(startTime).Milliseconds())
+	return nil
+}
+
+// warmupConnection 发送单个预热请求并读取完响应体，使连接归还到连接池
+func (c *httpClient) warmupConnection(ctx context.Context, upstream *balance.Upstream) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, "/", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.Do(req, upstream)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// ParseTLSVersion 将配置中的 TLS 版本转换为 tls 包的版本常量，为空时返回 0 表示使用默认值
+// version: 配置中的 TLS 版本，如 1.2 或 1.3
+func ParseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case "":
+		return 0, nil
+	case constants.TLSVersion12:
+		return tls.VersionTLS12, nil
+	case constants.TLSVersion13:
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported tls version '%s'", version)
+	}
+}
+
+// ParseCipherSuites 将加密套件名称转换为 tls 包的加密套件 ID，为空时返回 nil 表示使用默认值
+// 只接受 tls.CipherSuites 中的安全加密套件，已知不安全的加密套件会被拒绝
+// names: 加密套件名称列表，如 TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	supported := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		supported[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := supported[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported or insecure cipher suite '%s'", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// validateClientTLS 验证所有上游组 HTTP 客户端的 TLS 版本和加密套件名称
+// config: 待验证的配置实例
+func validateClientTLS(config *Config) error {
+	for i := range config.UpstreamGroups {
+		group := &config.UpstreamGroups[i]
+		if group.HTTPClient == nil || group.HTTPClient.TLS == nil {
+			continue
+		}
+		if _, err := ParseTLSVersion(group.HTTPClient.TLS.MinVersion); err != nil {
+			return fmt.Errorf("upstream group '%s' http client: %w", group.Name, err)
+		}
+		if _, err := ParseCipherSuites(group.HTTPClient.TLS.CipherSuites); err != nil {
+			return fmt.Errorf("upstream group '%s' http client: %w", group.Name, err)
+		}
+	}
+	return nil
+}
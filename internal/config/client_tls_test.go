@@ -0,0 +1,113 @@
+package config
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_LoadFromFile_ClientTLS(t *testing.T) {
+	tests := []struct {
+		name    string
+		tlsYAML string
+		wantErr string
+	}{
+		{
+			name: "valid version and cipher suites",
+			tlsYAML: `
+        minVersion: "1.2"
+        cipherSuites:
+          - TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
+          - TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384`,
+		},
+		{
+			name: "unsupported version",
+			tlsYAML: `
+        minVersion: "1.1"`,
+			wantErr: "config validation failed",
+		},
+		{
+			name: "unknown cipher suite",
+			tlsYAML: `
+        cipherSuites:
+          - TLS_UNKNOWN_CIPHER`,
+			wantErr: "unsupported or insecure cipher suite 'TLS_UNKNOWN_CIPHER'",
+		},
+		{
+			name: "insecure cipher suite",
+			tlsYAML: `
+        cipherSuites:
+          - TLS_RSA_WITH_RC4_128_SHA`,
+			wantErr: "unsupported or insecure cipher suite 'TLS_RSA_WITH_RC4_128_SHA'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configYAML := `
+httpServer:
+  forwards:
+    - name: to_openai
+      port: 3000
+      defaultGroup: openai
+  admin:
+    port: 9000
+upstreams:
+  - name: openai
+    url: "https://api.openai.com/v1"
+upstreamGroups:
+  - name: openai
+    upstreams:
+      - name: openai
+    httpClient:
+      tls:` + tt.tlsYAML + "\n"
+
+			manager, err := NewManager()
+			require.NoError(t, err)
+			err = manager.LoadFromFile(writeConfigFile(t, t.TempDir(), "config.yaml", configYAML))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			clientTLS := manager.GetConfig().UpstreamGroups[0].HTTPClient.TLS
+			require.NotNil(t, clientTLS)
+			assert.Equal(t, "1.2", clientTLS.MinVersion)
+			assert.Len(t, clientTLS.CipherSuites, 2)
+		})
+	}
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	version, err := ParseTLSVersion("")
+	require.NoError(t, err)
+	assert.Zero(t, version)
+
+	version, err = ParseTLSVersion("1.2")
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), version)
+
+	version, err = ParseTLSVersion("1.3")
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), version)
+
+	_, err = ParseTLSVersion("1.0")
+	assert.Error(t, err)
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	ids, err := ParseCipherSuites(nil)
+	require.NoError(t, err)
+	assert.Nil(t, ids)
+
+	ids, err = ParseCipherSuites([]string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"})
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, ids)
+
+	_, err = ParseCipherSuites([]string{"TLS_RSA_WITH_RC4_128_SHA"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnmarshalYAML 解析自定义错误响应，body 为字符串时原样作为 JSON，为映射或列表时转换为 JSON
+func (e *ErrorResponseConfig) UnmarshalYAML(node *yaml.Node) error {
+	var raw struct {
+		Body        yaml.Node `yaml:"body"`
+		ContentType string    `yaml:"contentType"`
+	}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+
+	body, err := decodeJSONBody(&raw.Body)
+	if err != nil {
+		return err
+	}
+	e.Body = body
+	e.ContentType = raw.ContentType
+	return nil
+}
+
+// UnmarshalYAML 解析静态响应，body 的处理方式与自定义错误响应相同
+func (s *StaticResponseConfig) UnmarshalYAML(node *yaml.Node) error {
+	var raw struct {
+		StatusCode  int       `yaml:"statusCode"`
+		Body        yaml.Node `yaml:"body"`
+		ContentType string    `yaml:"contentType"`
+	}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+
+	body, err := decodeJSONBody(&raw.Body)
+	if err != nil {
+		return err
+	}
+	s.StatusCode = raw.StatusCode
+	s.Body = body
+	s.ContentType = raw.ContentType
+	return nil
+}
+
+// decodeJSONBody 将 YAML 中的响应体转换为 JSON，字符串原样保留，映射或列表编码为 JSON，未配置时返回 nil
+func decodeJSONBody(node *yaml.Node) (json.RawMessage, error) {
+	switch node.Kind {
+	case 0:
+		return nil, nil
+	case yaml.ScalarNode:
+		return json.RawMessage(node.Value), nil
+	default:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return nil, err
+		}
+		body, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("response body at line %d cannot be converted to JSON: %w", node.Line, err)
+		}
+		return body, nil
+	}
+}
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// validateForwardTLS 验证所有启用 TLS 的转发服务的证书和私钥能够加载且相互匹配
+// config: 待验证的配置实例
+func validateForwardTLS(config *Config) error {
+	for i := range config.HTTPServer.Forwards {
+		forward := &config.HTTPServer.Forwards[i]
+		if forward.TLS == nil {
+			continue
+		}
+		if _, err := tls.LoadX509KeyPair(forward.TLS.CertFile, forward.TLS.KeyFile); err != nil {
+			return fmt.Errorf("forward service '%s' failed to load tls certificate: %w", forward.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_LoadFromFile_InvalidForwardTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile := writeConfigFile(t, dir, "tls.crt", "not a certificate")
+	keyFile := writeConfigFile(t, dir, "tls.key", "not a key")
+
+	configYAML := fmt.Sprintf(`
+httpServer:
+  forwards:
+    - name: to_openai
+      port: 3000
+      defaultGroup: openai
+      tls:
+        certFile: %q
+        keyFile: %q
+  admin:
+    port: 9000
+upstreams:
+  - name: openai
+    url: "https://api.openai.com/v1"
+upstreamGroups:
+  - name: openai
+    upstreams:
+      - name: openai
+`, certFile, keyFile)
+
+	manager, err := NewManager()
+	require.NoError(t, err)
+	err = manager.LoadFromFile(writeConfigFile(t, dir, "config.yaml", configYAML))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "forward service 'to_openai' failed to load tls certificate")
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/activation"
+)
+
+// listenerOwner 代表一个监听地址及其所属的服务，用于检测监听地址冲突
+type listenerOwner struct {
+	service string // 所属服务描述，如 forward service 'default'、admin server
+	address string // 监听地址
+	port    int    // 监听端口
+}
+
+// String 返回监听地址的 host:port 形式
+func (l listenerOwner) String() string {
+	return net.JoinHostPort(l.address, fmt.Sprint(l.port))
+}
+
+// validateListeners 检测所有转发服务和管理服务之间重复的监听地址
+// 通配地址（空、0.0.0.0、::）与同端口的任意地址冲突，端口 0 由系统随机分配，不参与检测
+// config: 待验证的配置实例
+func validateListeners(config *Config) error {
+	var listeners []listenerOwner
+	for i := range config.HTTPServer.Forwards {
+		forward := &config.HTTPServer.Forwards[i]
+		service := fmt.Sprintf("forward service '%s'", forward.Name)
+		for _, listener := range forward.GetListeners() {
+			listeners = append(listeners, listenerOwner{service: service, address: listener.Address, port: listener.Port})
+		}
+	}
+
+	admin := &config.HTTPServer.Admin
+	if admin.IsEnabled() {
+		listeners = append(listeners, listenerOwner{service: "admin server", address: admin.Address, port: admin.Port})
+	}
+
+	for i, listener := range listeners {
+		if listener.port == 0 {
+			continue
+		}
+		for _, previous := range listeners[:i] {
+			if previous.port == listener.port && addressesOverlap(previous.address, listener.address) {
+				return fmt.Errorf("%s listener %s conflicts with %s listener %s",
+					listener.service, listener, previous.service, previous)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateInheritedSockets 验证未配置监听端口的转发服务能够找到进程继承的监听套接字（systemd socket activation）
+// config: 待验证的配置实例
+func validateInheritedSockets(config *Config) error {
+	for i := range config.HTTPServer.Forwards {
+		forward := &config.HTTPServer.Forwards[i]
+		if forward.Port != 0 || len(forward.Listeners) > 0 {
+			continue
+		}
+		if _, ok := activation.Lookup(forward.Name); !ok {
+			return fmt.Errorf("forward service '%s' has no port or listeners configured and no inherited socket is available", forward.Name)
+		}
+	}
+	return nil
+}
+
+// addressesOverlap 判断两个监听地址在同一端口上是否冲突
+func addressesOverlap(a, b string) bool {
+	if isWildcardAddress(a) || isWildcardAddress(b) {
+		return true
+	}
+
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA != nil && ipB != nil {
+		return ipA.Equal(ipB)
+	}
+	return a == b
+}
+
+// isWildcardAddress 判断是否为监听所有网卡的通配地址
+func isWildcardAddress(address string) bool {
+	if address == "" {
+		return true
+	}
+	ip := net.ParseIP(address)
+	return ip != nil && ip.IsUnspecified()
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_LoadFromFile_ListenerConflict(t *testing.T) {
+	configYAML := `
+httpServer:
+  forwards:
+    - name: to_openai
+      port: 3000
+      defaultGroup: openai
+    - name: to_backup
+      address: "127.0.0.1"
+      port: 3000
+      defaultGroup: openai
+  admin:
+    port: 9000
+upstreams:
+  - name: openai
+    url: "https://api.openai.com/v1"
+upstreamGroups:
+  - name: openai
+    upstreams:
+      - name: openai
+`
+	manager, err := NewManager()
+	require.NoError(t, err)
+	err = manager.LoadFromFile(writeConfigFile(t, t.TempDir(), "config.yaml", configYAML))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "forward service 'to_backup' listener 127.0.0.1:3000 conflicts with forward service 'to_openai' listener 0.0.0.0:3000")
+}
+
+func TestValidateListeners(t *testing.T) {
+	enabled, disabled := true, false
+
+	newConfig := func(admin AdminConfig, forwards ...ForwardConfig) *Config {
+		return &Config{HTTPServer: HTTPServerConfig{Forwards: forwards, Admin: admin}}
+	}
+
+	tests := []struct {
+		name     string
+		config   *Config
+		conflict string
+	}{
+		{
+			name: "distinct ports",
+			config: newConfig(AdminConfig{Enabled: &enabled, Address: "0.0.0.0", Port: 9000},
+				ForwardConfig{Name: "a", Address: "0.0.0.0", Port: 3000},
+				ForwardConfig{Name: "b", Address: "0.0.0.0", Port: 3001}),
+		},
+		{
+			name: "same port on different specific addresses",
+			config: newConfig(AdminConfig{Enabled: &enabled, Address: "127.0.0.1", Port: 3000},
+				ForwardConfig{Name: "a", Address: "10.0.0.1", Port: 3000}),
+		},
+		{
+			name: "forward and admin on the same address",
+			config: newConfig(AdminConfig{Enabled: &enabled, Address: "0.0.0.0", Port: 3000},
+				ForwardConfig{Name: "a", Address: "0.0.0.0", Port: 3000}),
+			conflict: "admin server listener 0.0.0.0:3000 conflicts with forward service 'a' listener 0.0.0.0:3000",
+		},
+		{
+			name: "wildcard overlaps specific address",
+			config: newConfig(AdminConfig{Enabled: &enabled, Address: "127.0.0.1", Port: 3000},
+				ForwardConfig{Name: "a", Address: "::", Port: 3000}),
+			conflict: "admin server listener 127.0.0.1:3000 conflicts with forward service 'a' listener [::]:3000",
+		},
+		{
+			name: "disabled admin is exempt",
+			config: newConfig(AdminConfig{Enabled: &disabled, Address: "0.0.0.0", Port: 3000},
+				ForwardConfig{Name: "a", Address: "0.0.0.0", Port: 3000}),
+		},
+		{
+			name: "duplicate listeners within one forward",
+			config: newConfig(AdminConfig{Enabled: &enabled, Address: "0.0.0.0", Port: 9000},
+				ForwardConfig{Name: "a", Listeners: []ListenerConfig{{Address: "127.0.0.1", Port: 3000}, {Address: "127.0.0.1", Port: 3000}}}),
+			conflict: "forward service 'a' listener 127.0.0.1:3000 conflicts with forward service 'a' listener 127.0.0.1:3000",
+		},
+		{
+			name: "port 0 is exempt",
+			config: newConfig(AdminConfig{Enabled: &enabled, Address: "0.0.0.0", Port: 9000},
+				ForwardConfig{Name: "a", Address: "0.0.0.0", Port: 0},
+				ForwardConfig{Name: "b", Address: "0.0.0.0", Port: 0}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateListeners(tt.config)
+			if tt.conflict == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.conflict)
+		})
+	}
+}
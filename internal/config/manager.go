@@ -6,9 +6,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/go-playground/validator/v10"
-	"gopkg.in/yaml.v3"
+	"golang.org/x/net/http/httpguts"
 
 	"github.com/shengyanli1982/llmproxy-go/internal/constants"
 )
@@ -18,8 +19,9 @@ var validate = validator.New()
 
 // Manager 代表配置管理器，负责配置文件的加载、验证和管理
 type Manager struct {
+	mu         sync.RWMutex        // 读写锁，保护重新加载时的配置替换
 	config     *Config             // 当前加载的配置实例
-	configPath string              // 配置文件的绝对路径
+	configPath string              // 配置文件的绝对路径，多个文件时以逗号分隔
 	validator  *validator.Validate // 配置验证器
 }
 
@@ -35,10 +37,26 @@ func NewManager() (*Manager, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = validate.RegisterValidation("header_name", validateHeaderName)
+	if err != nil {
+		return nil, err
+	}
+	err = validate.RegisterValidation("header_value", validateHeaderValue)
+	if err != nil {
+		return nil, err
+	}
 	err = validate.RegisterValidation("http_url", validateHTTPURL)
 	if err != nil {
 		return nil, err
 	}
+	err = validate.RegisterValidation("proxy_url", validateProxyURL)
+	if err != nil {
+		return nil, err
+	}
+	err = validate.RegisterValidation("ascending", validateAscending)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Manager{
 		validator: validate,
@@ -48,42 +66,143 @@ func NewManager() (*Manager, error) {
 // LoadFromFile 从指定路径加载配置文件并进行验证
 // configPath: 配置文件路径
 func (m *Manager) LoadFromFile(configPath string) error {
-	// 检查文件是否存在
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return fmt.Errorf("config file not found: %s", configPath)
+	return m.LoadFromFiles(configPath)
+}
+
+// LoadFromFiles 按顺序加载并合并多个配置文件，对合并结果设置默认值并进行验证
+// 合并规则见 merge.go，后加载的文件覆盖或追加先加载文件中的配置
+// configPaths: 配置文件路径列表
+func (m *Manager) LoadFromFiles(configPaths ...string) error {
+	config, err := m.loadConfig(configPaths)
+	if err != nil {
+		return err
+	}
+
+	// 保存配置和路径
+	absPaths := make([]string, 0, len(configPaths))
+	for _, configPath := range configPaths {
+		absPath, _ := filepath.Abs(configPath)
+		absPaths = append(absPaths, absPath)
+	}
+
+	m.mu.Lock()
+	m.config = config
+	m.configPath = strings.Join(absPaths, constants.ConfigPathSeparator)
+	m.mu.Unlock()
+
+	// 配置加载成功，日志记录由调用者负责
+	return nil
+}
+
+// Reload 从上次加载的配置文件重新加载配置，从目录加载时不会发现目录中新增的文件
+// 读取、验证或应用失败时返回错误，当前配置保持不变
+// apply: 将新配置应用到运行中的服务，返回错误时不替换当前配置，为 nil 时直接替换
+func (m *Manager) Reload(apply func(*Config) error) error {
+	m.mu.RLock()
+	configPath := m.configPath
+	m.mu.RUnlock()
+
+	if configPath == "" {
+		return fmt.Errorf("no config loaded")
+	}
+
+	config, err := m.loadConfig(strings.Split(configPath, constants.ConfigPathSeparator))
+	if err != nil {
+		return err
+	}
+
+	if apply != nil {
+		if err := apply(config); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	m.config = config
+	m.mu.Unlock()
+
+	return nil
+}
+
+// loadConfig 读取并合并配置文件，设置默认值并完成全部验证，不修改当前配置
+// configPaths: 配置文件路径列表
+func (m *Manager) loadConfig(configPaths []string) (*Config, error) {
+	if len(configPaths) == 0 {
+		return nil, fmt.Errorf("no config files specified")
 	}
 
-	// 读取配置文件
-	data, err := os.ReadFile(configPath)
+	// 读取并合并配置文件
+	config, err := mergeConfigFiles(configPaths)
 	if err != nil {
-		return fmt.Errorf("failed to read config file: %w", err)
+		return nil, err
 	}
 
-	// 解析 YAML 配置
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return fmt.Errorf("failed to parse config file: %w", err)
+	// 读取密钥文件，需在验证前完成以满足认证字段的条件必填校验
+	if err := resolveSecretFiles(config); err != nil {
+		return nil, fmt.Errorf("failed to resolve secret files: %w", err)
 	}
 
 	// 设置默认值
-	m.SetDefaults(&config)
+	m.SetDefaults(config)
 
 	// 验证配置结构
-	if err := m.validator.Struct(&config); err != nil {
-		return fmt.Errorf("config validation failed: %w", err)
+	if err := m.validator.Struct(config); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
 
-	// 验证引用关系
-	if err := m.validateReferences(&config); err != nil {
-		return fmt.Errorf("config reference validation failed: %w", err)
+	// 验证引用关系，跨文件引用在合并后统一校验
+	if err := m.validateReferences(config); err != nil {
+		return nil, fmt.Errorf("config reference validation failed: %w", err)
 	}
 
-	// 保存配置和路径
-	m.config = &config
-	m.configPath, _ = filepath.Abs(configPath)
+	// 验证监听地址，避免启动时才因端口冲突绑定失败
+	if err := validateInheritedSockets(config); err != nil {
+		return nil, fmt.Errorf("config listener validation failed: %w", err)
+	}
+	if err := validateListeners(config); err != nil {
+		return nil, fmt.Errorf("config listener validation failed: %w", err)
+	}
 
-	// 配置加载成功，日志记录由调用者负责
-	return nil
+	// 验证转发服务的 TLS 证书能够加载，避免启动后才在握手时失败
+	if err := validateForwardTLS(config); err != nil {
+		return nil, fmt.Errorf("config tls validation failed: %w", err)
+	}
+	if err := validateClientTLS(config); err != nil {
+		return nil, fmt.Errorf("config tls validation failed: %w", err)
+	}
+
+	return config, nil
+}
+
+// LoadFromDir 按文件名顺序加载并合并目录中的所有 *.yaml、*.yml 配置文件
+// dir: 配置目录路径
+func (m *Manager) LoadFromDir(dir string) error {
+	configPaths, err := listConfigDir(dir)
+	if err != nil {
+		return err
+	}
+	return m.LoadFromFiles(configPaths...)
+}
+
+// LoadFromPath 根据命令行参数加载配置
+// 支持单个配置文件、配置目录或以逗号分隔的多个配置文件路径
+// path: 配置路径
+func (m *Manager) LoadFromPath(path string) error {
+	if strings.Contains(path, constants.ConfigPathSeparator) {
+		configPaths := make([]string, 0)
+		for _, configPath := range strings.Split(path, constants.ConfigPathSeparator) {
+			if configPath = strings.TrimSpace(configPath); configPath != "" {
+				configPaths = append(configPaths, configPath)
+			}
+		}
+		return m.LoadFromFiles(configPaths...)
+	}
+
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return m.LoadFromDir(path)
+	}
+
+	return m.LoadFromFile(path)
 }
 
 // validateReferences 验证配置中的引用关系是否正确
@@ -91,8 +210,10 @@ func (m *Manager) LoadFromFile(configPath string) error {
 func (m *Manager) validateReferences(config *Config) error {
 	// 构建上游服务名称映射，用于快速查找
 	upstreamNames := make(map[string]bool)
+	disabledUpstreams := make(map[string]bool)
 	for _, upstream := range config.Upstreams {
 		upstreamNames[upstream.Name] = true
+		disabledUpstreams[upstream.Name] = upstream.Disabled
 	}
 
 	// 构建上游组名称映射，并验证组内上游服务引用
@@ -101,20 +222,56 @@ func (m *Manager) validateReferences(config *Config) error {
 		groupNames[group.Name] = true
 
 		// 验证上游组中引用的上游服务是否存在
+		trafficPercent := 0
+		enabled := 0
 		for _, upstreamRef := range group.Upstreams {
 			if !upstreamNames[upstreamRef.Name] {
 				return fmt.Errorf("upstream group '%s' references unknown upstream '%s'",
 					group.Name, upstreamRef.Name)
 			}
+			if !disabledUpstreams[upstreamRef.Name] {
+				enabled++
+			}
+			trafficPercent += upstreamRef.TrafficPercent
+		}
+
+		// 验证上游组至少有一个未停用的上游
+		if len(group.Upstreams) > 0 && enabled == 0 {
+			return fmt.Errorf("upstream group '%s' has no enabled upstream, all of its upstreams are disabled", group.Name)
+		}
+
+		// 验证金丝雀流量百分比之和不超过 100
+		if trafficPercent > 100 {
+			return fmt.Errorf("upstream group '%s' traffic percentages sum to %d, exceeding 100",
+				group.Name, trafficPercent)
 		}
 	}
 
 	// 验证转发服务中引用的上游组是否存在
 	for _, forward := range config.HTTPServer.Forwards {
-		if !groupNames[forward.DefaultGroup] {
+		for _, name := range forward.CoalesceKeyHeaders {
+			if !httpguts.ValidHeaderFieldName(strings.TrimSpace(name)) {
+				return fmt.Errorf("forward service '%s' has invalid coalesce key header '%s'", forward.Name, name)
+			}
+		}
+
+		if forward.DefaultGroup != "" && !groupNames[forward.DefaultGroup] {
 			return fmt.Errorf("forward service '%s' references unknown upstream group '%s'",
 				forward.Name, forward.DefaultGroup)
 		}
+
+		referencedGroups := make(map[string]bool, len(forward.Groups))
+		for _, groupRef := range forward.Groups {
+			if !groupNames[groupRef.Name] {
+				return fmt.Errorf("forward service '%s' references unknown upstream group '%s'",
+					forward.Name, groupRef.Name)
+			}
+			if referencedGroups[groupRef.Name] {
+				return fmt.Errorf("forward service '%s' references upstream group '%s' more than once",
+					forward.Name, groupRef.Name)
+			}
+			referencedGroups[groupRef.Name] = true
+		}
 	}
 
 	return nil
@@ -122,17 +279,24 @@ func (m *Manager) validateReferences(config *Config) error {
 
 // GetConfig 返回当前加载的配置实例
 func (m *Manager) GetConfig() *Config {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.config
 }
 
-// GetConfigPath 返回当前配置文件的绝对路径
+// GetConfigPath 返回当前配置文件的绝对路径，加载多个配置文件时以逗号分隔
 func (m *Manager) GetConfigPath() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.configPath
 }
 
 // SetDefaults 为配置设置默认值，确保所有必需字段都有合理的默认值
 // config: 待设置默认值的配置实例
 func (m *Manager) SetDefaults(config *Config) {
+	// 设置 HTTP 服务器通用默认值
+	m.setHTTPServerDefaults(config)
+
 	// 设置 HTTP 服务器转发服务默认值
 	m.setForwardDefaults(config)
 
@@ -146,6 +310,52 @@ func (m *Manager) SetDefaults(config *Config) {
 	m.setUpstreamGroupDefaults(config)
 }
 
+// setHTTPServerDefaults 设置 HTTP 服务器通用的默认值
+func (m *Manager) setHTTPServerDefaults(config *Config) {
+	if config.HTTPServer.StreamBufferSize == 0 {
+		config.HTTPServer.StreamBufferSize = constants.DefaultStreamBufferSize
+	}
+	if config.HTTPServer.CopyBufferSize == 0 {
+		config.HTTPServer.CopyBufferSize = constants.DefaultCopyBufferSize
+	}
+	if config.HTTPServer.AccessLogSampleRate == 0 {
+		config.HTTPServer.AccessLogSampleRate = constants.DefaultAccessLogSampleRate
+	}
+	if config.HTTPServer.MaxHeaderBytes == 0 {
+		config.HTTPServer.MaxHeaderBytes = constants.DefaultMaxHeaderBytes
+	}
+	if config.HTTPServer.ShutdownTimeout == 0 {
+		config.HTTPServer.ShutdownTimeout = constants.DefaultShutdownTimeout
+	}
+	// 只有用户显式配置了全局ratelimit时才设置子字段默认值
+	if config.HTTPServer.RateLimit != nil {
+		if config.HTTPServer.RateLimit.PerSecond == 0 {
+			config.HTTPServer.RateLimit.PerSecond = constants.DefaultRatePerSecond
+		}
+		if config.HTTPServer.RateLimit.Burst == 0 {
+			config.HTTPServer.RateLimit.Burst = constants.DefaultRateBurst
+		}
+		if config.HTTPServer.RateLimit.KeyBy == "" {
+			config.HTTPServer.RateLimit.KeyBy = constants.DefaultRateLimitKeyBy
+		}
+		if config.HTTPServer.RateLimit.KeyBy == constants.RateLimitKeyByHeader && config.HTTPServer.RateLimit.Header == "" {
+			config.HTTPServer.RateLimit.Header = constants.DefaultRateLimitKeyHeader
+		}
+	}
+	// 只有用户显式配置了requestId时才设置子字段默认值
+	if config.HTTPServer.RequestID != nil {
+		if len(config.HTTPServer.RequestID.InboundHeaders) == 0 {
+			config.HTTPServer.RequestID.InboundHeaders = []string{constants.HeaderXRequestID}
+		}
+		if config.HTTPServer.RequestID.OutboundHeader == "" {
+			config.HTTPServer.RequestID.OutboundHeader = constants.HeaderXRequestID
+		}
+		if config.HTTPServer.RequestID.Format == "" {
+			config.HTTPServer.RequestID.Format = constants.DefaultRequestIDFormat
+		}
+	}
+}
+
 // setForwardDefaults 设置转发服务的默认值
 func (m *Manager) setForwardDefaults(config *Config) {
 	for i := range config.HTTPServer.Forwards {
@@ -153,6 +363,30 @@ func (m *Manager) setForwardDefaults(config *Config) {
 		if forward.Address == "" {
 			forward.Address = constants.DefaultAddress
 		}
+		for j := range forward.Listeners {
+			if forward.Listeners[j].Address == "" {
+				forward.Listeners[j].Address = constants.DefaultAddress
+			}
+		}
+		if forward.ErrorFormat == "" {
+			forward.ErrorFormat = constants.DefaultErrorFormat
+		}
+		for statusCode, errorResponse := range forward.ErrorResponses {
+			if errorResponse.ContentType == "" {
+				errorResponse.ContentType = constants.ContentTypeJSON
+				forward.ErrorResponses[statusCode] = errorResponse
+			}
+		}
+		for j := range forward.Groups {
+			if forward.Groups[j].Weight == 0 {
+				forward.Groups[j].Weight = constants.DefaultWeight
+			}
+		}
+		// 未单独配置ratelimit的转发服务继承全局默认IP限流
+		if forward.RateLimit == nil && config.HTTPServer.RateLimit != nil {
+			rateLimit := *config.HTTPServer.RateLimit
+			forward.RateLimit = &rateLimit
+		}
 		// 只有用户显式配置了ratelimit时才设置子字段默认值
 		if forward.RateLimit != nil {
 			if forward.RateLimit.PerSecond == 0 {
@@ -161,6 +395,48 @@ func (m *Manager) setForwardDefaults(config *Config) {
 			if forward.RateLimit.Burst == 0 {
 				forward.RateLimit.Burst = constants.DefaultRateBurst
 			}
+			if forward.RateLimit.KeyBy == "" {
+				forward.RateLimit.KeyBy = constants.DefaultRateLimitKeyBy
+			}
+			if forward.RateLimit.KeyBy == constants.RateLimitKeyByHeader && forward.RateLimit.Header == "" {
+				forward.RateLimit.Header = constants.DefaultRateLimitKeyHeader
+			}
+		}
+		if behavior := forward.NoUpstreamBehavior; behavior != nil {
+			if behavior.Mode == "" {
+				behavior.Mode = constants.DefaultNoUpstreamMode
+			}
+			if behavior.StaticResponse != nil {
+				if behavior.StaticResponse.StatusCode == 0 {
+					behavior.StaticResponse.StatusCode = constants.DefaultNoUpstreamStatusCode
+				}
+				if behavior.StaticResponse.ContentType == "" {
+					behavior.StaticResponse.ContentType = constants.ContentTypeJSON
+				}
+			}
+		}
+		if forward.ResponseAffinity != nil && forward.ResponseAffinity.TTL == 0 {
+			forward.ResponseAffinity.TTL = constants.DefaultResponseAffinityTTL
+		}
+		if forward.ResponseHeaderPolicy != nil && forward.ResponseHeaderPolicy.Mode == "" {
+			forward.ResponseHeaderPolicy.Mode = constants.DefaultResponseHeaderPolicy
+		}
+		if forward.Idempotency != nil {
+			if forward.Idempotency.TTL == 0 {
+				forward.Idempotency.TTL = constants.DefaultIdempotencyTTL
+			}
+			if forward.Idempotency.MaxBodySize == 0 {
+				forward.Idempotency.MaxBodySize = constants.DefaultIdempotencyMaxBodySize
+			}
+		}
+		for j := range forward.RateLimitRules {
+			rule := &forward.RateLimitRules[j]
+			if rule.PerSecond == 0 {
+				rule.PerSecond = constants.DefaultRatePerSecond
+			}
+			if rule.Burst == 0 {
+				rule.Burst = constants.DefaultRateBurst
+			}
 		}
 		if forward.Timeout == nil {
 			forward.Timeout = &TimeoutConfig{
@@ -193,6 +469,10 @@ func (m *Manager) setForwardDefaults(config *Config) {
 
 // setAdminDefaults 设置管理服务的默认值
 func (m *Manager) setAdminDefaults(config *Config) {
+	if config.HTTPServer.Admin.Enabled == nil {
+		enabled := true
+		config.HTTPServer.Admin.Enabled = &enabled
+	}
 	if config.HTTPServer.Admin.Port == 0 {
 		config.HTTPServer.Admin.Port = constants.DefaultAdminPort
 	}
@@ -231,9 +511,13 @@ func (m *Manager) setAdminDefaults(config *Config) {
 func (m *Manager) setUpstreamDefaults(config *Config) {
 	for i := range config.Upstreams {
 		upstream := &config.Upstreams[i]
-		if upstream.Auth == nil {
-			upstream.Auth = &AuthConfig{Type: constants.AuthTypeNone}
-		} else if upstream.Auth.Type == "" {
+		// 规范化上游 URL 并缓存解析结果，无效的 URL 保持原样，由结构验证报告错误
+		if parsedURL, err := NormalizeUpstreamURL(upstream.URL); err == nil {
+			upstream.URL = parsedURL.String()
+			upstream.parsedURL = parsedURL
+		}
+		// 未配置 auth 时保持为 nil，由所在上游组的 defaultAuth 决定认证方式
+		if upstream.Auth != nil && upstream.Auth.Type == "" {
 			upstream.Auth.Type = constants.AuthTypeNone
 		}
 		if upstream.Breaker != nil {
@@ -268,10 +552,15 @@ func (m *Manager) setUpstreamGroupDefaults(config *Config) {
 		if group.Balance == nil {
 			group.Balance = &BalanceConfig{Strategy: constants.DefaultBalanceStrategy}
 		}
+		if group.DefaultAuth != nil && group.DefaultAuth.Type == "" {
+			group.DefaultAuth.Type = constants.AuthTypeNone
+		}
 		if group.HTTPClient == nil {
 			group.HTTPClient = &HTTPClientConfig{
-				Agent:     constants.UserAgent,
-				KeepAlive: constants.DefaultKeepAlive,
+				Agent:                 constants.UserAgent,
+				KeepAlive:             constants.DefaultKeepAlive,
+				DNSCacheTTL:           constants.DefaultDNSCacheTTL,
+				ExpectContinueTimeout: constants.DefaultExpectContinueTimeout,
 				Connect: &ConnectConfig{
 					IdleTotal:   constants.DefaultIdleTotal,
 					IdlePerHost: constants.DefaultIdlePerHost,
@@ -291,6 +580,21 @@ func (m *Manager) setUpstreamGroupDefaults(config *Config) {
 				group.HTTPClient.KeepAlive = constants.DefaultKeepAlive
 			}
 
+			// 如果HTTPClient存在但DNSCacheTTL为0，设置默认值
+			if group.HTTPClient.DNSCacheTTL == 0 {
+				group.HTTPClient.DNSCacheTTL = constants.DefaultDNSCacheTTL
+			}
+
+			// 如果HTTPClient存在但ExpectContinueTimeout为0，设置默认值
+			if group.HTTPClient.ExpectContinueTimeout == 0 {
+				group.HTTPClient.ExpectContinueTimeout = constants.DefaultExpectContinueTimeout
+			}
+
+			// 启用连接预热但未配置预热连接数时，设置默认值
+			if group.HTTPClient.Warmup && group.HTTPClient.WarmupConnections == 0 {
+				group.HTTPClient.WarmupConnections = constants.DefaultWarmupConnections
+			}
+
 			// 如果HTTPClient存在但Connect为nil，设置默认的Connect配置
 			if group.HTTPClient.Connect == nil {
 				group.HTTPClient.Connect = &ConnectConfig{
@@ -342,8 +646,9 @@ func (m *Manager) setUpstreamGroupDefaults(config *Config) {
 
 		// 设置上游引用权重默认值
 		for j := range group.Upstreams {
-			if group.Upstreams[j].Weight == 0 {
-				group.Upstreams[j].Weight = constants.DefaultWeight
+			if group.Upstreams[j].Weight == nil {
+				weight := constants.DefaultWeight
+				group.Upstreams[j].Weight = &weight
 			}
 		}
 	}
@@ -390,6 +695,47 @@ func validateHeaderConditional(fl validator.FieldLevel) bool {
 	}
 }
 
+// validateHeaderName 验证头部名称是合法的 HTTP 头部字段名（token），防止通过换行注入额外头部
+// 头部操作执行时会去除首尾空白，因此验证去除空白后的名称
+func validateHeaderName(fl validator.FieldLevel) bool {
+	return httpguts.ValidHeaderFieldName(strings.TrimSpace(fl.Field().String()))
+}
+
+// validateHeaderValue 验证头部值不包含换行等控制字符
+func validateHeaderValue(fl validator.FieldLevel) bool {
+	return httpguts.ValidHeaderFieldValue(fl.Field().String())
+}
+
+// validateProxyURL 验证代理URL必须使用HTTP、HTTPS或SOCKS5协议
+func validateProxyURL(fl validator.FieldLevel) bool {
+	parsedURL, err := url.Parse(fl.Field().String())
+	if err != nil || parsedURL.Host == "" {
+		return false // URL格式无效或缺少host
+	}
+
+	switch strings.ToLower(parsedURL.Scheme) {
+	case constants.ProtocolHTTP, constants.ProtocolHTTPS, constants.ProtocolSOCKS5, constants.ProtocolSOCKS5H:
+		return true
+	default:
+		return false
+	}
+}
+
+// validateAscending 验证浮点数列表必须严格递增
+func validateAscending(fl validator.FieldLevel) bool {
+	values, ok := fl.Field().Interface().([]float64)
+	if !ok {
+		return false
+	}
+
+	for i := 1; i < len(values); i++ {
+		if values[i] <= values[i-1] {
+			return false
+		}
+	}
+	return true
+}
+
 // validateHTTPURL 验证URL必须使用HTTP或HTTPS协议
 func validateHTTPURL(fl validator.FieldLevel) bool {
 	urlStr := fl.Field().String()
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 多配置文件合并规则：
+//   - 映射：按键递归合并，后加载文件中的标量值覆盖先加载文件中的值
+//   - 列表：默认追加；列表元素为带 name 字段的映射时（如 upstreams、upstreamGroups、httpServer.forwards），
+//     后加载文件中的同名元素整体替换先前的元素，其余元素追加
+//   - 替换：在文件顶层的 merge.replace 中列出列表路径（如 upstreams、httpServer.forwards），
+//     该文件中的对应列表将整体替换先前合并的结果，而不是追加
+const (
+	// mergeDirectiveKey 合并指令的顶层键，仅在合并过程中使用，不会出现在最终配置中
+	mergeDirectiveKey = "merge"
+
+	// mergeReplaceKey 合并指令中指定整体替换的列表路径的键
+	mergeReplaceKey = "replace"
+
+	// mergeNameKey 列表元素的名称字段，同名元素后者替换前者
+	mergeNameKey = "name"
+)
+
+// configFileExtensions 配置目录中会被加载的文件扩展名
+var configFileExtensions = map[string]bool{".yaml": true, ".yml": true}
+
+// readConfigFile 读取并解析单个配置文件为通用映射，空文件返回空映射
+func readConfigFile(configPath string) (map[string]interface{}, error) {
+	// 检查文件是否存在
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("config file not found: %s", configPath)
+	}
+
+	// 读取配置文件
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file '%s': %w", configPath, err)
+	}
+
+	// 解析 YAML 配置
+	document := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse config file '%s': %w", configPath, err)
+	}
+
+	return document, nil
+}
+
+// mergeConfigFiles 按顺序读取并合并多个配置文件，返回合并后的配置
+func mergeConfigFiles(configPaths []string) (*Config, error) {
+	merged := make(map[string]interface{})
+
+	for _, configPath := range configPaths {
+		document, err := readConfigFile(configPath)
+		if err != nil {
+			return nil, err
+		}
+
+		replace, err := extractReplacePaths(document)
+		if err != nil {
+			return nil, fmt.Errorf("invalid merge directive in config file '%s': %w", configPath, err)
+		}
+
+		mergeMaps(merged, document, "", replace)
+	}
+
+	// 重新编码合并结果并解析为配置结构
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode merged config: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse merged config: %w", err)
+	}
+
+	return &config, nil
+}
+
+// extractReplacePaths 提取并移除文件顶层的合并指令，返回需要整体替换的列表路径集合
+func extractReplacePaths(document map[string]interface{}) (map[string]bool, error) {
+	replace := make(map[string]bool)
+
+	directive, ok := document[mergeDirectiveKey]
+	if !ok {
+		return replace, nil
+	}
+	delete(document, mergeDirectiveKey)
+
+	directiveMap, ok := directive.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("'%s' must be a mapping", mergeDirectiveKey)
+	}
+
+	paths, ok := directiveMap[mergeReplaceKey]
+	if !ok {
+		return replace, nil
+	}
+
+	pathList, ok := paths.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("'%s.%s' must be a list of paths", mergeDirectiveKey, mergeReplaceKey)
+	}
+
+	for _, path := range pathList {
+		pathString, ok := path.(string)
+		if !ok || pathString == "" {
+			return nil, fmt.Errorf("'%s.%s' entries must be non-empty strings", mergeDirectiveKey, mergeReplaceKey)
+		}
+		replace[pathString] = true
+	}
+
+	return replace, nil
+}
+
+// mergeMaps 将 src 合并到 dst
+// path: 当前映射在配置中的路径，顶层为空字符串
+// replace: 需要整体替换的列表路径集合
+func mergeMaps(dst, src map[string]interface{}, path string, replace map[string]bool) {
+	for key, srcValue := range src {
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+
+		dstValue, exists := dst[key]
+		if !exists {
+			dst[key] = srcValue
+			continue
+		}
+
+		switch srcTyped := srcValue.(type) {
+		case map[string]interface{}:
+			if dstTyped, ok := dstValue.(map[string]interface{}); ok {
+				mergeMaps(dstTyped, srcTyped, keyPath, replace)
+				continue
+			}
+		case []interface{}:
+			if dstTyped, ok := dstValue.([]interface{}); ok && !replace[keyPath] {
+				dst[key] = mergeLists(dstTyped, srcTyped)
+				continue
+			}
+		}
+
+		dst[key] = srcValue
+	}
+}
+
+// mergeLists 合并两个列表，带 name 字段的同名元素由 src 替换，其余元素追加
+func mergeLists(dst, src []interface{}) []interface{} {
+	merged := make([]interface{}, len(dst), len(dst)+len(src))
+	copy(merged, dst)
+
+	// 记录已有同名元素的位置
+	nameIndex := make(map[string]int)
+	for i, item := range merged {
+		if name, ok := listItemName(item); ok {
+			nameIndex[name] = i
+		}
+	}
+
+	for _, item := range src {
+		if name, ok := listItemName(item); ok {
+			if i, exists := nameIndex[name]; exists {
+				merged[i] = item
+				continue
+			}
+			nameIndex[name] = len(merged)
+		}
+		merged = append(merged, item)
+	}
+
+	return merged
+}
+
+// listItemName 获取列表元素的 name 字段值
+func listItemName(item interface{}) (string, bool) {
+	itemMap, ok := item.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	name, ok := itemMap[mergeNameKey].(string)
+	return name, ok && name != ""
+}
+
+// listConfigDir 列出配置目录中的配置文件，按文件名排序
+func listConfigDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config directory '%s': %w", dir, err)
+	}
+
+	configPaths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !configFileExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			continue
+		}
+		configPaths = append(configPaths, filepath.Join(dir, entry.Name()))
+	}
+
+	if len(configPaths) == 0 {
+		return nil, fmt.Errorf("no config files (*.yaml, *.yml) found in directory: %s", dir)
+	}
+
+	sort.Strings(configPaths)
+	return configPaths, nil
+}
@@ -0,0 +1,248 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testUpstreamsYAML = `
+upstreams:
+  - name: openai
+    url: "https://api.openai.com/v1"
+  - name: anthropic
+    url: "https://api.anthropic.com/v1"
+upstreamGroups:
+  - name: mixgroup
+    upstreams:
+      - name: openai
+      - name: anthropic
+`
+	testForwardsYAML = `
+httpServer:
+  forwards:
+    - name: to_mixgroup
+      port: 3000
+      defaultGroup: mixgroup
+  admin:
+    port: 9000
+`
+)
+
+// writeConfigFile 在目录中写入配置文件并返回路径
+func writeConfigFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestManager_Reload(t *testing.T) {
+	dir := t.TempDir()
+	upstreamsPath := writeConfigFile(t, dir, "10-upstreams.yaml", testUpstreamsYAML)
+	forwardsPath := writeConfigFile(t, dir, "20-forwards.yaml", testForwardsYAML)
+
+	manager, err := NewManager()
+	require.NoError(t, err)
+	require.NoError(t, manager.LoadFromFiles(upstreamsPath, forwardsPath))
+	loaded := manager.GetConfig()
+
+	// 验证失败时保留当前配置
+	writeConfigFile(t, dir, "20-forwards.yaml", strings.Replace(testForwardsYAML, "defaultGroup: mixgroup", "defaultGroup: missing", 1))
+	err = manager.Reload(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown upstream group 'missing'")
+	assert.Same(t, loaded, manager.GetConfig())
+
+	// 应用新配置失败时保留当前配置
+	writeConfigFile(t, dir, "20-forwards.yaml", strings.Replace(testForwardsYAML, "port: 3000", "port: 3001", 1))
+	assert.Error(t, manager.Reload(func(*Config) error { return errors.New("apply failed") }))
+	assert.Same(t, loaded, manager.GetConfig())
+
+	// 重新加载成功后替换为新配置
+	require.NoError(t, manager.Reload(nil))
+	assert.NotSame(t, loaded, manager.GetConfig())
+	assert.Equal(t, 3001, manager.GetConfig().HTTPServer.Forwards[0].Port)
+}
+
+func TestManager_LoadFromFiles_CrossFileReferences(t *testing.T) {
+	dir := t.TempDir()
+	upstreams := writeConfigFile(t, dir, "upstreams.yaml", testUpstreamsYAML)
+	forwards := writeConfigFile(t, dir, "forwards.yaml", testForwardsYAML)
+
+	manager, err := NewManager()
+	require.NoError(t, err)
+
+	// 转发服务在一个文件中引用另一个文件定义的上游组
+	require.NoError(t, manager.LoadFromFiles(upstreams, forwards))
+
+	cfg := manager.GetConfig()
+	require.Len(t, cfg.HTTPServer.Forwards, 1)
+	assert.Equal(t, "mixgroup", cfg.HTTPServer.Forwards[0].DefaultGroup)
+	assert.Len(t, cfg.Upstreams, 2)
+	assert.Len(t, cfg.UpstreamGroups, 1)
+
+	// 合并结果同样会设置默认值
+	assert.Equal(t, "0.0.0.0", cfg.HTTPServer.Forwards[0].Address)
+	assert.Contains(t, manager.GetConfigPath(), "upstreams.yaml")
+	assert.Contains(t, manager.GetConfigPath(), "forwards.yaml")
+}
+
+func TestManager_LoadFromFiles_UnknownCrossFileReference(t *testing.T) {
+	dir := t.TempDir()
+	upstreams := writeConfigFile(t, dir, "upstreams.yaml", testUpstreamsYAML)
+	forwards := writeConfigFile(t, dir, "forwards.yaml", strings.Replace(testForwardsYAML, "defaultGroup: mixgroup", "defaultGroup: missing", 1))
+
+	manager, err := NewManager()
+	require.NoError(t, err)
+
+	err = manager.LoadFromFiles(upstreams, forwards)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown upstream group 'missing'")
+}
+
+func TestManager_LoadFromFiles_MergeSemantics(t *testing.T) {
+	dir := t.TempDir()
+	base := writeConfigFile(t, dir, "base.yaml", testUpstreamsYAML+testForwardsYAML)
+
+	tests := []struct {
+		name    string
+		overlay string
+		check   func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "scalars override",
+			overlay: `
+httpServer:
+  admin:
+    port: 9100
+`,
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, 9100, cfg.HTTPServer.Admin.Port)
+				require.Len(t, cfg.HTTPServer.Forwards, 1)
+			},
+		},
+		{
+			name: "new named items append",
+			overlay: `
+upstreams:
+  - name: gemini
+    url: "https://generativelanguage.googleapis.com/v1"
+`,
+			check: func(t *testing.T, cfg *Config) {
+				require.Len(t, cfg.Upstreams, 3)
+				assert.Equal(t, "openai", cfg.Upstreams[0].Name)
+				assert.Equal(t, "gemini", cfg.Upstreams[2].Name)
+			},
+		},
+		{
+			name: "same named items replace",
+			overlay: `
+upstreams:
+  - name: openai
+    url: "https://openai-proxy.internal/v1"
+`,
+			check: func(t *testing.T, cfg *Config) {
+				require.Len(t, cfg.Upstreams, 2)
+				assert.Equal(t, "openai", cfg.Upstreams[0].Name)
+				assert.Equal(t, "https://openai-proxy.internal/v1", cfg.Upstreams[0].URL)
+			},
+		},
+		{
+			name: "merge replace directive",
+			overlay: `
+merge:
+  replace:
+    - httpServer.forwards
+httpServer:
+  forwards:
+    - name: replacement
+      port: 3100
+      defaultGroup: mixgroup
+`,
+			check: func(t *testing.T, cfg *Config) {
+				require.Len(t, cfg.HTTPServer.Forwards, 1)
+				assert.Equal(t, "replacement", cfg.HTTPServer.Forwards[0].Name)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overlay := writeConfigFile(t, t.TempDir(), "overlay.yaml", tt.overlay)
+
+			manager, err := NewManager()
+			require.NoError(t, err)
+			require.NoError(t, manager.LoadFromFiles(base, overlay))
+			tt.check(t, manager.GetConfig())
+		})
+	}
+}
+
+func TestManager_LoadFromDir(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "10-upstreams.yaml", testUpstreamsYAML)
+	writeConfigFile(t, dir, "20-forwards.yml", testForwardsYAML)
+	writeConfigFile(t, dir, "README.md", "not a config file")
+
+	manager, err := NewManager()
+	require.NoError(t, err)
+	require.NoError(t, manager.LoadFromDir(dir))
+	assert.Len(t, manager.GetConfig().HTTPServer.Forwards, 1)
+
+	// 空目录返回错误
+	assert.Error(t, manager.LoadFromDir(t.TempDir()))
+}
+
+func TestManager_LoadFromPath(t *testing.T) {
+	dir := t.TempDir()
+	upstreams := writeConfigFile(t, dir, "upstreams.yaml", testUpstreamsYAML)
+	forwards := writeConfigFile(t, dir, "forwards.yaml", testForwardsYAML)
+	single := writeConfigFile(t, t.TempDir(), "config.yaml", testUpstreamsYAML+testForwardsYAML)
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "single file", path: single},
+		{name: "comma separated files", path: upstreams + ", " + forwards},
+		{name: "directory", path: dir},
+		{name: "single file missing references", path: forwards, wantErr: true},
+		{name: "missing file", path: filepath.Join(dir, "missing.yaml"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, err := NewManager()
+			require.NoError(t, err)
+
+			err = manager.LoadFromPath(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, manager.GetConfig().HTTPServer.Forwards, 1)
+		})
+	}
+}
+
+func TestManager_LoadFromFiles_InvalidMergeDirective(t *testing.T) {
+	dir := t.TempDir()
+	base := writeConfigFile(t, dir, "base.yaml", testUpstreamsYAML+testForwardsYAML)
+	overlay := writeConfigFile(t, dir, "overlay.yaml", "merge:\n  replace: upstreams\n")
+
+	manager, err := NewManager()
+	require.NoError(t, err)
+
+	err = manager.LoadFromFiles(base, overlay)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "merge directive")
+}
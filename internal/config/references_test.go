@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_LoadFromFile_InvalidCoalesceKeyHeader(t *testing.T) {
+	configYAML := `
+httpServer:
+  forwards:
+    - name: to_openai
+      port: 3000
+      defaultGroup: openai
+      coalesce: true
+      coalesceKeyHeaders:
+        - "X-Api-Key\r\nX-Injected"
+  admin:
+    port: 9000
+upstreams:
+  - name: openai
+    url: "https://api.openai.com/v1"
+upstreamGroups:
+  - name: openai
+    upstreams:
+      - name: openai
+`
+
+	manager, err := NewManager()
+	require.NoError(t, err)
+	err = manager.LoadFromFile(writeConfigFile(t, t.TempDir(), "config.yaml", configYAML))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "forward service 'to_openai' has invalid coalesce key header")
+}
+
+func TestManager_LoadFromFile_AllUpstreamsDisabled(t *testing.T) {
+	configYAML := `
+httpServer:
+  forwards:
+    - name: to_openai
+      port: 3000
+      defaultGroup: openai
+  admin:
+    port: 9000
+upstreams:
+  - name: openai-a
+    url: "https://api.openai.com/v1"
+    disabled: true
+  - name: openai-b
+    url: "https://api.openai.com/v1"
+    disabled: %t
+upstreamGroups:
+  - name: openai
+    upstreams:
+      - name: openai-a
+      - name: openai-b
+`
+
+	manager, err := NewManager()
+	require.NoError(t, err)
+	err = manager.LoadFromFile(writeConfigFile(t, t.TempDir(), "config.yaml", fmt.Sprintf(configYAML, true)))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "upstream group 'openai' has no enabled upstream")
+
+	// 至少一个上游未停用时加载成功
+	manager, err = NewManager()
+	require.NoError(t, err)
+	assert.NoError(t, manager.LoadFromFile(writeConfigFile(t, t.TempDir(), "config.yaml", fmt.Sprintf(configYAML, false))))
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// resolveSecretFiles 读取配置中引用的密钥文件，将文件内容填入对应字段
+// 密钥以文件形式挂载（Docker/Kubernetes secrets）时，避免将密钥写入配置文件或环境变量
+// config: 合并后的配置实例
+func resolveSecretFiles(config *Config) error {
+	if err := resolveAuthSecretFiles(config.HTTPServer.Admin.Auth); err != nil {
+		return fmt.Errorf("admin auth: %w", err)
+	}
+
+	for i := range config.Upstreams {
+		if err := resolveAuthSecretFiles(config.Upstreams[i].Auth); err != nil {
+			return fmt.Errorf("upstream '%s' auth: %w", config.Upstreams[i].Name, err)
+		}
+	}
+
+	for i := range config.UpstreamGroups {
+		if err := resolveAuthSecretFiles(config.UpstreamGroups[i].DefaultAuth); err != nil {
+			return fmt.Errorf("upstream group '%s' default auth: %w", config.UpstreamGroups[i].Name, err)
+		}
+	}
+
+	return nil
+}
+
+// resolveAuthSecretFiles 读取认证配置的 tokenFile 和 passwordFile
+// auth: 认证配置，为 nil 时跳过
+func resolveAuthSecretFiles(auth *AuthConfig) error {
+	if auth == nil {
+		return nil
+	}
+
+	if auth.TokenFile != "" {
+		if auth.Token != "" {
+			return fmt.Errorf("token and tokenFile are mutually exclusive")
+		}
+		token, err := readSecretFile(auth.TokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read tokenFile: %w", err)
+		}
+		auth.Token = token
+	}
+
+	if auth.PasswordFile != "" {
+		if auth.Password != "" {
+			return fmt.Errorf("password and passwordFile are mutually exclusive")
+		}
+		password, err := readSecretFile(auth.PasswordFile)
+		if err != nil {
+			return fmt.Errorf("failed to read passwordFile: %w", err)
+		}
+		auth.Password = password
+	}
+
+	return nil
+}
+
+// readSecretFile 读取密钥文件内容，去除末尾的换行符
+// path: 密钥文件路径
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_LoadFromFile_SecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := writeConfigFile(t, dir, "openai-token", "sk-from-file\n")
+	passwordFile := writeConfigFile(t, dir, "admin-password", "admin-secret\r\n")
+
+	configYAML := `
+httpServer:
+  forwards:
+    - name: to_openai
+      port: 3000
+      defaultGroup: openai
+  admin:
+    port: 9000
+    auth:
+      type: basic
+      username: admin
+      passwordFile: "` + filepath.ToSlash(passwordFile) + `"
+upstreams:
+  - name: openai
+    url: "https://api.openai.com/v1"
+    auth:
+      type: bearer
+      tokenFile: "` + filepath.ToSlash(tokenFile) + `"
+upstreamGroups:
+  - name: openai
+    upstreams:
+      - name: openai
+`
+	manager, err := NewManager()
+	require.NoError(t, err)
+	require.NoError(t, manager.LoadFromFile(writeConfigFile(t, dir, "config.yaml", configYAML)))
+
+	cfg := manager.GetConfig()
+	assert.Equal(t, "sk-from-file", cfg.Upstreams[0].Auth.Token)
+	assert.Equal(t, "admin-secret", cfg.HTTPServer.Admin.Auth.Password)
+}
+
+func TestManager_LoadFromFile_SecretFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := writeConfigFile(t, dir, "token", "sk-from-file\n")
+
+	tests := []struct {
+		name    string
+		auth    string
+		wantErr string
+	}{
+		{
+			name: "token and tokenFile both set",
+			auth: `
+      type: bearer
+      token: sk-inline
+      tokenFile: "` + filepath.ToSlash(tokenFile) + `"`,
+			wantErr: "token and tokenFile are mutually exclusive",
+		},
+		{
+			name: "password and passwordFile both set",
+			auth: `
+      type: basic
+      username: user
+      password: inline
+      passwordFile: "` + filepath.ToSlash(tokenFile) + `"`,
+			wantErr: "password and passwordFile are mutually exclusive",
+		},
+		{
+			name: "missing token file",
+			auth: `
+      type: bearer
+      tokenFile: "` + filepath.ToSlash(filepath.Join(dir, "missing")) + `"`,
+			wantErr: "upstream 'openai' auth: failed to read tokenFile",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configYAML := `
+httpServer:
+  forwards:
+    - name: to_openai
+      port: 3000
+      defaultGroup: openai
+  admin:
+    port: 9000
+upstreams:
+  - name: openai
+    url: "https://api.openai.com/v1"
+    auth:` + tt.auth + `
+upstreamGroups:
+  - name: openai
+    upstreams:
+      - name: openai
+`
+			manager, err := NewManager()
+			require.NoError(t, err)
+
+			err = manager.LoadFromFile(writeConfigFile(t, t.TempDir(), "config.yaml", configYAML))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -1,5 +1,12 @@
 package config
 
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
 // Config 代表主配置结构体，包含HTTP服务器、上游服务和上游组的完整配置
 type Config struct {
 	HTTPServer     HTTPServerConfig      `yaml:"httpServer" validate:"required"`
@@ -9,31 +16,170 @@ type Config struct {
 
 // HTTPServerConfig 代表HTTP服务器配置，包含转发服务和管理服务设置
 type HTTPServerConfig struct {
-	Forwards []ForwardConfig `yaml:"forwards" validate:"required,dive"`
-	Admin    AdminConfig     `yaml:"admin"`
+	Forwards            []ForwardConfig  `yaml:"forwards" validate:"required,dive"`
+	Admin               AdminConfig      `yaml:"admin"`
+	RateLimit           *RateLimitConfig `yaml:"ratelimit,omitempty"`                                                 // 全局默认客户端限流，未单独配置限流的转发服务使用此配置
+	StreamBufferSize    int              `yaml:"streamBufferSize,omitempty" validate:"omitempty,min=512,max=4194304"` // 单位：字节，流式响应复制缓冲区大小
+	CopyBufferSize      int              `yaml:"copyBufferSize,omitempty" validate:"omitempty,min=512,max=4194304"`   // 单位：字节，非流式响应复制缓冲区大小
+	Metrics             *MetricsConfig   `yaml:"metrics,omitempty"`                                                   // Prometheus 指标配置
+	AccessLogSampleRate float64          `yaml:"accessLogSampleRate,omitempty" validate:"omitempty,gt=0,lte=1"`       // 成功请求访问日志的采样率，1.0 记录全部，错误日志始终记录
+	MaxHeaderBytes      int              `yaml:"maxHeaderBytes,omitempty" validate:"omitempty,min=1024,max=16777216"` // 单位：字节，转发服务和管理服务允许的最大请求头部大小
+	ShutdownTimeout     int              `yaml:"shutdownTimeout,omitempty" validate:"omitempty,min=1000,max=600000"`  // 单位：毫秒，收到 SIGTERM 后等待进行中请求完成的最长时间
+	TrustedProxies      []string         `yaml:"trustedProxies,omitempty" validate:"omitempty,dive,cidr|ip"`          // 可信代理 IP 或 CIDR，仅采信其设置的 X-Forwarded-* 头部，未配置时信任所有来源
+	RequestID           *RequestIDConfig `yaml:"requestId,omitempty"`                                                 // 请求 ID 的读取、生成和传递方式，未配置时仅读取 X-Request-ID 头部用于日志
+}
+
+// RequestIDConfig 代表请求 ID 配置
+type RequestIDConfig struct {
+	InboundHeaders []string `yaml:"inboundHeaders,omitempty" validate:"omitempty,dive,required"`   // 读取客户端请求 ID 的头部名称，按顺序使用第一个存在的头部
+	OutboundHeader string   `yaml:"outboundHeader,omitempty"`                                      // 转发到上游和返回客户端时设置的请求 ID 头部名称
+	Format         string   `yaml:"format,omitempty" validate:"omitempty,oneof=uuid4 ksuid short"` // 客户端未提供请求 ID 时生成的 ID 格式
+}
+
+// MetricsConfig 代表 Prometheus 指标配置
+type MetricsConfig struct {
+	DurationBuckets      []float64 `yaml:"durationBuckets,omitempty" validate:"omitempty,ascending,dive,gt=0"`       // 单位：秒，HTTP 请求、上游请求耗时和上游首字节时间直方图的桶边界，必须严格递增
+	HealthStatusInterval int       `yaml:"healthStatusInterval,omitempty" validate:"omitempty,min=1000,max=3600000"` // 单位：毫秒，按近期请求成功率上报上游健康状态指标的间隔
+	UpstreamLabels       []string  `yaml:"upstreamLabels,omitempty" validate:"omitempty,unique,dive,required"`       // 作为指标标签附加到上游请求指标的上游标签键，应只包含低基数的标签
 }
 
 // ForwardConfig 代表转发服务配置，定义单个代理转发实例的参数
 type ForwardConfig struct {
-	Name         string           `yaml:"name" validate:"required"`
-	Port         int              `yaml:"port" validate:"required,min=1,max=65535"`
-	Address      string           `yaml:"address"`
-	DefaultGroup string           `yaml:"defaultGroup" validate:"required"`
-	RateLimit    *RateLimitConfig `yaml:"ratelimit,omitempty"`
-	Timeout      *TimeoutConfig   `yaml:"timeout,omitempty"`
+	Name                  string                      `yaml:"name" validate:"required"`
+	Port                  int                         `yaml:"port" validate:"min=0,max=65535"` // 单个监听端口，是 listeners 的简写形式，未配置 listeners 时为 0 表示使用进程继承的监听套接字
+	Address               string                      `yaml:"address"`
+	Listeners             []ListenerConfig            `yaml:"listeners,omitempty" validate:"omitempty,dive"`   // 多个监听地址，共享同一处理器
+	DefaultGroup          string                      `yaml:"defaultGroup" validate:"required_without=Groups"` // 未配置 groups 时使用的上游组
+	Groups                []GroupRefConfig            `yaml:"groups,omitempty" validate:"omitempty,dive"`      // 按权重分配流量的多个上游组，配置后优先于 defaultGroup
+	RateLimit             *RateLimitConfig            `yaml:"ratelimit,omitempty"`
+	RateLimitRules        []RateLimitRuleConfig       `yaml:"rateLimitRules,omitempty" validate:"omitempty,dive"` // 按路径前缀覆盖的限流规则
+	Timeout               *TimeoutConfig              `yaml:"timeout,omitempty"`
+	ErrorFormat           string                      `yaml:"errorFormat,omitempty" validate:"omitempty,oneof=llmproxy openai"`                // 代理自身错误的响应格式
+	DebugHeaders          bool                        `yaml:"debugHeaders,omitempty"`                                                          // 是否在响应中添加上游和负载均衡策略调试头部
+	AllowUpstreamOverride bool                        `yaml:"allowUpstreamOverride,omitempty"`                                                 // 是否允许客户端通过 X-LLMProxy-Upstream 头部指定组内上游，绕过负载均衡
+	Idempotency           *IdempotencyConfig          `yaml:"idempotency,omitempty"`                                                           // 基于 Idempotency-Key 头部的请求去重
+	DecompressRequestBody bool                        `yaml:"decompressRequestBody,omitempty"`                                                 // 是否解压 gzip 编码的客户端请求体后再转发
+	StreamRequestBody     bool                        `yaml:"streamRequestBody,omitempty"`                                                     // 是否直接流式转发请求体，不预先读入内存
+	StreamLargeUploads    bool                        `yaml:"streamLargeUploads,omitempty"`                                                    // 是否仅对 multipart/form-data 文件上传流式转发请求体，其他请求仍预先读入内存
+	ExposeMetrics         bool                        `yaml:"exposeMetrics,omitempty"`                                                         // 是否在转发端口提供仅包含本转发服务指标的 /_metrics 端点
+	StreamErrorEvent      bool                        `yaml:"streamErrorEvent,omitempty"`                                                      // 上游在流式响应中途断开时是否向客户端追加 SSE 错误事件
+	AllowedContentTypes   []string                    `yaml:"allowedContentTypes,omitempty" validate:"omitempty,dive,required"`                // 允许的请求 Content-Type 列表，忽略大小写和参数，为空时允许所有类型
+	ClientTimeoutHeader   string                      `yaml:"clientTimeoutHeader,omitempty"`                                                   // 客户端指定请求超时时间的头部名称，如 X-Request-Timeout，为空时不启用
+	MaxConcurrentRequests int                         `yaml:"maxConcurrentRequests,omitempty" validate:"omitempty,min=1"`                      // 最大并发请求数，超出时立即返回 503，为 0 时不限制
+	ErrorResponses        map[int]ErrorResponseConfig `yaml:"errorResponses,omitempty" validate:"omitempty,dive,keys,min=400,max=599,endkeys"` // 按状态码覆盖代理自身错误的响应体
+	SlowRequestThreshold  int                         `yaml:"slowRequestThreshold,omitempty" validate:"omitempty,min=1"`                       // 单位：毫秒，请求耗时超过该值时记录慢请求日志，为 0 时不启用
+	Coalesce              bool                        `yaml:"coalesce,omitempty"`                                                              // 是否合并请求体相同的并发请求，只有一个请求访问上游，其余请求共享其非流式响应
+	CoalesceKeyHeaders    []string                    `yaml:"coalesceKeyHeaders,omitempty" validate:"omitempty,dive,required"`                 // 参与请求合并键计算的凭据头部，值不同的请求不会合并，默认 Authorization、X-Api-Key 和 Api-Key
+	MaxRequestDuration    int                         `yaml:"maxRequestDuration,omitempty" validate:"omitempty,min=1"`                         // 单位：毫秒，请求在代理内的最大处理时长，覆盖排队、等待和上游请求等全部阶段，超出时返回 504，为 0 时不限制
+	ResponseHeaderPolicy  *ResponseHeaderPolicyConfig `yaml:"responseHeaderPolicy,omitempty"`                                                  // 转发到客户端的上游响应头部过滤策略，未配置时转发全部头部
+	ForwardTrailers       bool                        `yaml:"forwardTrailers,omitempty"`                                                       // 是否将上游声明的 HTTP trailer 转发给客户端
+	StreamFlushInterval   int                         `yaml:"streamFlushInterval,omitempty" validate:"omitempty,min=1,max=10000"`              // 单位：毫秒，流式响应的最大刷新间隔，间隔内的写入合并刷新，为 0 时每次写入后立即刷新
+	TLS                   *ForwardTLSConfig           `yaml:"tls,omitempty"`                                                                   // 转发服务直接提供 HTTPS 时使用的证书，未配置时提供 HTTP
+	NoUpstreamBehavior    *NoUpstreamBehaviorConfig   `yaml:"noUpstreamBehavior,omitempty"`                                                    // 上游组没有可选择的上游时的处理方式，未配置时返回错误响应
+	ResponseAffinity      *ResponseAffinityConfig     `yaml:"responseAffinity,omitempty"`                                                      // 基于上游响应头部的会话亲和，未配置时不启用
+}
+
+// ResponseAffinityConfig 代表由上游响应驱动的会话亲和配置
+// 上游响应携带 upstreamHeader 头部时，将 sessionHeader 头部标识的会话绑定到处理该请求的上游，有效期内的后续请求优先发送到该上游
+type ResponseAffinityConfig struct {
+	SessionHeader  string `yaml:"sessionHeader" validate:"required,header_name"`            // 标识客户端会话的请求头部名称，如 X-Session-ID
+	UpstreamHeader string `yaml:"upstreamHeader" validate:"required,header_name"`           // 触发亲和绑定的上游响应头部名称，如 x-region
+	TTL            int    `yaml:"ttl,omitempty" validate:"omitempty,min=1000,max=86400000"` // 单位：毫秒，亲和记录有效期，每次命中时顺延
+}
+
+// NoUpstreamBehaviorConfig 代表上游组没有可选择的上游（未配置上游或所有上游均熔断）时的处理方式
+type NoUpstreamBehaviorConfig struct {
+	Mode           string                `yaml:"mode,omitempty" validate:"omitempty,oneof=error static fallback"`               // error 返回错误响应，static 返回静态响应，fallback 转发到备用地址
+	StaticResponse *StaticResponseConfig `yaml:"staticResponse,omitempty" validate:"required_if=Mode static"`                   // static 模式返回的响应
+	FallbackURL    string                `yaml:"fallbackURL,omitempty" validate:"required_if=Mode fallback,omitempty,http_url"` // fallback 模式的备用地址，请求路径拼接方式与上游 url 相同
+}
+
+// StaticResponseConfig 代表代理直接返回的静态响应
+// body 可以写成 JSON 字符串，也可以写成 YAML 映射，加载时统一转换为 JSON
+type StaticResponseConfig struct {
+	StatusCode  int             `yaml:"statusCode,omitempty" validate:"omitempty,min=200,max=599"` // 响应状态码，默认 503
+	Body        json.RawMessage `yaml:"body" validate:"required,json"`
+	ContentType string          `yaml:"contentType,omitempty"` // 响应的 Content-Type，默认 application/json
+}
+
+// ForwardTLSConfig 代表转发服务的 TLS 证书配置，重新加载配置时从文件重新读取证书
+type ForwardTLSConfig struct {
+	CertFile string `yaml:"certFile" validate:"required,file"` // PEM 格式的证书文件路径，可包含中间证书
+	KeyFile  string `yaml:"keyFile" validate:"required,file"`  // PEM 格式的私钥文件路径
+}
+
+// ResponseHeaderPolicyConfig 代表上游响应头部过滤策略
+// 逐跳头部无论采用何种模式都不会转发给客户端
+type ResponseHeaderPolicyConfig struct {
+	Mode    string   `yaml:"mode,omitempty" validate:"omitempty,oneof=all allow deny"` // 过滤模式：all 转发全部头部，allow 仅转发列出的头部，deny 移除列出的头部
+	Headers []string `yaml:"headers,omitempty" validate:"omitempty,dive,required"`     // 头部名称列表，忽略大小写
+}
+
+// ErrorResponseConfig 代表指定状态码的自定义错误响应
+// body 可以写成 JSON 字符串，也可以写成 YAML 映射，加载时统一转换为 JSON
+type ErrorResponseConfig struct {
+	Body        json.RawMessage `yaml:"body" validate:"required,json"`
+	ContentType string          `yaml:"contentType,omitempty"` // 响应的 Content-Type，默认 application/json
+}
+
+// ListenerConfig 代表转发服务的一个监听地址
+type ListenerConfig struct {
+	Address string `yaml:"address"`
+	Port    int    `yaml:"port" validate:"required,min=1,max=65535"`
+}
+
+// GetListeners 获取转发服务的全部监听地址
+// 未配置 listeners 时使用 address/port，配置后 address/port 仅在指定了端口时作为额外的监听地址
+func (c *ForwardConfig) GetListeners() []ListenerConfig {
+	if len(c.Listeners) == 0 {
+		return []ListenerConfig{{Address: c.Address, Port: c.Port}}
+	}
+
+	listeners := make([]ListenerConfig, 0, len(c.Listeners)+1)
+	if c.Port > 0 {
+		listeners = append(listeners, ListenerConfig{Address: c.Address, Port: c.Port})
+	}
+	return append(listeners, c.Listeners...)
 }
 
 // AdminConfig 代表管理服务配置，用于健康检查和监控指标暴露
 type AdminConfig struct {
-	Port    int            `yaml:"port" validate:"min=1,max=65535"`
-	Address string         `yaml:"address"`
-	Timeout *TimeoutConfig `yaml:"timeout,omitempty"`
+	Enabled      *bool          `yaml:"enabled,omitempty"` // 是否启用管理服务，默认启用
+	Port         int            `yaml:"port" validate:"min=1,max=65535"`
+	Address      string         `yaml:"address"`
+	Timeout      *TimeoutConfig `yaml:"timeout,omitempty"`
+	Auth         *AuthConfig    `yaml:"auth,omitempty"`                                               // 管理接口认证配置，未配置时不校验
+	PublicPaths  []string       `yaml:"publicPaths,omitempty" validate:"omitempty,dive,startswith=/"` // 免认证的管理接口路径，如 /metrics、/health
+	EnablePprof  bool           `yaml:"enablePprof,omitempty"`                                        // 是否在 /debug/pprof/ 下提供性能分析端点，默认关闭
+	EnableReplay bool           `yaml:"enableReplay,omitempty"`                                       // 是否提供 /admin/replay 请求重放端点，默认关闭
+}
+
+// IsEnabled 判断管理服务是否启用，未配置时默认启用
+func (c *AdminConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
 }
 
 // RateLimitConfig 代表限流配置，控制请求频率和突发流量
 type RateLimitConfig struct {
-	PerSecond int `yaml:"perSecond" validate:"omitempty,min=1,max=65535"`
-	Burst     int `yaml:"burst" validate:"omitempty,min=1,max=65535"`
+	PerSecond int    `yaml:"perSecond" validate:"omitempty,min=1,max=65535"`
+	Burst     int    `yaml:"burst" validate:"omitempty,min=1,max=65535"`
+	KeyBy     string `yaml:"keyBy,omitempty" validate:"omitempty,oneof=ip header token"` // 限流键类型：ip、header 或 token
+	Header    string `yaml:"header,omitempty"`                                           // keyBy 为 header 时使用的请求头部名称
+}
+
+// IdempotencyConfig 代表幂等键去重配置
+// 携带 Idempotency-Key 头部的请求，首个成功响应在 TTL 内被缓存，相同幂等键的后续或并发请求直接返回该响应
+type IdempotencyConfig struct {
+	Enabled     bool `yaml:"enabled"`
+	TTL         int  `yaml:"ttl,omitempty" validate:"omitempty,min=1000,max=86400000"`         // 单位：毫秒，响应缓存时间
+	MaxBodySize int  `yaml:"maxBodySize,omitempty" validate:"omitempty,min=1024,max=67108864"` // 单位：字节，可缓存的最大响应体大小
+}
+
+// RateLimitRuleConfig 代表按路径前缀覆盖的限流规则，限流键类型沿用转发服务的 ratelimit 配置
+type RateLimitRuleConfig struct {
+	PathPrefix string `yaml:"pathPrefix" validate:"required,startswith=/"`
+	PerSecond  int    `yaml:"perSecond" validate:"omitempty,min=1,max=65535"`
+	Burst      int    `yaml:"burst" validate:"omitempty,min=1,max=65535"`
 }
 
 // TimeoutConfig 代表超时配置，定义各种操作的超时时间（单位：毫秒）
@@ -43,31 +189,63 @@ type TimeoutConfig struct {
 	Write   int `yaml:"write,omitempty" validate:"omitempty,min=1000,max=86400000"`
 	Connect int `yaml:"connect,omitempty" validate:"omitempty,min=1000,max=86400000"`
 	Request int `yaml:"request,omitempty" validate:"omitempty,min=1000,max=86400000"`
+	// StreamWrite 仅用于转发服务，流式响应的写入超时，0 表示不限制
+	StreamWrite int `yaml:"streamWrite,omitempty" validate:"omitempty,min=1000,max=86400000"`
 }
 
 // UpstreamConfig 代表上游服务配置，定义后端LLM API服务的连接参数
 type UpstreamConfig struct {
-	Name      string           `yaml:"name" validate:"required"`
-	URL       string           `yaml:"url" validate:"required,http_url"`
-	Auth      *AuthConfig      `yaml:"auth,omitempty"`
-	Headers   []HeaderOpConfig `yaml:"headers,omitempty"`
-	Breaker   *BreakerConfig   `yaml:"breaker,omitempty"`
-	RateLimit *RateLimitConfig `yaml:"ratelimit,omitempty"`
+	Name                  string                `yaml:"name" validate:"required"`
+	URL                   string                `yaml:"url" validate:"required,http_url"`
+	Auth                  *AuthConfig           `yaml:"auth,omitempty"`
+	Headers               []HeaderOpConfig      `yaml:"headers,omitempty" validate:"omitempty,max=100,dive"` // 转发前依次执行的头部操作，最多 100 个
+	Breaker               *BreakerConfig        `yaml:"breaker,omitempty"`
+	RateLimit             *RateLimitConfig      `yaml:"ratelimit,omitempty"`
+	TLS                   *TLSConfig            `yaml:"tls,omitempty"`
+	StripHeaders          []string              `yaml:"stripHeaders,omitempty" validate:"omitempty,dive,required"`                                  // 转发到该上游前移除的客户端请求头部，在应用上游认证之前执行
+	UserAgent             string                `yaml:"userAgent,omitempty"`                                                                        // 发往该上游请求使用的User-Agent，为空时使用客户端默认值
+	StatusMap             map[int]int           `yaml:"statusMap,omitempty" validate:"omitempty,dive,keys,min=100,max=599,endkeys,min=100,max=599"` // 返回客户端前的上游状态码映射，如 529 映射为 503
+	RequestTransform      []BodyTransformConfig `yaml:"requestTransform,omitempty" validate:"omitempty,dive"`                                       // 转发前对 JSON 请求体依次执行的转换操作
+	ResponseTransform     []BodyTransformConfig `yaml:"responseTransform,omitempty" validate:"omitempty,dive"`                                      // 返回客户端前对非流式 JSON 响应体依次执行的转换操作
+	Labels                map[string]string     `yaml:"labels,omitempty"`                                                                           // 运维标签，如 region、provider，用于管理接口筛选和可选的指标标签
+	FailurePenalty        int                   `yaml:"failurePenalty,omitempty" validate:"omitempty,min=100,max=600000"`                           // 请求失败后的惩罚时长，期间负载均衡优先跳过该上游，单位：毫秒
+	RequestAcceptEncoding string                `yaml:"requestAcceptEncoding,omitempty"`                                                            // 覆盖发往该上游请求的 Accept-Encoding，如 identity 使上游返回未压缩的响应，为空时保留客户端的值
+	StreamFormat          string                `yaml:"streamFormat,omitempty" validate:"omitempty,oneof=openai anthropic passthrough"`             // 返回客户端的流式响应事件格式，openai/anthropic 逐个转换 SSE 事件，默认 passthrough 原样转发
+	Disabled              bool                  `yaml:"disabled,omitempty"`                                                                         // 是否暂时停用，停用的上游保留配置但不参与负载均衡，可通过管理接口在运行时启用
+
+	parsedURL *url.URL // 加载配置时规范化并解析的 URL，避免每个请求重复解析
+}
+
+// TLSConfig 代表上游 TLS 配置，用于私有 CA 和指定 SNI 的上游服务
+type TLSConfig struct {
+	CACertFile         string `yaml:"caCertFile,omitempty" validate:"omitempty,file"` // PEM 格式的 CA 证书文件路径
+	ServerName         string `yaml:"serverName,omitempty"`                           // TLS 握手使用的 SNI 及证书校验主机名
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify,omitempty"`                   // 是否跳过证书校验，仅用于测试环境
 }
 
 // AuthConfig 代表认证配置，支持Bearer Token和Basic Auth
 type AuthConfig struct {
-	Type     string `yaml:"type,omitempty" validate:"oneof='' none bearer basic"`
-	Token    string `yaml:"token,omitempty" validate:"auth_conditional"`
-	Username string `yaml:"username,omitempty" validate:"auth_conditional"`
-	Password string `yaml:"password,omitempty" validate:"auth_conditional"`
+	Type         string `yaml:"type,omitempty" validate:"oneof='' none bearer basic"`
+	Token        string `yaml:"token,omitempty" validate:"auth_conditional"`
+	Username     string `yaml:"username,omitempty" validate:"auth_conditional"`
+	Password     string `yaml:"password,omitempty" validate:"auth_conditional"`
+	TokenFile    string `yaml:"tokenFile,omitempty"`    // 从文件读取 token，与 token 互斥
+	PasswordFile string `yaml:"passwordFile,omitempty"` // 从文件读取 password，与 password 互斥
 }
 
 // HeaderOpConfig 代表HTTP头部操作配置，用于修改转发请求的头部信息
 type HeaderOpConfig struct {
 	Op    string `yaml:"op" validate:"required,oneof=insert replace remove"`
-	Key   string `yaml:"key" validate:"required"`
-	Value string `yaml:"value,omitempty" validate:"header_conditional"`
+	Key   string `yaml:"key" validate:"required,header_name"`                        // 头部名称，必须是合法的 HTTP 头部字段名
+	Value string `yaml:"value,omitempty" validate:"header_conditional,header_value"` // 头部值，不能包含换行等控制字符
+}
+
+// BodyTransformConfig 代表 JSON 请求体/响应体的转换操作，field 和 to 使用点号分隔的嵌套字段路径
+type BodyTransformConfig struct {
+	Op    string      `yaml:"op" validate:"required,oneof=rename default delete wrap unwrap"`
+	Field string      `yaml:"field" validate:"required"`
+	To    string      `yaml:"to,omitempty" validate:"required_if=Op rename"`     // rename 操作的目标字段路径
+	Value interface{} `yaml:"value,omitempty" validate:"required_if=Op default"` // default 操作设置的默认值
 }
 
 // BreakerConfig 代表熔断器配置，用于保护上游服务避免过载
@@ -76,34 +254,72 @@ type BreakerConfig struct {
 	Cooldown    int     `yaml:"cooldown,omitempty" validate:"omitempty,min=1000,max=3600000"` // 单位：毫秒，熔断器开放状态持续时间
 	MaxRequests uint32  `yaml:"maxRequests,omitempty" validate:"omitempty,min=1,max=100"`     // 半开状态下允许通过的最大请求数
 	Interval    int     `yaml:"interval,omitempty" validate:"omitempty,min=1000,max=3600000"` // 单位：毫秒，闭合状态下统计周期重置间隔
+	ProbePath   string  `yaml:"probePath,omitempty" validate:"omitempty,startswith=/"`        // 半开状态下发送合成探测请求的路径，探测成功前不转发用户请求，为空时使用用户请求探测
 }
 
 // UpstreamGroupConfig 代表上游组配置，将多个上游服务组织为一个逻辑单元
 type UpstreamGroupConfig struct {
-	Name       string              `yaml:"name" validate:"required"`
-	Upstreams  []UpstreamRefConfig `yaml:"upstreams" validate:"required,dive"`
-	Balance    *BalanceConfig      `yaml:"balance,omitempty"`
-	HTTPClient *HTTPClientConfig   `yaml:"httpClient,omitempty"`
+	Name        string              `yaml:"name" validate:"required"`
+	Upstreams   []UpstreamRefConfig `yaml:"upstreams" validate:"required,dive"`
+	Balance     *BalanceConfig      `yaml:"balance,omitempty"`
+	HTTPClient  *HTTPClientConfig   `yaml:"httpClient,omitempty"`
+	DefaultAuth *AuthConfig         `yaml:"defaultAuth,omitempty"` // 组内未配置 auth 的上游使用的默认认证配置
 }
 
-// UpstreamRefConfig 代表上游引用配置，在上游组中引用具体的上游服务
-type UpstreamRefConfig struct {
+// GroupRefConfig 代表转发服务对上游组的引用，按权重在多个上游组之间分配流量
+type GroupRefConfig struct {
 	Name   string `yaml:"name" validate:"required"`
 	Weight int    `yaml:"weight,omitempty" validate:"min=1,max=65535"`
 }
 
+// UpstreamRefConfig 代表上游引用配置，在上游组中引用具体的上游服务
+type UpstreamRefConfig struct {
+	Name           string `yaml:"name" validate:"required"`
+	Weight         *int   `yaml:"weight,omitempty" validate:"omitempty,min=0,max=65535"`       // 为 0 表示备用上游，未配置时使用默认权重
+	TrafficPercent int    `yaml:"trafficPercent,omitempty" validate:"omitempty,min=0,max=100"` // canary 策略下分配给该上游的固定流量百分比，0 表示按权重分配剩余流量
+}
+
+// GetWeight 获取上游权重，未配置时返回默认权重
+func (c *UpstreamRefConfig) GetWeight() int {
+	if c.Weight == nil {
+		return constants.DefaultWeight
+	}
+	return *c.Weight
+}
+
+// IsStandby 判断是否为备用上游，仅显式配置权重为 0 时成立
+func (c *UpstreamRefConfig) IsStandby() bool {
+	return c.Weight != nil && *c.Weight == 0
+}
+
 // BalanceConfig 代表负载均衡配置，定义选择上游服务的策略
 type BalanceConfig struct {
-	Strategy string `yaml:"strategy" validate:"oneof=roundrobin weighted_roundrobin random iphash"`
+	Strategy    string `yaml:"strategy" validate:"oneof=roundrobin weighted_roundrobin random iphash failover canary weighted_least_request"`
+	AffinityTTL int    `yaml:"affinityTTL,omitempty" validate:"omitempty,min=1000,max=86400000"` // 单位：毫秒，iphash 会话亲和有效期，0 表示禁用
+	Seed        int64  `yaml:"seed,omitempty"`                                                   // random、weighted_least_request 和 iphash 降级随机选择的随机种子，相同种子产生相同的选择序列，0 表示使用当前时间
 }
 
 // HTTPClientConfig 代表HTTP客户端配置，控制与上游服务的连接行为
 type HTTPClientConfig struct {
-	Agent     string         `yaml:"agent"`
-	KeepAlive int            `yaml:"keepalive" validate:"min=0,max=600000"` // 单位：毫秒
-	Connect   *ConnectConfig `yaml:"connect,omitempty"`
-	Timeout   *TimeoutConfig `yaml:"timeout,omitempty"`
-	Proxy     *ProxyConfig   `yaml:"proxy,omitempty"`
+	Agent                 string           `yaml:"agent"`
+	KeepAlive             int              `yaml:"keepalive" validate:"min=0,max=600000"`                                  // 单位：毫秒
+	TCPKeepAlive          int              `yaml:"tcpKeepAlive,omitempty" validate:"omitempty,min=1000,max=600000"`        // 单位：毫秒，上游连接的 TCP keepalive 探测间隔，与控制 Connection 头部的 keepalive 无关
+	DNSCacheTTL           int              `yaml:"dnsCacheTTL,omitempty" validate:"omitempty,min=1000,max=3600000"`        // 单位：毫秒，上游 DNS 解析缓存时间
+	Warmup                bool             `yaml:"warmup,omitempty"`                                                       // 启动时是否预先建立到上游的空闲连接
+	WarmupConnections     int              `yaml:"warmupConnections,omitempty" validate:"omitempty,min=1,max=100"`         // 每个上游预热的连接数
+	ExpectContinueTimeout int              `yaml:"expectContinueTimeout,omitempty" validate:"omitempty,min=100,max=60000"` // 单位：毫秒，发送 Expect: 100-continue 后等待上游响应的时间
+	PreserveClientHeaders bool             `yaml:"preserveClientHeaders,omitempty"`                                        // 是否保留客户端的 User-Agent、Connection 和 X-Forwarded-Host，仅在缺失时补充默认值
+	FollowRedirects       bool             `yaml:"followRedirects,omitempty"`                                              // 是否跟随上游返回的 3xx 重定向，默认不跟随并将重定向响应原样返回客户端
+	Connect               *ConnectConfig   `yaml:"connect,omitempty"`
+	Timeout               *TimeoutConfig   `yaml:"timeout,omitempty"`
+	Proxy                 *ProxyConfig     `yaml:"proxy,omitempty"`
+	TLS                   *ClientTLSConfig `yaml:"tls,omitempty"` // 与上游建立 TLS 连接时的协议版本和加密套件限制
+}
+
+// ClientTLSConfig 代表连接上游时的 TLS 协议限制，未配置时使用 Go 的安全默认值
+type ClientTLSConfig struct {
+	MinVersion   string   `yaml:"minVersion,omitempty" validate:"omitempty,oneof=1.2 1.3"` // 最低 TLS 版本
+	CipherSuites []string `yaml:"cipherSuites,omitempty"`                                  // TLS 1.2 允许的加密套件名称，如 TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256，TLS 1.3 的加密套件不可配置
 }
 
 // ConnectConfig 代表连接池配置，控制HTTP连接的复用和管理
@@ -113,7 +329,7 @@ type ConnectConfig struct {
 	MaxPerHost  int `yaml:"maxPerHost" validate:"min=0,max=500"`
 }
 
-// ProxyConfig 代表代理配置，设置HTTP代理服务器
+// ProxyConfig 代表代理配置，设置HTTP或SOCKS5代理服务器
 type ProxyConfig struct {
-	URL string `yaml:"url" validate:"required,http_url"`
+	URL string `yaml:"url" validate:"required,proxy_url"` // 支持 http、https、socks5、socks5h 协议
 }
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// NormalizeUpstreamURL 规范化上游 URL
+// 缺少协议时补全默认协议，协议和主机名统一为小写，去除协议默认端口，基础 URL 末尾多余的斜杠被去除
+// 带有具体路径的 URL 保留原路径，以支持完整端点配置
+// rawURL: 配置中的上游 URL，如 api.openai.com、127.0.0.1:8080 或 https://api.openai.com/
+func NormalizeUpstreamURL(rawURL string) (*url.URL, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return nil, fmt.Errorf("upstream URL cannot be empty")
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = constants.DefaultScheme + rawURL
+	}
+
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedURL.Scheme = strings.ToLower(parsedURL.Scheme)
+	if parsedURL.Scheme != constants.ProtocolHTTP && parsedURL.Scheme != constants.ProtocolHTTPS {
+		return nil, fmt.Errorf("unsupported scheme '%s'", parsedURL.Scheme)
+	}
+	if parsedURL.Host == "" {
+		return nil, fmt.Errorf("URL must include host")
+	}
+
+	// 去除协议默认端口，使 http://host:80 与 http://host 指向同一连接池
+	hostname, port := strings.ToLower(parsedURL.Hostname()), parsedURL.Port()
+	if (parsedURL.Scheme == constants.ProtocolHTTP && port == "80") || (parsedURL.Scheme == constants.ProtocolHTTPS && port == "443") {
+		port = ""
+	}
+	if port != "" {
+		parsedURL.Host = net.JoinHostPort(hostname, port)
+	} else if strings.Contains(hostname, ":") {
+		parsedURL.Host = "[" + hostname + "]"
+	} else {
+		parsedURL.Host = hostname
+	}
+
+	// 基础 URL 只包含斜杠的路径等同于空路径，转发时拼接客户端请求路径
+	if strings.Trim(parsedURL.Path, "/") == "" {
+		parsedURL.Path = ""
+		parsedURL.RawPath = ""
+	}
+
+	return parsedURL, nil
+}
+
+// ParsedURL 返回加载配置时规范化并解析的上游 URL，未经过 SetDefaults 的配置返回 nil
+func (c *UpstreamConfig) ParsedURL() *url.URL {
+	return c.parsedURL
+}
@@ -7,6 +7,12 @@ const (
 	// DefaultVersion 应用程序默认版本号
 	DefaultVersion = "0.0.0"
 
+	// DefaultBuildValue 未通过 ldflags 注入时的默认构建信息值
+	DefaultBuildValue = "unknown"
+
+	// RedactedValue 日志中替换凭据等敏感值的文本，与 url.URL.Redacted 一致
+	RedactedValue = "xxxxx"
+
 	// AppName 应用程序名称
 	AppName = "LLMProxy"
 
@@ -15,6 +21,9 @@ const (
 
 	// DefaultConfigPath 默认配置文件路径
 	DefaultConfigPath = "./config.yaml"
+
+	// ConfigPathSeparator 多个配置文件路径的分隔符
+	ConfigPathSeparator = ","
 )
 
 const (
@@ -39,3 +48,41 @@ const (
 	// MetricsNamespace 指标命名空间
 	MetricsNamespace = "llmproxy"
 )
+
+const (
+	// Request outcomes - 请求处理结果，作为请求指标的 outcome 标签，取值固定以控制基数
+
+	// OutcomeDirect 请求直接转发到上游
+	OutcomeDirect = "direct"
+
+	// OutcomeCacheHit 重放已缓存的响应，未访问上游
+	OutcomeCacheHit = "cache_hit"
+
+	// OutcomeCoalesced 与相同的并发请求合并，共享其上游响应，未单独访问上游
+	OutcomeCoalesced = "coalesced"
+
+	// OutcomeRetried 请求经过重试后完成，当前版本不重试请求，预留给重试功能
+	OutcomeRetried = "retried"
+
+	// OutcomeBreakerOpen 熔断器开启，请求被拒绝
+	OutcomeBreakerOpen = "breaker_open"
+
+	// OutcomeRateLimited 客户端或上游限流，请求被拒绝
+	OutcomeRateLimited = "rate_limited"
+)
+
+const (
+	// Log levels - 运行时日志级别
+
+	// LogLevelError 仅输出错误日志
+	LogLevelError = "error"
+
+	// LogLevelInfo 输出 V(0) 信息日志，发布模式的默认级别
+	LogLevelInfo = "info"
+
+	// LogLevelDebug 额外输出 V(1) 调试日志，如未被采样的访问日志
+	LogLevelDebug = "debug"
+
+	// LogLevelTrace 额外输出 V(2) 详细日志，开发模式的默认级别
+	LogLevelTrace = "trace"
+)
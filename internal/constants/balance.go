@@ -15,6 +15,18 @@ const (
 	// BalanceIPHash IP哈希负载均衡策略
 	BalanceIPHash = "iphash"
 
+	// BalanceFailover 主备故障转移负载均衡策略
+	BalanceFailover = "failover"
+
+	// BalanceCanary 金丝雀负载均衡策略，按固定流量百分比分配金丝雀上游，其余流量按权重分配
+	BalanceCanary = "canary"
+
+	// BalanceWeightedLeastRequest 加权最少请求负载均衡策略，在随机抽取的上游中选择进行中请求数与权重之比最小的上游
+	BalanceWeightedLeastRequest = "weighted_least_request"
+
+	// DefaultLeastRequestChoiceCount 加权最少请求策略每次随机抽取的上游数量
+	DefaultLeastRequestChoiceCount = 2
+
 	// DefaultBalanceStrategy 默认负载均衡策略
 	DefaultBalanceStrategy = BalanceRoundRobin
 )
@@ -59,6 +59,12 @@ const (
 
 	// MaxRequests 熔断器半开状态最大请求数
 	MaxRequests = 100
+
+	// MinBufferSize 最小响应复制缓冲区大小（字节）
+	MinBufferSize = 512
+
+	// MaxBufferSize 最大响应复制缓冲区大小（字节，4MB）
+	MaxBufferSize = 4 << 20
 )
 
 const (
@@ -91,12 +97,54 @@ const (
 	// DefaultKeepAlive 默认Keep-Alive时间（毫秒）
 	DefaultKeepAlive = 60000
 
+	// DefaultTCPKeepAlive 默认上游连接 TCP keepalive 探测间隔（毫秒）
+	DefaultTCPKeepAlive = 30000
+
+	// DefaultDNSCacheTTL 默认上游 DNS 解析缓存时间（毫秒）
+	DefaultDNSCacheTTL = 30000
+
+	// DefaultExpectContinueTimeout 默认 Expect: 100-continue 等待时间（毫秒）
+	DefaultExpectContinueTimeout = 1000
+
+	// DefaultWarmupConnections 默认每个上游预热的连接数
+	DefaultWarmupConnections = 2
+
+	// DefaultMaxRedirects 启用跟随重定向时的最大重定向次数，与 http.Client 默认值一致
+	DefaultMaxRedirects = 10
+
+	// DefaultWarmupTimeout 默认连接预热超时（毫秒）
+	DefaultWarmupTimeout = 10000
+
 	// DefaultRatePerSecond 默认每秒请求数
 	DefaultRatePerSecond = 100
 
 	// DefaultRateBurst 默认突发请求数
 	DefaultRateBurst = 1
 
+	// RateLimitKeyByIP 按客户端 IP 限流
+	RateLimitKeyByIP = "ip"
+
+	// RateLimitKeyByHeader 按指定请求头部值（哈希后）限流
+	RateLimitKeyByHeader = "header"
+
+	// RateLimitKeyByToken 按 Authorization 中的 Bearer Token（哈希后）限流
+	RateLimitKeyByToken = "token"
+
+	// DefaultRateLimitKeyBy 默认限流键类型
+	DefaultRateLimitKeyBy = RateLimitKeyByIP
+
+	// DefaultRateLimitKeyHeader 按头部限流时的默认头部名称
+	DefaultRateLimitKeyHeader = HeaderAuthorization
+
+	// RateLimitResetIP 重置指定客户端 IP 的限流状态
+	RateLimitResetIP = "ip"
+
+	// RateLimitResetKey 重置指定 API Key / Token 哈希的限流状态
+	RateLimitResetKey = "key"
+
+	// RateLimitResetUpstream 重置指定上游的限流状态
+	RateLimitResetUpstream = "upstream"
+
 	// DefaultBreakerThreshold 默认熔断器阈值
 	DefaultBreakerThreshold = 0.5
 
@@ -120,6 +168,54 @@ const (
 
 	// DefaultWeight 默认权重
 	DefaultWeight = 1
+
+	// DefaultStreamBufferSize 默认流式响应复制缓冲区大小（字节）
+	DefaultStreamBufferSize = 4096
+
+	// DefaultCopyBufferSize 默认非流式响应复制缓冲区大小（字节）
+	DefaultCopyBufferSize = 32 * 1024
+
+	// DefaultAccessLogSampleRate 默认访问日志采样率，记录全部请求
+	DefaultAccessLogSampleRate = 1.0
+
+	// DefaultMaxHeaderBytes 默认最大请求头部大小（字节），与 Go 标准库 http.DefaultMaxHeaderBytes 一致
+	DefaultMaxHeaderBytes = 1 << 20
+
+	// DefaultShutdownTimeout 默认收到 SIGTERM 后等待进行中请求完成的最长时间（毫秒）
+	DefaultShutdownTimeout = 30000
+
+	// DefaultDrainProgressInterval 关闭排空期间输出剩余请求数日志的间隔（毫秒）
+	DefaultDrainProgressInterval = 1000
+
+	// DefaultDrainPollInterval 关闭排空期间检查进行中请求数的间隔（毫秒）
+	DefaultDrainPollInterval = 50
+
+	// DefaultServerShutdownTimeout net/http 转发服务器（HTTPS 或继承监听套接字）优雅关闭的最长等待时间（毫秒），与 orbit 引擎一致
+	DefaultServerShutdownTimeout = 10000
+
+	// DefaultHealthStatusInterval 默认上游健康状态指标上报间隔（毫秒）
+	DefaultHealthStatusInterval = 15000
+
+	// DefaultHealthStatusFailureRatio 上报周期内失败率达到该比例时上游健康状态指标记为不健康
+	DefaultHealthStatusFailureRatio = 0.5
+
+	// DefaultIdempotencyTTL 默认幂等键响应缓存时间（毫秒）
+	DefaultIdempotencyTTL = 600000
+
+	// DefaultIdempotencyMaxBodySize 默认幂等键可缓存的最大响应体大小（字节，1MB）
+	DefaultIdempotencyMaxBodySize = 1 << 20
+
+	// DefaultIdempotencyMaxEntries 默认幂等键最大缓存项数量
+	DefaultIdempotencyMaxEntries = 10000
+
+	// DefaultResponseAffinityTTL 默认响应亲和记录有效期（毫秒）
+	DefaultResponseAffinityTTL = 1800000
+
+	// DefaultResponseAffinityMaxEntries 默认响应亲和最大记录数量，达到上限后不再记录新的会话
+	DefaultResponseAffinityMaxEntries = 10000
+
+	// DefaultConcurrencyRetryAfter 达到最大并发请求数时建议客户端重试等待的秒数
+	DefaultConcurrencyRetryAfter = 1
 )
 
 const (
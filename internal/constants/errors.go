@@ -45,8 +45,35 @@ const (
 	// ErrMsgEmptyUpstreams 空上游列表错误消息
 	ErrMsgEmptyUpstreams = "upstreams cannot be empty"
 
+	// ErrMsgAllUpstreamsUnhealthy 所有上游均不健康错误消息
+	ErrMsgAllUpstreamsUnhealthy = "all upstreams are unhealthy"
+
 	// ErrMsgNilBalanceConfig 空负载均衡配置错误消息
 	ErrMsgNilBalanceConfig = "balance config cannot be nil"
+
+	// ErrMsgRequestBodyTooLarge 请求体超过大小限制错误消息
+	ErrMsgRequestBodyTooLarge = "request body too large"
+
+	// ErrMsgUnsupportedContentType 请求 Content-Type 不在允许列表中错误消息
+	ErrMsgUnsupportedContentType = "unsupported request content type"
+
+	// ErrMsgInterceptorRejectedRequest 拦截器拒绝请求错误消息
+	ErrMsgInterceptorRejectedRequest = "interceptor rejected request"
+
+	// ErrMsgUpstreamOverrideNotFound 指定的上游不存在错误消息
+	ErrMsgUpstreamOverrideNotFound = "upstream override not found"
+
+	// ErrMsgUpstreamOverrideNotInGroup 指定的上游不属于上游组错误消息
+	ErrMsgUpstreamOverrideNotInGroup = "upstream override not in group"
+
+	// ErrMsgUpstreamOverrideDisabled 指定的上游已停用错误消息
+	ErrMsgUpstreamOverrideDisabled = "upstream override disabled"
+
+	// ErrMsgLastEnabledUpstream 停用后上游组将没有可用上游错误消息
+	ErrMsgLastEnabledUpstream = "upstream is the last enabled upstream in group"
+
+	// ErrMsgReloadRequiresRestart 重新加载的配置包含无法在运行时应用的变更错误消息
+	ErrMsgReloadRequiresRestart = "configuration changes other than forward tls certificates require a restart"
 )
 
 const (
@@ -58,9 +85,33 @@ const (
 	// ErrorTypeSelection 选择错误类型
 	ErrorTypeSelection = "selection_failed"
 
+	// ErrorTypeNoUpstreams 上游组没有上游服务（配置错误）
+	ErrorTypeNoUpstreams = "no_upstreams"
+
+	// ErrorTypeAllUpstreamsDisabled 上游组内所有上游均已通过管理接口停用
+	ErrorTypeAllUpstreamsDisabled = "all_upstreams_disabled"
+
+	// ErrorTypeNoHealthyUpstream 上游组内所有上游的熔断器均处于开启状态
+	ErrorTypeNoHealthyUpstream = "no_healthy_upstream"
+
 	// ErrorTypeExecution 执行错误类型
 	ErrorTypeExecution = "execution_error"
 
+	// ErrorTypeUpstreamTimeout 上游超时错误类型
+	ErrorTypeUpstreamTimeout = "upstream_timeout"
+
+	// ErrorTypeCircuitBreakerOpen 熔断器开启错误类型
+	ErrorTypeCircuitBreakerOpen = "circuit_breaker_open"
+
+	// ErrorTypeStreamTruncated 上游在流式响应完成前断开连接
+	ErrorTypeStreamTruncated = "stream_truncated"
+
+	// ErrorTypeClientDisconnected 客户端在流式响应完成前断开连接
+	ErrorTypeClientDisconnected = "client_disconnected"
+
 	// ErrorTypeUnknown 未知错误类型
 	ErrorTypeUnknown = "unknown"
+
+	// ErrorTypePanic 处理器 panic 错误类型
+	ErrorTypePanic = "panic"
 )
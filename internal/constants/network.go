@@ -19,6 +19,12 @@ const (
 
 	// ProtocolHTTPS HTTPS协议名称
 	ProtocolHTTPS = "https"
+
+	// ProtocolSOCKS5 SOCKS5代理协议名称
+	ProtocolSOCKS5 = "socks5"
+
+	// ProtocolSOCKS5H SOCKS5代理协议名称，目标主机名由代理服务器解析
+	ProtocolSOCKS5H = "socks5h"
 )
 
 const (
@@ -48,11 +54,62 @@ const (
 	// HeaderAuthorization Authorization头部名称
 	HeaderAuthorization = "Authorization"
 
+	// HeaderXAPIKey X-Api-Key头部名称，部分上游（如 Anthropic）使用该头部传递 API 密钥
+	HeaderXAPIKey = "X-Api-Key"
+
+	// HeaderAPIKey Api-Key头部名称，部分上游（如 Azure OpenAI）使用该头部传递 API 密钥
+	HeaderAPIKey = "Api-Key"
+
+	// HeaderIdempotencyKey Idempotency-Key头部名称
+	HeaderIdempotencyKey = "Idempotency-Key"
+
+	// HeaderIdempotentReplayed Idempotent-Replayed头部名称，标识响应为幂等键缓存重放
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	// HeaderRetryAfter Retry-After头部名称
+	HeaderRetryAfter = "Retry-After"
+
+	// HeaderLLMProxyUpstream 调试头部，标识处理请求的上游名称
+	HeaderLLMProxyUpstream = "X-LLMProxy-Upstream"
+
+	// HeaderLLMProxyBalancer 调试头部，标识使用的负载均衡策略
+	HeaderLLMProxyBalancer = "X-LLMProxy-Balancer"
+
 	// HeaderContentType Content-Type头部名称
 	HeaderContentType = "Content-Type"
 
 	// HeaderTransferEncoding Transfer-Encoding头部名称
 	HeaderTransferEncoding = "Transfer-Encoding"
+
+	// HeaderKeepAlive Keep-Alive头部名称
+	HeaderKeepAlive = "Keep-Alive"
+
+	// HeaderProxyAuthenticate Proxy-Authenticate头部名称
+	HeaderProxyAuthenticate = "Proxy-Authenticate"
+
+	// HeaderProxyAuthorization Proxy-Authorization头部名称
+	HeaderProxyAuthorization = "Proxy-Authorization"
+
+	// HeaderProxyConnection Proxy-Connection头部名称（非标准，部分客户端使用）
+	HeaderProxyConnection = "Proxy-Connection"
+
+	// HeaderTE TE头部名称
+	HeaderTE = "Te"
+
+	// HeaderTrailer Trailer头部名称
+	HeaderTrailer = "Trailer"
+
+	// HeaderUpgrade Upgrade头部名称
+	HeaderUpgrade = "Upgrade"
+
+	// HeaderContentLength Content-Length头部名称
+	HeaderContentLength = "Content-Length"
+
+	// HeaderContentEncoding Content-Encoding头部名称
+	HeaderContentEncoding = "Content-Encoding"
+
+	// HeaderAcceptEncoding Accept-Encoding头部名称
+	HeaderAcceptEncoding = "Accept-Encoding"
 )
 
 const (
@@ -78,6 +135,89 @@ const (
 	AuthTypeBasic = "basic"
 )
 
+const (
+	// Error response formats - 错误响应格式
+
+	// ErrorFormatLLMProxy 默认错误响应格式（统一响应信封）
+	ErrorFormatLLMProxy = "llmproxy"
+
+	// ErrorFormatOpenAI OpenAI 风格错误响应格式
+	ErrorFormatOpenAI = "openai"
+
+	// DefaultErrorFormat 默认错误响应格式
+	DefaultErrorFormat = ErrorFormatLLMProxy
+)
+
+const (
+	// Stream formats - 流式响应 SSE 事件格式
+
+	// StreamFormatPassthrough 原样转发上游事件
+	StreamFormatPassthrough = "passthrough"
+
+	// StreamFormatOpenAI 将 Anthropic Messages 事件转换为 OpenAI chat.completion.chunk 事件
+	StreamFormatOpenAI = "openai"
+
+	// StreamFormatAnthropic 将 OpenAI chat.completion.chunk 事件转换为 Anthropic Messages 事件
+	StreamFormatAnthropic = "anthropic"
+
+	// DefaultStreamFormat 默认流式响应格式
+	DefaultStreamFormat = StreamFormatPassthrough
+)
+
+const (
+	// No upstream behavior modes - 上游组没有可选择的上游时的处理方式
+
+	// NoUpstreamModeError 返回错误响应
+	NoUpstreamModeError = "error"
+
+	// NoUpstreamModeStatic 返回配置的静态响应
+	NoUpstreamModeStatic = "static"
+
+	// NoUpstreamModeFallback 将请求转发到配置的备用地址
+	NoUpstreamModeFallback = "fallback"
+
+	// DefaultNoUpstreamMode 默认无可用上游处理方式
+	DefaultNoUpstreamMode = NoUpstreamModeError
+
+	// DefaultNoUpstreamStatusCode 静态响应的默认状态码
+	DefaultNoUpstreamStatusCode = 503
+
+	// NoUpstreamFallbackName 备用地址在日志和指标中使用的上游名称
+	NoUpstreamFallbackName = "fallback"
+)
+
+const (
+	// Request ID formats - 请求 ID 格式
+
+	// RequestIDFormatUUID4 随机 UUID（版本 4）
+	RequestIDFormatUUID4 = "uuid4"
+
+	// RequestIDFormatKSUID 按时间排序的 KSUID（27 个 base62 字符）
+	RequestIDFormatKSUID = "ksuid"
+
+	// RequestIDFormatShort 较短的 xid（20 个字符）
+	RequestIDFormatShort = "short"
+
+	// DefaultRequestIDFormat 默认请求 ID 格式
+	DefaultRequestIDFormat = RequestIDFormatUUID4
+)
+
+const (
+	// Response header policy modes - 响应头部过滤模式
+
+	// ResponseHeaderPolicyAll 转发全部上游响应头部
+	ResponseHeaderPolicyAll = "all"
+
+	// ResponseHeaderPolicyAllow 仅转发列出的上游响应头部
+	ResponseHeaderPolicyAllow = "allow"
+
+	// ResponseHeaderPolicyDeny 移除列出的上游响应头部
+	ResponseHeaderPolicyDeny = "deny"
+
+	// DefaultResponseHeaderPolicy 默认响应头部过滤模式
+	DefaultResponseHeaderPolicy = ResponseHeaderPolicyAll
+)
+
 const (
 	// Authentication prefixes - 认证前缀
 
@@ -99,6 +239,28 @@ const (
 
 	// HeaderOpRemove 移除头部操作
 	HeaderOpRemove = "remove"
+
+	// MaxHeaderOperations 每个上游允许的最大头部操作数，与配置验证的上限一致
+	MaxHeaderOperations = 100
+)
+
+const (
+	// Body transform operations - JSON 请求体/响应体转换操作类型
+
+	// BodyTransformRename 重命名字段操作
+	BodyTransformRename = "rename"
+
+	// BodyTransformDefault 字段不存在时设置默认值操作
+	BodyTransformDefault = "default"
+
+	// BodyTransformDelete 删除字段操作
+	BodyTransformDelete = "delete"
+
+	// BodyTransformWrap 将整个 JSON 包装到指定字段下的操作
+	BodyTransformWrap = "wrap"
+
+	// BodyTransformUnwrap 使用指定字段的值替换整个 JSON 的操作
+	BodyTransformUnwrap = "unwrap"
 )
 
 const (
@@ -109,6 +271,15 @@ const (
 
 	// ContentTypeStreamJSON JSON流内容类型
 	ContentTypeStreamJSON = "application/stream+json"
+
+	// ContentTypeJSON JSON内容类型
+	ContentTypeJSON = "application/json"
+
+	// ContentTypeMultipartFormData 文件上传使用的多部分表单内容类型
+	ContentTypeMultipartFormData = "multipart/form-data"
+
+	// ContentTypeHTML HTML内容类型
+	ContentTypeHTML = "text/html; charset=utf-8"
 )
 
 const (
@@ -116,4 +287,72 @@ const (
 
 	// TransferEncodingChunked 分块传输编码
 	TransferEncodingChunked = "chunked"
+
+	// ContentEncodingGzip gzip 内容编码
+	ContentEncodingGzip = "gzip"
 )
+
+const (
+	// ForwardMetricsPath 转发服务自身暴露的指标路径，仅包含该转发服务的指标
+	ForwardMetricsPath = "/_metrics"
+
+	// ForwardReadyPath 转发服务自身暴露的就绪检查路径，排空期间返回 503
+	ForwardReadyPath = "/_ready"
+
+	// PprofURLPath 管理服务性能分析端点的路径前缀
+	PprofURLPath = "/debug/pprof"
+
+	// AdminDashboardPath 管理服务内置监控页面的路径
+	AdminDashboardPath = "/admin/"
+
+	// AdminReplayPath 管理服务请求重放端点的路径
+	AdminReplayPath = "/admin/replay"
+
+	// AdminReplayMaxResponseBytes 请求重放端点返回的上游响应体最大字节数，超出部分被截断
+	AdminReplayMaxResponseBytes = 1 << 20
+
+	// BreakerStateDisabled 上游未配置熔断器时在管理接口中展示的状态
+	BreakerStateDisabled = "disabled"
+)
+
+const (
+	// StreamErrorEventUpstreamDisconnected 上游在流式响应中途断开时追加给客户端的 SSE 错误事件
+	StreamErrorEventUpstreamDisconnected = "data: {\"error\":\"upstream_disconnected\"}\n\n"
+)
+
+const (
+	// Socket activation - 监听套接字继承
+
+	// EnvListenFDs systemd socket activation 传递的监听套接字数量环境变量
+	EnvListenFDs = "LISTEN_FDS"
+
+	// EnvListenPID systemd socket activation 的目标进程 ID 环境变量，与当前进程不一致时忽略传递的套接字
+	EnvListenPID = "LISTEN_PID"
+
+	// EnvListenFDNames systemd socket activation 传递的套接字名称环境变量，名称之间以冒号分隔
+	EnvListenFDNames = "LISTEN_FDNAMES"
+
+	// EnvForwardFD 手动指定转发服务监听套接字的环境变量，格式为 name=fd，多个之间以逗号分隔
+	EnvForwardFD = "LLMPROXY_FORWARD_FD"
+
+	// ListenFDsStart systemd socket activation 传递的第一个套接字的文件描述符
+	ListenFDsStart = 3
+)
+
+const (
+	// Upstream TLS versions - 上游 TLS 协议版本
+
+	// TLSVersion12 TLS 1.2 协议版本配置值
+	TLSVersion12 = "1.2"
+
+	// TLSVersion13 TLS 1.3 协议版本配置值
+	TLSVersion13 = "1.3"
+)
+
+// CredentialHeaders 标识客户端凭据的请求头部，用于区分客户端的缓存键计算及跨主机重定向时移除凭据
+// 调用方不得修改该切片
+var CredentialHeaders = []string{
+	HeaderAuthorization,
+	HeaderXAPIKey,
+	HeaderAPIKey,
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -136,6 +137,59 @@ func TestOperatorErrorHandling(t *testing.T) {
 	assert.Error(t, err)
 }
 
+// TestOperatorHeaderInjection 测试拒绝包含换行等非法字符的头部名称和值
+func TestOperatorHeaderInjection(t *testing.T) {
+	operator := NewOperator()
+
+	tests := []struct {
+		name    string
+		op      config.HeaderOpConfig
+		wantErr error
+	}{
+		{name: "CRLF in key", op: config.HeaderOpConfig{Op: "insert", Key: "X\r\nEvil", Value: "value"}, wantErr: ErrInvalidHeaderKey},
+		{name: "LF in key", op: config.HeaderOpConfig{Op: "replace", Key: "X-Test\nEvil: 1", Value: "value"}, wantErr: ErrInvalidHeaderKey},
+		{name: "space in key", op: config.HeaderOpConfig{Op: "insert", Key: "X Test", Value: "value"}, wantErr: ErrInvalidHeaderKey},
+		{name: "colon in key", op: config.HeaderOpConfig{Op: "remove", Key: "X-Test:"}, wantErr: ErrInvalidHeaderKey},
+		{name: "CRLF in value", op: config.HeaderOpConfig{Op: "insert", Key: "X-Test", Value: "value\r\nEvil: 1"}, wantErr: ErrInvalidHeaderValue},
+		{name: "NUL in value", op: config.HeaderOpConfig{Op: "replace", Key: "X-Test", Value: "value\x00"}, wantErr: ErrInvalidHeaderValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := make(http.Header)
+			err := operator.ProcessSingle(headers, tt.op)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Empty(t, headers)
+		})
+	}
+
+	// 批量处理在非法操作处停止，错误包含操作序号
+	headers := make(http.Header)
+	err := operator.Process(headers, []config.HeaderOpConfig{
+		{Op: "insert", Key: "X-Valid", Value: "value"},
+		{Op: "insert", Key: "X\r\nEvil", Value: "value"},
+	})
+	assert.ErrorIs(t, err, ErrInvalidHeaderKey)
+	assert.Contains(t, err.Error(), "operation 1 failed")
+	assert.Equal(t, "value", headers.Get("X-Valid"))
+}
+
+// TestOperatorTooManyOperations 测试拒绝超过上限的头部操作数
+func TestOperatorTooManyOperations(t *testing.T) {
+	operator := NewOperator()
+
+	ops := make([]config.HeaderOpConfig, constants.MaxHeaderOperations)
+	for i := range ops {
+		ops[i] = config.HeaderOpConfig{Op: "replace", Key: "X-Test", Value: "value"}
+	}
+	require.NoError(t, operator.Process(make(http.Header), ops))
+
+	ops = append(ops, config.HeaderOpConfig{Op: "remove", Key: "X-Test"})
+	headers := make(http.Header)
+	assert.ErrorIs(t, operator.Process(headers, ops), ErrTooManyOperations)
+	assert.Empty(t, headers)
+}
+
 // TestProcessor 测试处理器
 func TestProcessor(t *testing.T) {
 	processor := NewProcessor()
@@ -2,18 +2,23 @@ package headers
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/shengyanli1982/llmproxy-go/internal/config"
 	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"golang.org/x/net/http/httpguts"
 )
 
 // 头部操作相关错误定义
 var (
-	ErrInvalidOperation = errors.New("invalid header operation")
-	ErrEmptyHeaderKey   = errors.New("header key cannot be empty")
-	ErrNilHeader        = errors.New("header cannot be nil")
+	ErrInvalidOperation   = errors.New("invalid header operation")
+	ErrEmptyHeaderKey     = errors.New("header key cannot be empty")
+	ErrNilHeader          = errors.New("header cannot be nil")
+	ErrInvalidHeaderKey   = errors.New("header key is not a valid HTTP header name")
+	ErrInvalidHeaderValue = errors.New("header value contains invalid characters")
+	ErrTooManyOperations  = errors.New("too many header operations")
 )
 
 // HeaderOperator 代表HTTP头部操作器接口
@@ -38,17 +43,21 @@ func NewOperator() HeaderOperator {
 }
 
 // Process 批量处理HTTP头部操作，按配置顺序执行
+// 每个操作只访问自身的头部，总开销与操作数成线性关系；操作数超过 MaxHeaderOperations 时拒绝执行
 // headers: 要操作的HTTP头部
 // ops: 操作配置列表
 func (o *defaultOperator) Process(headers http.Header, ops []config.HeaderOpConfig) error {
 	if headers == nil {
 		return ErrNilHeader
 	}
+	if len(ops) > constants.MaxHeaderOperations {
+		return fmt.Errorf("%w: %d exceeds limit %d", ErrTooManyOperations, len(ops), constants.MaxHeaderOperations)
+	}
 
 	// 按顺序执行每个操作
 	for i, op := range ops {
 		if err := o.ProcessSingle(headers, op); err != nil {
-			return errors.New("operation " + string(rune(i)) + " failed: " + err.Error())
+			return fmt.Errorf("operation %d failed: %w", i, err)
 		}
 	}
 
@@ -70,10 +79,21 @@ func (o *defaultOperator) ProcessSingle(headers http.Header, op config.HeaderOpC
 
 	key := strings.TrimSpace(op.Key)
 
+	// 拒绝包含换行等字符的头部名称和值，防止注入额外头部
+	if !httpguts.ValidHeaderFieldName(key) {
+		return ErrInvalidHeaderKey
+	}
+
 	switch strings.ToLower(op.Op) {
 	case constants.HeaderOpInsert:
+		if !httpguts.ValidHeaderFieldValue(op.Value) {
+			return ErrInvalidHeaderValue
+		}
 		return o.insertHeader(headers, key, op.Value)
 	case constants.HeaderOpReplace:
+		if !httpguts.ValidHeaderFieldValue(op.Value) {
+			return ErrInvalidHeaderValue
+		}
 		return o.replaceHeader(headers, key, op.Value)
 	case constants.HeaderOpRemove:
 		return o.removeHeader(headers, key)
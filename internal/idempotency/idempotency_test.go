@@ -0,0 +1,216 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_AcquireAndComplete(t *testing.T) {
+	store := NewStore(time.Minute, 100)
+	ctx := context.Background()
+
+	// 首个请求负责执行
+	result, err := store.Acquire(ctx, "key")
+	require.NoError(t, err)
+	assert.Nil(t, result.Response)
+	assert.False(t, result.Waited)
+
+	store.Complete("key", &Response{StatusCode: http.StatusOK, Body: []byte("ok")})
+
+	// 后续请求直接获取缓存的响应
+	result, err = store.Acquire(ctx, "key")
+	require.NoError(t, err)
+	require.NotNil(t, result.Response)
+	assert.Equal(t, []byte("ok"), result.Response.Body)
+	assert.False(t, result.Waited)
+}
+
+func TestStore_SingleFlight(t *testing.T) {
+	store := NewStore(time.Minute, 100)
+	ctx := context.Background()
+
+	_, err := store.Acquire(ctx, "key")
+	require.NoError(t, err)
+
+	const waiters = 5
+	results := make([]Result, waiters)
+	var wg sync.WaitGroup
+	for i := 0; i < waiters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result, err := store.Acquire(ctx, "key")
+			assert.NoError(t, err)
+			results[i] = result
+		}(i)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	store.Complete("key", &Response{StatusCode: http.StatusOK, Body: []byte("shared")})
+	wg.Wait()
+
+	for _, result := range results {
+		require.NotNil(t, result.Response)
+		assert.Equal(t, []byte("shared"), result.Response.Body)
+		assert.True(t, result.Waited)
+	}
+}
+
+func TestStore_FailedCompleteReleasesKey(t *testing.T) {
+	store := NewStore(time.Minute, 100)
+	ctx := context.Background()
+
+	_, err := store.Acquire(ctx, "key")
+	require.NoError(t, err)
+
+	done := make(chan Result)
+	go func() {
+		result, err := store.Acquire(ctx, "key")
+		assert.NoError(t, err)
+		done <- result
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	store.Complete("key", nil)
+
+	// 等待者重新竞争并成为新的执行者
+	result := <-done
+	assert.Nil(t, result.Response)
+	assert.True(t, result.Waited)
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestStore_WaitCanceled(t *testing.T) {
+	store := NewStore(time.Minute, 100)
+
+	_, err := store.Acquire(context.Background(), "key")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	result, err := store.Acquire(ctx, "key")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, result.Waited)
+}
+
+func TestStore_Expiry(t *testing.T) {
+	store := NewStore(20*time.Millisecond, 100)
+	ctx := context.Background()
+
+	_, err := store.Acquire(ctx, "key")
+	require.NoError(t, err)
+	store.Complete("key", &Response{StatusCode: http.StatusOK})
+
+	time.Sleep(30 * time.Millisecond)
+
+	// 过期后重新执行
+	result, err := store.Acquire(ctx, "key")
+	require.NoError(t, err)
+	assert.Nil(t, result.Response)
+}
+
+func TestStore_MaxEntries(t *testing.T) {
+	store := NewStore(time.Minute, 1)
+	ctx := context.Background()
+
+	_, err := store.Acquire(ctx, "key-1")
+	require.NoError(t, err)
+
+	// 达到上限后新的幂等键不再登记，请求直接执行
+	result, err := store.Acquire(ctx, "key-2")
+	require.NoError(t, err)
+	assert.Nil(t, result.Response)
+	assert.Equal(t, 1, store.Len())
+
+	store.Complete("key-2", &Response{StatusCode: http.StatusOK})
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestRecorder_Response(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		maxBody    int
+		discard    bool
+		wantCached bool
+	}{
+		{name: "success", statusCode: http.StatusOK, body: "ok", maxBody: 16, wantCached: true},
+		{name: "client error", statusCode: http.StatusBadRequest, body: "bad", maxBody: 16},
+		{name: "server error", statusCode: http.StatusBadGateway, body: "bad", maxBody: 16},
+		{name: "body too large", statusCode: http.StatusOK, body: "0123456789abcdef0", maxBody: 16},
+		{name: "no response", maxBody: 16},
+		{name: "discarded", statusCode: http.StatusOK, body: "partial", maxBody: 16, discard: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := NewRecorder(tt.maxBody)
+			if tt.statusCode != 0 {
+				recorder.WriteHeader(tt.statusCode, http.Header{"Content-Type": []string{"application/json"}})
+				n, err := recorder.Write([]byte(tt.body))
+				require.NoError(t, err)
+				assert.Equal(t, len(tt.body), n)
+			}
+			if tt.discard {
+				recorder.Discard()
+			}
+
+			response := recorder.Response()
+			if !tt.wantCached {
+				assert.Nil(t, response)
+				return
+			}
+			require.NotNil(t, response)
+			assert.Equal(t, tt.statusCode, response.StatusCode)
+			assert.Equal(t, tt.body, string(response.Body))
+			assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
+		})
+	}
+}
+
+func TestRequestKey(t *testing.T) {
+	newRequest := func(method, path, auth, key string) *http.Request {
+		req := httptest.NewRequest(method, path, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		return req
+	}
+
+	base := RequestKey(newRequest("POST", "/v1/chat/completions", "Bearer a", "k1"))
+	assert.NotEmpty(t, base)
+	assert.NotContains(t, base, "k1")
+	assert.Equal(t, base, RequestKey(newRequest("POST", "/v1/chat/completions", "Bearer a", "k1")))
+
+	// 未携带幂等键
+	assert.Empty(t, RequestKey(newRequest("POST", "/v1/chat/completions", "Bearer a", "")))
+
+	// 方法、路径、凭据或幂等键不同时缓存键不同
+	assert.NotEqual(t, base, RequestKey(newRequest("GET", "/v1/chat/completions", "Bearer a", "k1")))
+	assert.NotEqual(t, base, RequestKey(newRequest("POST", "/v1/completions", "Bearer a", "k1")))
+	assert.NotEqual(t, base, RequestKey(newRequest("POST", "/v1/chat/completions", "Bearer b", "k1")))
+	assert.NotEqual(t, base, RequestKey(newRequest("POST", "/v1/chat/completions", "Bearer a", "k2")))
+
+	// 使用 X-Api-Key 或 Api-Key 认证的不同客户端缓存键不同
+	newKeyRequest := func(header, value string) *http.Request {
+		req := newRequest("POST", "/v1/messages", "", "k1")
+		req.Header.Set(header, value)
+		return req
+	}
+	for _, header := range []string{"X-Api-Key", "Api-Key"} {
+		assert.NotEqual(t, RequestKey(newKeyRequest(header, "sk-a")), RequestKey(newKeyRequest(header, "sk-b")), header)
+	}
+	assert.NotEqual(t, RequestKey(newKeyRequest("X-Api-Key", "sk-a")), RequestKey(newKeyRequest("Api-Key", "sk-a")))
+}
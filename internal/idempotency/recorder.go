@@ -0,0 +1,62 @@
+package idempotency
+
+import (
+	"net/http"
+)
+
+// Recorder 记录转发给客户端的响应，用于缓存幂等键对应的响应
+// 仅 2xx 且未超过大小上限的响应会被缓存
+type Recorder struct {
+	statusCode  int
+	header      http.Header
+	body        []byte
+	maxBodySize int
+	overflow    bool
+	discarded   bool
+}
+
+// NewRecorder 创建新的响应记录器实例
+// maxBodySize: 可缓存的最大响应体字节数
+func NewRecorder(maxBodySize int) *Recorder {
+	return &Recorder{
+		maxBodySize: maxBodySize,
+	}
+}
+
+// WriteHeader 记录响应状态码和头部
+func (r *Recorder) WriteHeader(statusCode int, header http.Header) {
+	r.statusCode = statusCode
+	r.header = header.Clone()
+}
+
+// Write 记录响应体，超过大小上限后丢弃数据并标记为不可缓存，始终返回成功
+func (r *Recorder) Write(p []byte) (int, error) {
+	if r.overflow {
+		return len(p), nil
+	}
+	if len(r.body)+len(p) > r.maxBodySize {
+		r.overflow = true
+		r.body = nil
+		return len(p), nil
+	}
+	r.body = append(r.body, p...)
+	return len(p), nil
+}
+
+// Discard 标记响应不可缓存，用于响应未完整转发给客户端的情况
+func (r *Recorder) Discard() {
+	r.discarded = true
+	r.body = nil
+}
+
+// Response 获取可缓存的响应，响应不可缓存时返回 nil
+func (r *Recorder) Response() *Response {
+	if r.overflow || r.discarded || r.statusCode < http.StatusOK || r.statusCode >= http.StatusMultipleChoices {
+		return nil
+	}
+	return &Response{
+		StatusCode: r.statusCode,
+		Header:     r.header,
+		Body:       r.body,
+	}
+}
@@ -0,0 +1,155 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// Response 代表已缓存的上游响应
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Result 代表获取幂等键的结果
+type Result struct {
+	Response *Response // 已缓存的响应，为 nil 时表示当前请求需要执行并通过 Complete 提交结果
+	Waited   bool      // 是否等待过同一幂等键的进行中请求
+}
+
+// entry 代表幂等键对应的缓存项
+type entry struct {
+	done      chan struct{} // 进行中请求完成时关闭
+	response  *Response     // 完成后的响应，进行中时为 nil
+	expiresAt time.Time     // 过期时间，进行中时为零值
+}
+
+// Store 代表幂等键响应存储，同一幂等键的并发请求仅执行一次（single-flight）
+type Store struct {
+	mu         sync.Mutex
+	entries    map[string]*entry
+	ttl        time.Duration
+	maxEntries int
+	lastSweep  time.Time
+}
+
+// NewStore 创建新的幂等键响应存储实例
+// ttl: 响应缓存时间
+// maxEntries: 最大缓存项数量，达到上限时新的幂等键不再去重
+func NewStore(ttl time.Duration, maxEntries int) *Store {
+	return &Store{
+		entries:    make(map[string]*entry),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		lastSweep:  time.Now(),
+	}
+}
+
+// Acquire 获取幂等键
+// 已有缓存响应时直接返回；存在进行中的请求时阻塞等待其完成；
+// 否则登记为进行中并返回空响应，调用方执行请求后必须调用 Complete
+func (s *Store) Acquire(ctx context.Context, key string) (Result, error) {
+	var result Result
+
+	for {
+		s.mu.Lock()
+		now := time.Now()
+		s.sweepLocked(now)
+
+		e, ok := s.entries[key]
+		if ok && e.response != nil && now.Before(e.expiresAt) {
+			s.mu.Unlock()
+			result.Response = e.response
+			return result, nil
+		}
+
+		if !ok || e.response != nil {
+			// 无缓存或缓存已过期，当前请求负责执行
+			if len(s.entries) < s.maxEntries || ok {
+				s.entries[key] = &entry{done: make(chan struct{})}
+			}
+			s.mu.Unlock()
+			return result, nil
+		}
+		s.mu.Unlock()
+
+		// 等待进行中的请求完成后重新检查
+		result.Waited = true
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return result, ctx.Err()
+		}
+	}
+}
+
+// Complete 提交幂等键的执行结果并唤醒等待者
+// response 为 nil 时表示结果不可缓存（如请求失败），等待者将重新竞争执行
+func (s *Store) Complete(key string, response *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok || e.response != nil {
+		return
+	}
+
+	if response != nil {
+		e.response = response
+		e.expiresAt = time.Now().Add(s.ttl)
+	} else {
+		delete(s.entries, key)
+	}
+	close(e.done)
+}
+
+// Len 获取当前缓存项数量，包括进行中的请求
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// sweepLocked 清理过期的缓存项，每个 TTL 周期最多执行一次，调用方必须持有锁
+func (s *Store) sweepLocked(now time.Time) {
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	s.lastSweep = now
+
+	for key, e := range s.entries {
+		if e.response != nil && !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// RequestKey 根据请求生成幂等缓存键，请求未携带幂等键时返回空字符串
+// 缓存键由请求方法、路径、凭据头部（Authorization、X-Api-Key 等）和幂等键共同哈希得到，避免不同客户端之间误用或窃取彼此的响应
+func RequestKey(req *http.Request) string {
+	idempotencyKey := req.Header.Get(constants.HeaderIdempotencyKey)
+	if idempotencyKey == "" {
+		return ""
+	}
+
+	parts := make([]string, 0, len(constants.CredentialHeaders)+3)
+	parts = append(parts, req.Method, req.URL.Path)
+	for _, name := range constants.CredentialHeaders {
+		parts = append(parts, req.Header.Get(name))
+	}
+	parts = append(parts, idempotencyKey)
+
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
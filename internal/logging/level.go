@@ -0,0 +1,123 @@
+// Package logging 提供可在运行时调整级别的日志记录器
+package logging
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/zapr"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/shengyanli1982/orbit/utils/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"k8s.io/klog/v2"
+)
+
+// maxVerbosity 支持的最大 V 级别，对应 trace 日志级别
+const maxVerbosity = 2
+
+// levels 日志级别名称到 zap 级别的映射，logr 的 V(n) 对应 zap 级别 -n
+var levels = map[string]zapcore.Level{
+	constants.LogLevelError: zapcore.ErrorLevel,
+	constants.LogLevelInfo:  zapcore.InfoLevel,
+	constants.LogLevelDebug: zapcore.DebugLevel,
+	constants.LogLevelTrace: zapcore.Level(-maxVerbosity),
+}
+
+// NewAtomicLevel 创建运行时日志级别，发布模式默认 info，开发模式默认 trace
+func NewAtomicLevel(releaseMode bool) zap.AtomicLevel {
+	if releaseMode {
+		return zap.NewAtomicLevelAt(levels[constants.LogLevelInfo])
+	}
+	return zap.NewAtomicLevelAt(levels[constants.LogLevelTrace])
+}
+
+// ParseLevel 解析日志级别名称，支持 error、info、debug 和 trace
+func ParseLevel(name string) (zapcore.Level, error) {
+	level, ok := levels[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown log level '%s', expected one of: error, info, debug, trace", name)
+	}
+	return level, nil
+}
+
+// LevelName 获取 zap 级别对应的日志级别名称
+func LevelName(level zapcore.Level) string {
+	switch {
+	case level <= levels[constants.LogLevelTrace]:
+		return constants.LogLevelTrace
+	case level <= levels[constants.LogLevelDebug]:
+		return constants.LogLevelDebug
+	case level <= levels[constants.LogLevelInfo]:
+		return constants.LogLevelInfo
+	default:
+		return constants.LogLevelError
+	}
+}
+
+// NewZapLogger 创建使用运行时日志级别的 JSON 格式日志记录器，编码配置与 orbit 保持一致
+func NewZapLogger(ws zapcore.WriteSyncer, level zap.AtomicLevel) *logr.Logger {
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(log.LogEncodingConfig), ws, level)
+	logger := zapr.NewLogger(zap.New(core, zap.AddCaller()))
+	return &logger
+}
+
+// WithLevel 使用运行时日志级别过滤普通格式日志记录器的输出
+// klog 的 V 级别提升到最大值，由运行时日志级别决定实际输出的日志
+func WithLevel(logger *logr.Logger, level zap.AtomicLevel) *logr.Logger {
+	fs := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(fs)
+	_ = fs.Set("v", fmt.Sprint(maxVerbosity))
+
+	// 包装层额外增加一层调用栈，保持日志中的调用位置不变
+	sink := logger.GetSink()
+	if callDepthSink, ok := sink.(logr.CallDepthLogSink); ok {
+		sink = callDepthSink.WithCallDepth(1)
+	}
+
+	wrapped := logr.New(&levelSink{sink: sink, level: level})
+	return &wrapped
+}
+
+// levelSink 代表按运行时日志级别过滤的日志输出
+type levelSink struct {
+	sink  logr.LogSink
+	level zap.AtomicLevel
+}
+
+// Init 内部日志输出已初始化，这里无需处理
+func (s *levelSink) Init(info logr.RuntimeInfo) {}
+
+// Enabled 判断指定 V 级别的日志是否输出
+func (s *levelSink) Enabled(level int) bool {
+	return s.level.Enabled(zapcore.Level(-level)) && s.sink.Enabled(level)
+}
+
+// Info 输出信息日志
+func (s *levelSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.sink.Info(level, msg, keysAndValues...)
+}
+
+// Error 输出错误日志，错误日志不受运行时日志级别影响
+func (s *levelSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.sink.Error(err, msg, keysAndValues...)
+}
+
+// WithValues 返回附加键值对的日志输出
+func (s *levelSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &levelSink{sink: s.sink.WithValues(keysAndValues...), level: s.level}
+}
+
+// WithName 返回附加名称的日志输出
+func (s *levelSink) WithName(name string) logr.LogSink {
+	return &levelSink{sink: s.sink.WithName(name), level: s.level}
+}
+
+// WithCallDepth 返回调整调用栈深度的日志输出
+func (s *levelSink) WithCallDepth(depth int) logr.LogSink {
+	if callDepthSink, ok := s.sink.(logr.CallDepthLogSink); ok {
+		return &levelSink{sink: callDepthSink.WithCallDepth(depth), level: s.level}
+	}
+	return s
+}
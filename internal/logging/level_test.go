@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestParseLevel 测试日志级别名称解析
+func TestParseLevel(t *testing.T) {
+	for _, name := range []string{"error", "info", "debug", "trace"} {
+		level, err := ParseLevel(name)
+		require.NoError(t, err)
+		assert.Equal(t, name, LevelName(level))
+	}
+
+	_, err := ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+// TestNewAtomicLevel 测试发布模式和开发模式的默认日志级别
+func TestNewAtomicLevel(t *testing.T) {
+	assert.Equal(t, "info", LevelName(NewAtomicLevel(true).Level()))
+	assert.Equal(t, "trace", LevelName(NewAtomicLevel(false).Level()))
+}
+
+// TestWithLevel 测试普通格式日志记录器按运行时日志级别过滤输出
+func TestWithLevel(t *testing.T) {
+	var messages []string
+	base := funcr.New(func(prefix, args string) {
+		messages = append(messages, args)
+	}, funcr.Options{Verbosity: 2})
+
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	logger := WithLevel(&base, level).WithValues("component", "test")
+
+	logger.Info("info")
+	logger.V(1).Info("debug")
+	assert.Len(t, messages, 1)
+
+	level.SetLevel(zapcore.DebugLevel)
+	logger.V(1).Info("debug")
+	logger.V(2).Info("trace")
+	assert.Len(t, messages, 2)
+
+	// error 级别过滤信息日志，错误日志始终输出
+	level.SetLevel(zapcore.ErrorLevel)
+	logger.Info("info")
+	logger.Error(nil, "error")
+	assert.Len(t, messages, 3)
+	assert.Contains(t, messages[2], `"component"="test"`)
+}
@@ -24,8 +24,29 @@ const (
 	LabelToState        = "to_state"
 	LabelBalancerType   = "balancer_type"
 	LabelLimitType      = "limit_type"
+	LabelRule           = "rule"
+	LabelStream         = "stream"
+	LabelOriginalStatus = "original_status"
+	LabelMappedStatus   = "mapped_status"
+	LabelOutcome        = "outcome"
 )
 
+// Config reload result constants - 配置重新加载结果标签值
+const (
+	ReloadResultSuccess = "success"
+	ReloadResultFailure = "failure"
+)
+
+// reservedUpstreamLabels 上游请求指标的内置标签，上游标签键不能与之重名
+var reservedUpstreamLabels = map[string]struct{}{
+	LabelUpstreamGroup: {},
+	LabelUpstreamName:  {},
+	LabelMethod:        {},
+	LabelStatusCode:    {},
+	LabelStream:        {},
+	LabelErrorType:     {},
+}
+
 // 预定义常见状态码字符串，避免频繁的格式化操作
 var statusCodeStrings = map[int]string{
 	200: "200", 201: "201", 202: "202", 204: "204",
@@ -54,11 +75,15 @@ type prometheusCollector struct {
 	httpRequestDuration   *prometheus.HistogramVec
 	httpRequestSizeBytes  *prometheus.HistogramVec
 	httpResponseSizeBytes *prometheus.HistogramVec
+	httpRequestsInFlight  *prometheus.GaugeVec
+	httpErrorsTotal       *prometheus.CounterVec
 
 	// 上游服务指标
 	upstreamRequestsTotal   *prometheus.CounterVec
 	upstreamRequestDuration *prometheus.HistogramVec
 	upstreamErrorsTotal     *prometheus.CounterVec
+	upstreamStatusRemapped  *prometheus.CounterVec
+	upstreamTimeToFirstByte *prometheus.HistogramVec
 
 	// 断路器指标
 	circuitBreakerState         *prometheus.GaugeVec
@@ -68,10 +93,22 @@ type prometheusCollector struct {
 	// 负载均衡器指标
 	loadBalancerSelectionsTotal *prometheus.CounterVec
 	upstreamHealthStatus        *prometheus.GaugeVec
+	upstreamConnectionsActive   *prometheus.GaugeVec
+	upstreamConnectionsIdle     *prometheus.GaugeVec
 
 	// 系统级指标
 	activeConnections        *prometheus.GaugeVec
 	rateLimitRejectionsTotal *prometheus.CounterVec
+	idempotencyHitsTotal     *prometheus.CounterVec
+	idempotencyWaitsTotal    *prometheus.CounterVec
+	concurrencyLimit         *prometheus.GaugeVec
+	concurrencyRejections    *prometheus.CounterVec
+	requestBodyTooLarge      *prometheus.CounterVec
+	configReloadsTotal       *prometheus.CounterVec
+	configLastReload         prometheus.Gauge
+
+	// 附加到上游请求指标的上游标签，键为上游名称，值按 config.UpstreamLabels 顺序排列
+	upstreamLabelValues map[string][]string
 }
 
 // NewPrometheusCollectorWithRegistry 创建使用指定注册器的 Prometheus 指标收集器实例
@@ -82,11 +119,18 @@ func NewPrometheusCollectorWithRegistry(config *Config, registry *prometheus.Reg
 	if registry == nil {
 		return nil, fmt.Errorf("registry cannot be nil")
 	}
+	if err := ValidateDurationBuckets(config.DurationBuckets); err != nil {
+		return nil, err
+	}
+	if err := ValidateUpstreamLabels(config.UpstreamLabels); err != nil {
+		return nil, err
+	}
 
 	collector := &prometheusCollector{
-		name:     "prometheus",
-		registry: registry,
-		config:   config,
+		name:                "prometheus",
+		registry:            registry,
+		config:              config,
+		upstreamLabelValues: make(map[string][]string),
 	}
 
 	if err := collector.initMetrics(); err != nil {
@@ -113,16 +157,32 @@ func (c *prometheusCollector) initMetrics() error {
 			Name: prefix + "_http_requests_total",
 			Help: "Total number of HTTP requests",
 		},
-		[]string{LabelForwardName, LabelMethod, LabelPath, LabelStatusCode},
+		[]string{LabelForwardName, LabelMethod, LabelPath, LabelStatusCode, LabelStream, LabelOutcome},
 	)
 
 	c.httpRequestDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    prefix + "_http_request_duration_seconds",
 			Help:    "HTTP request duration in seconds",
-			Buckets: []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60, 120},
+			Buckets: c.config.durationBuckets(),
 		},
-		[]string{LabelForwardName, LabelMethod, LabelPath},
+		[]string{LabelForwardName, LabelMethod, LabelPath, LabelOutcome},
+	)
+
+	c.httpRequestsInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: prefix + "_http_requests_in_flight",
+			Help: "Number of HTTP requests currently being processed",
+		},
+		[]string{LabelForwardName},
+	)
+
+	c.httpErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: prefix + "_http_errors_total",
+			Help: "Total number of HTTP request processing errors",
+		},
+		[]string{LabelForwardName, LabelErrorType},
 	)
 
 	c.httpRequestSizeBytes = prometheus.NewHistogramVec(
@@ -149,16 +209,16 @@ func (c *prometheusCollector) initMetrics() error {
 			Name: prefix + "_upstream_requests_total",
 			Help: "Total number of upstream requests",
 		},
-		[]string{LabelUpstreamGroup, LabelUpstreamName, LabelMethod, LabelStatusCode},
+		append([]string{LabelUpstreamGroup, LabelUpstreamName, LabelMethod, LabelStatusCode, LabelStream}, c.config.UpstreamLabels...),
 	)
 
 	c.upstreamRequestDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    prefix + "_upstream_request_duration_seconds",
 			Help:    "Upstream request duration in seconds",
-			Buckets: []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60, 120},
+			Buckets: c.config.durationBuckets(),
 		},
-		[]string{LabelUpstreamGroup, LabelUpstreamName, LabelMethod},
+		append([]string{LabelUpstreamGroup, LabelUpstreamName, LabelMethod}, c.config.UpstreamLabels...),
 	)
 
 	c.upstreamErrorsTotal = prometheus.NewCounterVec(
@@ -166,7 +226,24 @@ func (c *prometheusCollector) initMetrics() error {
 			Name: prefix + "_upstream_errors_total",
 			Help: "Total number of upstream errors",
 		},
-		[]string{LabelUpstreamGroup, LabelUpstreamName, LabelErrorType},
+		append([]string{LabelUpstreamGroup, LabelUpstreamName, LabelErrorType}, c.config.UpstreamLabels...),
+	)
+
+	c.upstreamStatusRemapped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: prefix + "_upstream_status_remapped_total",
+			Help: "Total number of upstream status codes remapped before returning to the client",
+		},
+		[]string{LabelUpstreamGroup, LabelUpstreamName, LabelOriginalStatus, LabelMappedStatus},
+	)
+
+	c.upstreamTimeToFirstByte = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    prefix + "_upstream_time_to_first_byte_seconds",
+			Help:    "Time from sending the upstream request to receiving the first response body byte in seconds",
+			Buckets: c.config.durationBuckets(),
+		},
+		[]string{LabelUpstreamGroup, LabelUpstreamName},
 	)
 
 	// 断路器指标
@@ -211,6 +288,22 @@ func (c *prometheusCollector) initMetrics() error {
 		[]string{LabelUpstreamGroup, LabelUpstreamName},
 	)
 
+	c.upstreamConnectionsActive = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: prefix + "_upstream_connections_active",
+			Help: "Number of pooled upstream connections currently serving requests",
+		},
+		[]string{LabelUpstreamGroup, LabelUpstreamName},
+	)
+
+	c.upstreamConnectionsIdle = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: prefix + "_upstream_connections_idle",
+			Help: "Number of idle upstream connections in the connection pool",
+		},
+		[]string{LabelUpstreamGroup, LabelUpstreamName},
+	)
+
 	// 系统级指标
 	c.activeConnections = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -225,7 +318,62 @@ func (c *prometheusCollector) initMetrics() error {
 			Name: prefix + "_rate_limit_rejections_total",
 			Help: "Total number of rate limit rejections",
 		},
-		[]string{LabelForwardName, LabelLimitType},
+		[]string{LabelForwardName, LabelLimitType, LabelRule},
+	)
+
+	c.idempotencyHitsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: prefix + "_idempotency_hits_total",
+			Help: "Total number of responses replayed from the idempotency key cache",
+		},
+		[]string{LabelForwardName},
+	)
+
+	c.idempotencyWaitsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: prefix + "_idempotency_waits_total",
+			Help: "Total number of requests that waited for an in-flight request with the same idempotency key",
+		},
+		[]string{LabelForwardName},
+	)
+
+	c.concurrencyLimit = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: prefix + "_concurrency_limit",
+			Help: "Maximum number of concurrent requests allowed per forward (0=unlimited)",
+		},
+		[]string{LabelForwardName},
+	)
+
+	c.concurrencyRejections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: prefix + "_concurrency_rejections_total",
+			Help: "Total number of requests rejected because the forward reached its concurrency limit",
+		},
+		[]string{LabelForwardName},
+	)
+
+	c.requestBodyTooLarge = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: prefix + "_request_body_too_large_total",
+			Help: "Total number of requests rejected because the request body exceeded the size limit",
+		},
+		[]string{LabelForwardName},
+	)
+
+	c.configReloadsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: prefix + "_config_reloads_total",
+			Help: "Total number of configuration reloads",
+		},
+		[]string{LabelResult},
+	)
+
+	c.configLastReload = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: prefix + "_config_last_reload_timestamp_seconds",
+			Help: "Unix timestamp of the last successful configuration reload",
+		},
 	)
 
 	// 注册所有指标到注册器
@@ -234,16 +382,29 @@ func (c *prometheusCollector) initMetrics() error {
 		c.httpRequestDuration,
 		c.httpRequestSizeBytes,
 		c.httpResponseSizeBytes,
+		c.httpRequestsInFlight,
+		c.httpErrorsTotal,
 		c.upstreamRequestsTotal,
 		c.upstreamRequestDuration,
 		c.upstreamErrorsTotal,
+		c.upstreamStatusRemapped,
+		c.upstreamTimeToFirstByte,
 		c.circuitBreakerState,
 		c.circuitBreakerRequestsTotal,
 		c.circuitBreakerStateChanges,
 		c.loadBalancerSelectionsTotal,
 		c.upstreamHealthStatus,
+		c.upstreamConnectionsActive,
+		c.upstreamConnectionsIdle,
 		c.activeConnections,
 		c.rateLimitRejectionsTotal,
+		c.idempotencyHitsTotal,
+		c.idempotencyWaitsTotal,
+		c.concurrencyLimit,
+		c.concurrencyRejections,
+		c.requestBodyTooLarge,
+		c.configReloadsTotal,
+		c.configLastReload,
 	}
 
 	for _, collector := range collectors {
@@ -257,20 +418,26 @@ func (c *prometheusCollector) initMetrics() error {
 
 // HTTP 服务器指标收集方法实现
 
-// RecordRequest 记录 HTTP 请求
+// RecordRequest 记录 HTTP 请求开始，增加进行中的请求数
+// 请求计数将在 RecordResponse 中统一处理
 func (c *prometheusCollector) RecordRequest(forwardName, method, path string) {
-	// 请求计数将在 RecordResponse 中统一处理，这里可以预留扩展
+	c.httpRequestsInFlight.WithLabelValues(forwardName).Inc()
+}
+
+// RecordRequestDone 记录 HTTP 请求结束，减少进行中的请求数
+func (c *prometheusCollector) RecordRequestDone(forwardName string) {
+	c.httpRequestsInFlight.WithLabelValues(forwardName).Dec()
 }
 
 // RecordResponse 记录 HTTP 响应
-func (c *prometheusCollector) RecordResponse(forwardName, method, path string, statusCode int, duration time.Duration, requestSize, responseSize int64) {
+func (c *prometheusCollector) RecordResponse(forwardName, method, path string, statusCode int, stream bool, outcome string, duration time.Duration, requestSize, responseSize int64) {
 	statusCodeStr := formatStatusCode(statusCode)
 
 	// 记录请求总数
-	c.httpRequestsTotal.WithLabelValues(forwardName, method, path, statusCodeStr).Inc()
+	c.httpRequestsTotal.WithLabelValues(forwardName, method, path, statusCodeStr, strconv.FormatBool(stream), outcome).Inc()
 
 	// 记录请求处理时间
-	c.httpRequestDuration.WithLabelValues(forwardName, method, path).Observe(duration.Seconds())
+	c.httpRequestDuration.WithLabelValues(forwardName, method, path, outcome).Observe(duration.Seconds())
 
 	// 记录请求体大小
 	if requestSize > 0 {
@@ -284,9 +451,9 @@ func (c *prometheusCollector) RecordResponse(forwardName, method, path string, s
 }
 
 // RecordError 记录 HTTP 错误
+// 状态码统计由 RecordResponse 处理，这里按错误类型计数
 func (c *prometheusCollector) RecordError(forwardName, errorType string) {
-	// HTTP 错误通过 RecordResponse 中的状态码处理
-	// 这里可以记录额外的错误信息，如果需要的话
+	c.httpErrorsTotal.WithLabelValues(forwardName, errorType).Inc()
 }
 
 // 上游服务指标收集方法实现
@@ -297,19 +464,60 @@ func (c *prometheusCollector) RecordUpstreamRequest(upstreamGroup, upstreamName,
 }
 
 // RecordUpstreamResponse 记录上游响应
-func (c *prometheusCollector) RecordUpstreamResponse(upstreamGroup, upstreamName, method string, statusCode int, duration time.Duration) {
+func (c *prometheusCollector) RecordUpstreamResponse(upstreamGroup, upstreamName, method string, statusCode int, stream bool, duration time.Duration) {
 	statusCodeStr := formatStatusCode(statusCode)
 
 	// 记录上游请求总数
-	c.upstreamRequestsTotal.WithLabelValues(upstreamGroup, upstreamName, method, statusCodeStr).Inc()
+	c.upstreamRequestsTotal.WithLabelValues(c.withUpstreamLabels(upstreamName, upstreamGroup, upstreamName, method, statusCodeStr, strconv.FormatBool(stream))...).Inc()
 
 	// 记录上游响应时间
-	c.upstreamRequestDuration.WithLabelValues(upstreamGroup, upstreamName, method).Observe(duration.Seconds())
+	c.upstreamRequestDuration.WithLabelValues(c.withUpstreamLabels(upstreamName, upstreamGroup, upstreamName, method)...).Observe(duration.Seconds())
 }
 
 // RecordUpstreamError 记录上游错误
 func (c *prometheusCollector) RecordUpstreamError(upstreamGroup, upstreamName, errorType string) {
-	c.upstreamErrorsTotal.WithLabelValues(upstreamGroup, upstreamName, errorType).Inc()
+	c.upstreamErrorsTotal.WithLabelValues(c.withUpstreamLabels(upstreamName, upstreamGroup, upstreamName, errorType)...).Inc()
+}
+
+// SetUpstreamLabels 设置上游的运维标签
+func (c *prometheusCollector) SetUpstreamLabels(upstreamName string, labels map[string]string) {
+	if len(c.config.UpstreamLabels) == 0 {
+		return
+	}
+
+	values := make([]string, len(c.config.UpstreamLabels))
+	for i, key := range c.config.UpstreamLabels {
+		values[i] = labels[key]
+	}
+
+	c.mu.Lock()
+	c.upstreamLabelValues[upstreamName] = values
+	c.mu.Unlock()
+}
+
+// withUpstreamLabels 在内置标签值之后追加上游标签值，未设置标签的上游使用空字符串
+func (c *prometheusCollector) withUpstreamLabels(upstreamName string, values ...string) []string {
+	if len(c.config.UpstreamLabels) == 0 {
+		return values
+	}
+
+	c.mu.RLock()
+	labelValues, exists := c.upstreamLabelValues[upstreamName]
+	c.mu.RUnlock()
+	if !exists {
+		labelValues = make([]string, len(c.config.UpstreamLabels))
+	}
+	return append(values, labelValues...)
+}
+
+// RecordUpstreamStatusRemap 记录上游状态码映射
+func (c *prometheusCollector) RecordUpstreamStatusRemap(upstreamGroup, upstreamName string, originalStatus, mappedStatus int) {
+	c.upstreamStatusRemapped.WithLabelValues(upstreamGroup, upstreamName, formatStatusCode(originalStatus), formatStatusCode(mappedStatus)).Inc()
+}
+
+// RecordUpstreamTimeToFirstByte 记录上游首字节时间
+func (c *prometheusCollector) RecordUpstreamTimeToFirstByte(upstreamGroup, upstreamName string, ttfb time.Duration) {
+	c.upstreamTimeToFirstByte.WithLabelValues(upstreamGroup, upstreamName).Observe(ttfb.Seconds())
 }
 
 // 断路器指标收集方法实现
@@ -345,6 +553,12 @@ func (c *prometheusCollector) RecordUpstreamHealthStatus(upstreamGroup, upstream
 	c.upstreamHealthStatus.WithLabelValues(upstreamGroup, upstreamName).Set(healthValue)
 }
 
+// RecordUpstreamConnections 记录上游连接池的活跃和空闲连接数
+func (c *prometheusCollector) RecordUpstreamConnections(upstreamGroup, upstreamName string, active, idle int) {
+	c.upstreamConnectionsActive.WithLabelValues(upstreamGroup, upstreamName).Set(float64(active))
+	c.upstreamConnectionsIdle.WithLabelValues(upstreamGroup, upstreamName).Set(float64(idle))
+}
+
 // 系统级指标收集方法实现
 
 // RecordActiveConnections 记录活跃连接数
@@ -353,8 +567,43 @@ func (c *prometheusCollector) RecordActiveConnections(forwardName string, connec
 }
 
 // RecordRateLimitRejection 记录限流拒绝
-func (c *prometheusCollector) RecordRateLimitRejection(forwardName, limitType string) {
-	c.rateLimitRejectionsTotal.WithLabelValues(forwardName, limitType).Inc()
+func (c *prometheusCollector) RecordRateLimitRejection(forwardName, limitType, rule string) {
+	c.rateLimitRejectionsTotal.WithLabelValues(forwardName, limitType, rule).Inc()
+}
+
+// RecordIdempotencyHit 记录幂等键缓存命中
+func (c *prometheusCollector) RecordIdempotencyHit(forwardName string) {
+	c.idempotencyHitsTotal.WithLabelValues(forwardName).Inc()
+}
+
+// RecordIdempotencyWait 记录等待进行中的同幂等键请求
+func (c *prometheusCollector) RecordIdempotencyWait(forwardName string) {
+	c.idempotencyWaitsTotal.WithLabelValues(forwardName).Inc()
+}
+
+// SetConcurrencyLimit 设置转发服务的最大并发请求数
+func (c *prometheusCollector) SetConcurrencyLimit(forwardName string, limit int) {
+	c.concurrencyLimit.WithLabelValues(forwardName).Set(float64(limit))
+}
+
+// RecordConcurrencyRejection 记录因达到最大并发请求数而拒绝的请求
+func (c *prometheusCollector) RecordConcurrencyRejection(forwardName string) {
+	c.concurrencyRejections.WithLabelValues(forwardName).Inc()
+}
+
+// RecordRequestBodyTooLarge 记录因请求体超过大小限制而拒绝的请求
+func (c *prometheusCollector) RecordRequestBodyTooLarge(forwardName string) {
+	c.requestBodyTooLarge.WithLabelValues(forwardName).Inc()
+}
+
+// RecordConfigReload 记录配置重新加载
+func (c *prometheusCollector) RecordConfigReload(success bool) {
+	if !success {
+		c.configReloadsTotal.WithLabelValues(ReloadResultFailure).Inc()
+		return
+	}
+	c.configReloadsTotal.WithLabelValues(ReloadResultSuccess).Inc()
+	c.configLastReload.SetToCurrentTime()
 }
 
 // 工具方法实现
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -76,7 +77,7 @@ func TestPrometheusCollector_HTTPMetrics(t *testing.T) {
 	collector := createTestCollector(t, "test", "")
 
 	// 记录 HTTP 响应
-	collector.RecordResponse("test-forward", "POST", "/v1/chat", 200, 100*time.Millisecond, 1024, 2048)
+	collector.RecordResponse("test-forward", "POST", "/v1/chat", 200, false, "direct", 100*time.Millisecond, 1024, 2048)
 
 	// 验证指标是否正确记录
 	registry := collector.GetRegistry()
@@ -106,11 +107,17 @@ func TestPrometheusCollector_UpstreamMetrics(t *testing.T) {
 	collector := createTestCollector(t, "test", "")
 
 	// 记录上游响应
-	collector.RecordUpstreamResponse("openai-group", "openai-primary", "POST", 200, 500*time.Millisecond)
+	collector.RecordUpstreamResponse("openai-group", "openai-primary", "POST", 200, false, 500*time.Millisecond)
 
 	// 记录上游错误
 	collector.RecordUpstreamError("openai-group", "openai-primary", "timeout")
 
+	// 记录上游状态码映射
+	collector.RecordUpstreamStatusRemap("openai-group", "openai-primary", 529, 503)
+
+	// 记录上游首字节时间
+	collector.RecordUpstreamTimeToFirstByte("openai-group", "openai-primary", 200*time.Millisecond)
+
 	// 验证指标是否正确记录
 	registry := collector.GetRegistry()
 	metricFamilies, err := registry.Gather()
@@ -121,6 +128,8 @@ func TestPrometheusCollector_UpstreamMetrics(t *testing.T) {
 	// 检查上游请求指标
 	foundRequests := false
 	foundErrors := false
+	foundRemaps := false
+	foundTTFB := false
 	for _, mf := range metricFamilies {
 		if strings.Contains(mf.GetName(), "upstream_requests_total") {
 			foundRequests = true
@@ -128,6 +137,15 @@ func TestPrometheusCollector_UpstreamMetrics(t *testing.T) {
 		if strings.Contains(mf.GetName(), "upstream_errors_total") {
 			foundErrors = true
 		}
+		if strings.Contains(mf.GetName(), "upstream_status_remapped_total") {
+			foundRemaps = true
+		}
+		if strings.Contains(mf.GetName(), "upstream_time_to_first_byte_seconds") {
+			foundTTFB = true
+			if got := mf.GetMetric()[0].GetHistogram().GetSampleSum(); got != 0.2 {
+				t.Errorf("Expected time to first byte sum 0.2, got %v", got)
+			}
+		}
 	}
 	if !foundRequests {
 		t.Error("Expected to find upstream_requests_total metric")
@@ -135,6 +153,12 @@ func TestPrometheusCollector_UpstreamMetrics(t *testing.T) {
 	if !foundErrors {
 		t.Error("Expected to find upstream_errors_total metric")
 	}
+	if !foundRemaps {
+		t.Error("Expected to find upstream_status_remapped_total metric")
+	}
+	if !foundTTFB {
+		t.Error("Expected to find upstream_time_to_first_byte_seconds metric")
+	}
 }
 
 // TestPrometheusCollector_CircuitBreakerMetrics 测试断路器指标收集
@@ -193,6 +217,9 @@ func TestPrometheusCollector_LoadBalancerMetrics(t *testing.T) {
 	// 记录上游健康状态
 	collector.RecordUpstreamHealthStatus("openai-group", "openai-primary", true)
 
+	// 记录上游连接池连接数
+	collector.RecordUpstreamConnections("openai-group", "openai-primary", 3, 2)
+
 	// 验证指标是否正确记录
 	registry := collector.GetRegistry()
 	metricFamilies, err := registry.Gather()
@@ -202,6 +229,8 @@ func TestPrometheusCollector_LoadBalancerMetrics(t *testing.T) {
 
 	foundSelections := false
 	foundHealth := false
+	activeConnections := float64(-1)
+	idleConnections := float64(-1)
 	for _, mf := range metricFamilies {
 		name := mf.GetName()
 		if strings.Contains(name, "load_balancer_selections_total") {
@@ -210,6 +239,12 @@ func TestPrometheusCollector_LoadBalancerMetrics(t *testing.T) {
 		if strings.Contains(name, "upstream_health_status") {
 			foundHealth = true
 		}
+		if name == "test_upstream_connections_active" {
+			activeConnections = mf.GetMetric()[0].GetGauge().GetValue()
+		}
+		if name == "test_upstream_connections_idle" {
+			idleConnections = mf.GetMetric()[0].GetGauge().GetValue()
+		}
 	}
 	if !foundSelections {
 		t.Error("Expected to find load_balancer_selections_total metric")
@@ -217,6 +252,12 @@ func TestPrometheusCollector_LoadBalancerMetrics(t *testing.T) {
 	if !foundHealth {
 		t.Error("Expected to find upstream_health_status metric")
 	}
+	if activeConnections != 3 {
+		t.Errorf("Expected upstream_connections_active 3, got %v", activeConnections)
+	}
+	if idleConnections != 2 {
+		t.Errorf("Expected upstream_connections_idle 2, got %v", idleConnections)
+	}
 }
 
 // TestPrometheusCollector_SystemMetrics 测试系统级指标收集
@@ -227,7 +268,14 @@ func TestPrometheusCollector_SystemMetrics(t *testing.T) {
 	collector.RecordActiveConnections("test-forward", 10)
 
 	// 记录限流拒绝
-	collector.RecordRateLimitRejection("test-forward", "ip")
+	collector.RecordRateLimitRejection("test-forward", "ip", "default")
+
+	// 记录并发上限和并发拒绝
+	collector.SetConcurrencyLimit("test-forward", 100)
+	collector.RecordConcurrencyRejection("test-forward")
+
+	// 记录请求体超限拒绝
+	collector.RecordRequestBodyTooLarge("test-forward")
 
 	// 验证指标是否正确记录
 	registry := collector.GetRegistry()
@@ -238,6 +286,9 @@ func TestPrometheusCollector_SystemMetrics(t *testing.T) {
 
 	foundConnections := false
 	foundRejections := false
+	foundConcurrencyLimit := false
+	foundConcurrencyRejections := false
+	foundBodyTooLarge := false
 	for _, mf := range metricFamilies {
 		name := mf.GetName()
 		if strings.Contains(name, "active_connections") {
@@ -246,6 +297,24 @@ func TestPrometheusCollector_SystemMetrics(t *testing.T) {
 		if strings.Contains(name, "rate_limit_rejections_total") {
 			foundRejections = true
 		}
+		if name == "test_concurrency_limit" && mf.GetMetric()[0].GetGauge().GetValue() == 100 {
+			foundConcurrencyLimit = true
+		}
+		if name == "test_concurrency_rejections_total" && mf.GetMetric()[0].GetCounter().GetValue() == 1 {
+			foundConcurrencyRejections = true
+		}
+		if name == "test_request_body_too_large_total" && mf.GetMetric()[0].GetCounter().GetValue() == 1 {
+			foundBodyTooLarge = true
+		}
+	}
+	if !foundBodyTooLarge {
+		t.Error("Expected to find request_body_too_large_total metric with value 1")
+	}
+	if !foundConcurrencyLimit {
+		t.Error("Expected to find concurrency_limit metric with value 100")
+	}
+	if !foundConcurrencyRejections {
+		t.Error("Expected to find concurrency_rejections_total metric with value 1")
 	}
 	if !foundConnections {
 		t.Error("Expected to find active_connections metric")
@@ -255,12 +324,115 @@ func TestPrometheusCollector_SystemMetrics(t *testing.T) {
 	}
 }
 
+// TestPrometheusCollector_ConfigReload 测试配置重新加载指标
+func TestPrometheusCollector_ConfigReload(t *testing.T) {
+	collector := createTestCollector(t, "test", "")
+
+	collector.RecordConfigReload(false)
+	collector.RecordConfigReload(true)
+	collector.RecordConfigReload(false)
+
+	metricFamilies, err := collector.GetRegistry().Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	reloads := make(map[string]float64)
+	var lastReload float64
+	for _, mf := range metricFamilies {
+		switch mf.GetName() {
+		case "test_config_reloads_total":
+			for _, m := range mf.GetMetric() {
+				reloads[m.GetLabel()[0].GetValue()] = m.GetCounter().GetValue()
+			}
+		case "test_config_last_reload_timestamp_seconds":
+			lastReload = mf.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+
+	if reloads[ReloadResultSuccess] != 1 || reloads[ReloadResultFailure] != 2 {
+		t.Errorf("Expected 1 successful and 2 failed reloads, got %v", reloads)
+	}
+	if lastReload < float64(time.Now().Add(-time.Minute).Unix()) {
+		t.Errorf("Expected last reload timestamp to be recent, got %v", lastReload)
+	}
+}
+
+// TestPrometheusCollector_UpstreamLabels 测试按配置将上游标签附加到上游请求指标
+func TestPrometheusCollector_UpstreamLabels(t *testing.T) {
+	config := &Config{
+		Type:           "prometheus",
+		Enabled:        true,
+		Namespace:      "test",
+		UpstreamLabels: []string{"provider", "region"},
+	}
+	collector, err := NewPrometheusCollectorWithRegistry(config, prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("Failed to create collector: %v", err)
+	}
+
+	collector.SetUpstreamLabels("openai-us", map[string]string{"provider": "openai", "region": "us", "team": "ml"})
+	collector.RecordUpstreamResponse("group", "openai-us", "POST", 200, false, 100*time.Millisecond)
+	collector.RecordUpstreamError("group", "unlabeled", "timeout")
+
+	metricFamilies, err := collector.GetRegistry().Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	labelsOf := func(name string) map[string]string {
+		for _, mf := range metricFamilies {
+			if mf.GetName() != name {
+				continue
+			}
+			labels := make(map[string]string)
+			for _, label := range mf.GetMetric()[0].GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			return labels
+		}
+		t.Fatalf("Expected to find metric %s", name)
+		return nil
+	}
+
+	requestLabels := labelsOf("test_upstream_requests_total")
+	if requestLabels["provider"] != "openai" || requestLabels["region"] != "us" {
+		t.Errorf("Expected upstream labels on upstream_requests_total, got %v", requestLabels)
+	}
+	if _, exists := requestLabels["team"]; exists {
+		t.Errorf("Expected undeclared label to be ignored, got %v", requestLabels)
+	}
+	if labels := labelsOf("test_upstream_request_duration_seconds"); labels["provider"] != "openai" {
+		t.Errorf("Expected upstream labels on upstream_request_duration_seconds, got %v", labels)
+	}
+	if labels := labelsOf("test_upstream_errors_total"); labels["provider"] != "" || labels["region"] != "" {
+		t.Errorf("Expected empty upstream labels for unlabeled upstream, got %v", labels)
+	}
+}
+
+// TestValidateUpstreamLabels 测试上游标签键验证
+func TestValidateUpstreamLabels(t *testing.T) {
+	valid := [][]string{nil, {"provider"}, {"region", "provider_2"}}
+	for _, labels := range valid {
+		if err := ValidateUpstreamLabels(labels); err != nil {
+			t.Errorf("Expected labels %v to be valid, got %v", labels, err)
+		}
+	}
+
+	invalid := [][]string{{""}, {"2region"}, {"provider-name"}, {"__name"}, {"upstream_name"}, {"method"}, {"region", "region"}}
+	for _, labels := range invalid {
+		if err := ValidateUpstreamLabels(labels); !errors.Is(err, ErrInvalidUpstreamLabels) {
+			t.Errorf("Expected labels %v to be invalid, got %v", labels, err)
+		}
+	}
+}
+
 // TestPrometheusCollector_MetricNaming 测试指标命名
 func TestPrometheusCollector_MetricNaming(t *testing.T) {
 	collector := createTestCollector(t, "llmproxy", "test")
 
 	// 记录一些指标
-	collector.RecordResponse("test-forward", "POST", "/v1/chat", 200, 100*time.Millisecond, 1024, 2048)
+	collector.RecordResponse("test-forward", "POST", "/v1/chat", 200, false, "direct", 100*time.Millisecond, 1024, 2048)
 
 	// 验证指标命名
 	registry := collector.GetRegistry()
@@ -297,8 +469,8 @@ func TestPrometheusCollector_ConcurrentAccess(t *testing.T) {
 	for i := 0; i < 10; i++ {
 		go func(id int) {
 			for j := 0; j < 100; j++ {
-				collector.RecordResponse("test-forward", "POST", "/v1/chat", 200, 100*time.Millisecond, 1024, 2048)
-				collector.RecordUpstreamResponse("test-group", "test-upstream", "POST", 200, 500*time.Millisecond)
+				collector.RecordResponse("test-forward", "POST", "/v1/chat", 200, false, "direct", 100*time.Millisecond, 1024, 2048)
+				collector.RecordUpstreamResponse("test-group", "test-upstream", "POST", 200, false, 500*time.Millisecond)
 				collector.RecordCircuitBreakerState("test-group", "test-upstream", 0)
 			}
 			done <- true
@@ -321,3 +493,150 @@ func TestPrometheusCollector_ConcurrentAccess(t *testing.T) {
 		t.Error("Expected metrics to be recorded")
 	}
 }
+
+// TestPrometheusCollector_DurationBuckets 测试耗时直方图桶边界配置
+func TestPrometheusCollector_DurationBuckets(t *testing.T) {
+	config := &Config{
+		Type:            "prometheus",
+		Enabled:         true,
+		Namespace:       "test",
+		DurationBuckets: []float64{1, 30, 300},
+	}
+
+	registry := prometheus.NewRegistry()
+	collector, err := NewPrometheusCollectorWithRegistry(config, registry)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	collector.RecordResponse("test-forward", "POST", "/v1/chat", 200, false, "direct", 100*time.Second, 1024, 2048)
+	collector.RecordUpstreamResponse("openai-group", "openai-primary", "POST", 200, false, 100*time.Second)
+
+	metricFamilies, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	checked := 0
+	for _, mf := range metricFamilies {
+		if !strings.HasSuffix(mf.GetName(), "request_duration_seconds") {
+			continue
+		}
+		checked++
+		buckets := mf.GetMetric()[0].GetHistogram().GetBucket()
+		if len(buckets) != len(config.DurationBuckets) {
+			t.Fatalf("Expected %d buckets for %s, got %d", len(config.DurationBuckets), mf.GetName(), len(buckets))
+		}
+		for i, bucket := range buckets {
+			if bucket.GetUpperBound() != config.DurationBuckets[i] {
+				t.Errorf("Expected bucket %d of %s to be %v, got %v", i, mf.GetName(), config.DurationBuckets[i], bucket.GetUpperBound())
+			}
+		}
+	}
+	if checked != 2 {
+		t.Errorf("Expected 2 duration histograms, got %d", checked)
+	}
+}
+
+// TestPrometheusCollector_StreamLabel 测试请求总数按流式和非流式响应区分
+func TestPrometheusCollector_StreamLabel(t *testing.T) {
+	collector := createTestCollector(t, "test", "")
+
+	collector.RecordResponse("test-forward", "POST", "/v1/chat", 200, true, "direct", time.Second, 1024, 2048)
+	collector.RecordResponse("test-forward", "POST", "/v1/chat", 200, true, "direct", time.Second, 1024, 2048)
+	collector.RecordResponse("test-forward", "POST", "/v1/chat", 200, false, "direct", time.Second, 1024, 2048)
+	collector.RecordUpstreamResponse("openai-group", "openai-primary", "POST", 200, true, time.Second)
+	collector.RecordUpstreamResponse("openai-group", "openai-primary", "POST", 200, false, time.Second)
+
+	metricFamilies, err := collector.GetRegistry().Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	expected := map[string]map[string]float64{
+		"test_http_requests_total":     {"true": 2, "false": 1},
+		"test_upstream_requests_total": {"true": 1, "false": 1},
+	}
+	for _, mf := range metricFamilies {
+		want, ok := expected[mf.GetName()]
+		if !ok {
+			continue
+		}
+		got := make(map[string]float64)
+		for _, m := range mf.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == LabelStream {
+					got[label.GetValue()] = m.GetCounter().GetValue()
+				}
+			}
+		}
+		for stream, count := range want {
+			if got[stream] != count {
+				t.Errorf("Expected %s{stream=%q} to be %v, got %v", mf.GetName(), stream, count, got[stream])
+			}
+		}
+		delete(expected, mf.GetName())
+	}
+	if len(expected) != 0 {
+		t.Errorf("Missing metrics: %v", expected)
+	}
+}
+
+// TestPrometheusCollector_InvalidDurationBuckets 测试无效的耗时直方图桶边界
+func TestPrometheusCollector_InvalidDurationBuckets(t *testing.T) {
+	for _, buckets := range [][]float64{{5, 1}, {1, 1}, {0, 1}, {-1}} {
+		config := &Config{
+			Type:            "prometheus",
+			Enabled:         true,
+			Namespace:       "test",
+			DurationBuckets: buckets,
+		}
+
+		_, err := NewPrometheusCollectorWithRegistry(config, prometheus.NewRegistry())
+		if err != ErrInvalidDurationBuckets {
+			t.Errorf("Expected ErrInvalidDurationBuckets for %v, got %v", buckets, err)
+		}
+	}
+}
+
+// TestPrometheusCollector_OutcomeLabel 测试请求总数和耗时按处理结果区分
+func TestPrometheusCollector_OutcomeLabel(t *testing.T) {
+	collector := createTestCollector(t, "test", "")
+
+	collector.RecordResponse("test-forward", "POST", "/v1/chat", 200, false, "direct", time.Second, 1024, 2048)
+	collector.RecordResponse("test-forward", "POST", "/v1/chat", 200, false, "cache_hit", time.Millisecond, 1024, 2048)
+	collector.RecordResponse("test-forward", "POST", "/v1/chat", 429, false, "rate_limited", time.Millisecond, 1024, 128)
+	collector.RecordResponse("test-forward", "POST", "/v1/chat", 429, false, "rate_limited", time.Millisecond, 1024, 128)
+
+	metricFamilies, err := collector.GetRegistry().Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	want := map[string]float64{"direct": 1, "cache_hit": 1, "rate_limited": 2}
+	for _, name := range []string{"test_http_requests_total", "test_http_request_duration_seconds"} {
+		got := make(map[string]float64)
+		for _, mf := range metricFamilies {
+			if mf.GetName() != name {
+				continue
+			}
+			for _, m := range mf.GetMetric() {
+				for _, label := range m.GetLabel() {
+					if label.GetName() != LabelOutcome {
+						continue
+					}
+					if m.GetCounter() != nil {
+						got[label.GetValue()] += m.GetCounter().GetValue()
+					} else {
+						got[label.GetValue()] += float64(m.GetHistogram().GetSampleCount())
+					}
+				}
+			}
+		}
+		for outcome, count := range want {
+			if got[outcome] != count {
+				t.Errorf("Expected %s{outcome=%q} to be %v, got %v", name, outcome, count, got[outcome])
+			}
+		}
+	}
+}
@@ -7,12 +7,14 @@ import (
 
 // 工厂相关错误定义
 var (
-	ErrInvalidMetricsType    = errors.New("invalid metrics type")
-	ErrNilConfig             = errors.New("metrics config cannot be nil")
-	ErrInvalidConfig         = errors.New("invalid metrics config")
-	ErrMetricsDisabled       = errors.New("metrics collection is disabled")
-	ErrMetricsTypeEmpty      = errors.New("metrics type cannot be empty")
-	ErrMetricsNamespaceEmpty = errors.New("metrics namespace cannot be empty")
+	ErrInvalidMetricsType     = errors.New("invalid metrics type")
+	ErrNilConfig              = errors.New("metrics config cannot be nil")
+	ErrInvalidConfig          = errors.New("invalid metrics config")
+	ErrMetricsDisabled        = errors.New("metrics collection is disabled")
+	ErrMetricsTypeEmpty       = errors.New("metrics type cannot be empty")
+	ErrMetricsNamespaceEmpty  = errors.New("metrics namespace cannot be empty")
+	ErrInvalidDurationBuckets = errors.New("metrics duration buckets must be positive and sorted in strictly ascending order")
+	ErrInvalidUpstreamLabels  = errors.New("invalid metrics upstream labels")
 )
 
 const NoopType = "noop"
@@ -69,6 +71,14 @@ func (f *metricsFactory) validateConfig(config *Config) error {
 		}
 	}
 
+	if err := ValidateDurationBuckets(config.DurationBuckets); err != nil {
+		return err
+	}
+
+	if err := ValidateUpstreamLabels(config.UpstreamLabels); err != nil {
+		return err
+	}
+
 	// 验证子系统格式（如果提供）
 	if config.Subsystem != "" {
 		for _, r := range config.Subsystem {
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// labelFilterGatherer 按标签值过滤指标样本的收集器包装
+type labelFilterGatherer struct {
+	gatherer   prometheus.Gatherer
+	labelName  string
+	labelValue string
+}
+
+// NewLabelFilterGatherer 创建按标签过滤的指标收集器
+// 只保留带有指定标签且标签值匹配的样本，没有匹配样本的指标族不会输出
+// gatherer: 被包装的指标收集器
+// labelName: 过滤使用的标签名称
+// labelValue: 需要保留的标签值
+func NewLabelFilterGatherer(gatherer prometheus.Gatherer, labelName, labelValue string) prometheus.Gatherer {
+	return &labelFilterGatherer{
+		gatherer:   gatherer,
+		labelName:  labelName,
+		labelValue: labelValue,
+	}
+}
+
+// Gather 收集指标并过滤出匹配标签的样本
+func (g *labelFilterGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.gatherer.Gather()
+
+	filtered := make([]*dto.MetricFamily, 0, len(families))
+	for _, family := range families {
+		metrics := make([]*dto.Metric, 0, len(family.GetMetric()))
+		for _, metric := range family.GetMetric() {
+			if g.matches(metric) {
+				metrics = append(metrics, metric)
+			}
+		}
+		if len(metrics) == 0 {
+			continue
+		}
+
+		filtered = append(filtered, &dto.MetricFamily{
+			Name:   family.Name,
+			Help:   family.Help,
+			Type:   family.Type,
+			Unit:   family.Unit,
+			Metric: metrics,
+		})
+	}
+
+	return filtered, err
+}
+
+// matches 判断样本是否带有匹配的标签值
+func (g *labelFilterGatherer) matches(metric *dto.Metric) bool {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == g.labelName {
+			return label.GetValue() == g.labelValue
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestLabelFilterGatherer 测试按标签值过滤指标样本
+func TestLabelFilterGatherer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "test_requests_total",
+		Help: "Test requests",
+	}, []string{LabelForwardName})
+	upstreams := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "test_upstream_requests_total",
+		Help: "Test upstream requests",
+	}, []string{LabelUpstreamName})
+	registry.MustRegister(requests, upstreams)
+
+	requests.WithLabelValues("forward-a").Inc()
+	requests.WithLabelValues("forward-b").Add(2)
+	upstreams.WithLabelValues("upstream-a").Inc()
+
+	families, err := NewLabelFilterGatherer(registry, LabelForwardName, "forward-a").Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+
+	if len(families) != 1 {
+		t.Fatalf("Expected 1 metric family, got %d", len(families))
+	}
+	if families[0].GetName() != "test_requests_total" {
+		t.Errorf("Expected test_requests_total, got %s", families[0].GetName())
+	}
+	if len(families[0].GetMetric()) != 1 {
+		t.Fatalf("Expected 1 sample, got %d", len(families[0].GetMetric()))
+	}
+	if value := families[0].GetMetric()[0].GetCounter().GetValue(); value != 1 {
+		t.Errorf("Expected counter value 1, got %v", value)
+	}
+
+	families, err = NewLabelFilterGatherer(registry, LabelForwardName, "missing").Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	if len(families) != 0 {
+		t.Errorf("Expected no metric families, got %d", len(families))
+	}
+}
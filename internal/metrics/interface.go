@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -10,21 +12,27 @@ import (
 type MetricsCollector interface {
 	// HTTP 服务器指标收集方法
 
-	// RecordRequest 记录 HTTP 请求
+	// RecordRequest 记录 HTTP 请求开始，进行中的请求数加一
 	// forwardName: 转发服务名称
 	// method: HTTP 方法
 	// path: 请求路径
 	RecordRequest(forwardName, method, path string)
 
+	// RecordRequestDone 记录 HTTP 请求结束，进行中的请求数减一，必须与 RecordRequest 成对调用
+	// forwardName: 转发服务名称
+	RecordRequestDone(forwardName string)
+
 	// RecordResponse 记录 HTTP 响应
 	// forwardName: 转发服务名称
 	// method: HTTP 方法
 	// path: 请求路径
 	// statusCode: HTTP 状态码
+	// stream: 是否为流式响应
+	// outcome: 请求处理结果，取值见 constants.Outcome* 常量
 	// duration: 请求处理时间
 	// requestSize: 请求体大小（字节）
 	// responseSize: 响应体大小（字节）
-	RecordResponse(forwardName, method, path string, statusCode int, duration time.Duration, requestSize, responseSize int64)
+	RecordResponse(forwardName, method, path string, statusCode int, stream bool, outcome string, duration time.Duration, requestSize, responseSize int64)
 
 	// RecordError 记录 HTTP 错误
 	// forwardName: 转发服务名称
@@ -44,8 +52,9 @@ type MetricsCollector interface {
 	// upstreamName: 上游服务名称
 	// method: HTTP 方法
 	// statusCode: HTTP 状态码
+	// stream: 是否为流式响应
 	// duration: 响应时间
-	RecordUpstreamResponse(upstreamGroup, upstreamName, method string, statusCode int, duration time.Duration)
+	RecordUpstreamResponse(upstreamGroup, upstreamName, method string, statusCode int, stream bool, duration time.Duration)
 
 	// RecordUpstreamError 记录上游错误
 	// upstreamGroup: 上游组名称
@@ -53,6 +62,19 @@ type MetricsCollector interface {
 	// errorType: 错误类型
 	RecordUpstreamError(upstreamGroup, upstreamName, errorType string)
 
+	// RecordUpstreamStatusRemap 记录上游状态码映射
+	// upstreamGroup: 上游组名称
+	// upstreamName: 上游服务名称
+	// originalStatus: 上游返回的原始状态码
+	// mappedStatus: 映射后返回给客户端的状态码
+	RecordUpstreamStatusRemap(upstreamGroup, upstreamName string, originalStatus, mappedStatus int)
+
+	// RecordUpstreamTimeToFirstByte 记录上游首字节时间
+	// upstreamGroup: 上游组名称
+	// upstreamName: 上游服务名称
+	// ttfb: 从发送上游请求到读取到第一个响应体字节的时间，非流式响应为收到响应的时间
+	RecordUpstreamTimeToFirstByte(upstreamGroup, upstreamName string, ttfb time.Duration)
+
 	// 断路器指标收集方法
 
 	// RecordCircuitBreakerState 记录断路器状态
@@ -88,6 +110,13 @@ type MetricsCollector interface {
 	// healthy: 健康状态（true=健康, false=不健康）
 	RecordUpstreamHealthStatus(upstreamGroup, upstreamName string, healthy bool)
 
+	// RecordUpstreamConnections 记录上游连接池的活跃和空闲连接数
+	// upstreamGroup: 上游组名称
+	// upstreamName: 上游服务名称
+	// active: 正在处理请求的连接数
+	// idle: 空闲连接数
+	RecordUpstreamConnections(upstreamGroup, upstreamName string, active, idle int)
+
 	// 系统级指标收集方法
 
 	// RecordActiveConnections 记录活跃连接数
@@ -97,8 +126,39 @@ type MetricsCollector interface {
 
 	// RecordRateLimitRejection 记录限流拒绝
 	// forwardName: 转发服务名称
-	// limitType: 限流类型（ip, global）
-	RecordRateLimitRejection(forwardName, limitType string)
+	// limitType: 限流类型（ip, header, token, upstream）
+	// rule: 命中的限流规则（路径前缀），转发服务级别限流为 default，上游限流为上游名称
+	RecordRateLimitRejection(forwardName, limitType, rule string)
+
+	// RecordIdempotencyHit 记录幂等键缓存命中（直接重放已缓存的响应）
+	// forwardName: 转发服务名称
+	RecordIdempotencyHit(forwardName string)
+
+	// RecordIdempotencyWait 记录等待进行中的同幂等键请求
+	// forwardName: 转发服务名称
+	RecordIdempotencyWait(forwardName string)
+
+	// SetConcurrencyLimit 设置转发服务的最大并发请求数，当前并发数见进行中的请求数指标
+	// forwardName: 转发服务名称
+	// limit: 最大并发请求数，0 表示不限制
+	SetConcurrencyLimit(forwardName string, limit int)
+
+	// RecordConcurrencyRejection 记录因达到最大并发请求数而拒绝的请求
+	// forwardName: 转发服务名称
+	RecordConcurrencyRejection(forwardName string)
+
+	// RecordRequestBodyTooLarge 记录因请求体超过大小限制而拒绝的请求
+	// forwardName: 转发服务名称
+	RecordRequestBodyTooLarge(forwardName string)
+
+	// SetUpstreamLabels 设置上游的运维标签，仅 Config.UpstreamLabels 中声明的标签会附加到上游请求指标
+	// upstreamName: 上游服务名称
+	// labels: 上游配置的标签，未声明的标签键被忽略，缺失的标签值为空字符串
+	SetUpstreamLabels(upstreamName string, labels map[string]string)
+
+	// RecordConfigReload 记录配置重新加载，成功时同时更新最近一次重新加载的时间
+	// success: 是否重新加载成功，失败时继续使用当前配置
+	RecordConfigReload(success bool)
 
 	// 工具方法
 
@@ -132,6 +192,64 @@ type Config struct {
 
 	// Subsystem 指标子系统名称
 	Subsystem string `yaml:"subsystem" json:"subsystem"`
+
+	// DurationBuckets 请求耗时直方图的桶边界（秒），为空时使用 DefaultDurationBuckets
+	DurationBuckets []float64 `yaml:"durationBuckets" json:"durationBuckets"`
+
+	// UpstreamLabels 附加到上游请求指标的上游标签键，为空时不附加，应只包含低基数的标签
+	UpstreamLabels []string `yaml:"upstreamLabels" json:"upstreamLabels"`
+}
+
+// DefaultDurationBuckets 默认的请求耗时直方图桶边界（秒），覆盖 LLM 请求常见的长耗时场景
+var DefaultDurationBuckets = []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60, 120}
+
+// ValidateDurationBuckets 验证耗时直方图桶边界必须为正数且严格递增，空列表视为有效
+func ValidateDurationBuckets(buckets []float64) error {
+	for i, bucket := range buckets {
+		if bucket <= 0 || (i > 0 && bucket <= buckets[i-1]) {
+			return ErrInvalidDurationBuckets
+		}
+	}
+	return nil
+}
+
+// ValidateUpstreamLabels 验证上游标签键必须是合法的 Prometheus 标签名，且不能与内置标签重名或重复
+func ValidateUpstreamLabels(labels []string) error {
+	seen := make(map[string]struct{}, len(labels))
+	for _, label := range labels {
+		if !isValidLabelName(label) {
+			return fmt.Errorf("%w: %q is not a valid label name", ErrInvalidUpstreamLabels, label)
+		}
+		if _, reserved := reservedUpstreamLabels[label]; reserved {
+			return fmt.Errorf("%w: %q conflicts with a built-in label", ErrInvalidUpstreamLabels, label)
+		}
+		if _, duplicated := seen[label]; duplicated {
+			return fmt.Errorf("%w: %q is duplicated", ErrInvalidUpstreamLabels, label)
+		}
+		seen[label] = struct{}{}
+	}
+	return nil
+}
+
+// isValidLabelName 判断是否为合法的 Prometheus 标签名，以双下划线开头的名称保留给 Prometheus 内部使用
+func isValidLabelName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "__") {
+		return false
+	}
+	for i, r := range name {
+		if !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_' || (i > 0 && r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// durationBuckets 获取配置的耗时直方图桶边界，未配置时返回默认值
+func (c *Config) durationBuckets() []float64 {
+	if len(c.DurationBuckets) == 0 {
+		return DefaultDurationBuckets
+	}
+	return c.DurationBuckets
 }
 
 // DefaultConfig 返回默认配置
@@ -24,7 +24,11 @@ func (c *noopCollector) RecordRequest(forwardName, method, path string) {
 	// 空实现
 }
 
-func (c *noopCollector) RecordResponse(forwardName, method, path string, statusCode int, duration time.Duration, requestSize, responseSize int64) {
+func (c *noopCollector) RecordRequestDone(forwardName string) {
+	// 空实现
+}
+
+func (c *noopCollector) RecordResponse(forwardName, method, path string, statusCode int, stream bool, outcome string, duration time.Duration, requestSize, responseSize int64) {
 	// 空实现
 }
 
@@ -38,7 +42,7 @@ func (c *noopCollector) RecordUpstreamRequest(upstreamGroup, upstreamName, metho
 	// 空实现
 }
 
-func (c *noopCollector) RecordUpstreamResponse(upstreamGroup, upstreamName, method string, statusCode int, duration time.Duration) {
+func (c *noopCollector) RecordUpstreamResponse(upstreamGroup, upstreamName, method string, statusCode int, stream bool, duration time.Duration) {
 	// 空实现
 }
 
@@ -46,6 +50,14 @@ func (c *noopCollector) RecordUpstreamError(upstreamGroup, upstreamName, errorTy
 	// 空实现
 }
 
+func (c *noopCollector) RecordUpstreamStatusRemap(upstreamGroup, upstreamName string, originalStatus, mappedStatus int) {
+	// 空实现
+}
+
+func (c *noopCollector) RecordUpstreamTimeToFirstByte(upstreamGroup, upstreamName string, ttfb time.Duration) {
+	// 空实现
+}
+
 // 断路器指标收集方法（空实现）
 
 func (c *noopCollector) RecordCircuitBreakerState(upstreamGroup, upstreamName string, state int) {
@@ -70,13 +82,45 @@ func (c *noopCollector) RecordUpstreamHealthStatus(upstreamGroup, upstreamName s
 	// 空实现
 }
 
+func (c *noopCollector) RecordUpstreamConnections(upstreamGroup, upstreamName string, active, idle int) {
+	// 空实现
+}
+
 // 系统级指标收集方法（空实现）
 
 func (c *noopCollector) RecordActiveConnections(forwardName string, connections int) {
 	// 空实现
 }
 
-func (c *noopCollector) RecordRateLimitRejection(forwardName, limitType string) {
+func (c *noopCollector) RecordRateLimitRejection(forwardName, limitType, rule string) {
+	// 空实现
+}
+
+func (c *noopCollector) RecordIdempotencyHit(forwardName string) {
+	// 空实现
+}
+
+func (c *noopCollector) RecordIdempotencyWait(forwardName string) {
+	// 空实现
+}
+
+func (c *noopCollector) SetConcurrencyLimit(forwardName string, limit int) {
+	// 空实现
+}
+
+func (c *noopCollector) RecordConcurrencyRejection(forwardName string) {
+	// 空实现
+}
+
+func (c *noopCollector) RecordRequestBodyTooLarge(forwardName string) {
+	// 空实现
+}
+
+func (c *noopCollector) SetUpstreamLabels(upstreamName string, labels map[string]string) {
+	// 空实现
+}
+
+func (c *noopCollector) RecordConfigReload(success bool) {
 	// 空实现
 }
 
@@ -7,9 +7,13 @@ import (
 	"github.com/shengyanli1982/llmproxy-go/internal/constants"
 )
 
+// ClientIPFunc 解析请求的客户端 IP，用于 IP 级别的限流键
+type ClientIPFunc func(req *http.Request) string
+
 // IPLimiter IP级别限流器
 type IPLimiter struct {
-	limiter RateLimiter
+	limiter  RateLimiter
+	clientIP ClientIPFunc // 客户端 IP 解析函数，为 nil 时使用 getClientIP
 }
 
 // NewIPLimiter 创建新的IP限流器实例
@@ -19,9 +23,15 @@ func NewIPLimiter(perSecond float64, burst int) *IPLimiter {
 	}
 }
 
+// SetClientIPFunc 设置客户端 IP 解析函数，如按可信代理解析转发头部
+// 需在开始处理请求前设置
+func (l *IPLimiter) SetClientIPFunc(fn ClientIPFunc) {
+	l.clientIP = fn
+}
+
 // Allow 检查请求IP是否允许通过
 func (l *IPLimiter) Allow(req *http.Request) bool {
-	ip := l.getClientIP(req)
+	ip := l.resolveClientIP(req)
 	if ip == "" {
 		return true // 无法获取IP时默认通过
 	}
@@ -34,6 +44,14 @@ func (l *IPLimiter) Reset(ip string) {
 	l.limiter.Reset(ip)
 }
 
+// resolveClientIP 获取限流使用的客户端 IP，优先使用设置的解析函数
+func (l *IPLimiter) resolveClientIP(req *http.Request) string {
+	if l.clientIP != nil {
+		return l.clientIP(req)
+	}
+	return l.getClientIP(req)
+}
+
 // getClientIP 从HTTP请求中获取客户端真实IP地址
 func (l *IPLimiter) getClientIP(req *http.Request) string {
 	// 优先检查X-Forwarded-For头部
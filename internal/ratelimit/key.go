@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// bearerPrefix Authorization 头部中 Bearer Token 的前缀
+const bearerPrefix = "bearer "
+
+// KeyLimiter 按 API Key / Token 限流的限流器
+// 限流键为头部值的哈希，原始凭据不会出现在限流器状态或日志中
+// 请求未携带对应凭据时回退到 IP 级别限流
+type KeyLimiter struct {
+	limiter  RateLimiter
+	fallback *IPLimiter
+	keyBy    string
+	header   string
+}
+
+// NewKeyLimiter 创建新的按键限流器实例
+// keyBy: 限流键类型，header 或 token
+// header: keyBy 为 header 时读取的请求头部名称，为空时使用 Authorization
+func NewKeyLimiter(perSecond float64, burst int, keyBy, header string) *KeyLimiter {
+	return &KeyLimiter{
+		limiter:  NewTokenBucketLimiter(perSecond, burst),
+		fallback: NewIPLimiter(perSecond, burst),
+		keyBy:    keyBy,
+		header:   keyHeader(keyBy, header),
+	}
+}
+
+// Allow 检查请求的 API Key / Token 是否允许通过
+func (l *KeyLimiter) Allow(req *http.Request) bool {
+	key := l.Key(req)
+	if key == "" {
+		return l.fallback.Allow(req) // 无凭据时按 IP 限流
+	}
+
+	return l.limiter.Allow(key)
+}
+
+// SetClientIPFunc 设置无凭据请求回退 IP 限流时使用的客户端 IP 解析函数
+func (l *KeyLimiter) SetClientIPFunc(fn ClientIPFunc) {
+	l.fallback.SetClientIPFunc(fn)
+}
+
+// Reset 重置指定键的限流状态，key 为 Key 返回的哈希值
+func (l *KeyLimiter) Reset(key string) {
+	l.limiter.Reset(key)
+}
+
+// ResetIP 重置无凭据请求回退 IP 限流时指定IP的限流状态
+func (l *KeyLimiter) ResetIP(ip string) {
+	l.fallback.Reset(ip)
+}
+
+// Key 返回请求的限流键（凭据哈希），无凭据时返回空字符串
+func (l *KeyLimiter) Key(req *http.Request) string {
+	return credentialKey(req, l.keyBy, l.header)
+}
+
+// keyHeader 返回限流键读取的请求头部名称，token 模式固定读取 Authorization
+func keyHeader(keyBy, header string) string {
+	if header == "" || keyBy == constants.RateLimitKeyByToken {
+		return constants.HeaderAuthorization
+	}
+	return header
+}
+
+// credentialKey 从请求头部提取凭据并返回其哈希，无凭据时返回空字符串
+func credentialKey(req *http.Request, keyBy, header string) string {
+	value := strings.TrimSpace(req.Header.Get(header))
+	if keyBy == constants.RateLimitKeyByToken {
+		if len(value) < len(bearerPrefix) || !strings.EqualFold(value[:len(bearerPrefix)], bearerPrefix) {
+			return ""
+		}
+		value = strings.TrimSpace(value[len(bearerPrefix):])
+	}
+	if value == "" {
+		return ""
+	}
+
+	return hashKey(value)
+}
+
+// hashKey 计算凭据的 SHA-256 哈希，取前 16 字节的十六进制表示作为限流键
+func hashKey(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:16])
+}
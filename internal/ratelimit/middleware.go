@@ -2,25 +2,96 @@ package ratelimit
 
 import (
 	"net/http"
+	"sort"
 
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
 	"github.com/shengyanli1982/llmproxy-go/internal/response"
 	"github.com/shengyanli1982/orbit"
 )
 
-// RateLimitMiddleware 限流中间件结构
+// RateLimitMiddleware 限流中间件结构，负责客户端级别限流（按 IP 或 API Key / Token）
+// 支持按路径前缀配置覆盖规则，请求使用最具体（前缀最长）的匹配规则，未匹配时使用转发服务级别限流
+// 上游级别限流由各上游的 UpstreamLimiter 独立完成（见 UpstreamConfig.RateLimit）
 type RateLimitMiddleware struct {
-	ipLimiter       *IPLimiter
-	upstreamLimiter *UpstreamLimiter
-	enabled         bool
+	defaultLimiter *ruleLimiter   // 转发服务级别限流，未配置时为 nil
+	rules          []*ruleLimiter // 路径规则限流，按前缀长度降序排列
+	keyBy          string
+	header         string
+	clientIP       ClientIPFunc // IP 限流使用的客户端 IP 解析函数，为 nil 时使用默认解析
+	enabled        bool
 }
 
-// NewRateLimitMiddleware 创建新的限流中间件实例
-func NewRateLimitMiddleware(ipPerSecond float64, ipBurst int, upstreamPerSecond float64, upstreamBurst int) *RateLimitMiddleware {
+// NewRateLimitMiddleware 创建新的 IP 限流中间件实例
+func NewRateLimitMiddleware(ipPerSecond float64, ipBurst int) *RateLimitMiddleware {
+	return NewRateLimitMiddlewareWithKey(ipPerSecond, ipBurst, constants.RateLimitKeyByIP, "")
+}
+
+// NewRateLimitMiddlewareWithKey 创建指定限流键类型的限流中间件实例
+// keyBy: 限流键类型，ip、header 或 token，未知类型按 ip 处理
+// header: keyBy 为 header 时读取的请求头部名称
+func NewRateLimitMiddlewareWithKey(perSecond float64, burst int, keyBy, header string) *RateLimitMiddleware {
+	m := NewRuleRateLimitMiddleware(keyBy, header)
+	m.defaultLimiter = newRuleLimiter("", perSecond, burst, m.keyBy, m.header)
+	return m
+}
+
+// NewRuleRateLimitMiddleware 创建不含转发服务级别限流的中间件实例，仅对匹配路径规则的请求限流
+// 规则通过 AddRule 添加
+func NewRuleRateLimitMiddleware(keyBy, header string) *RateLimitMiddleware {
+	if !isKeyedMode(keyBy) {
+		keyBy = constants.RateLimitKeyByIP
+	}
 	return &RateLimitMiddleware{
-		ipLimiter:       NewIPLimiter(ipPerSecond, ipBurst),
-		upstreamLimiter: NewUpstreamLimiter(upstreamPerSecond, upstreamBurst),
-		enabled:         true,
+		keyBy:   keyBy,
+		header:  keyHeader(keyBy, header),
+		enabled: true,
+	}
+}
+
+// AddRule 添加按路径前缀覆盖的限流规则，规则与中间件使用相同的限流键类型
+func (m *RateLimitMiddleware) AddRule(pathPrefix string, perSecond float64, burst int) {
+	rule := newRuleLimiter(pathPrefix, perSecond, burst, m.keyBy, m.header)
+	if m.clientIP != nil {
+		rule.setClientIPFunc(m.clientIP)
+	}
+	m.rules = append(m.rules, rule)
+	sort.SliceStable(m.rules, func(i, j int) bool {
+		return len(m.rules[i].pathPrefix) > len(m.rules[j].pathPrefix)
+	})
+}
+
+// SetClientIPFunc 设置所有规则 IP 限流使用的客户端 IP 解析函数，之后添加的规则同样使用该函数
+// 需在开始处理请求前设置
+func (m *RateLimitMiddleware) SetClientIPFunc(fn ClientIPFunc) {
+	m.clientIP = fn
+	for _, limiter := range m.limiters() {
+		limiter.setClientIPFunc(fn)
+	}
+}
+
+// MatchRule 获取请求路径匹配的规则名称（路径前缀），未匹配任何规则时返回 DefaultRuleName
+func (m *RateLimitMiddleware) MatchRule(path string) string {
+	for _, rule := range m.rules {
+		if rule.matches(path) {
+			return rule.pathPrefix
+		}
 	}
+	return DefaultRuleName
+}
+
+// limiterFor 获取请求路径对应的限流器，未匹配规则时返回转发服务级别限流器（可能为 nil）
+func (m *RateLimitMiddleware) limiterFor(path string) *ruleLimiter {
+	for _, rule := range m.rules {
+		if rule.matches(path) {
+			return rule
+		}
+	}
+	return m.defaultLimiter
+}
+
+// isKeyedMode 检查限流键类型是否为按凭据限流
+func isKeyedMode(keyBy string) bool {
+	return keyBy == constants.RateLimitKeyByHeader || keyBy == constants.RateLimitKeyByToken
 }
 
 // Middleware 返回orbit中间件函数
@@ -31,33 +102,19 @@ func (m *RateLimitMiddleware) Middleware() orbit.HandlerFunc {
 			return
 		}
 
-		// IP级别限流检查
-		if !m.ipLimiter.Allow(c.Request) {
+		// 客户端级别限流检查
+		if !m.AllowRequest(c.Request) {
 			detail := map[string]interface{}{
-				"type": "ipLimit",
+				"type": m.keyBy + "Limit",
+				"rule": m.MatchRule(c.Request.URL.Path),
 			}
-			response.Error(response.CodeRateLimit, "too many requests from this IP").
+			response.Error(response.CodeRateLimit, m.RejectMessage(c.Request)).
 				WithDetail(detail).
 				JSON(c, http.StatusTooManyRequests)
 			c.Abort()
 			return
 		}
 
-		// 上游级别限流检查（如果有指定上游）
-		if upstream := c.GetString("upstream"); upstream != "" {
-			if !m.upstreamLimiter.Allow(upstream) {
-				detail := map[string]interface{}{
-					"type":     "upstreamLimit",
-					"upstream": upstream,
-				}
-				response.Error(response.CodeUpstreamLimit, "too many requests to this upstream").
-					WithDetail(detail).
-					JSON(c, http.StatusTooManyRequests)
-				c.Abort()
-				return
-			}
-		}
-
 		c.Next()
 	}
 }
@@ -77,28 +134,58 @@ func (m *RateLimitMiddleware) IsEnabled() bool {
 	return m.enabled
 }
 
-// ResetIP 重置指定IP的限流状态
+// ResetIP 重置指定IP在所有规则下的限流状态
 func (m *RateLimitMiddleware) ResetIP(ip string) {
-	m.ipLimiter.Reset(ip)
+	for _, limiter := range m.limiters() {
+		limiter.resetIP(ip)
+	}
 }
 
-// ResetUpstream 重置指定上游的限流状态
-func (m *RateLimitMiddleware) ResetUpstream(upstream string) {
-	m.upstreamLimiter.Reset(upstream)
+// ResetKey 重置指定限流键（凭据哈希）在所有规则下的限流状态，IP 模式下无效果
+func (m *RateLimitMiddleware) ResetKey(key string) {
+	for _, limiter := range m.limiters() {
+		limiter.resetKey(key)
+	}
 }
 
-// AllowRequest 检查HTTP请求是否允许通过（IP级别限流）
-func (m *RateLimitMiddleware) AllowRequest(req *http.Request) bool {
-	if !m.enabled {
-		return true
+// limiters 获取所有限流器，包括转发服务级别限流器和路径规则限流器
+func (m *RateLimitMiddleware) limiters() []*ruleLimiter {
+	limiters := make([]*ruleLimiter, 0, len(m.rules)+1)
+	if m.defaultLimiter != nil {
+		limiters = append(limiters, m.defaultLimiter)
 	}
-	return m.ipLimiter.Allow(req)
+	return append(limiters, m.rules...)
 }
 
-// AllowUpstream 检查指定上游是否允许通过（上游级别限流）
-func (m *RateLimitMiddleware) AllowUpstream(upstream string) bool {
+// KeyBy 获取限流键类型
+func (m *RateLimitMiddleware) KeyBy() string {
+	return m.keyBy
+}
+
+// RequestKey 获取请求的限流键（凭据哈希），IP 模式或无凭据时返回空字符串
+func (m *RateLimitMiddleware) RequestKey(req *http.Request) string {
+	if !isKeyedMode(m.keyBy) {
+		return ""
+	}
+	return credentialKey(req, m.keyBy, m.header)
+}
+
+// RejectMessage 获取请求被限流时的错误信息，无凭据回退 IP 限流的请求按 IP 提示
+func (m *RateLimitMiddleware) RejectMessage(req *http.Request) string {
+	if m.RequestKey(req) != "" {
+		return "too many requests for this API key"
+	}
+	return "too many requests from this IP"
+}
+
+// AllowRequest 检查HTTP请求是否允许通过，按请求路径匹配的规则及限流键类型进行限流
+func (m *RateLimitMiddleware) AllowRequest(req *http.Request) bool {
 	if !m.enabled {
 		return true
 	}
-	return m.upstreamLimiter.Allow(upstream)
+	limiter := m.limiterFor(req.URL.Path)
+	if limiter == nil {
+		return true // 未配置转发服务级别限流且未匹配任何规则
+	}
+	return limiter.allow(req)
 }
@@ -1,6 +1,7 @@
 package ratelimit
 
 import (
+	"net/http"
 	"net/http/httptest"
 	"sync"
 	"testing"
@@ -155,6 +156,145 @@ func TestIPLimiter_Reset(t *testing.T) {
 	assert.True(t, limiter.Allow(req))
 }
 
+func TestKeyLimiter_MultipleKeysBehindOneIP(t *testing.T) {
+	tests := []struct {
+		name   string
+		keyBy  string
+		header string
+		value  func(key string) string
+	}{
+		{
+			name:  "token",
+			keyBy: "token",
+			value: func(key string) string { return "Bearer " + key },
+		},
+		{
+			name:   "custom header",
+			keyBy:  "header",
+			header: "X-API-Key",
+			value:  func(key string) string { return key },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewKeyLimiter(1.0, 1, tt.keyBy, tt.header)
+			headerName := tt.header
+			if headerName == "" {
+				headerName = "Authorization"
+			}
+
+			reqA := httptest.NewRequest("GET", "/test", nil)
+			reqA.RemoteAddr = "203.0.113.10:12345"
+			reqA.Header.Set(headerName, tt.value("sk-client-a"))
+
+			reqB := httptest.NewRequest("GET", "/test", nil)
+			reqB.RemoteAddr = "203.0.113.10:23456"
+			reqB.Header.Set(headerName, tt.value("sk-client-b"))
+
+			// 同一 IP 下不同凭据拥有独立的令牌桶
+			assert.True(t, limiter.Allow(reqA))
+			assert.False(t, limiter.Allow(reqA))
+			assert.True(t, limiter.Allow(reqB))
+			assert.False(t, limiter.Allow(reqB))
+
+			// 限流键为凭据哈希，不包含原始凭据
+			keyA := limiter.Key(reqA)
+			assert.NotEmpty(t, keyA)
+			assert.NotContains(t, keyA, "sk-client-a")
+			assert.NotEqual(t, keyA, limiter.Key(reqB))
+
+			// 重置后恢复访问
+			limiter.Reset(keyA)
+			assert.True(t, limiter.Allow(reqA))
+		})
+	}
+}
+
+func TestKeyLimiter_FallbackToIP(t *testing.T) {
+	limiter := NewKeyLimiter(1.0, 1, "token", "")
+
+	// 非 Bearer 凭据和缺失凭据均回退到 IP 限流
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.RemoteAddr = "192.168.1.1:12345"
+	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	assert.Empty(t, limiter.Key(req))
+
+	assert.True(t, limiter.Allow(req))
+	req.Header.Del("Authorization")
+	assert.False(t, limiter.Allow(req))
+
+	// 其他 IP 不受影响
+	other := httptest.NewRequest("GET", "/test", nil)
+	other.RemoteAddr = "192.168.1.2:12345"
+	assert.True(t, limiter.Allow(other))
+}
+
+func TestRateLimitMiddleware_KeyBy(t *testing.T) {
+	assert.Equal(t, "ip", NewRateLimitMiddleware(1.0, 1).KeyBy())
+	assert.Equal(t, "ip", NewRateLimitMiddlewareWithKey(1.0, 1, "unknown", "").KeyBy())
+
+	middleware := NewRateLimitMiddlewareWithKey(1.0, 1, "token", "")
+	assert.Equal(t, "token", middleware.KeyBy())
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.RemoteAddr = "192.168.1.1:12345"
+	req.Header.Set("Authorization", "Bearer sk-client-a")
+	assert.Equal(t, "too many requests for this API key", middleware.RejectMessage(req))
+
+	assert.True(t, middleware.AllowRequest(req))
+	assert.False(t, middleware.AllowRequest(req))
+	middleware.ResetKey(middleware.RequestKey(req))
+	assert.True(t, middleware.AllowRequest(req))
+}
+
+func TestRateLimitMiddleware_Rules(t *testing.T) {
+	middleware := NewRateLimitMiddleware(100.0, 100)
+	middleware.AddRule("/v1", 10.0, 2)
+	middleware.AddRule("/v1/chat/completions", 1.0, 1)
+
+	// 最具体的规则优先匹配，与添加顺序无关
+	assert.Equal(t, "/v1/chat/completions", middleware.MatchRule("/v1/chat/completions"))
+	assert.Equal(t, "/v1", middleware.MatchRule("/v1/models"))
+	assert.Equal(t, DefaultRuleName, middleware.MatchRule("/health"))
+
+	newRequest := func(path string) *http.Request {
+		req := httptest.NewRequest("POST", path, nil)
+		req.RemoteAddr = "192.168.1.1:12345"
+		return req
+	}
+
+	// 各规则使用独立的限流器
+	assert.True(t, middleware.AllowRequest(newRequest("/v1/chat/completions")))
+	assert.False(t, middleware.AllowRequest(newRequest("/v1/chat/completions")))
+	assert.True(t, middleware.AllowRequest(newRequest("/v1/models")))
+	assert.True(t, middleware.AllowRequest(newRequest("/v1/models")))
+	assert.False(t, middleware.AllowRequest(newRequest("/v1/models")))
+	assert.True(t, middleware.AllowRequest(newRequest("/health")))
+
+	// 重置 IP 作用于所有规则
+	middleware.ResetIP("192.168.1.1")
+	assert.True(t, middleware.AllowRequest(newRequest("/v1/chat/completions")))
+	assert.True(t, middleware.AllowRequest(newRequest("/v1/models")))
+}
+
+func TestRateLimitMiddleware_RulesOnly(t *testing.T) {
+	middleware := NewRuleRateLimitMiddleware("ip", "")
+	middleware.AddRule("/v1/chat/completions", 1.0, 1)
+
+	req := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+	req.RemoteAddr = "192.168.1.1:12345"
+	assert.True(t, middleware.AllowRequest(req))
+	assert.False(t, middleware.AllowRequest(req))
+
+	// 未匹配规则且无转发服务级别限流时不限流
+	other := httptest.NewRequest("GET", "/v1/models", nil)
+	other.RemoteAddr = "192.168.1.1:12345"
+	for i := 0; i < 5; i++ {
+		assert.True(t, middleware.AllowRequest(other))
+	}
+}
+
 func TestUpstreamLimiter_Allow(t *testing.T) {
 	limiter := NewUpstreamLimiter(2.0, 3)
 
@@ -246,7 +386,7 @@ func TestRateLimitFactory_Create(t *testing.T) {
 }
 
 func TestRateLimitMiddleware(t *testing.T) {
-	middleware := NewRateLimitMiddleware(1.0, 1, 1.0, 1)
+	middleware := NewRateLimitMiddleware(1.0, 1)
 
 	// Test that middleware is enabled by default
 	assert.True(t, middleware.IsEnabled())
@@ -260,11 +400,10 @@ func TestRateLimitMiddleware(t *testing.T) {
 }
 
 func TestRateLimitMiddleware_ResetMethods(t *testing.T) {
-	middleware := NewRateLimitMiddleware(1.0, 1, 1.0, 1)
+	middleware := NewRateLimitMiddleware(1.0, 1)
 
 	// Test reset methods don't panic
 	middleware.ResetIP("192.168.1.1")
-	middleware.ResetUpstream("upstream1")
 }
 
 func TestDefaultConfig(t *testing.T) {
@@ -348,7 +487,7 @@ func BenchmarkIPLimiter_Allow(b *testing.B) {
 }
 
 func TestRateLimitMiddleware_Middleware(t *testing.T) {
-	middleware := NewRateLimitMiddleware(1.0, 1, 1.0, 1)
+	middleware := NewRateLimitMiddleware(1.0, 1)
 
 	t.Run("middleware creation", func(t *testing.T) {
 		handler := middleware.Middleware()
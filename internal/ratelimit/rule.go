@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"net/http"
+	"strings"
+)
+
+// DefaultRuleName 未匹配任何路径规则时使用的规则名称（转发服务级别限流）
+const DefaultRuleName = "default"
+
+// ruleLimiter 代表单条限流规则对应的客户端限流器
+type ruleLimiter struct {
+	pathPrefix string
+	ipLimiter  *IPLimiter  // IP 模式下使用
+	keyLimiter *KeyLimiter // 按 API Key / Token 限流时使用，IP 模式下为 nil
+}
+
+// newRuleLimiter 创建限流规则对应的客户端限流器
+// pathPrefix: 规则匹配的路径前缀，转发服务级别限流为空字符串
+func newRuleLimiter(pathPrefix string, perSecond float64, burst int, keyBy, header string) *ruleLimiter {
+	if isKeyedMode(keyBy) {
+		return &ruleLimiter{
+			pathPrefix: pathPrefix,
+			keyLimiter: NewKeyLimiter(perSecond, burst, keyBy, header),
+		}
+	}
+	return &ruleLimiter{
+		pathPrefix: pathPrefix,
+		ipLimiter:  NewIPLimiter(perSecond, burst),
+	}
+}
+
+// allow 检查请求是否允许通过
+func (r *ruleLimiter) allow(req *http.Request) bool {
+	if r.keyLimiter != nil {
+		return r.keyLimiter.Allow(req)
+	}
+	return r.ipLimiter.Allow(req)
+}
+
+// setClientIPFunc 设置 IP 限流使用的客户端 IP 解析函数
+func (r *ruleLimiter) setClientIPFunc(fn ClientIPFunc) {
+	if r.keyLimiter != nil {
+		r.keyLimiter.SetClientIPFunc(fn)
+		return
+	}
+	r.ipLimiter.SetClientIPFunc(fn)
+}
+
+// resetIP 重置指定IP的限流状态
+func (r *ruleLimiter) resetIP(ip string) {
+	if r.keyLimiter != nil {
+		r.keyLimiter.ResetIP(ip)
+		return
+	}
+	r.ipLimiter.Reset(ip)
+}
+
+// resetKey 重置指定限流键（凭据哈希）的限流状态，IP 模式下无效果
+func (r *ruleLimiter) resetKey(key string) {
+	if r.keyLimiter != nil {
+		r.keyLimiter.Reset(key)
+	}
+}
+
+// matches 检查请求路径是否匹配规则前缀
+func (r *ruleLimiter) matches(path string) bool {
+	return strings.HasPrefix(path, r.pathPrefix)
+}
@@ -234,3 +234,48 @@ func BenchmarkResponseBuilder_Concurrent(b *testing.B) {
 		}
 	})
 }
+
+// TestOpenAIErrorFormat 测试 OpenAI 风格错误响应格式
+func TestOpenAIErrorFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Error Type Mapping", func(t *testing.T) {
+		tests := []struct {
+			code     int64
+			expected string
+		}{
+			{CodeBadRequest, OpenAIErrorTypeInvalidRequest},
+			{CodeUnauthorized, OpenAIErrorTypeAuthentication},
+			{CodeForbidden, OpenAIErrorTypePermission},
+			{CodeNotFound, OpenAIErrorTypeNotFound},
+			{CodeRateLimit, OpenAIErrorTypeRateLimit},
+			{CodeUpstreamLimit, OpenAIErrorTypeRateLimit},
+			{CodeServiceUnavailable, OpenAIErrorTypeServer},
+			{CodeGatewayTimeout, OpenAIErrorTypeServer},
+			{CodeCircuitBreaker, OpenAIErrorTypeServer},
+		}
+
+		for _, tt := range tests {
+			assert.Equal(t, tt.expected, OpenAIErrorType(tt.code))
+		}
+	})
+
+	t.Run("Error Response Body", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		OpenAIError(c, http.StatusGatewayTimeout, CodeGatewayTimeout, "Upstream request timed out")
+
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+		var body map[string]map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "Upstream request timed out", body["error"]["message"])
+		assert.Equal(t, OpenAIErrorTypeServer, body["error"]["type"])
+		assert.Equal(t, "2003", body["error"]["code"])
+		assert.Contains(t, body["error"], "param")
+		assert.Nil(t, body["error"]["param"])
+	})
+}
@@ -0,0 +1,59 @@
+package response
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAI 风格错误类型
+const (
+	OpenAIErrorTypeInvalidRequest = "invalid_request_error"
+	OpenAIErrorTypeAuthentication = "authentication_error"
+	OpenAIErrorTypePermission     = "permission_error"
+	OpenAIErrorTypeNotFound       = "not_found_error"
+	OpenAIErrorTypeRateLimit      = "rate_limit_error"
+	OpenAIErrorTypeServer         = "server_error"
+)
+
+// OpenAIErrorResponse 代表 OpenAI 风格的错误响应，格式为 {"error": {...}}
+type OpenAIErrorResponse struct {
+	Error OpenAIErrorBody `json:"error"`
+}
+
+// OpenAIErrorBody 代表 OpenAI 风格错误响应的错误体
+type OpenAIErrorBody struct {
+	Message string  `json:"message"`
+	Type    string  `json:"type"`
+	Param   *string `json:"param"`
+	Code    string  `json:"code"`
+}
+
+// OpenAIErrorType 将内部响应代码映射为 OpenAI 错误类型
+func OpenAIErrorType(code int64) string {
+	switch code {
+	case CodeBadRequest:
+		return OpenAIErrorTypeInvalidRequest
+	case CodeUnauthorized:
+		return OpenAIErrorTypeAuthentication
+	case CodeForbidden:
+		return OpenAIErrorTypePermission
+	case CodeNotFound:
+		return OpenAIErrorTypeNotFound
+	case CodeRateLimit, CodeUpstreamLimit:
+		return OpenAIErrorTypeRateLimit
+	default:
+		return OpenAIErrorTypeServer
+	}
+}
+
+// OpenAIError 以 OpenAI 风格输出错误响应，内部响应代码保留在 code 字段中
+func OpenAIError(c *gin.Context, httpStatus int, code int64, message string) {
+	c.JSON(httpStatus, &OpenAIErrorResponse{
+		Error: OpenAIErrorBody{
+			Message: message,
+			Type:    OpenAIErrorType(code),
+			Code:    strconv.FormatInt(code, 10),
+		},
+	})
+}
@@ -38,7 +38,8 @@ func NewAdminServer(debug bool, logger *logr.Logger, config *config.AdminConfig,
 		WithHttpIdleTimeout(uint32(config.Timeout.Idle)). // 配置提供的单位是毫秒，直接使用
 		WithHttpReadHeaderTimeout(uint32(config.Timeout.Read)).
 		WithHttpReadTimeout(uint32(config.Timeout.Read)).
-		WithHttpWriteTimeout(uint32(config.Timeout.Write))
+		WithHttpWriteTimeout(uint32(config.Timeout.Write)).
+		WithMaxHeaderBytes(maxHeaderBytes(globalConfig))
 
 	// 创建引擎选项
 	opts := orbit.NewOptions().EnablePProf().EnableSwagger()
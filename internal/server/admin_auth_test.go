@@ -0,0 +1,133 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/response"
+	"github.com/shengyanli1982/toolkit/pkg/httptool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAdminAuthTestRouter 创建挂载了管理服务路由的测试路由器
+func newAdminAuthTestRouter(t *testing.T, adminConfig *config.AdminConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	logger := logr.Discard()
+	globalConfig := &config.Config{
+		HTTPServer: config.HTTPServerConfig{
+			Admin: *adminConfig,
+		},
+	}
+
+	service := NewAdminServices()
+	service.Initialize(adminConfig, globalConfig, &logger, nil)
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+	require.NotNil(t, router)
+
+	return router
+}
+
+// TestAdminService_AuthMiddleware 测试管理接口认证中间件
+func TestAdminService_AuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		auth           *config.AuthConfig
+		publicPaths    []string
+		authorization  string
+		expectedStatus int
+	}{
+		{
+			name:           "no auth configured",
+			auth:           nil,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "auth type none",
+			auth:           &config.AuthConfig{Type: "none"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "bearer missing header",
+			auth:           &config.AuthConfig{Type: "bearer", Token: "admin-token"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "bearer wrong token",
+			auth:           &config.AuthConfig{Type: "bearer", Token: "admin-token"},
+			authorization:  "Bearer wrong-token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "bearer valid token",
+			auth:           &config.AuthConfig{Type: "bearer", Token: "admin-token"},
+			authorization:  "Bearer admin-token",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "basic valid credentials",
+			auth:           &config.AuthConfig{Type: "basic", Username: "user", Password: "pass"},
+			authorization:  "Basic dXNlcjpwYXNz", // base64("user:pass")
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "basic wrong credentials",
+			auth:           &config.AuthConfig{Type: "basic", Username: "user", Password: "pass"},
+			authorization:  "Basic dXNlcjp3cm9uZw==", // base64("user:wrong")
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "public path skips auth",
+			auth:           &config.AuthConfig{Type: "bearer", Token: "admin-token"},
+			publicPaths:    []string{"/metrics"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unrelated public path does not exempt metrics",
+			auth:           &config.AuthConfig{Type: "bearer", Token: "admin-token"},
+			publicPaths:    []string{"/health"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "invalid auth config rejects requests",
+			auth:           &config.AuthConfig{Type: "bearer"},
+			authorization:  "Bearer ",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newAdminAuthTestRouter(t, &config.AdminConfig{
+				Address:     "127.0.0.1",
+				Port:        9000,
+				Auth:        tt.auth,
+				PublicPaths: tt.publicPaths,
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedStatus == http.StatusUnauthorized {
+				var resp httptool.BaseHttpResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, int64(response.CodeUnauthorized), resp.Code)
+				assert.NotEmpty(t, resp.ErrorMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdminService_BalanceStats 测试负载均衡选择分布端点
+func TestAdminService_BalanceStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstreamServer.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "upstream-a", URL: upstreamServer.URL},
+			{Name: "upstream-b", URL: upstreamServer.URL},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{{
+			Name:      "rr-group",
+			Upstreams: []config.UpstreamRefConfig{{Name: "upstream-a"}, {Name: "upstream-b"}},
+			Balance:   &config.BalanceConfig{Strategy: "roundrobin"},
+		}},
+	}
+	forwardConfig := &config.ForwardConfig{Name: "balance-forward", DefaultGroup: "rr-group"}
+	forwardService := NewForwardServices()
+	require.NoError(t, forwardService.Initialize(forwardConfig, globalConfig, &logger))
+	defer forwardService.Stop()
+
+	forwardRouter := gin.New()
+	forwardService.RegisterGroup(forwardRouter.Group("/"))
+	for i := 0; i < 10; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`))
+		w := httptest.NewRecorder()
+		forwardRouter.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	srv := &Server{
+		forwardServers: map[string]*ForwardServer{
+			forwardConfig.Name: {config: forwardConfig, service: forwardService},
+		},
+		logger: &logger,
+	}
+	adminService := NewAdminServices()
+	adminService.Initialize(&config.AdminConfig{Address: "127.0.0.1", Port: 9000}, &config.Config{}, &logger, srv)
+	adminRouter := gin.New()
+	adminService.RegisterGroup(adminRouter.Group("/"))
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/balance/stats", nil)
+	w := httptest.NewRecorder()
+	adminRouter.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data struct {
+			Forwards []forwardBalanceStats `json:"forwards"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data.Forwards, 1)
+	assert.Equal(t, "balance-forward", resp.Data.Forwards[0].Name)
+	assert.Equal(t, []groupSelectionStats{{
+		Name:           "rr-group",
+		Balancer:       "roundrobin",
+		Total:          10,
+		ImbalanceRatio: 1,
+		Upstreams: []upstreamSelectionStats{
+			{Name: "upstream-a", Weight: 1, Selections: 5},
+			{Name: "upstream-b", Weight: 1, Selections: 5},
+		},
+	}}, resp.Data.Forwards[0].Groups)
+}
+
+// TestImbalanceRatio 测试选择次数不均衡比例的计算
+func TestImbalanceRatio(t *testing.T) {
+	tests := []struct {
+		name      string
+		upstreams []upstreamSelectionStats
+		expected  float64
+	}{
+		{name: "no selections", upstreams: []upstreamSelectionStats{{Name: "a"}, {Name: "b"}}, expected: 0},
+		{name: "balanced", upstreams: []upstreamSelectionStats{{Name: "a", Selections: 50}, {Name: "b", Selections: 50}}, expected: 1},
+		{name: "skewed", upstreams: []upstreamSelectionStats{{Name: "a", Selections: 90}, {Name: "b", Selections: 30}}, expected: 3},
+		{name: "stuck on one upstream", upstreams: []upstreamSelectionStats{{Name: "a", Selections: 100}, {Name: "b"}}, expected: 100},
+		{
+			name: "standby ignored",
+			upstreams: []upstreamSelectionStats{
+				{Name: "a", Selections: 40},
+				{Name: "b", Selections: 40},
+				{Name: "standby", Standby: true},
+			},
+			expected: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, imbalanceRatio(tt.upstreams))
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package server
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// dashboardHTML 内置监控页面，仅使用原生 fetch 轮询管理接口，不依赖外部脚本
+//
+//go:embed dashboard.html
+var dashboardHTML []byte
+
+// handleDashboard 返回内置监控页面，页面与其他管理接口一样受管理接口认证保护
+func (s *AdminService) handleDashboard(c *gin.Context) {
+	c.Data(http.StatusOK, constants.ContentTypeHTML, dashboardHTML)
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdminService_Dashboard 测试内置监控页面受管理接口认证保护
+func TestAdminService_Dashboard(t *testing.T) {
+	router := newAdminAuthTestRouter(t, &config.AdminConfig{
+		Address: "127.0.0.1",
+		Port:    9000,
+		Auth:    &config.AuthConfig{Type: "bearer", Token: "admin-token"},
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), "<title>LLMProxy Dashboard</title>")
+	for _, endpoint := range []string{`"status"`, `"upstreams"`, `"breakers"`} {
+		assert.Contains(t, w.Body.String(), endpoint)
+	}
+}
+
+// TestAdminService_Breakers 测试熔断器状态端点
+func TestAdminService_Breakers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "with-breaker", URL: "http://127.0.0.1:1", Breaker: &config.BreakerConfig{Threshold: 0.5, Cooldown: 30000, MaxRequests: 1, Interval: 10000}},
+			{Name: "without-breaker", URL: "http://127.0.0.1:1"},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{{
+			Name:      "breaker-group",
+			Upstreams: []config.UpstreamRefConfig{{Name: "with-breaker"}, {Name: "without-breaker"}},
+		}},
+	}
+	forwardConfig := &config.ForwardConfig{Name: "breaker-forward", DefaultGroup: "breaker-group"}
+	forwardService := NewForwardServices()
+	require.NoError(t, forwardService.Initialize(forwardConfig, globalConfig, &logger))
+	defer forwardService.Stop()
+
+	srv := &Server{
+		forwardServers: map[string]*ForwardServer{
+			forwardConfig.Name: {config: forwardConfig, service: forwardService},
+		},
+		logger: &logger,
+	}
+	adminService := NewAdminServices()
+	adminService.Initialize(&config.AdminConfig{Address: "127.0.0.1", Port: 9000}, globalConfig, &logger, srv)
+	router := gin.New()
+	adminService.RegisterGroup(router.Group("/"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/breakers", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data struct {
+			Forwards []forwardBreakerStates `json:"forwards"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []forwardBreakerStates{{
+		Name: "breaker-forward",
+		Groups: []groupBreakerStates{{
+			Name: "breaker-group",
+			Upstreams: []upstreamBreakerState{
+				{Name: "with-breaker", State: "closed", Healthy: true},
+				{Name: "without-breaker", State: "disabled", Healthy: true},
+			},
+		}},
+	}}, resp.Data.Forwards)
+}
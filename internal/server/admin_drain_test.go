@@ -0,0 +1,144 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/shengyanli1982/llmproxy-go/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdminService_DrainForward 测试通过管理接口排空和恢复指定转发服务
+func TestAdminService_DrainForward(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	// 上游在收到释放信号前一直阻塞，模拟进行中的长请求
+	release := make(chan struct{})
+	var upstreamHits atomic.Int32
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if upstreamHits.Add(1) == 1 {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstreamServer.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{{Name: "slow-upstream", URL: upstreamServer.URL}},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{Name: "slow-group", Upstreams: []config.UpstreamRefConfig{{Name: "slow-upstream"}}},
+		},
+	}
+	forwardConfig := &config.ForwardConfig{Name: "drain-forward", DefaultGroup: "slow-group"}
+	forwardService := NewForwardServices()
+	require.NoError(t, forwardService.Initialize(forwardConfig, globalConfig, &logger))
+	defer forwardService.Stop()
+	otherConfig := &config.ForwardConfig{Name: "other-forward", DefaultGroup: "slow-group"}
+	otherService := NewForwardServices()
+	require.NoError(t, otherService.Initialize(otherConfig, globalConfig, &logger))
+	defer otherService.Stop()
+
+	srv := &Server{
+		forwardServers: map[string]*ForwardServer{
+			forwardConfig.Name: {config: forwardConfig, service: forwardService},
+			otherConfig.Name:   {config: otherConfig, service: otherService},
+		},
+		logger: &logger,
+	}
+
+	forwardRouter := gin.New()
+	forwardService.RegisterGroup(forwardRouter.Group("/"))
+	sendForward := func(method, path string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{}`))
+		w := httptest.NewRecorder()
+		forwardRouter.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	adminService := NewAdminServices()
+	adminService.Initialize(&config.AdminConfig{Address: "127.0.0.1", Port: 9000}, &config.Config{}, &logger, srv)
+	adminRouter := gin.New()
+	adminService.RegisterGroup(adminRouter.Group("/"))
+	sendAdmin := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		adminRouter.ServeHTTP(w, req)
+		return w
+	}
+	listForwards := func() map[string]forwardStatus {
+		w := sendAdmin(http.MethodGet, "/admin/forwards")
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Data struct {
+				Forwards []forwardStatus `json:"forwards"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		forwards := make(map[string]forwardStatus)
+		for _, status := range resp.Data.Forwards {
+			forwards[status.Name] = status
+		}
+		return forwards
+	}
+
+	assert.Equal(t, http.StatusOK, sendForward(http.MethodGet, constants.ForwardReadyPath))
+	assert.False(t, listForwards()["drain-forward"].Draining)
+
+	// 发起一个进行中的请求
+	inFlightCode := make(chan int, 1)
+	go func() {
+		inFlightCode <- sendForward(http.MethodPost, "/v1/chat/completions")
+	}()
+	require.Eventually(t, func() bool {
+		return upstreamHits.Load() == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// 排空后新请求和就绪检查返回 503，其他转发服务不受影响
+	w := sendAdmin(http.MethodPost, "/admin/forwards/drain-forward/drain")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"draining":true`)
+	assert.Equal(t, http.StatusServiceUnavailable, sendForward(http.MethodPost, "/v1/chat/completions"))
+	assert.Equal(t, http.StatusServiceUnavailable, sendForward(http.MethodGet, constants.ForwardReadyPath))
+
+	forwards := listForwards()
+	assert.True(t, forwards["drain-forward"].Draining)
+	assert.Equal(t, int64(1), forwards["drain-forward"].InFlightRequests)
+	assert.False(t, forwards["other-forward"].Draining)
+
+	// 进行中的请求继续完成
+	close(release)
+	select {
+	case code := <-inFlightCode:
+		assert.Equal(t, http.StatusOK, code)
+	case <-time.After(2 * time.Second):
+		t.Fatal("in-flight request did not complete while draining")
+	}
+
+	// 恢复后重新接收新请求
+	w = sendAdmin(http.MethodPost, "/admin/forwards/drain-forward/undrain")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"draining":false`)
+	assert.Equal(t, http.StatusOK, sendForward(http.MethodPost, "/v1/chat/completions"))
+	assert.Equal(t, http.StatusOK, sendForward(http.MethodGet, constants.ForwardReadyPath))
+	assert.False(t, listForwards()["drain-forward"].Draining)
+
+	// 不存在的转发服务返回 404
+	assert.Equal(t, http.StatusNotFound, sendAdmin(http.MethodPost, "/admin/forwards/missing/drain").Code)
+	assert.Equal(t, http.StatusNotFound, sendAdmin(http.MethodPost, "/admin/forwards/missing/undrain").Code)
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// infoResponse 代表 /admin/info 的响应结构
+type infoResponse struct {
+	Code int64 `json:"code"`
+	Data struct {
+		App     map[string]interface{} `json:"app"`
+		Runtime map[string]interface{} `json:"runtime"`
+		Config  map[string]interface{} `json:"config"`
+	} `json:"data"`
+}
+
+// TestAdminService_Info 测试 /admin/info 返回构建信息、运行时信息和配置加载信息
+func TestAdminService_Info(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	getInfo := func(t *testing.T, srv *Server) infoResponse {
+		adminService := NewAdminServices()
+		adminService.Initialize(&config.AdminConfig{Address: "127.0.0.1", Port: 9000}, &config.Config{}, &logger, srv)
+		router := gin.New()
+		adminService.RegisterGroup(router.Group("/"))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/info", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp infoResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	t.Run("build and config info", func(t *testing.T) {
+		loadedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+		srv := &Server{forwardServers: map[string]*ForwardServer{}, logger: &logger}
+		srv.SetBuildInfo(BuildInfo{Version: "1.2.3", GitCommit: "abc1234", BuildTime: "2025-01-01_00:00:00"})
+		srv.SetConfigInfo(ConfigInfo{Path: "/etc/llmproxy/config.yaml", LoadedAt: loadedAt})
+
+		resp := getInfo(t, srv)
+		assert.Equal(t, constants.AppName, resp.Data.App["name"])
+		assert.Equal(t, "1.2.3", resp.Data.App["version"])
+		assert.Equal(t, "abc1234", resp.Data.App["gitCommit"])
+		assert.Equal(t, "2025-01-01_00:00:00", resp.Data.App["buildTime"])
+
+		assert.Equal(t, runtime.Version(), resp.Data.Runtime["goVersion"])
+		assert.Contains(t, resp.Data.Runtime, "uptimeSeconds")
+
+		assert.Equal(t, "/etc/llmproxy/config.yaml", resp.Data.Config["path"])
+		assert.Equal(t, loadedAt.Format(time.RFC3339), resp.Data.Config["configLoadedAt"])
+		assert.NotContains(t, resp.Data.Config, "lastReloadAt")
+	})
+
+	t.Run("defaults without server", func(t *testing.T) {
+		resp := getInfo(t, nil)
+		assert.Equal(t, constants.DefaultVersion, resp.Data.App["version"])
+		assert.Equal(t, constants.DefaultBuildValue, resp.Data.App["gitCommit"])
+		assert.NotContains(t, resp.Data.Config, "configLoadedAt")
+	})
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/logging"
+	"github.com/shengyanli1982/toolkit/pkg/httptool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestAdminService_LogLevel 测试通过管理接口在运行时查询和调整日志级别
+func TestAdminService_LogLevel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var output bytes.Buffer
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	logger := logging.NewZapLogger(zapcore.AddSync(&output), level)
+
+	srv := &Server{forwardServers: make(map[string]*ForwardServer), logger: logger}
+	srv.SetLogLevel(level)
+
+	adminService := NewAdminServices()
+	adminService.Initialize(&config.AdminConfig{Address: "127.0.0.1", Port: 9000}, &config.Config{}, logger, srv)
+	router := gin.New()
+	adminService.RegisterGroup(router.Group("/"))
+
+	send := func(method, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest(method, "/admin/loglevel", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var resp httptool.BaseHttpResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		data, _ := resp.Data.(map[string]interface{})
+		return w, data
+	}
+
+	// 默认 info 级别不输出 V(1) 日志
+	w, data := send(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "info", data["level"])
+
+	logger.V(1).Info("debug message before change")
+	assert.NotContains(t, output.String(), "debug message before change")
+
+	// 调整为 debug 后立即输出 V(1) 日志，V(2) 日志仍被过滤
+	w, data = send(http.MethodPost, `{"level":"debug"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "debug", data["level"])
+	assert.Equal(t, "info", data["previous"])
+
+	logger.V(1).Info("debug message after change")
+	logger.V(2).Info("trace message after change")
+	assert.Contains(t, output.String(), "debug message after change")
+	assert.NotContains(t, output.String(), "trace message after change")
+
+	_, data = send(http.MethodGet, "")
+	assert.Equal(t, "debug", data["level"])
+
+	// 无效的日志级别被拒绝，级别保持不变
+	w, _ = send(http.MethodPost, `{"level":"verbose"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+}
+
+// TestAdminService_LogLevelNotAdjustable 测试未设置运行时日志级别时返回 404
+func TestAdminService_LogLevelNotAdjustable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	srv := &Server{forwardServers: make(map[string]*ForwardServer), logger: &logger}
+	adminService := NewAdminServices()
+	adminService.Initialize(&config.AdminConfig{Address: "127.0.0.1", Port: 9000}, &config.Config{}, &logger, srv)
+	router := gin.New()
+	adminService.RegisterGroup(router.Group("/"))
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/loglevel", strings.NewReader(`{"level":"debug"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -122,6 +123,60 @@ func TestAdminService_MetricsEndpointError(t *testing.T) {
 	}
 }
 
+// TestAdminService_MetricsContentNegotiation 测试 metrics 端点按 Accept 头部输出 Prometheus 文本格式或 OpenMetrics 格式
+func TestAdminService_MetricsContentNegotiation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	logger := logr.Discard()
+	adminConfig := &config.AdminConfig{Port: 8085, Address: "127.0.0.1"}
+
+	service := NewAdminServices()
+	service.Initialize(adminConfig, &config.Config{}, &logger, nil)
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+	}{
+		{name: "no accept header", accept: "", contentType: "text/plain; version=0.0.4"},
+		{name: "prometheus text", accept: "text/plain", contentType: "text/plain; version=0.0.4"},
+		{name: "openmetrics", accept: "application/openmetrics-text; version=1.0.0", contentType: "application/openmetrics-text; version=1.0.0"},
+		{
+			name:        "openmetrics preferred",
+			accept:      "application/openmetrics-text;version=1.0.0;q=0.9,text/plain;version=0.0.4;q=0.5",
+			contentType: "application/openmetrics-text; version=1.0.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", w.Code)
+			}
+			if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, tt.contentType) {
+				t.Errorf("Expected Content-Type %q, got %q", tt.contentType, contentType)
+			}
+			// OpenMetrics 格式以 # EOF 结尾
+			openMetrics := strings.HasPrefix(tt.contentType, "application/openmetrics-text")
+			if strings.HasSuffix(w.Body.String(), "# EOF\n") != openMetrics {
+				t.Errorf("Unexpected body terminator for %s: %q", tt.contentType, w.Body.String())
+			}
+		})
+	}
+}
+
 // TestAdminServer_MetricsRegistryIntegration 测试 AdminServer 的 metrics registry 集成
 func TestAdminServer_MetricsRegistryIntegration(t *testing.T) {
 	// 创建测试配置
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAdminService_Pprof 测试性能分析端点仅在显式开启时注册，并受管理接口认证保护
+func TestAdminService_Pprof(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	newRouter := func(cfg *config.AdminConfig) *gin.Engine {
+		adminService := NewAdminServices()
+		adminService.Initialize(cfg, &config.Config{}, &logger, nil)
+		router := gin.New()
+		adminService.RegisterGroup(router.Group("/"))
+		return router
+	}
+
+	get := func(router *gin.Engine, path, authorization string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if authorization != "" {
+			req.Header.Set(constants.HeaderAuthorization, authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		router := newRouter(&config.AdminConfig{Port: 9000})
+		assert.Equal(t, http.StatusNotFound, get(router, "/debug/pprof/", ""))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		router := newRouter(&config.AdminConfig{Port: 9000, EnablePprof: true})
+		assert.Equal(t, http.StatusOK, get(router, "/debug/pprof/", ""))
+		assert.Equal(t, http.StatusOK, get(router, "/debug/pprof/goroutine?debug=1", ""))
+	})
+
+	t.Run("protected by admin auth", func(t *testing.T) {
+		router := newRouter(&config.AdminConfig{
+			Port:        9000,
+			EnablePprof: true,
+			Auth:        &config.AuthConfig{Type: constants.AuthTypeBearer, Token: "admin-token"},
+		})
+		assert.Equal(t, http.StatusUnauthorized, get(router, "/debug/pprof/", ""))
+		assert.Equal(t, http.StatusOK, get(router, "/debug/pprof/", "Bearer admin-token"))
+	})
+}
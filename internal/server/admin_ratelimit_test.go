@@ -0,0 +1,112 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/balance"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/ratelimit"
+	"github.com/shengyanli1982/llmproxy-go/internal/response"
+	"github.com/shengyanli1982/toolkit/pkg/httptool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdminService_RateLimitReset 测试通过管理接口重置限流状态
+func TestAdminService_RateLimitReset(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	// 创建限流严格的转发服务
+	forwardConfig := &config.ForwardConfig{Name: "test-forward"}
+	upstreamLimiter := ratelimit.NewUpstreamLimiter(1.0, 1)
+	forwardService := &ForwardService{
+		config:      forwardConfig,
+		logger:      &logger,
+		rateLimitMW: ratelimit.NewRateLimitMiddleware(1.0, 1),
+		groups: []*upstreamGroup{{
+			name: "openai-group",
+			upstreams: []balance.Upstream{
+				{Name: "openai", RateLimiter: upstreamLimiter},
+			},
+		}},
+	}
+	srv := &Server{
+		forwardServers: map[string]*ForwardServer{
+			forwardConfig.Name: {config: forwardConfig, service: forwardService},
+		},
+		logger: &logger,
+	}
+
+	forwardRouter := gin.New()
+	forwardRouter.Use(forwardService.ginRateLimitMiddleware())
+	forwardRouter.GET("/v1/models", func(c *gin.Context) {
+		response.OK(c, nil)
+	})
+	sendForward := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+		req.RemoteAddr = "1.2.3.4:12345"
+		w := httptest.NewRecorder()
+		forwardRouter.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	adminConfig := &config.AdminConfig{Address: "127.0.0.1", Port: 9000}
+	adminService := NewAdminServices()
+	adminService.Initialize(adminConfig, &config.Config{}, &logger, srv)
+	adminRouter := gin.New()
+	adminService.RegisterGroup(adminRouter.Group("/"))
+	sendReset := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/ratelimit/reset", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		adminRouter.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("reset ip", func(t *testing.T) {
+		// 耗尽限流器
+		assert.Equal(t, http.StatusOK, sendForward())
+		assert.Equal(t, http.StatusTooManyRequests, sendForward())
+
+		w := sendReset(`{"type":"ip","key":"1.2.3.4"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp httptool.BaseHttpResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, int64(response.CodeSuccess), resp.Code)
+		assert.Contains(t, w.Body.String(), "test-forward")
+
+		// 重置后请求恢复
+		assert.Equal(t, http.StatusOK, sendForward())
+	})
+
+	t.Run("reset upstream", func(t *testing.T) {
+		assert.True(t, upstreamLimiter.Allow("openai"))
+		assert.False(t, upstreamLimiter.Allow("openai"))
+
+		w := sendReset(`{"type":"upstream","key":"openai"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, upstreamLimiter.Allow("openai"))
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		w := sendReset(`{"type":"cookie","key":"abc"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var resp httptool.BaseHttpResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, int64(response.CodeBadRequest), resp.Code)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		w := sendReset(`{"type":"ip"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/shengyanli1982/llmproxy-go/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdminService_Replay 测试请求重放端点绕过负载均衡将请求发送到指定上游，并返回上游的原始响应
+func TestAdminService_Replay(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	var received struct {
+		method, path, auth, header, body string
+	}
+	replayUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received.method, received.path, received.body = r.Method, r.URL.RequestURI(), string(body)
+		received.auth, received.header = r.Header.Get(constants.HeaderAuthorization), r.Header.Get("X-Debug")
+		w.Header().Set("X-Upstream", "replay")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = io.WriteString(w, `{"error": "rate limited"}`)
+	}))
+	defer replayUpstream.Close()
+
+	otherUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to other upstream: %s", r.URL.Path)
+	}))
+	defer otherUpstream.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "other-upstream", URL: otherUpstream.URL},
+			{
+				Name: "replay-upstream",
+				URL:  replayUpstream.URL,
+				Auth: &config.AuthConfig{Type: constants.AuthTypeBearer, Token: "upstream-token"},
+			},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{{
+			Name:      "replay-group",
+			Upstreams: []config.UpstreamRefConfig{{Name: "other-upstream"}, {Name: "replay-upstream"}},
+		}},
+	}
+	forwardConfig := &config.ForwardConfig{Name: "replay-forward", DefaultGroup: "replay-group"}
+	forwardService := NewForwardServices()
+	require.NoError(t, forwardService.Initialize(forwardConfig, globalConfig, &logger))
+	defer forwardService.Stop()
+
+	srv := &Server{
+		forwardServers: map[string]*ForwardServer{
+			forwardConfig.Name: {config: forwardConfig, service: forwardService},
+		},
+		logger: &logger,
+	}
+	newRouter := func(cfg *config.AdminConfig) *gin.Engine {
+		adminService := NewAdminServices()
+		adminService.Initialize(cfg, &config.Config{}, &logger, srv)
+		router := gin.New()
+		adminService.RegisterGroup(router.Group("/"))
+		return router
+	}
+	replay := func(router *gin.Engine, body, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/replay", strings.NewReader(body))
+		req.Header.Set(constants.HeaderContentType, "application/json")
+		if authorization != "" {
+			req.Header.Set(constants.HeaderAuthorization, authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	const replayBody = `{
+		"upstream": "replay-upstream",
+		"path": "/v1/chat/completions?debug=1",
+		"headers": {"X-Debug": "true"},
+		"body": {"model": "gpt-4o", "messages": []}
+	}`
+
+	t.Run("disabled by default", func(t *testing.T) {
+		router := newRouter(&config.AdminConfig{Port: 9000})
+		assert.Equal(t, http.StatusNotFound, replay(router, replayBody, "").Code)
+	})
+
+	t.Run("protected by admin auth", func(t *testing.T) {
+		router := newRouter(&config.AdminConfig{
+			Port:         9000,
+			EnableReplay: true,
+			Auth:         &config.AuthConfig{Type: constants.AuthTypeBearer, Token: "admin-token"},
+		})
+		assert.Equal(t, http.StatusUnauthorized, replay(router, replayBody, "").Code)
+		assert.Equal(t, http.StatusOK, replay(router, replayBody, "Bearer admin-token").Code)
+	})
+
+	router := newRouter(&config.AdminConfig{Port: 9000, EnableReplay: true})
+
+	t.Run("replays request to named upstream", func(t *testing.T) {
+		w := replay(router, replayBody, "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		assert.Equal(t, http.MethodPost, received.method)
+		assert.Equal(t, "/v1/chat/completions?debug=1", received.path)
+		assert.Equal(t, "Bearer upstream-token", received.auth)
+		assert.Equal(t, "true", received.header)
+		assert.JSONEq(t, `{"model": "gpt-4o", "messages": []}`, received.body)
+
+		var resp struct {
+			Data replayResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "replay-forward", resp.Data.Forward)
+		assert.Equal(t, "replay-upstream", resp.Data.Upstream)
+		assert.Equal(t, http.StatusTooManyRequests, resp.Data.StatusCode)
+		assert.Equal(t, "replay", resp.Data.Headers.Get("X-Upstream"))
+		assert.Equal(t, `{"error": "rate limited"}`, resp.Data.Body)
+		assert.False(t, resp.Data.Truncated)
+	})
+
+	t.Run("string body sent verbatim", func(t *testing.T) {
+		w := replay(router, `{"upstream": "replay-upstream", "method": "put", "path": "/raw", "body": "plain text"}`, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.MethodPut, received.method)
+		assert.Equal(t, "plain text", received.body)
+	})
+
+	t.Run("unknown upstream", func(t *testing.T) {
+		w := replay(router, `{"upstream": "missing", "path": "/v1/models"}`, "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, replay(router, `{"path": "/v1/models"}`, "").Code)
+		assert.Equal(t, http.StatusBadRequest, replay(router, `{"upstream": "replay-upstream", "path": "v1/models"}`, "").Code)
+		assert.Equal(t, http.StatusBadRequest, replay(router, `not json`, "").Code)
+	})
+}
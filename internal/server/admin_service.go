@@ -1,16 +1,29 @@
 package server
 
 import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
 	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-logr/logr"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/shengyanli1982/llmproxy-go/internal/auth"
+	"github.com/shengyanli1982/llmproxy-go/internal/client"
 	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/shengyanli1982/llmproxy-go/internal/logging"
 	"github.com/shengyanli1982/llmproxy-go/internal/metrics"
 	"github.com/shengyanli1982/llmproxy-go/internal/response"
+	"go.uber.org/zap"
 )
 
 // AdminService 代表管理服务，提供基本的管理功能
@@ -22,6 +35,10 @@ type AdminService struct {
 	logger          *logr.Logger
 	server          *Server                  // 引用主服务器以获取状态信息
 	metricsRegistry *metrics.MetricsRegistry // 指标注册器
+	authEnabled     bool                     // 是否启用管理接口认证
+	authorization   string                   // 期望的 Authorization 头部值
+	publicPaths     map[string]struct{}      // 免认证的路径集合
+	upstreamStateMu sync.Mutex               // 串行化上游停用状态变更，避免并发停用绕过最后一个可用上游的检查
 	startTime       time.Time
 	running         bool
 }
@@ -45,14 +62,126 @@ func (s *AdminService) Initialize(config *config.AdminConfig, globalConfig *conf
 
 	// 初始化指标注册器
 	s.metricsRegistry = metrics.GetGlobalRegistry()
+
+	// 初始化管理接口认证
+	s.initializeAuth()
+}
+
+// initializeAuth 根据配置生成期望的 Authorization 头部值
+// 认证器创建失败时仍保持认证开启，拒绝所有非公开路径的请求
+func (s *AdminService) initializeAuth() {
+	s.authEnabled = false
+	s.authorization = ""
+	s.publicPaths = make(map[string]struct{})
+
+	if s.config == nil {
+		return
+	}
+
+	for _, path := range s.config.PublicPaths {
+		s.publicPaths[path] = struct{}{}
+	}
+
+	if s.config.Auth == nil || s.config.Auth.Type == "" || s.config.Auth.Type == constants.AuthTypeNone {
+		return
+	}
+
+	s.authEnabled = true
+
+	authenticator, err := auth.NewFactory().Create(s.config.Auth)
+	if err != nil {
+		if s.logger != nil {
+			s.logger.Error(err, "Failed to create admin authenticator, all protected admin endpoints will be rejected")
+		}
+		return
+	}
+
+	// 借助认证器生成请求头，与上游认证保持相同的编码方式
+	req := &http.Request{Header: make(http.Header)}
+	if err := authenticator.Apply(req); err != nil {
+		if s.logger != nil {
+			s.logger.Error(err, "Failed to build admin authorization, all protected admin endpoints will be rejected")
+		}
+		return
+	}
+	s.authorization = req.Header.Get(constants.HeaderAuthorization)
 }
 
 // RegisterGroup 注册路由组和处理器
 // 注意: prometheus metrics 通过 /metrics 端点由 orbit 框架自动提供
 // 注意: health check 通过 /ping 端点由 orbit 框架自动提供
 func (s *AdminService) RegisterGroup(g *gin.RouterGroup) {
+	// 管理接口认证，必须在注册路由之前挂载
+	g.Use(s.authMiddleware())
+
 	// 统一指标端点（替代 orbit 框架的默认 /metrics）
 	g.GET("/metrics", s.handleMetrics)
+
+	// 内置监控页面，轮询下列管理接口展示转发服务、上游和熔断器状态
+	g.GET(constants.AdminDashboardPath, s.handleDashboard)
+
+	// 构建、运行时与配置信息端点
+	g.GET("/admin/info", s.handleInfo)
+
+	// 转发服务运行状态端点
+	g.GET("/admin/status", s.handleStatus)
+
+	// 转发服务排空控制端点，用于发布时让负载均衡器摘除指定转发服务
+	g.GET("/admin/forwards", s.handleListForwards)
+	g.POST("/admin/forwards/:name/drain", s.handleDrainForward)
+	g.POST("/admin/forwards/:name/undrain", s.handleUndrainForward)
+
+	// 上游列表端点，支持按标签筛选
+	g.GET("/admin/upstreams", s.handleListUpstreams)
+
+	// 上游停用控制端点，用于维护期间暂时将上游移出负载均衡
+	g.POST("/admin/upstreams/:name/disable", s.handleDisableUpstream)
+	g.POST("/admin/upstreams/:name/enable", s.handleEnableUpstream)
+
+	// 负载均衡选择分布端点
+	g.GET("/admin/balance/stats", s.handleBalanceStats)
+
+	// 熔断器状态端点
+	g.GET("/admin/breakers", s.handleBreakers)
+
+	// 限流状态重置端点
+	g.POST("/admin/ratelimit/reset", s.handleRateLimitReset)
+
+	// 运行时日志级别查询与调整端点
+	g.GET("/admin/loglevel", s.handleGetLogLevel)
+	g.POST("/admin/loglevel", s.handleSetLogLevel)
+
+	// 请求重放端点，需显式开启
+	if s.config != nil && s.config.EnableReplay {
+		if !s.authEnabled && s.logger != nil {
+			s.logger.Info("Replay endpoint is enabled without admin authentication, restrict access to the admin port")
+		}
+		g.POST(constants.AdminReplayPath, s.handleReplay)
+	}
+
+	// 性能分析端点，需显式开启
+	if s.config != nil && s.config.EnablePprof {
+		s.registerPprof(g)
+	}
+}
+
+// registerPprof 注册 net/http/pprof 性能分析端点，与其他管理接口一样受管理接口认证保护
+// 性能分析端点会暴露内存中的数据（如堆中的请求内容和密钥），CPU 分析和 trace 也会带来额外开销
+func (s *AdminService) registerPprof(g *gin.RouterGroup) {
+	if !s.authEnabled && s.logger != nil {
+		s.logger.Info("Pprof endpoints are enabled without admin authentication, restrict access to the admin port")
+	}
+
+	pprofGroup := g.Group(constants.PprofURLPath)
+	pprofGroup.GET("/", gin.WrapF(pprof.Index))
+	pprofGroup.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+	pprofGroup.GET("/profile", gin.WrapF(pprof.Profile))
+	pprofGroup.GET("/symbol", gin.WrapF(pprof.Symbol))
+	pprofGroup.POST("/symbol", gin.WrapF(pprof.Symbol))
+	pprofGroup.GET("/trace", gin.WrapF(pprof.Trace))
+	for _, profile := range []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"} {
+		pprofGroup.GET("/"+profile, gin.WrapH(pprof.Handler(profile)))
+	}
 }
 
 // Run 启动管理服务
@@ -92,7 +221,643 @@ func (s *AdminService) IsRunning() bool {
 	return s.running
 }
 
+// authMiddleware 返回管理接口认证中间件，校验 Authorization 头部
+func (s *AdminService) authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s.mu.RLock()
+		enabled := s.authEnabled
+		expected := s.authorization
+		_, public := s.publicPaths[c.Request.URL.Path]
+		s.mu.RUnlock()
+
+		if !enabled || public {
+			c.Next()
+			return
+		}
+
+		provided := c.GetHeader(constants.HeaderAuthorization)
+		if expected == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) != 1 {
+			response.Unauthorized(c, "invalid or missing admin credentials")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// handleInfo 处理信息查询请求，返回应用构建信息、运行时信息和配置加载信息
+func (s *AdminService) handleInfo(c *gin.Context) {
+	s.mu.RLock()
+	server := s.server
+	startTime := s.startTime
+	s.mu.RUnlock()
+
+	buildInfo := BuildInfo{
+		Version:   constants.DefaultVersion,
+		GitCommit: constants.DefaultBuildValue,
+		BuildTime: constants.DefaultBuildValue,
+	}
+	var configInfo ConfigInfo
+	if server != nil {
+		buildInfo = server.GetBuildInfo()
+		configInfo = server.GetConfigInfo()
+	}
+
+	configData := map[string]interface{}{
+		"path": configInfo.Path,
+	}
+	if !configInfo.LoadedAt.IsZero() {
+		configData["configLoadedAt"] = configInfo.LoadedAt.Format(time.RFC3339)
+	}
+	if !configInfo.LastReloadAt.IsZero() {
+		configData["lastReloadAt"] = configInfo.LastReloadAt.Format(time.RFC3339)
+	}
+
+	response.OK(c, map[string]interface{}{
+		"app": map[string]interface{}{
+			"name":      constants.AppName,
+			"version":   buildInfo.Version,
+			"gitCommit": buildInfo.GitCommit,
+			"buildTime": buildInfo.BuildTime,
+		},
+		"runtime": map[string]interface{}{
+			"goVersion":     runtime.Version(),
+			"os":            runtime.GOOS,
+			"arch":          runtime.GOARCH,
+			"numCPU":        runtime.NumCPU(),
+			"numGoroutine":  runtime.NumGoroutine(),
+			"startTime":     startTime.Format(time.RFC3339),
+			"uptimeSeconds": int64(time.Since(startTime).Seconds()),
+		},
+		"config": configData,
+	})
+}
+
+// forwardStatus 代表转发服务的运行状态
+type forwardStatus struct {
+	Name                  string `json:"name"`                  // 转发服务名称
+	InFlightRequests      int64  `json:"inFlightRequests"`      // 当前并发请求数
+	MaxConcurrentRequests int    `json:"maxConcurrentRequests"` // 最大并发请求数，0 表示不限制
+	Draining              bool   `json:"draining"`              // 是否正在排空
+}
+
+// newForwardStatus 获取转发服务器的运行状态
+func newForwardStatus(forwardServer *ForwardServer) forwardStatus {
+	status := forwardStatus{Name: forwardServer.GetConfig().Name}
+	if service := forwardServer.GetService(); service != nil {
+		status.InFlightRequests = service.InFlightRequests()
+		status.MaxConcurrentRequests = service.MaxConcurrentRequests()
+		status.Draining = service.IsDraining()
+	}
+	return status
+}
+
+// forwardStatuses 获取所有转发服务的运行状态，按名称排序
+func (s *AdminService) forwardStatuses() []forwardStatus {
+	s.mu.RLock()
+	server := s.server
+	s.mu.RUnlock()
+
+	forwards := make([]forwardStatus, 0)
+	if server != nil {
+		for _, forwardServer := range server.GetForwardServers() {
+			forwards = append(forwards, newForwardStatus(forwardServer))
+		}
+	}
+	sort.Slice(forwards, func(i, j int) bool { return forwards[i].Name < forwards[j].Name })
+	return forwards
+}
+
+// handleStatus 处理运行状态查询请求，返回各转发服务的当前并发请求数和最大并发请求数
+func (s *AdminService) handleStatus(c *gin.Context) {
+	response.OK(c, map[string]interface{}{
+		"forwards": s.forwardStatuses(),
+	})
+}
+
+// handleListForwards 处理转发服务列表查询请求，返回各转发服务的排空状态
+func (s *AdminService) handleListForwards(c *gin.Context) {
+	response.OK(c, map[string]interface{}{
+		"forwards": s.forwardStatuses(),
+	})
+}
+
+// handleDrainForward 处理转发服务排空请求，之后到达的新请求返回 503，进行中的请求继续完成
+func (s *AdminService) handleDrainForward(c *gin.Context) {
+	s.setForwardDraining(c, true)
+}
+
+// handleUndrainForward 处理转发服务恢复请求，恢复接收新请求
+func (s *AdminService) handleUndrainForward(c *gin.Context) {
+	s.setForwardDraining(c, false)
+}
+
+// setForwardDraining 设置路径参数指定的转发服务的排空状态，转发服务不存在时返回 404
+func (s *AdminService) setForwardDraining(c *gin.Context, draining bool) {
+	name := c.Param("name")
+
+	s.mu.RLock()
+	server := s.server
+	s.mu.RUnlock()
+
+	var forwardServer *ForwardServer
+	if server != nil {
+		forwardServer = server.GetForwardServer(name)
+	}
+	if forwardServer == nil || forwardServer.GetService() == nil {
+		response.NotFound(c, "forward not found")
+		return
+	}
+
+	if draining {
+		forwardServer.GetService().BeginDrain()
+	} else {
+		forwardServer.GetService().EndDrain()
+	}
+
+	status := newForwardStatus(forwardServer)
+	if s.logger != nil {
+		s.logger.Info("Forward drain state changed",
+			"forward", name,
+			"draining", draining,
+			"in_flight", status.InFlightRequests)
+	}
+
+	response.OK(c, status)
+}
+
+// upstreamInfo 代表上游服务的基本信息，不包含认证等敏感配置
+type upstreamInfo struct {
+	Name        string                 `json:"name"`        // 上游服务名称
+	URL         string                 `json:"url"`         // 上游服务地址
+	Labels      map[string]string      `json:"labels"`      // 运维标签
+	Connections client.ConnectionStats `json:"connections"` // 所有转发服务到该上游的活跃和空闲连接数
+	Disabled    bool                   `json:"disabled"`    // 是否在任一转发服务中停用
+}
+
+// handleListUpstreams 处理上游列表查询请求，按名称排序
+// 可通过一个或多个 label=key:value 查询参数筛选，只返回匹配全部标签的上游
+func (s *AdminService) handleListUpstreams(c *gin.Context) {
+	filters := make(map[string]string)
+	for _, filter := range c.QueryArray("label") {
+		key, value, ok := strings.Cut(filter, ":")
+		if !ok || key == "" {
+			response.BadRequest(c, "invalid label filter, expected key:value")
+			return
+		}
+		filters[key] = value
+	}
+
+	s.mu.RLock()
+	globalConfig := s.globalConfig
+	server := s.server
+	s.mu.RUnlock()
+
+	connections := make(map[string]client.ConnectionStats)
+	var services []*ForwardService
+	if server != nil {
+		for _, forwardServer := range server.GetForwardServers() {
+			service := forwardServer.GetService()
+			if service == nil {
+				continue
+			}
+			services = append(services, service)
+			for name, stats := range service.UpstreamConnections() {
+				total := connections[name]
+				total.Active += stats.Active
+				total.Idle += stats.Idle
+				connections[name] = total
+			}
+		}
+	}
+
+	upstreams := make([]upstreamInfo, 0)
+	if globalConfig != nil {
+		for _, upstream := range globalConfig.Upstreams {
+			if !matchLabels(upstream.Labels, filters) {
+				continue
+			}
+			labels := upstream.Labels
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			info := upstreamInfo{
+				Name:        upstream.Name,
+				URL:         upstream.URL,
+				Labels:      labels,
+				Connections: connections[upstream.Name],
+			}
+			for _, service := range services {
+				if service.IsUpstreamDisabled(upstream.Name) {
+					info.Disabled = true
+					break
+				}
+			}
+			upstreams = append(upstreams, info)
+		}
+	}
+	sort.Slice(upstreams, func(i, j int) bool { return upstreams[i].Name < upstreams[j].Name })
+
+	response.OK(c, map[string]interface{}{
+		"upstreams": upstreams,
+	})
+}
+
+// upstreamState 代表上游的运行时停用状态
+type upstreamState struct {
+	Name     string   `json:"name"`     // 上游服务名称
+	Disabled bool     `json:"disabled"` // 是否已停用
+	Forwards []string `json:"forwards"` // 引用该上游的转发服务名称，按名称排序
+}
+
+// handleDisableUpstream 处理上游停用请求，上游从所有引用它的上游组的负载均衡中排除，进行中的请求不受影响
+// 停用后某个上游组将没有可用的上游时返回 409，携带 force=true 查询参数时强制停用
+func (s *AdminService) handleDisableUpstream(c *gin.Context) {
+	s.setUpstreamDisabled(c, true)
+}
+
+// handleEnableUpstream 处理上游启用请求，上游重新参与负载均衡
+func (s *AdminService) handleEnableUpstream(c *gin.Context) {
+	s.setUpstreamDisabled(c, false)
+}
+
+// setUpstreamDisabled 设置路径参数指定的上游在所有转发服务中的停用状态，没有转发服务引用该上游时返回 404
+// 停用状态仅保存在内存中，重启后恢复为配置文件中的 disabled 设置
+func (s *AdminService) setUpstreamDisabled(c *gin.Context, disabled bool) {
+	name := c.Param("name")
+
+	s.mu.RLock()
+	server := s.server
+	s.mu.RUnlock()
+
+	var forwardServers []*ForwardServer
+	if server != nil {
+		for _, forwardServer := range server.GetForwardServers() {
+			if forwardServer.GetService() != nil {
+				forwardServers = append(forwardServers, forwardServer)
+			}
+		}
+	}
+
+	s.upstreamStateMu.Lock()
+	defer s.upstreamStateMu.Unlock()
+
+	// 先检查全部转发服务，避免部分转发服务已停用后才发现冲突
+	if disabled && c.Query("force") != "true" {
+		for _, forwardServer := range forwardServers {
+			if err := forwardServer.GetService().CheckDisableUpstream(name); err != nil {
+				response.Error(response.CodeBadRequest, "cannot disable the last enabled upstream in group, use force=true to override").
+					WithDetail(err.Error()).
+					JSON(c, http.StatusConflict)
+				return
+			}
+		}
+	}
+
+	state := upstreamState{Name: name, Disabled: disabled, Forwards: make([]string, 0)}
+	for _, forwardServer := range forwardServers {
+		if forwardServer.GetService().SetUpstreamDisabled(name, disabled) {
+			state.Forwards = append(state.Forwards, forwardServer.name)
+		}
+	}
+	if len(state.Forwards) == 0 {
+		response.NotFound(c, "upstream not found")
+		return
+	}
+	sort.Strings(state.Forwards)
+
+	if s.logger != nil {
+		s.logger.Info("Upstream disabled state changed", "upstream", name, "disabled", disabled, "forwards", state.Forwards)
+	}
+
+	response.OK(c, state)
+}
+
+// matchLabels 判断标签是否包含全部筛选条件
+func matchLabels(labels, filters map[string]string) bool {
+	for key, value := range filters {
+		if labelValue, exists := labels[key]; !exists || labelValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// forwardBalanceStats 代表转发服务各上游组的选择分布
+type forwardBalanceStats struct {
+	Name   string                `json:"name"`   // 转发服务名称
+	Groups []groupSelectionStats `json:"groups"` // 按配置顺序的上游组选择分布
+}
+
+// handleBalanceStats 处理负载均衡选择分布查询请求，返回各上游组内上游的累计选择次数和不均衡比例
+func (s *AdminService) handleBalanceStats(c *gin.Context) {
+	s.mu.RLock()
+	server := s.server
+	s.mu.RUnlock()
+
+	forwards := make([]forwardBalanceStats, 0)
+	if server != nil {
+		for _, forwardServer := range server.GetForwardServers() {
+			service := forwardServer.GetService()
+			if service == nil {
+				continue
+			}
+			forwards = append(forwards, forwardBalanceStats{
+				Name:   forwardServer.GetConfig().Name,
+				Groups: service.BalanceStats(),
+			})
+		}
+	}
+	sort.Slice(forwards, func(i, j int) bool { return forwards[i].Name < forwards[j].Name })
+
+	response.OK(c, map[string]interface{}{
+		"forwards": forwards,
+	})
+}
+
+// forwardBreakerStates 代表转发服务各上游组的熔断器状态
+type forwardBreakerStates struct {
+	Name   string               `json:"name"`   // 转发服务名称
+	Groups []groupBreakerStates `json:"groups"` // 按配置顺序的上游组熔断器状态
+}
+
+// handleBreakers 处理熔断器状态查询请求，返回各上游组内上游的熔断器状态和健康状态
+func (s *AdminService) handleBreakers(c *gin.Context) {
+	s.mu.RLock()
+	server := s.server
+	s.mu.RUnlock()
+
+	forwards := make([]forwardBreakerStates, 0)
+	if server != nil {
+		for _, forwardServer := range server.GetForwardServers() {
+			service := forwardServer.GetService()
+			if service == nil {
+				continue
+			}
+			forwards = append(forwards, forwardBreakerStates{
+				Name:   forwardServer.GetConfig().Name,
+				Groups: service.BreakerStates(),
+			})
+		}
+	}
+	sort.Slice(forwards, func(i, j int) bool { return forwards[i].Name < forwards[j].Name })
+
+	response.OK(c, map[string]interface{}{
+		"forwards": forwards,
+	})
+}
+
+// rateLimitResetRequest 代表限流状态重置请求
+type rateLimitResetRequest struct {
+	Type string `json:"type"` // 限流类型：ip、key 或 upstream
+	Key  string `json:"key"`  // 客户端 IP、API Key / Token 哈希或上游名称
+}
+
+// handleRateLimitReset 处理限流状态重置请求，对所有转发服务执行重置
+func (s *AdminService) handleRateLimitReset(c *gin.Context) {
+	var req rateLimitResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	switch req.Type {
+	case constants.RateLimitResetIP, constants.RateLimitResetKey, constants.RateLimitResetUpstream:
+	default:
+		response.BadRequest(c, "unknown rate limit type, expected one of: ip, key, upstream")
+		return
+	}
+	if req.Key == "" {
+		response.BadRequest(c, "key is required")
+		return
+	}
+
+	s.mu.RLock()
+	server := s.server
+	s.mu.RUnlock()
+
+	forwards := make([]string, 0)
+	if server != nil {
+		for _, forwardServer := range server.GetForwardServers() {
+			service := forwardServer.GetService()
+			if service != nil && service.ResetRateLimit(req.Type, req.Key) {
+				forwards = append(forwards, forwardServer.GetConfig().Name)
+			}
+		}
+	}
+
+	if s.logger != nil {
+		s.logger.Info("Rate limit reset", "type", req.Type, "key", req.Key, "forwards", forwards)
+	}
+
+	response.OK(c, map[string]interface{}{
+		"type":     req.Type,
+		"key":      req.Key,
+		"forwards": forwards,
+	})
+}
+
+// replayRequest 代表请求重放请求
+type replayRequest struct {
+	Forward  string            `json:"forward"`  // 转发服务名称，可选，为空时使用第一个包含该上游的转发服务
+	Upstream string            `json:"upstream"` // 上游名称
+	Method   string            `json:"method"`   // 请求方法，默认 POST
+	Path     string            `json:"path"`     // 请求路径，可包含查询参数
+	Headers  map[string]string `json:"headers"`  // 请求头部
+	Body     json.RawMessage   `json:"body"`     // 请求体，JSON 字符串按原文发送，其他 JSON 值按编码后的内容发送
+}
+
+// replayResponse 代表上游对重放请求的原始响应
+type replayResponse struct {
+	Forward    string      `json:"forward"`    // 执行请求的转发服务名称
+	Upstream   string      `json:"upstream"`   // 上游名称
+	StatusCode int         `json:"statusCode"` // 上游响应状态码
+	Headers    http.Header `json:"headers"`    // 上游响应头部
+	Body       string      `json:"body"`       // 上游响应体
+	Truncated  bool        `json:"truncated"`  // 响应体是否超出上限被截断
+	DurationMs int64       `json:"durationMs"` // 请求耗时，单位：毫秒
+}
+
+// body 获取重放请求的请求体
+func (r *replayRequest) body() ([]byte, error) {
+	trimmed := bytes.TrimSpace(r.Body)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil, nil
+	}
+	if trimmed[0] != '"' {
+		return trimmed, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(trimmed, &text); err != nil {
+		return nil, err
+	}
+	return []byte(text), nil
+}
+
+// handleReplay 处理请求重放请求，绕过负载均衡将请求直接发送到指定上游，返回上游的原始响应
+// 用于排查上游服务的问题，请求仍会应用上游的认证、头部操作和请求体转换
+func (s *AdminService) handleReplay(c *gin.Context) {
+	var req replayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+	if req.Upstream == "" {
+		response.BadRequest(c, "upstream is required")
+		return
+	}
+	if !strings.HasPrefix(req.Path, "/") {
+		response.BadRequest(c, "path must start with /")
+		return
+	}
+	if req.Method == "" {
+		req.Method = http.MethodPost
+	}
+	body, err := req.body()
+	if err != nil {
+		response.BadRequest(c, "invalid body")
+		return
+	}
+
+	upstreamReq, err := http.NewRequestWithContext(c.Request.Context(), strings.ToUpper(req.Method), req.Path, bytes.NewReader(body))
+	if err != nil {
+		response.BadRequest(c, "invalid method or path")
+		return
+	}
+	for name, value := range req.Headers {
+		upstreamReq.Header.Set(name, value)
+	}
+
+	s.mu.RLock()
+	server := s.server
+	s.mu.RUnlock()
+
+	var forwardServers []*ForwardServer
+	if server != nil {
+		forwardServers = server.GetForwardServers()
+	}
+	sort.Slice(forwardServers, func(i, j int) bool {
+		return forwardServers[i].GetConfig().Name < forwardServers[j].GetConfig().Name
+	})
+
+	for _, forwardServer := range forwardServers {
+		forwardName := forwardServer.GetConfig().Name
+		service := forwardServer.GetService()
+		if service == nil || (req.Forward != "" && req.Forward != forwardName) {
+			continue
+		}
+
+		startTime := time.Now()
+		resp, found, err := service.Replay(req.Upstream, upstreamReq)
+		if !found {
+			continue
+		}
+
+		if s.logger != nil {
+			s.logger.Info("Replayed request to upstream",
+				"forward", forwardName,
+				"upstream", req.Upstream,
+				"method", upstreamReq.Method,
+				"path", req.Path)
+		}
+		if err != nil {
+			response.BadGateway(c, "replay request failed: "+err.Error())
+			return
+		}
+		defer resp.Body.Close()
+
+		respBody, err := io.ReadAll(io.LimitReader(resp.Body, constants.AdminReplayMaxResponseBytes+1))
+		if err != nil {
+			response.BadGateway(c, "failed to read upstream response: "+err.Error())
+			return
+		}
+		truncated := len(respBody) > constants.AdminReplayMaxResponseBytes
+		if truncated {
+			respBody = respBody[:constants.AdminReplayMaxResponseBytes]
+		}
+
+		response.OK(c, replayResponse{
+			Forward:    forwardName,
+			Upstream:   req.Upstream,
+			StatusCode: resp.StatusCode,
+			Headers:    resp.Header,
+			Body:       string(respBody),
+			Truncated:  truncated,
+			DurationMs: time.Since(startTime).Milliseconds(),
+		})
+		return
+	}
+
+	response.NotFound(c, "upstream not found: "+req.Upstream)
+}
+
+// logLevelRequest 代表日志级别调整请求
+type logLevelRequest struct {
+	Level string `json:"level"` // 日志级别：error、info、debug 或 trace
+}
+
+// logLevel 获取运行时日志级别，未设置时返回 false
+func (s *AdminService) logLevel() (zap.AtomicLevel, bool) {
+	s.mu.RLock()
+	server := s.server
+	s.mu.RUnlock()
+
+	if server == nil {
+		return zap.AtomicLevel{}, false
+	}
+	return server.GetLogLevel()
+}
+
+// handleGetLogLevel 处理日志级别查询请求
+func (s *AdminService) handleGetLogLevel(c *gin.Context) {
+	level, ok := s.logLevel()
+	if !ok {
+		response.Error(response.CodeNotFound, "log level is not adjustable").JSON(c, http.StatusNotFound)
+		return
+	}
+
+	response.OK(c, map[string]interface{}{
+		"level": logging.LevelName(level.Level()),
+	})
+}
+
+// handleSetLogLevel 处理日志级别调整请求，立即对所有日志记录器生效
+func (s *AdminService) handleSetLogLevel(c *gin.Context) {
+	var req logLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	newLevel, err := logging.ParseLevel(req.Level)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	level, ok := s.logLevel()
+	if !ok {
+		response.Error(response.CodeNotFound, "log level is not adjustable").JSON(c, http.StatusNotFound)
+		return
+	}
+
+	previous := logging.LevelName(level.Level())
+	level.SetLevel(newLevel)
+
+	if s.logger != nil {
+		s.logger.Info("Log level changed", "from", previous, "to", req.Level)
+	}
+
+	response.OK(c, map[string]interface{}{
+		"level":    req.Level,
+		"previous": previous,
+	})
+}
+
 // handleMetrics 处理统一指标请求（替代 orbit 默认的 /metrics）
+// 按 Accept 头部协商输出格式：接受 application/openmetrics-text 时输出 OpenMetrics 格式，否则输出 Prometheus 文本格式
 func (s *AdminService) handleMetrics(c *gin.Context) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -109,7 +874,7 @@ func (s *AdminService) handleMetrics(c *gin.Context) {
 		return
 	}
 
-	// 使用 Prometheus HTTP 处理器
+	// 使用 Prometheus HTTP 处理器，启用 OpenMetrics 后由处理器通过 expfmt.NegotiateIncludingOpenMetrics 协商输出格式
 	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{
 		EnableOpenMetrics: true,
 	})
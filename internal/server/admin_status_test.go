@@ -0,0 +1,54 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdminService_Status 测试运行状态端点返回各转发服务的并发请求数
+func TestAdminService_Status(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	limitedConfig := &config.ForwardConfig{Name: "limited-forward", MaxConcurrentRequests: 8}
+	limitedService := &ForwardService{config: limitedConfig, logger: &logger, concurrencySem: make(chan struct{}, 8)}
+	limitedService.inFlight.Add(3)
+	unlimitedConfig := &config.ForwardConfig{Name: "default-forward"}
+	unlimitedService := &ForwardService{config: unlimitedConfig, logger: &logger}
+	srv := &Server{
+		forwardServers: map[string]*ForwardServer{
+			limitedConfig.Name:   {config: limitedConfig, service: limitedService},
+			unlimitedConfig.Name: {config: unlimitedConfig, service: unlimitedService},
+		},
+		logger: &logger,
+	}
+
+	adminService := NewAdminServices()
+	adminService.Initialize(&config.AdminConfig{Address: "127.0.0.1", Port: 9000}, &config.Config{}, &logger, srv)
+	router := gin.New()
+	adminService.RegisterGroup(router.Group("/"))
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/status", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data struct {
+			Forwards []forwardStatus `json:"forwards"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []forwardStatus{
+		{Name: "default-forward", InFlightRequests: 0, MaxConcurrentRequests: 0},
+		{Name: "limited-forward", InFlightRequests: 3, MaxConcurrentRequests: 8},
+	}, resp.Data.Forwards)
+}
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdminService_ListUpstreams 测试上游列表端点及按标签筛选
+func TestAdminService_ListUpstreams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "openai-us", URL: "https://api.openai.com", Labels: map[string]string{"provider": "openai", "region": "us"}},
+			{Name: "anthropic-us", URL: "https://api.anthropic.com", Labels: map[string]string{"provider": "anthropic", "region": "us"},
+				Auth: &config.AuthConfig{Type: "bearer", Token: "secret-token"}},
+			{Name: "openai-eu", URL: "https://eu.api.openai.com", Labels: map[string]string{"provider": "openai", "region": "eu"}},
+			{Name: "local", URL: "http://127.0.0.1:8000"},
+		},
+	}
+	adminService := NewAdminServices()
+	adminService.Initialize(&config.AdminConfig{Address: "127.0.0.1", Port: 9000}, globalConfig, &logger, &Server{logger: &logger})
+	router := gin.New()
+	adminService.RegisterGroup(router.Group("/"))
+
+	list := func(query string) (int, []string, []byte) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/upstreams"+query, nil))
+		if w.Code != http.StatusOK {
+			return w.Code, nil, w.Body.Bytes()
+		}
+
+		var resp struct {
+			Data struct {
+				Upstreams []upstreamInfo `json:"upstreams"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		names := make([]string, 0, len(resp.Data.Upstreams))
+		for _, upstream := range resp.Data.Upstreams {
+			names = append(names, upstream.Name)
+		}
+		return w.Code, names, w.Body.Bytes()
+	}
+
+	code, names, body := list("")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"anthropic-us", "local", "openai-eu", "openai-us"}, names)
+	assert.NotContains(t, string(body), "secret-token")
+	assert.Contains(t, string(body), `"region":"eu"`)
+
+	_, names, _ = list("?label=provider:openai")
+	assert.Equal(t, []string{"openai-eu", "openai-us"}, names)
+
+	_, names, _ = list("?label=provider:openai&label=region:us")
+	assert.Equal(t, []string{"openai-us"}, names)
+
+	_, names, _ = list("?label=provider:azure")
+	assert.Empty(t, names)
+
+	code, _, _ = list("?label=provider")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+// TestAdminService_DisableUpstream 测试运行时停用和启用上游对负载均衡选择的影响
+func TestAdminService_DisableUpstream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	newUpstream := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(name))
+		}))
+	}
+	upstreamA, upstreamB, upstreamC := newUpstream("a"), newUpstream("b"), newUpstream("c")
+	defer upstreamA.Close()
+	defer upstreamB.Close()
+	defer upstreamC.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "upstream-a", URL: upstreamA.URL},
+			{Name: "upstream-b", URL: upstreamB.URL},
+			{Name: "upstream-c", URL: upstreamC.URL, Disabled: true},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{{
+			Name:      "rr-group",
+			Balance:   &config.BalanceConfig{Strategy: "roundrobin"},
+			Upstreams: []config.UpstreamRefConfig{{Name: "upstream-a"}, {Name: "upstream-b"}, {Name: "upstream-c"}},
+		}},
+	}
+	forwardConfig := &config.ForwardConfig{Name: "disable-forward", DefaultGroup: "rr-group", AllowUpstreamOverride: true}
+	forwardService := NewForwardServices()
+	require.NoError(t, forwardService.Initialize(forwardConfig, globalConfig, &logger))
+	defer forwardService.Stop()
+
+	forwardRouter := gin.New()
+	forwardService.RegisterGroup(forwardRouter.Group("/"))
+
+	// selections 发送请求并统计各上游处理的请求数
+	selections := func(total int) map[string]int {
+		counts := make(map[string]int)
+		for i := 0; i < total; i++ {
+			w := httptest.NewRecorder()
+			forwardRouter.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`)))
+			if w.Code == http.StatusOK {
+				counts[w.Body.String()]++
+			} else {
+				counts["error"]++
+			}
+		}
+		return counts
+	}
+
+	srv := &Server{
+		forwardServers: map[string]*ForwardServer{
+			forwardConfig.Name: {name: forwardConfig.Name, config: forwardConfig, service: forwardService},
+		},
+		logger: &logger,
+	}
+	adminService := NewAdminServices()
+	adminService.Initialize(&config.AdminConfig{Address: "127.0.0.1", Port: 9000}, globalConfig, &logger, srv)
+	adminRouter := gin.New()
+	adminService.RegisterGroup(adminRouter.Group("/"))
+
+	post := func(path string) (int, upstreamState) {
+		w := httptest.NewRecorder()
+		adminRouter.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+
+		var resp struct {
+			Data upstreamState `json:"data"`
+		}
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		}
+		return w.Code, resp.Data
+	}
+
+	// 配置中停用的上游不参与负载均衡
+	assert.Equal(t, map[string]int{"a": 5, "b": 5}, selections(10))
+
+	// 停用 upstream-a 后所有请求由 upstream-b 处理
+	code, state := post("/admin/upstreams/upstream-a/disable")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, upstreamState{Name: "upstream-a", Disabled: true, Forwards: []string{"disable-forward"}}, state)
+	assert.Equal(t, map[string]int{"b": 10}, selections(10))
+
+	// 停用的上游不能通过头部指定
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`))
+	req.Header.Set("X-LLMProxy-Upstream", "upstream-a")
+	w := httptest.NewRecorder()
+	forwardRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Upstream disabled")
+
+	// 上游列表标记停用状态
+	w = httptest.NewRecorder()
+	adminRouter.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/upstreams", nil))
+	var list struct {
+		Data struct {
+			Upstreams []upstreamInfo `json:"upstreams"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	disabled := make(map[string]bool)
+	for _, upstream := range list.Data.Upstreams {
+		disabled[upstream.Name] = upstream.Disabled
+	}
+	assert.Equal(t, map[string]bool{"upstream-a": true, "upstream-b": false, "upstream-c": true}, disabled)
+
+	// 停用组内最后一个可用上游需要 force=true
+	code, _ = post("/admin/upstreams/upstream-b/disable")
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, map[string]int{"b": 3}, selections(3))
+
+	code, _ = post("/admin/upstreams/upstream-b/disable?force=true")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]int{"error": 3}, selections(3))
+
+	// 所有上游均已停用时返回 503，而不是配置错误的 500
+	w = httptest.NewRecorder()
+	forwardRouter.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	// 启用后重新参与负载均衡
+	code, state = post("/admin/upstreams/upstream-c/enable")
+	require.Equal(t, http.StatusOK, code)
+	assert.False(t, state.Disabled)
+	assert.Equal(t, map[string]int{"c": 4}, selections(4))
+
+	code, _ = post("/admin/upstreams/upstream-a/enable")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]int{"a": 2, "c": 2}, selections(4))
+
+	// 没有转发服务引用的上游返回 404
+	code, _ = post("/admin/upstreams/unknown/disable")
+	assert.Equal(t, http.StatusNotFound, code)
+}
@@ -0,0 +1,86 @@
+package server
+
+// upstreamSelectionStats 代表单个上游的累计选择次数
+type upstreamSelectionStats struct {
+	Name       string `json:"name"`       // 上游名称
+	Weight     int    `json:"weight"`     // 上游权重
+	Standby    bool   `json:"standby"`    // 是否为备用上游
+	Selections int64  `json:"selections"` // 累计被选中的次数
+}
+
+// groupSelectionStats 代表上游组内的选择分布
+type groupSelectionStats struct {
+	Name           string                   `json:"name"`           // 上游组名称
+	Balancer       string                   `json:"balancer"`       // 负载均衡策略
+	Total          int64                    `json:"total"`          // 组内累计选择次数
+	ImbalanceRatio float64                  `json:"imbalanceRatio"` // 非备用上游最多与最少选择次数之比
+	Upstreams      []upstreamSelectionStats `json:"upstreams"`      // 按配置顺序的上游选择次数
+}
+
+// recordUpstreamSelection 记录负载均衡器选中的上游，用于观察选择分布
+func (s *ForwardService) recordUpstreamSelection(g *upstreamGroup, upstreamName string) {
+	if stats, ok := g.upstreamHealth[upstreamName]; ok {
+		stats.selections.Add(1)
+	}
+}
+
+// BalanceStats 获取各上游组内上游的累计选择次数和不均衡比例
+func (s *ForwardService) BalanceStats() []groupSelectionStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	groups := make([]groupSelectionStats, 0, len(s.groups))
+	for _, g := range s.groups {
+		stats := groupSelectionStats{
+			Name:      g.name,
+			Upstreams: make([]upstreamSelectionStats, 0, len(g.upstreams)),
+		}
+		if g.loadBalancer != nil {
+			stats.Balancer = g.loadBalancer.Type()
+		}
+
+		for _, upstream := range g.upstreams {
+			var selections int64
+			if health, ok := g.upstreamHealth[upstream.Name]; ok {
+				selections = health.selections.Load()
+			}
+			stats.Total += selections
+			stats.Upstreams = append(stats.Upstreams, upstreamSelectionStats{
+				Name:       upstream.Name,
+				Weight:     upstream.Weight,
+				Standby:    upstream.Standby,
+				Selections: selections,
+			})
+		}
+		stats.ImbalanceRatio = imbalanceRatio(stats.Upstreams)
+
+		groups = append(groups, stats)
+	}
+	return groups
+}
+
+// imbalanceRatio 计算非备用上游最多与最少选择次数之比，用于发现卡在单个上游的轮询
+// 未被选中的上游按 1 次计算，避免除零；没有任何选择时返回 0
+// 加权策略下的比例应与权重比例接近，而不是 1
+func imbalanceRatio(upstreams []upstreamSelectionStats) float64 {
+	var maxSelections, minSelections int64 = 0, -1
+	for _, upstream := range upstreams {
+		if upstream.Standby {
+			continue
+		}
+		if upstream.Selections > maxSelections {
+			maxSelections = upstream.Selections
+		}
+		if minSelections < 0 || upstream.Selections < minSelections {
+			minSelections = upstream.Selections
+		}
+	}
+
+	if maxSelections == 0 {
+		return 0
+	}
+	if minSelections < 1 {
+		minSelections = 1
+	}
+	return float64(maxSelections) / float64(minSelections)
+}
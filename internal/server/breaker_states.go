@@ -0,0 +1,55 @@
+package server
+
+import (
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/sony/gobreaker"
+)
+
+// upstreamBreakerState 代表单个上游的熔断器状态和健康状态
+type upstreamBreakerState struct {
+	Name    string `json:"name"`    // 上游名称
+	State   string `json:"state"`   // 熔断器状态：closed、half-open、open，未配置熔断器时为 disabled
+	Healthy bool   `json:"healthy"` // 最近一次上报的健康状态，熔断器打开时始终为 false
+}
+
+// groupBreakerStates 代表上游组内各上游的熔断器状态
+type groupBreakerStates struct {
+	Name      string                 `json:"name"`      // 上游组名称
+	Upstreams []upstreamBreakerState `json:"upstreams"` // 按配置顺序的上游熔断器状态
+}
+
+// BreakerStates 获取各上游组内上游的熔断器状态和健康状态
+func (s *ForwardService) BreakerStates() []groupBreakerStates {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	groups := make([]groupBreakerStates, 0, len(s.groups))
+	for _, g := range s.groups {
+		states := groupBreakerStates{
+			Name:      g.name,
+			Upstreams: make([]upstreamBreakerState, 0, len(g.upstreams)),
+		}
+
+		for _, upstream := range g.upstreams {
+			state := upstreamBreakerState{
+				Name:    upstream.Name,
+				State:   constants.BreakerStateDisabled,
+				Healthy: true,
+			}
+			if health, ok := g.upstreamHealth[upstream.Name]; ok {
+				state.Healthy = health.healthy.Load()
+			}
+			if upstream.Breaker != nil {
+				breakerState := upstream.Breaker.State()
+				state.State = breakerState.String()
+				if breakerState == gobreaker.StateOpen {
+					state.Healthy = false
+				}
+			}
+			states.Upstreams = append(states.Upstreams, state)
+		}
+
+		groups = append(groups, states)
+	}
+	return groups
+}
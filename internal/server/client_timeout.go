@@ -0,0 +1,36 @@
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// clientTimeout 解析客户端通过 clientTimeoutHeader 头部指定的请求超时时间，格式如 30s、1500ms
+// 未启用、未携带头部或头部值无效时返回 false，超时时间不超过上游组的请求超时时间
+// req: 客户端请求
+// requestID: 请求 ID，用于日志
+// maxTimeout: 上游组的请求超时时间，为 0 时不限制
+func (s *ForwardService) clientTimeout(req *http.Request, requestID string, maxTimeout time.Duration) (time.Duration, bool) {
+	if s.config == nil || s.config.ClientTimeoutHeader == "" {
+		return 0, false
+	}
+
+	value := req.Header.Get(s.config.ClientTimeoutHeader)
+	if value == "" {
+		return 0, false
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		s.logger.V(1).Info("Ignoring invalid client timeout header",
+			"request_id", requestID,
+			"header", s.config.ClientTimeoutHeader,
+			"value", value)
+		return 0, false
+	}
+
+	if maxTimeout > 0 && timeout > maxTimeout {
+		timeout = maxTimeout
+	}
+	return timeout, true
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/balance"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"golang.org/x/sync/singleflight"
+)
+
+// coalescedResponse 代表合并请求共享的上游响应，响应体已完整读入内存
+type coalescedResponse struct {
+	upstream   balance.Upstream // 实际处理请求的上游服务
+	aborted    bool             // 发起请求者的上下文已结束（客户端取消或超时），结果不适用于其他请求
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+// newResponse 为每个共享响应的请求创建独立的 http.Response，避免并发修改头部和读取响应体
+func (r *coalescedResponse) newResponse() *http.Response {
+	return &http.Response{
+		StatusCode:    r.statusCode,
+		Header:        r.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(r.body)),
+		ContentLength: int64(len(r.body)),
+	}
+}
+
+// defaultCoalesceKeyHeaders 默认参与合并键计算的凭据头部
+var defaultCoalesceKeyHeaders = constants.CredentialHeaders
+
+// newCoalesceKeyHeaders 获取参与合并键计算的凭据头部，未配置时使用默认头部
+// 按头部限流时的限流键头部同样标识客户端，总是参与计算
+func newCoalesceKeyHeaders(cfg *config.ForwardConfig) []string {
+	configured := cfg.CoalesceKeyHeaders
+	if len(configured) == 0 {
+		configured = defaultCoalesceKeyHeaders
+	}
+	if cfg.RateLimit != nil && cfg.RateLimit.KeyBy == constants.RateLimitKeyByHeader && cfg.RateLimit.Header != "" {
+		configured = append(configured[:len(configured):len(configured)], cfg.RateLimit.Header)
+	}
+
+	headers := make([]string, 0, len(configured))
+	seen := make(map[string]struct{}, len(configured))
+	for _, name := range configured {
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if _, exists := seen[name]; exists {
+			continue
+		}
+		seen[name] = struct{}{}
+		headers = append(headers, name)
+	}
+	return headers
+}
+
+// coalesceKey 生成合并请求的键，未启用请求合并或请求体无法重复读取（流式转发）时返回空字符串
+// 键由上游组、请求方法、请求 URI、凭据头部、客户端指定的上游和请求体哈希共同得到，避免不同客户端之间共享响应
+func (s *ForwardService) coalesceKey(req, proxyReq *http.Request, group *upstreamGroup) (string, error) {
+	if s.config == nil || !s.config.Coalesce {
+		return "", nil
+	}
+
+	hash := sha256.New()
+	for _, part := range []string{
+		group.name,
+		req.Method,
+		req.URL.RequestURI(),
+		req.Header.Get(constants.HeaderLLMProxyUpstream),
+	} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	for _, name := range s.coalesceKeyHeaders {
+		hash.Write([]byte(name))
+		hash.Write([]byte{0})
+		for _, value := range req.Header.Values(name) {
+			hash.Write([]byte(value))
+			hash.Write([]byte{0})
+		}
+		hash.Write([]byte{0})
+	}
+
+	if proxyReq.Body != nil && proxyReq.Body != http.NoBody {
+		if proxyReq.GetBody == nil {
+			return "", nil
+		}
+		body, err := proxyReq.GetBody()
+		if err != nil {
+			return "", fmt.Errorf("failed to read request body for coalescing: %w", err)
+		}
+		defer body.Close()
+		if _, err := io.Copy(hash, body); err != nil {
+			return "", fmt.Errorf("failed to read request body for coalescing: %w", err)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// executeCoalesced 合并相同键的并发请求，只有第一个请求访问上游，其余请求共享其非流式响应或执行错误
+// 共享结果时返回 true 和实际处理请求的上游服务，调用方不应重复记录上游指标和健康状态
+// 上游返回流式响应时无法共享，等待中的请求各自执行上游请求；发起请求者因取消或超时失败时，等待中的请求同样各自执行
+// ctx: 当前请求的上游请求上下文，等待期间结束时立即返回
+// upstream: 当前请求选择的上游服务
+// execute: 访问 upstream 的上游请求
+func (s *ForwardService) executeCoalesced(ctx context.Context, key string, upstream balance.Upstream, execute func() (*http.Response, error)) (*http.Response, balance.Upstream, bool, error) {
+	var leader atomic.Bool
+	var streamResp *http.Response
+	ch := s.coalesceGroup.DoChan(key, func() (any, error) {
+		leader.Store(true)
+		shared := &coalescedResponse{upstream: upstream}
+
+		resp, err := execute()
+		if err != nil {
+			shared.aborted = ctx.Err() != nil
+			return shared, err
+		}
+		if s.isStreamingResponse(resp) {
+			streamResp = resp
+			return nil, nil
+		}
+
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return shared, fmt.Errorf("failed to read upstream response: %w", err)
+		}
+		shared.statusCode, shared.header, shared.body = resp.StatusCode, resp.Header, body
+		return shared, nil
+	})
+
+	var result singleflight.Result
+	select {
+	case result = <-ch:
+	case <-ctx.Done():
+		// 发起请求者的上游请求使用同一上下文，随之结束，未被读取的流式响应在后台关闭
+		go func() {
+			<-ch
+			if streamResp != nil {
+				streamResp.Body.Close()
+			}
+		}()
+		return nil, upstream, !leader.Load(), ctx.Err()
+	}
+
+	if leader.Load() {
+		if streamResp != nil {
+			return streamResp, upstream, false, nil
+		}
+		if result.Err != nil {
+			return nil, upstream, false, result.Err
+		}
+		return result.Val.(*coalescedResponse).newResponse(), upstream, false, nil
+	}
+
+	// 共享的结果无法使用时各自执行上游请求
+	if result.Val == nil || result.Val.(*coalescedResponse).aborted {
+		resp, err := execute()
+		return resp, upstream, false, err
+	}
+
+	shared := result.Val.(*coalescedResponse)
+	if result.Err != nil {
+		return nil, shared.upstream, true, result.Err
+	}
+	return shared.newResponse(), shared.upstream, true, nil
+}
@@ -0,0 +1,42 @@
+package server
+
+import "github.com/shengyanli1982/llmproxy-go/internal/client"
+
+// reportUpstreamConnections 上报各上游组内每个上游的连接池活跃和空闲连接数指标
+func (s *ForwardService) reportUpstreamConnections() {
+	if s.metricsCollector == nil {
+		return
+	}
+
+	for _, g := range s.groups {
+		if g.httpClient == nil {
+			continue
+		}
+		for i := range g.upstreams {
+			stats := g.httpClient.ConnectionStats(&g.upstreams[i])
+			s.metricsCollector.RecordUpstreamConnections(g.name, g.upstreams[i].Name, stats.Active, stats.Idle)
+		}
+	}
+}
+
+// UpstreamConnections 获取每个上游的连接池活跃和空闲连接数，键为上游名称
+// 上游被多个上游组引用时，各组使用独立的连接池，连接数合并计算
+func (s *ForwardService) UpstreamConnections() map[string]client.ConnectionStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	connections := make(map[string]client.ConnectionStats)
+	for _, g := range s.groups {
+		if g.httpClient == nil {
+			continue
+		}
+		for i := range g.upstreams {
+			stats := g.httpClient.ConnectionStats(&g.upstreams[i])
+			total := connections[g.upstreams[i].Name]
+			total.Active += stats.Active
+			total.Idle += stats.Idle
+			connections[g.upstreams[i].Name] = total
+		}
+	}
+	return connections
+}
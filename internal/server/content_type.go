@@ -0,0 +1,48 @@
+package server
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// parseAllowedContentTypes 将允许的 Content-Type 列表规范化为小写媒体类型集合
+// 未配置时返回 nil，表示允许所有 Content-Type
+func parseAllowedContentTypes(contentTypes []string) map[string]struct{} {
+	if len(contentTypes) == 0 {
+		return nil
+	}
+
+	allowed := make(map[string]struct{}, len(contentTypes))
+	for _, contentType := range contentTypes {
+		allowed[normalizeMediaType(contentType)] = struct{}{}
+	}
+	return allowed
+}
+
+// normalizeMediaType 提取 Content-Type 中的媒体类型，忽略 charset 等参数并统一为小写
+func normalizeMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, _, _ = strings.Cut(contentType, ";")
+	}
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// isContentTypeAllowed 判断请求的 Content-Type 是否在允许列表中
+// 未配置允许列表时放行所有请求，不携带请求体且未声明 Content-Type 的请求同样放行
+func (s *ForwardService) isContentTypeAllowed(req *http.Request) bool {
+	if s.allowedContentTypes == nil {
+		return true
+	}
+
+	contentType := req.Header.Get(constants.HeaderContentType)
+	if contentType == "" {
+		return req.ContentLength == 0
+	}
+
+	_, allowed := s.allowedContentTypes[normalizeMediaType(contentType)]
+	return allowed
+}
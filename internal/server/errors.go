@@ -17,4 +17,25 @@ var (
 	ErrServiceAlreadyStarted = errors.New(constants.ErrMsgServiceAlreadyStarted)
 	ErrServiceNotStarted     = errors.New(constants.ErrMsgServiceNotStarted)
 	ErrServiceIsNotRunning   = errors.New(constants.ErrMsgServiceNotRunning)
+
+	// 请求错误
+	ErrRequestBodyTooLarge        = errors.New(constants.ErrMsgRequestBodyTooLarge)
+	ErrUnsupportedContentType     = errors.New(constants.ErrMsgUnsupportedContentType)
+	ErrInterceptorRejectedRequest = errors.New(constants.ErrMsgInterceptorRejectedRequest)
+	ErrUpstreamOverrideNotFound   = errors.New(constants.ErrMsgUpstreamOverrideNotFound)
+	ErrUpstreamOverrideNotInGroup = errors.New(constants.ErrMsgUpstreamOverrideNotInGroup)
+	ErrUpstreamOverrideDisabled   = errors.New(constants.ErrMsgUpstreamOverrideDisabled)
+
+	// 上游停用错误
+	ErrLastEnabledUpstream = errors.New(constants.ErrMsgLastEnabledUpstream)
+
+	// 配置重新加载错误
+	ErrReloadRequiresRestart = errors.New(constants.ErrMsgReloadRequiresRestart)
 )
+
+// isClientRejection 判断错误是否为客户端请求被拒绝，此类错误已返回对应的 4xx 响应，不计为处理错误
+func isClientRejection(err error) bool {
+	return errors.Is(err, ErrUnsupportedContentType) || errors.Is(err, ErrRequestBodyTooLarge) ||
+		errors.Is(err, ErrUpstreamOverrideNotFound) || errors.Is(err, ErrUpstreamOverrideNotInGroup) ||
+		errors.Is(err, ErrUpstreamOverrideDisabled) || errors.Is(err, ErrInterceptorRejectedRequest)
+}
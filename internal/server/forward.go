@@ -2,18 +2,22 @@ package server
 
 import (
 	"fmt"
+	"net"
 	"sync"
 
 	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/activation"
 	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
 	"github.com/shengyanli1982/orbit"
 )
 
 // ForwardServer 代表转发服务器，负责处理客户端请求并转发到上游服务
 type ForwardServer struct {
 	name         string                // 服务器名称
-	endpoint     string                // 服务器监听地址
-	httpEngine   *orbit.Engine         // HTTP 引擎实例
+	endpoints    []string              // 服务器配置的监听地址，与 httpEngines 一一对应
+	httpEngines  []forwardEngine       // 每个监听地址对应的 HTTP 引擎实例，共享同一转发服务
+	certificates *certificateStore     // TLS 证书存储，未启用 TLS 时为 nil
 	closeOnce    sync.Once             // 确保只关闭一次
 	config       *config.ForwardConfig // 转发服务配置
 	globalConfig *config.Config        // 全局配置
@@ -28,17 +32,72 @@ type ForwardServer struct {
 // config: 转发服务配置
 // globalConfig: 全局配置
 func NewForwardServer(debug bool, logger *logr.Logger, config *config.ForwardConfig, globalConfig *config.Config) *ForwardServer {
-	endpoint := fmt.Sprintf("%s:%d", config.Address, config.Port)
+	// 创建转发服务实例
+	svcs := NewForwardServices()
 
+	// 初始化转发服务
+	if err := svcs.Initialize(config, globalConfig, logger); err != nil {
+		logger.Error(err, "Failed to initialize forward service")
+		// 在实际项目中可能需要更好的错误处理
+	}
+
+	// 启用 TLS 时加载证书，所有监听地址共享同一证书
+	var certificates *certificateStore
+	if config.TLS != nil {
+		certificates = &certificateStore{}
+		if err := certificates.Load(config.TLS); err != nil {
+			logger.Error(err, "Failed to load forward tls certificate", "name", config.Name)
+		}
+	}
+
+	// 为每个监听地址创建 HTTP 引擎，所有引擎共享同一转发服务
+	listeners := config.GetListeners()
+	endpoints := make([]string, 0, len(listeners))
+	engines := make([]forwardEngine, 0, len(listeners))
+	for _, listener := range listeners {
+		endpoint := fmt.Sprintf("%s:%d", listener.Address, listener.Port)
+
+		var engine forwardEngine
+		if fd, ok := inheritedSocket(config, listener); ok {
+			// 使用进程继承的监听套接字（systemd socket activation），不自行绑定地址
+			endpoint = fmt.Sprintf("fd:%d", fd)
+			engine = newServerEngine(logger, config, globalConfig, endpoint, inheritedListen(config.Name), certificates)
+		} else if certificates != nil {
+			engine = newServerEngine(logger, config, globalConfig, endpoint, tcpListen(endpoint), certificates)
+		} else {
+			engine = newForwardEngine(debug, logger, config, globalConfig, listener)
+		}
+		engine.RegisterService(svcs)
+
+		endpoints = append(endpoints, endpoint)
+		engines = append(engines, engine)
+	}
+
+	return &ForwardServer{
+		name:         config.Name,
+		endpoints:    endpoints,
+		httpEngines:  engines,
+		certificates: certificates,
+		config:       config,
+		globalConfig: globalConfig,
+		debug:        debug,
+		logger:       logger,
+		service:      svcs,
+	}
+}
+
+// newForwardEngine 创建监听指定地址的 HTTP 引擎
+func newForwardEngine(debug bool, logger *logr.Logger, config *config.ForwardConfig, globalConfig *config.Config, listener config.ListenerConfig) *orbit.Engine {
 	// 创建 Orbit 引擎配置
 	cfg := orbit.NewConfig().
 		WithLogger(logger).
-		WithAddress(config.Address).
-		WithPort(uint16(config.Port)).
+		WithAddress(listener.Address).
+		WithPort(uint16(listener.Port)).
 		WithHttpIdleTimeout(uint32(config.Timeout.Idle)). // 配置提供的单位是毫秒，直接使用
 		WithHttpReadHeaderTimeout(uint32(config.Timeout.Read)).
 		WithHttpReadTimeout(uint32(config.Timeout.Read)).
-		WithHttpWriteTimeout(uint32(config.Timeout.Write))
+		WithHttpWriteTimeout(uint32(config.Timeout.Write)).
+		WithMaxHeaderBytes(maxHeaderBytes(globalConfig))
 
 	// 创建引擎选项
 	opts := orbit.EmptyOptions()
@@ -47,46 +106,57 @@ func NewForwardServer(debug bool, logger *logr.Logger, config *config.ForwardCon
 	}
 
 	// 创建 HTTP 引擎
-	engine := orbit.NewEngine(cfg, opts)
-
-	// 创建转发服务实例
-	svcs := NewForwardServices()
+	return orbit.NewEngine(cfg, opts)
+}
 
-	// 初始化转发服务
-	if err := svcs.Initialize(config, globalConfig, logger); err != nil {
-		logger.Error(err, "Failed to initialize forward service")
-		// 在实际项目中可能需要更好的错误处理
+// inheritedSocket 查找监听地址对应的继承监听套接字，仅在未配置 listeners 且端口为 0 时使用
+func inheritedSocket(config *config.ForwardConfig, listener config.ListenerConfig) (int, bool) {
+	if listener.Port != 0 || len(config.Listeners) > 0 {
+		return 0, false
 	}
+	return activation.Lookup(config.Name)
+}
 
-	// 注册服务到引擎
-	engine.RegisterService(svcs)
+// inheritedListen 返回从转发服务对应的继承监听套接字创建监听器的函数
+func inheritedListen(name string) listenFunc {
+	return func() (net.Listener, error) {
+		listener, ok, err := activation.Listener(name)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("no inherited socket for forward service %s", name)
+		}
+		return listener, nil
+	}
+}
 
-	return &ForwardServer{
-		name:         config.Name,
-		endpoint:     endpoint,
-		httpEngine:   engine,
-		config:       config,
-		globalConfig: globalConfig,
-		debug:        debug,
-		logger:       logger,
-		service:      svcs,
+// maxHeaderBytes 获取允许的最大请求头部大小，未配置时使用默认值
+// 超过限制的请求由 net/http 在进入处理器之前直接返回 431，无法使用统一的响应信封
+// globalConfig: 全局配置
+func maxHeaderBytes(globalConfig *config.Config) uint32 {
+	if globalConfig != nil && globalConfig.HTTPServer.MaxHeaderBytes > 0 {
+		return uint32(globalConfig.HTTPServer.MaxHeaderBytes)
 	}
+	return constants.DefaultMaxHeaderBytes
 }
 
 // Start 启动转发服务器
 func (s *ForwardServer) Start() {
-	if s.httpEngine.IsRunning() {
+	if s.IsRunning() {
 		s.logger.Error(ErrServerAlreadyStarted, "Forward server is already started", "name", s.name)
 		return
 	}
 
-	s.logger.Info("Starting forward server", "name", s.name, "endpoint", s.endpoint)
+	s.logger.Info("Starting forward server", "name", s.name, "endpoints", s.endpoints)
 
 	// 启动转发服务
 	s.service.Run()
 
-	// 启动 HTTP 引擎
-	s.httpEngine.Run()
+	// 启动所有监听地址的 HTTP 引擎
+	for _, engine := range s.httpEngines {
+		engine.Run()
+	}
 
 	// 重置关闭标志
 	s.closeOnce = sync.Once{}
@@ -94,7 +164,7 @@ func (s *ForwardServer) Start() {
 
 // Stop 停止转发服务器
 func (s *ForwardServer) Stop() {
-	if !s.httpEngine.IsRunning() {
+	if !s.IsRunning() {
 		s.logger.Info("Forward server is not running", "name", s.name)
 		return
 	}
@@ -102,25 +172,54 @@ func (s *ForwardServer) Stop() {
 	s.logger.Info("Stopping forward server", "name", s.name)
 
 	s.closeOnce.Do(func() {
-		// 停止 HTTP 引擎
-		s.httpEngine.Stop()
+		// 停止所有监听地址的 HTTP 引擎
+		for _, engine := range s.httpEngines {
+			if engine.IsRunning() {
+				engine.Stop()
+			}
+		}
 
 		// 停止转发服务
 		s.service.Stop()
 	})
 }
 
-// IsRunning 检查转发服务器是否正在运行
+// IsRunning 检查转发服务器是否正在运行，任一监听地址的 HTTP 引擎运行即视为运行中
 func (s *ForwardServer) IsRunning() bool {
-	return s.httpEngine.IsRunning()
+	for _, engine := range s.httpEngines {
+		if engine.IsRunning() {
+			return true
+		}
+	}
+	return false
 }
 
-// GetEndpoint 获取服务器实际监听地址（运行时分配的地址）
+// GetEndpoint 获取服务器第一个监听地址的实际监听地址（运行时分配的地址）
 func (s *ForwardServer) GetEndpoint() string {
-	if s.httpEngine != nil {
-		return s.httpEngine.GetListenEndpoint()
+	endpoints := s.GetEndpoints()
+	if len(endpoints) == 0 {
+		return ""
+	}
+	return endpoints[0]
+}
+
+// GetEndpoints 获取服务器所有监听地址的实际监听地址，顺序与配置一致
+func (s *ForwardServer) GetEndpoints() []string {
+	endpoints := make([]string, 0, len(s.httpEngines))
+	for _, engine := range s.httpEngines {
+		endpoints = append(endpoints, engine.GetListenEndpoint())
+	}
+	return endpoints
+}
+
+// ReloadCertificate 从证书文件重新加载 TLS 证书，新建立的连接使用新证书，已建立的连接不受影响
+// 加载失败时继续使用当前证书；启动时未启用 TLS 的转发服务器需要重启才能切换为 HTTPS
+// cfg: 转发服务的 TLS 证书配置
+func (s *ForwardServer) ReloadCertificate(cfg *config.ForwardTLSConfig) error {
+	if s.certificates == nil {
+		return fmt.Errorf("forward server %s was started without tls, restart required", s.name)
 	}
-	return s.endpoint
+	return s.certificates.Load(cfg)
 }
 
 // GetConfig 获取转发服务配置
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/shengyanli1982/orbit"
+)
+
+// forwardEngine 代表转发服务器监听地址的 HTTP 引擎，orbit 引擎和 serverEngine 均实现该接口
+type forwardEngine interface {
+	Run()
+	Stop()
+	IsRunning() bool
+	GetListenEndpoint() string
+	RegisterService(service orbit.Service)
+}
+
+// listenFunc 创建 HTTP 引擎使用的监听器，每次启动时调用
+type listenFunc func() (net.Listener, error)
+
+// serverEngine 代表基于 net/http 服务器的 HTTP 引擎
+// orbit 引擎只能自行绑定地址且不支持 TLS，直接提供 HTTPS 或使用继承的监听套接字时使用该引擎
+// 直接提供 HTTPS 时请求在 TLS 连接上处理，req.TLS 不为空，转发时 X-Forwarded-Proto 为 https
+type serverEngine struct {
+	endpoint     string                // 监听地址和端口，启动后更新为监听器的实际地址
+	listen       listenFunc            // 创建监听器
+	router       *gin.Engine           // 路由引擎
+	server       *http.Server          // HTTP 服务器，启动时创建
+	certificates *certificateStore     // 证书存储，与转发服务器共享，为 nil 时提供 HTTP
+	timeout      *config.TimeoutConfig // 转发服务超时配置
+	headerBytes  uint32                // 允许的最大请求头部大小
+	logger       *logr.Logger          // 日志记录器
+	services     []orbit.Service       // 启动时注册到路由的服务
+	running      bool                  // 运行状态
+	mu           sync.Mutex            // 保护运行状态
+	wg           sync.WaitGroup        // 等待服务协程退出
+}
+
+// newServerEngine 创建使用指定监听器的 HTTP 引擎，超时配置与 orbit 引擎一致
+// endpoint: 启动前显示的监听地址
+// listen: 创建监听器，如绑定地址或使用继承的套接字
+// certificates: 证书存储，为 nil 时提供 HTTP
+func newServerEngine(logger *logr.Logger, config *config.ForwardConfig, globalConfig *config.Config, endpoint string, listen listenFunc, certificates *certificateStore) *serverEngine {
+	router := gin.New()
+	router.HandleMethodNotAllowed = true
+	router.Use(gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		logger.Error(fmt.Errorf("%v", err), "Recovered from panic in forward handler", "path", c.Request.URL.Path)
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+
+	return &serverEngine{
+		endpoint:     endpoint,
+		listen:       listen,
+		router:       router,
+		certificates: certificates,
+		timeout:      config.Timeout,
+		headerBytes:  maxHeaderBytes(globalConfig),
+		logger:       logger,
+	}
+}
+
+// tcpListen 返回绑定指定地址的监听器创建函数
+func tcpListen(endpoint string) listenFunc {
+	return func() (net.Listener, error) {
+		return net.Listen("tcp", endpoint)
+	}
+}
+
+// RegisterService 注册转发服务，仅在启动前有效
+func (e *serverEngine) RegisterService(service orbit.Service) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.running {
+		e.services = append(e.services, service)
+	}
+}
+
+// Run 注册服务路由，创建监听器并在后台启动 HTTP 服务器
+func (e *serverEngine) Run() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.running {
+		return
+	}
+
+	listener, err := e.listen()
+	if err != nil {
+		e.logger.Error(err, "failed to start http server", "address", e.endpoint)
+		return
+	}
+	e.endpoint = listener.Addr().String()
+
+	for _, service := range e.services {
+		service.RegisterGroup(&e.router.RouterGroup)
+	}
+	e.services = nil
+
+	e.server = &http.Server{
+		Handler:        e.router,
+		MaxHeaderBytes: int(e.headerBytes),
+	}
+	if e.certificates != nil {
+		e.server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: e.certificates.GetCertificate,
+		}
+	}
+	if e.timeout != nil {
+		e.server.IdleTimeout = time.Duration(e.timeout.Idle) * time.Millisecond
+		e.server.ReadHeaderTimeout = time.Duration(e.timeout.Read) * time.Millisecond
+		e.server.ReadTimeout = time.Duration(e.timeout.Read) * time.Millisecond
+		e.server.WriteTimeout = time.Duration(e.timeout.Write) * time.Millisecond
+	}
+
+	e.wg.Add(1)
+	go func(server *http.Server, endpoint string) {
+		defer e.wg.Done()
+		e.logger.Info("http server is ready", "address", endpoint, "tls", server.TLSConfig != nil)
+
+		var err error
+		if server.TLSConfig != nil {
+			// 证书由 TLSConfig.GetCertificate 提供，无需传入证书文件
+			err = server.ServeTLS(listener, "", "")
+		} else {
+			err = server.Serve(listener)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.logger.Error(err, "http server stopped unexpectedly", "address", endpoint)
+		}
+	}(e.server, e.endpoint)
+
+	e.running = true
+}
+
+// Stop 优雅关闭 HTTP 服务器
+func (e *serverEngine) Stop() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.running {
+		return
+	}
+	e.running = false
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(constants.DefaultServerShutdownTimeout)*time.Millisecond)
+	defer cancel()
+	if err := e.server.Shutdown(ctx); err != nil {
+		e.logger.Error(err, "http server forced to shutdown", "address", e.endpoint)
+	}
+	e.wg.Wait()
+}
+
+// IsRunning 检查 HTTP 服务器是否正在运行
+func (e *serverEngine) IsRunning() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.running
+}
+
+// GetListenEndpoint 获取监听地址
+func (e *serverEngine) GetListenEndpoint() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.endpoint
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/shengyanli1982/llmproxy-go/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestForwardServer_InheritedSocket 测试未配置端口时转发服务器使用进程继承的监听套接字
+func TestForwardServer_InheritedSocket(t *testing.T) {
+	logger := logr.Discard()
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer upstreamServer.Close()
+
+	// 模拟进程管理器预先创建的监听套接字
+	socket, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer socket.Close()
+	file, err := socket.(*net.TCPListener).File()
+	require.NoError(t, err)
+	defer file.Close()
+	t.Setenv(constants.EnvForwardFD, "activated-forward="+strconv.Itoa(int(file.Fd())))
+
+	forwardConfig := &config.ForwardConfig{
+		Name:         "activated-forward",
+		DefaultGroup: "activated-group",
+		Timeout:      &config.TimeoutConfig{Idle: 30000, Read: 15000, Write: 15000},
+	}
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{{Name: "activated-upstream", URL: upstreamServer.URL}},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{Name: "activated-group", Upstreams: []config.UpstreamRefConfig{{Name: "activated-upstream"}}},
+		},
+	}
+
+	forwardServer := NewForwardServer(false, &logger, forwardConfig, globalConfig)
+	assert.Equal(t, "fd:"+strconv.Itoa(int(file.Fd())), forwardServer.GetEndpoint())
+
+	forwardServer.Start()
+	defer forwardServer.Stop()
+	require.True(t, forwardServer.IsRunning())
+	assert.Equal(t, socket.Addr().String(), forwardServer.GetEndpoint())
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get("http://" + forwardServer.GetEndpoint() + "/v1/models")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"ok":true}`, string(body))
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/shengyanli1982/llmproxy-go/internal/metrics"
+)
+
+// ginMetricsMiddleware 在转发端口上提供仅包含当前转发服务指标的 /_metrics 端点
+// 转发处理器使用通配路由，无法再注册同级静态路由，因此通过中间件拦截该路径
+func (s *ForwardService) ginMetricsMiddleware() gin.HandlerFunc {
+	gatherer := metrics.NewLabelFilterGatherer(metrics.GetGlobalRegistry().GetRegistry(), metrics.LabelForwardName, s.config.Name)
+	handler := promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	})
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet || c.Request.URL.Path != constants.ForwardMetricsPath {
+			c.Next()
+			return
+		}
+
+		handler.ServeHTTP(c.Writer, c.Request)
+		c.Abort()
+	}
+}
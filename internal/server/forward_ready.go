@@ -0,0 +1,27 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/shengyanli1982/llmproxy-go/internal/response"
+)
+
+// ginReadyMiddleware 在转发端口上提供 /_ready 就绪检查端点，排空期间返回 503，供负载均衡器摘除该转发服务
+// 转发处理器使用通配路由，无法再注册同级静态路由，因此通过中间件拦截该路径
+func (s *ForwardService) ginReadyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet || c.Request.URL.Path != constants.ForwardReadyPath {
+			c.Next()
+			return
+		}
+
+		if s.IsDraining() {
+			response.ServiceUnavailable(c, "service is draining")
+		} else {
+			response.OK(c, map[string]interface{}{"ready": true})
+		}
+		c.Abort()
+	}
+}
@@ -2,16 +2,23 @@ package server
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
+	"net"
 	"net/http"
+	"runtime/debug"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-logr/logr"
-	"github.com/rs/xid"
 	"github.com/shengyanli1982/llmproxy-go/internal/auth"
 	"github.com/shengyanli1982/llmproxy-go/internal/balance"
 	"github.com/shengyanli1982/llmproxy-go/internal/breaker"
@@ -19,29 +26,34 @@ import (
 	"github.com/shengyanli1982/llmproxy-go/internal/config"
 	"github.com/shengyanli1982/llmproxy-go/internal/constants"
 	"github.com/shengyanli1982/llmproxy-go/internal/headers"
+	"github.com/shengyanli1982/llmproxy-go/internal/idempotency"
 	"github.com/shengyanli1982/llmproxy-go/internal/metrics"
 	"github.com/shengyanli1982/llmproxy-go/internal/ratelimit"
 	"github.com/shengyanli1982/llmproxy-go/internal/response"
+	"github.com/shengyanli1982/llmproxy-go/internal/transform"
+	"github.com/sony/gobreaker"
+	"golang.org/x/sync/singleflight"
 )
 
 const (
 	// MaxRequestBodySize 定义请求体的最大大小（64MB）
 	// 防止过大的请求体导致内存耗尽
 	MaxRequestBodySize = 64 << 20 // 64MB
-)
 
-// 流式传输缓冲区对象池，减少频繁的内存分配
-var streamingBufferPool = sync.Pool{
-	New: func() interface{} {
-		return make([]byte, 4096)
-	},
-}
+	// idempotencyRecorderKey gin 上下文中幂等响应记录器的键
+	idempotencyRecorderKey = "llmproxy.idempotency.recorder"
+
+	// streamTransformerKey gin 上下文中流式响应事件转换器的键
+	streamTransformerKey = "llmproxy.stream.transformer"
+)
 
-// 非流式传输缓冲区对象池，减少频繁的内存分配
-var nonStreamingBufferPool = sync.Pool{
-	New: func() interface{} {
-		return make([]byte, 32*1024)
-	},
+// newBufferPool 创建指定大小的缓冲区对象池，减少频繁的内存分配
+func newBufferPool(size int) *sync.Pool {
+	return &sync.Pool{
+		New: func() interface{} {
+			return make([]byte, size)
+		},
+	}
 }
 
 // ForwardService 代表转发服务，处理客户端请求转发逻辑
@@ -52,17 +64,61 @@ type ForwardService struct {
 	logger       *logr.Logger          // 日志记录器
 
 	// 功能模块
-	loadBalancer     balance.LoadBalancer           // 负载均衡器
-	httpClient       client.HTTPClient              // HTTP客户端
-	rateLimitMW      *ratelimit.RateLimitMiddleware // 限流中间件
-	authFactory      auth.AuthenticatorFactory      // 认证工厂
-	headerOperator   headers.HeaderOperator         // 头部操作器
-	breakerFactory   breaker.CircuitBreakerFactory  // 熔断器工厂
-	metricsCollector metrics.MetricsCollector       // 指标收集器
+	rateLimitMW        *ratelimit.RateLimitMiddleware // 限流中间件
+	authFactory        auth.AuthenticatorFactory      // 认证工厂
+	headerOperator     headers.HeaderOperator         // 头部操作器
+	breakerFactory     breaker.CircuitBreakerFactory  // 熔断器工厂
+	metricsCollector   metrics.MetricsCollector       // 指标收集器
+	idempotencyStore   *idempotency.Store             // 幂等键响应存储，未启用时为 nil
+	coalesceGroup      singleflight.Group             // 合并相同的并发请求，未启用请求合并时不使用
+	coalesceKeyHeaders []string                       // 参与合并键计算的凭据头部
+
+	// 访问日志采样率，取值 (0, 1]，1 表示记录全部成功请求的访问日志
+	accessLogSampleRate float64
+
+	// 慢请求日志阈值，为 0 时不记录慢请求日志
+	slowRequestThreshold time.Duration
+
+	// 请求在代理内的最大处理时长，为 0 时不限制
+	maxRequestDuration time.Duration
+
+	// 可信代理网段，为 nil 时信任所有来源的 X-Forwarded-* 头部
+	trustedProxies []*net.IPNet
+
+	// 允许的请求媒体类型集合，为 nil 时允许所有 Content-Type
+	allowedContentTypes map[string]struct{}
+
+	// 上游响应头部过滤策略，为 nil 时转发全部头部
+	responseHeaderPolicy *responseHeaderPolicy
+
+	// 上游组没有可选择的上游时的处理方式，为 nil 时返回错误响应
+	noUpstreamBehavior *noUpstreamBehavior
+
+	// 基于上游响应头部的会话亲和，为 nil 时不启用
+	responseAffinity *responseAffinity
+
+	// 请求 ID 策略，为 nil 时仅读取 X-Request-ID 头部用于日志
+	requestIDPolicy *requestIDPolicy
+
+	// 并发准入控制，容量为最大并发请求数，为 nil 时不限制
+	concurrencySem chan struct{}
+
+	// 关闭排空
+	inFlight atomic.Int64 // 进行中的请求数
+	draining atomic.Bool  // 是否正在排空，排空期间拒绝新请求
+
+	// 上游健康状态指标上报间隔
+	healthStatusInterval time.Duration
+
+	// 响应复制缓冲区
+	streamingBufferPool    *sync.Pool // 流式传输缓冲区对象池
+	nonStreamingBufferPool *sync.Pool // 非流式传输缓冲区对象池
 
 	// 运行时数据
-	upstreams   []balance.Upstream                // 上游服务列表
-	upstreamMap map[string]*config.UpstreamConfig // 上游配置映射
+	groups        []*upstreamGroup                  // 引用的上游组，按配置顺序
+	groupRefs     []balance.Upstream                // 上游组名称和权重，用于按权重选择上游组
+	groupBalancer balance.LoadBalancer              // 上游组选择器（平滑加权轮询）
+	upstreamMap   map[string]*config.UpstreamConfig // 上游配置映射
 
 	// 状态控制
 	running bool          // 运行状态
@@ -80,6 +136,9 @@ func NewForwardServices() *ForwardService {
 		breakerFactory: breaker.NewFactory(),
 		upstreamMap:    make(map[string]*config.UpstreamConfig),
 		stopCh:         make(chan struct{}),
+
+		streamingBufferPool:    newBufferPool(constants.DefaultStreamBufferSize),
+		nonStreamingBufferPool: newBufferPool(constants.DefaultCopyBufferSize),
 	}
 }
 
@@ -97,56 +156,56 @@ func (s *ForwardService) Initialize(cfg *config.ForwardConfig, globalConfig *con
 		"address", cfg.Address,
 		"port", cfg.Port)
 
-	// 初始化限流中间件
-	if cfg.RateLimit != nil {
-		s.rateLimitMW = ratelimit.NewRateLimitMiddleware(
-			float64(cfg.RateLimit.PerSecond), cfg.RateLimit.Burst,
-			float64(cfg.RateLimit.PerSecond), cfg.RateLimit.Burst,
-		)
-	} else {
-		s.logger.Info("Rate limiting disabled")
-	}
-
-	// 查找默认上游组
-	var defaultGroup *config.UpstreamGroupConfig
-	for _, group := range globalConfig.UpstreamGroups {
-		if group.Name == cfg.DefaultGroup {
-			defaultGroup = &group
-			break
-		}
-	}
+	// 按配置重建响应复制缓冲区对象池
+	s.initializeBufferPools(&globalConfig.HTTPServer)
 
-	if defaultGroup == nil {
-		s.logger.Error(nil, "Default upstream group not found", "group_name", cfg.DefaultGroup)
-		return fmt.Errorf("default upstream group '%s' not found", cfg.DefaultGroup)
+	s.accessLogSampleRate = constants.DefaultAccessLogSampleRate
+	if globalConfig.HTTPServer.AccessLogSampleRate > 0 {
+		s.accessLogSampleRate = globalConfig.HTTPServer.AccessLogSampleRate
 	}
 
-	s.logger.Info("Found default upstream group",
-		"group_name", defaultGroup.Name,
-		"upstream_count", len(defaultGroup.Upstreams))
+	s.slowRequestThreshold = time.Duration(cfg.SlowRequestThreshold) * time.Millisecond
+	s.maxRequestDuration = time.Duration(cfg.MaxRequestDuration) * time.Millisecond
 
-	// 构建上游服务列表
-	if err := s.buildUpstreams(defaultGroup, globalConfig); err != nil {
-		s.logger.Error(err, "Failed to build upstreams")
-		return fmt.Errorf("failed to build upstreams: %w", err)
+	trustedProxies, err := parseTrustedProxies(globalConfig.HTTPServer.TrustedProxies)
+	if err != nil {
+		s.logger.Error(err, "Failed to parse trusted proxies")
+		return err
 	}
+	s.trustedProxies = trustedProxies
+	s.allowedContentTypes = parseAllowedContentTypes(cfg.AllowedContentTypes)
+	s.responseHeaderPolicy = newResponseHeaderPolicy(cfg.ResponseHeaderPolicy)
+	s.noUpstreamBehavior = newNoUpstreamBehavior(cfg.NoUpstreamBehavior)
+	s.responseAffinity = newResponseAffinity(cfg.ResponseAffinity)
+	s.coalesceKeyHeaders = newCoalesceKeyHeaders(cfg)
+	s.requestIDPolicy = newRequestIDPolicy(globalConfig.HTTPServer.RequestID)
+
+	s.healthStatusInterval = time.Duration(constants.DefaultHealthStatusInterval) * time.Millisecond
+	if metricsConfig := globalConfig.HTTPServer.Metrics; metricsConfig != nil && metricsConfig.HealthStatusInterval > 0 {
+		s.healthStatusInterval = time.Duration(metricsConfig.HealthStatusInterval) * time.Millisecond
+	}
+
+	// 初始化客户端限流中间件，上游限流由各上游的限流器在 buildUpstreams 中独立创建
+	s.initializeRateLimit(cfg)
 
-	// 创建负载均衡器
-	if err := s.createLoadBalancer(defaultGroup); err != nil {
-		s.logger.Error(err, "Failed to create load balancer")
-		return fmt.Errorf("failed to create load balancer: %w", err)
+	// 初始化幂等键去重
+	if cfg.Idempotency != nil && cfg.Idempotency.Enabled {
+		s.idempotencyStore = idempotency.NewStore(time.Duration(cfg.Idempotency.TTL)*time.Millisecond, constants.DefaultIdempotencyMaxEntries)
+		s.logger.Info("Idempotency key deduplication enabled",
+			"ttl_ms", cfg.Idempotency.TTL,
+			"max_body_size", cfg.Idempotency.MaxBodySize)
 	}
 
-	// 创建HTTP客户端
-	if err := s.createHttpClient(defaultGroup); err != nil {
-		s.logger.Error(err, "Failed to create HTTP client")
-		return fmt.Errorf("failed to create http client: %w", err)
+	// 初始化并发准入控制
+	s.concurrencySem = nil
+	if cfg.MaxConcurrentRequests > 0 {
+		s.concurrencySem = make(chan struct{}, cfg.MaxConcurrentRequests)
+		s.logger.Info("Concurrency limit enabled", "max_concurrent_requests", cfg.MaxConcurrentRequests)
 	}
 
-	// 初始化负载均衡器熔断器
-	if err := s.initializeCircuitBreakers(); err != nil {
-		s.logger.Error(err, "Failed to initialize circuit breakers")
-		return fmt.Errorf("failed to initialize circuit breakers: %w", err)
+	// 初始化引用的上游组
+	if err := s.initializeUpstreamGroups(cfg, globalConfig); err != nil {
+		return err
 	}
 
 	// 初始化指标收集器
@@ -154,24 +213,51 @@ func (s *ForwardService) Initialize(cfg *config.ForwardConfig, globalConfig *con
 		s.logger.Error(err, "Failed to initialize metrics collector")
 		return fmt.Errorf("failed to initialize metrics collector: %w", err)
 	}
+	if s.metricsCollector != nil {
+		s.metricsCollector.SetConcurrencyLimit(cfg.Name, cfg.MaxConcurrentRequests)
+		for name, upstreamConfig := range s.upstreamMap {
+			s.metricsCollector.SetUpstreamLabels(name, upstreamConfig.Labels)
+		}
+	}
 
 	s.logger.Info("Forward service initialized successfully",
-		"upstream_count", len(s.upstreams),
-		"load_balancer_type", s.loadBalancer.Type(),
+		"group_count", len(s.groups),
 		"rate_limit_enabled", s.rateLimitMW != nil)
 
 	return nil
 }
 
-// buildUpstreams 构建上游服务列表
-func (s *ForwardService) buildUpstreams(group *config.UpstreamGroupConfig, globalConfig *config.Config) error {
+// initializeBufferPools 根据 HTTP 服务器配置创建响应复制缓冲区对象池，未配置时使用默认大小
+func (s *ForwardService) initializeBufferPools(httpServer *config.HTTPServerConfig) {
+	streamBufferSize := constants.DefaultStreamBufferSize
+	if httpServer.StreamBufferSize > 0 {
+		streamBufferSize = httpServer.StreamBufferSize
+	}
+
+	copyBufferSize := constants.DefaultCopyBufferSize
+	if httpServer.CopyBufferSize > 0 {
+		copyBufferSize = httpServer.CopyBufferSize
+	}
+
+	s.streamingBufferPool = newBufferPool(streamBufferSize)
+	s.nonStreamingBufferPool = newBufferPool(copyBufferSize)
+
+	s.logger.Info("Response buffer pools initialized",
+		"stream_buffer_size", streamBufferSize,
+		"copy_buffer_size", copyBufferSize)
+}
+
+// buildUpstreams 构建上游组的上游服务列表
+func (s *ForwardService) buildUpstreams(g *upstreamGroup, group *config.UpstreamGroupConfig, globalConfig *config.Config) error {
 	// 预分配map容量，减少rehash操作
 	upstreamConfigMap := make(map[string]*config.UpstreamConfig, len(globalConfig.Upstreams))
 	for i := range globalConfig.Upstreams {
 		upstreamConfigMap[globalConfig.Upstreams[i].Name] = &globalConfig.Upstreams[i]
 	}
 
-	s.upstreams = make([]balance.Upstream, 0, len(group.Upstreams))
+	g.upstreams = make([]balance.Upstream, 0, len(group.Upstreams))
+	g.upstreamHealth = make(map[string]*upstreamHealthStats, len(group.Upstreams))
+	g.disabled = make(map[string]struct{})
 
 	for _, upstreamRef := range group.Upstreams {
 		upstreamConfig, exists := upstreamConfigMap[upstreamRef.Name]
@@ -179,13 +265,14 @@ func (s *ForwardService) buildUpstreams(group *config.UpstreamGroupConfig, globa
 			return fmt.Errorf("upstream '%s' not found in configuration", upstreamRef.Name)
 		}
 
-		weight := upstreamRef.Weight
-		if weight <= 0 {
-			weight = 1 // 默认权重
+		// 创建认证器，上游未配置认证时使用上游组的默认认证
+		var authenticator auth.Authenticator
+		var err error
+		if upstreamConfig.Auth == nil && group.DefaultAuth != nil {
+			authenticator, err = s.authFactory.Create(group.DefaultAuth)
+		} else {
+			authenticator, err = auth.CreateFromConfig(upstreamConfig)
 		}
-
-		// 创建认证器
-		authenticator, err := auth.CreateFromConfig(upstreamConfig)
 		if err != nil {
 			return fmt.Errorf("failed to create authenticator for %s: %w", upstreamConfig.Name, err)
 		}
@@ -194,10 +281,24 @@ func (s *ForwardService) buildUpstreams(group *config.UpstreamGroupConfig, globa
 		var breakerInstance breaker.CircuitBreaker
 		if upstreamConfig.Breaker != nil {
 			settings := breaker.CreateFromConfig(upstreamConfig.Name, upstreamConfig.Breaker)
+			settings.OnStateChange = func(name string, from gobreaker.State, to gobreaker.State) {
+				s.onBreakerStateChange(g, name, from, to)
+			}
 			breakerInstance, err = s.breakerFactory.Create(upstreamConfig.Name, settings)
 			if err != nil {
 				return fmt.Errorf("failed to create breaker for %s: %w", upstreamConfig.Name, err)
 			}
+
+			// 配置探测路径时，半开状态下使用合成探测请求代替用户请求
+			if upstreamConfig.Breaker.ProbePath != "" {
+				probeTarget := &balance.Upstream{
+					Name:          upstreamConfig.Name,
+					URL:           upstreamConfig.URL,
+					Config:        upstreamConfig,
+					Authenticator: authenticator,
+				}
+				breakerInstance = breaker.NewProbeBreaker(breakerInstance, s.newBreakerProbe(g, probeTarget, upstreamConfig.Breaker.ProbePath))
+			}
 		}
 
 		// 创建限流器
@@ -209,24 +310,56 @@ func (s *ForwardService) buildUpstreams(group *config.UpstreamGroupConfig, globa
 		}
 
 		upstream := balance.Upstream{
-			Name:          upstreamConfig.Name,
-			URL:           upstreamConfig.URL,
-			Weight:        weight,
-			Config:        upstreamConfig,
-			Authenticator: authenticator,
-			Breaker:       breakerInstance,
-			RateLimiter:   rateLimiterInstance,
+			Name:           upstreamConfig.Name,
+			URL:            upstreamConfig.URL,
+			Weight:         upstreamRef.GetWeight(),
+			Standby:        upstreamRef.IsStandby(),
+			TrafficPercent: upstreamRef.TrafficPercent,
+			Config:         upstreamConfig,
+			Authenticator:  authenticator,
+			Breaker:        breakerInstance,
+			RateLimiter:    rateLimiterInstance,
+		}
+		if upstreamConfig.FailurePenalty > 0 {
+			upstream.Penalty = balance.NewFailurePenalty(time.Duration(upstreamConfig.FailurePenalty) * time.Millisecond)
 		}
 
-		s.upstreams = append(s.upstreams, upstream)
+		g.upstreams = append(g.upstreams, upstream)
+		g.upstreamHealth[upstreamConfig.Name] = newUpstreamHealthStats()
 		s.upstreamMap[upstreamConfig.Name] = upstreamConfig
+
+		// 停用的上游保留在上游列表中，仅从参与负载均衡的上游列表中排除
+		if upstreamConfig.Disabled {
+			g.disabled[upstreamConfig.Name] = struct{}{}
+			s.logger.Info("Upstream disabled by configuration", "group_name", g.name, "upstream", upstreamConfig.Name)
+		}
 	}
 
+	g.disabledMu.Lock()
+	g.rebuildSelectable()
+	g.disabledMu.Unlock()
+
 	return nil
 }
 
-// createLoadBalancer 创建负载均衡器
-func (s *ForwardService) createLoadBalancer(group *config.UpstreamGroupConfig) error {
+// newBreakerProbe 创建熔断器半开状态下的探测函数，探测请求使用上游组的 HTTP 客户端和请求超时
+func (s *ForwardService) newBreakerProbe(g *upstreamGroup, upstream *balance.Upstream, path string) func() error {
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), g.requestTimeout)
+		defer cancel()
+
+		err := g.httpClient.Probe(ctx, upstream, path)
+		if err != nil {
+			s.logger.Info("Breaker probe failed, keeping upstream open", "upstream", upstream.Name, "path", path, "error", err.Error())
+		} else {
+			s.logger.Info("Breaker probe succeeded", "upstream", upstream.Name, "path", path)
+		}
+		return err
+	}
+}
+
+// createLoadBalancer 创建上游组的负载均衡器
+func (s *ForwardService) createLoadBalancer(g *upstreamGroup, group *config.UpstreamGroupConfig) error {
 	factory := balance.NewFactory()
 
 	var balanceConfig *config.BalanceConfig
@@ -241,12 +374,12 @@ func (s *ForwardService) createLoadBalancer(group *config.UpstreamGroupConfig) e
 		return err
 	}
 
-	s.loadBalancer = lb
+	g.loadBalancer = lb
 	return nil
 }
 
-// createHttpClient 创建HTTP客户端
-func (s *ForwardService) createHttpClient(group *config.UpstreamGroupConfig) error {
+// createHttpClient 创建上游组的HTTP客户端
+func (s *ForwardService) createHttpClient(g *upstreamGroup, group *config.UpstreamGroupConfig) error {
 	factory := client.NewFactory()
 
 	// 构建客户端配置
@@ -274,16 +407,29 @@ func (s *ForwardService) createHttpClient(group *config.UpstreamGroupConfig) err
 		clientWithLogger.SetLogger(*s.logger)
 	}
 
-	s.httpClient = httpClient
+	g.preserveClientHeaders = clientConfig.PreserveClientHeaders
+	g.requestTimeout = time.Duration(constants.DefaultRequestTimeout) * time.Millisecond
+	if clientConfig.Timeout != nil && clientConfig.Timeout.Request > 0 {
+		g.requestTimeout = time.Duration(clientConfig.Timeout.Request) * time.Millisecond
+	}
+
+	if clientConfig.Warmup {
+		g.warmupConnections = clientConfig.WarmupConnections
+		if g.warmupConnections <= 0 {
+			g.warmupConnections = constants.DefaultWarmupConnections
+		}
+	}
+
+	g.httpClient = httpClient
 	return nil
 }
 
-// initializeCircuitBreakers 初始化负载均衡器中的熔断器
-func (s *ForwardService) initializeCircuitBreakers() error {
-	for _, upstream := range s.upstreams {
+// initializeCircuitBreakers 初始化上游组负载均衡器中的熔断器
+func (s *ForwardService) initializeCircuitBreakers(g *upstreamGroup) error {
+	for _, upstream := range g.upstreams {
 		if upstream.Breaker != nil {
 			// 如果负载均衡器支持熔断器，设置熔断器
-			if lbWithBreaker, ok := s.loadBalancer.(balance.LoadBalancerWithBreaker); ok {
+			if lbWithBreaker, ok := g.loadBalancer.(balance.LoadBalancerWithBreaker); ok {
 				settings := breaker.CreateFromConfig(upstream.Name, upstream.Config.Breaker)
 				if err := lbWithBreaker.CreateBreaker(upstream.Name, settings); err != nil {
 					s.logger.Error(err, "Failed to create breaker in load balancer", "upstream", upstream.Name)
@@ -296,6 +442,17 @@ func (s *ForwardService) initializeCircuitBreakers() error {
 
 // RegisterGroup 实现orbit.Service接口，注册到orbit引擎
 func (s *ForwardService) RegisterGroup(g *gin.RouterGroup) {
+	// 注册 panic 恢复中间件，需位于其他中间件之前
+	g.Use(s.ginRecoveryMiddleware())
+
+	// 注册就绪检查端点，位于限流之前，避免负载均衡器探测被限流
+	g.Use(s.ginReadyMiddleware())
+
+	// 注册转发服务指标端点，位于限流之前，避免指标抓取占用限流配额
+	if s.config != nil && s.config.ExposeMetrics {
+		g.Use(s.ginMetricsMiddleware())
+	}
+
 	// 注册限流中间件
 	if s.rateLimitMW != nil {
 		// 将orbit中间件转换为gin中间件
@@ -307,26 +464,131 @@ func (s *ForwardService) RegisterGroup(g *gin.RouterGroup) {
 		GET("/*path", s.handleForward)
 }
 
+// initializeRateLimit 根据转发服务级别限流和路径规则创建客户端限流中间件
+// 仅配置路径规则时，未匹配规则的请求不限流
+func (s *ForwardService) initializeRateLimit(cfg *config.ForwardConfig) {
+	switch {
+	case cfg.RateLimit != nil:
+		s.rateLimitMW = ratelimit.NewRateLimitMiddlewareWithKey(float64(cfg.RateLimit.PerSecond), cfg.RateLimit.Burst,
+			cfg.RateLimit.KeyBy, cfg.RateLimit.Header)
+	case len(cfg.RateLimitRules) > 0:
+		s.rateLimitMW = ratelimit.NewRuleRateLimitMiddleware(constants.DefaultRateLimitKeyBy, "")
+	default:
+		s.logger.Info("IP rate limiting disabled")
+		return
+	}
+	// IP 限流与负载均衡使用相同的客户端 IP，仅采信可信代理设置的转发头部
+	s.rateLimitMW.SetClientIPFunc(s.getClientIP)
+
+	for _, rule := range cfg.RateLimitRules {
+		s.rateLimitMW.AddRule(rule.PathPrefix, float64(rule.PerSecond), rule.Burst)
+		s.logger.Info("Rate limit rule added",
+			"path_prefix", rule.PathPrefix,
+			"per_second", rule.PerSecond,
+			"burst", rule.Burst)
+	}
+}
+
+// ResetRateLimit 重置限流状态，返回是否存在可重置的限流器
+// limitType: 限流类型，ip、key 或 upstream
+// key: 客户端 IP、API Key / Token 哈希或上游名称
+func (s *ForwardService) ResetRateLimit(limitType, key string) bool {
+	switch limitType {
+	case constants.RateLimitResetIP:
+		if s.rateLimitMW == nil {
+			return false
+		}
+		s.rateLimitMW.ResetIP(key)
+		return true
+	case constants.RateLimitResetKey:
+		if s.rateLimitMW == nil {
+			return false
+		}
+		s.rateLimitMW.ResetKey(key)
+		return true
+	case constants.RateLimitResetUpstream:
+		for _, g := range s.groups {
+			for _, upstream := range g.upstreams {
+				if upstream.Name == key && upstream.RateLimiter != nil {
+					upstream.RateLimiter.Reset(key)
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// ginRecoveryMiddleware 恢复处理器中的 panic，记录日志和指标并返回统一格式的 500 错误响应
+func (s *ForwardService) ginRecoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// http.ErrAbortHandler 用于主动中断响应，交由 net/http 处理
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			s.logger.Error(fmt.Errorf("panic: %v", recovered), "Recovered from panic in request handler",
+				"method", c.Request.Method,
+				"path", c.Request.URL.Path,
+				"client_ip", c.ClientIP(),
+				"stack", string(debug.Stack()))
+
+			if s.metricsCollector != nil {
+				s.metricsCollector.RecordError(s.config.Name, constants.ErrorTypePanic)
+			}
+
+			// 响应已开始写入时无法再输出错误响应，仅中断处理链
+			if !c.Writer.Written() {
+				detail := map[string]interface{}{
+					"error":     http.StatusText(http.StatusInternalServerError),
+					"timestamp": time.Now().Unix(),
+				}
+				s.writeErrorResponse(c, http.StatusInternalServerError, response.CodeInternalError, "Internal server error", detail)
+			}
+			c.Abort()
+		}()
+
+		c.Next()
+	}
+}
+
 // ginRateLimitMiddleware 将orbit限流中间件转换为gin中间件
 func (s *ForwardService) ginRateLimitMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		startTime := time.Now()
+
 		// 检查限流中间件是否启用
 		if s.rateLimitMW == nil || !s.rateLimitMW.IsEnabled() {
 			c.Next()
 			return
 		}
 
-		// 执行IP级别的限流检查
+		// 执行客户端级别的限流检查（按 IP 或 API Key / Token）
 		if !s.rateLimitMW.AllowRequest(c.Request) {
 			clientIP := s.getClientIP(c.Request)
-			s.logger.Info("Rate limit exceeded for IP", "ip", clientIP, "method", c.Request.Method, "path", c.Request.URL.Path)
+			rule := s.rateLimitMW.MatchRule(c.Request.URL.Path)
 			detail := map[string]interface{}{
 				"code": "RATE_LIMIT_EXCEEDED",
 				"ip":   clientIP,
+				"rule": rule,
+			}
+			// 仅记录凭据哈希，避免原始 Token 出现在日志和响应中
+			if key := s.rateLimitMW.RequestKey(c.Request); key != "" {
+				detail["key"] = key
+				s.logger.Info("Rate limit exceeded for API key", "key", key, "ip", clientIP, "rule", rule, "method", c.Request.Method, "path", c.Request.URL.Path)
+			} else {
+				s.logger.Info("Rate limit exceeded for IP", "ip", clientIP, "rule", rule, "method", c.Request.Method, "path", c.Request.URL.Path)
+			}
+			if s.metricsCollector != nil {
+				s.metricsCollector.RecordRateLimitRejection(s.config.Name, s.rateLimitMW.KeyBy(), rule)
 			}
-			response.Error(response.CodeRateLimit, "too many requests from this IP").
-				WithDetail(detail).
-				JSON(c, http.StatusTooManyRequests)
+			s.writeErrorResponse(c, http.StatusTooManyRequests, response.CodeRateLimit, s.rateLimitMW.RejectMessage(c.Request), detail)
+			s.recordOutcome(c, startTime, constants.OutcomeRateLimited)
 			c.Abort()
 			return
 		}
@@ -342,13 +604,38 @@ func (s *ForwardService) ginRateLimitMiddleware() gin.HandlerFunc {
 // handleForward 处理转发请求
 func (s *ForwardService) handleForward(c *gin.Context) {
 	startTime := time.Now()
-	requestID := c.GetHeader(constants.HeaderXRequestID)
-	if requestID == "" {
-		requestID = fmt.Sprintf("req-%s", xid.New().String())
+	requestID := s.resolveRequestID(c.Request)
+	if s.requestIDPolicy != nil {
+		c.Header(s.requestIDPolicy.outboundHeader, requestID)
+	}
+
+	// 先计入进行中的请求再检查排空状态，确保排空等待不会遗漏已通过检查的请求
+	s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+
+	// 服务排空期间拒绝新请求，并提示客户端关闭连接
+	if s.draining.Load() {
+		c.Header(constants.HeaderConnection, constants.ConnectionClose)
+		s.sendErrorResponse(c, http.StatusServiceUnavailable, "Service is draining")
+		return
+	}
+
+	// 并发准入控制：达到最大并发请求数时立即拒绝，不做任何上游处理
+	if s.concurrencySem != nil {
+		select {
+		case s.concurrencySem <- struct{}{}:
+			defer func() { <-s.concurrencySem }()
+		default:
+			s.rejectConcurrency(c, requestID)
+			return
+		}
 	}
 
+	// 按采样率决定是否记录本次请求的访问日志，错误日志不受影响
+	accessLog := s.accessLogger()
+
 	// 记录请求接收
-	s.logger.Info("Request received",
+	accessLog.Info("Request received",
 		"request_id", requestID,
 		"method", c.Request.Method,
 		"path", c.Request.URL.Path,
@@ -356,13 +643,36 @@ func (s *ForwardService) handleForward(c *gin.Context) {
 		"user_agent", c.GetHeader(constants.HeaderUserAgent),
 		"content_length", c.Request.ContentLength)
 
-	// 记录请求开始
+	// 记录请求开始，请求结束（包括提前返回和 panic）时减少进行中的请求数
 	if s.metricsCollector != nil {
 		s.metricsCollector.RecordRequest(s.config.Name, c.Request.Method, c.Request.URL.Path)
+		defer s.metricsCollector.RecordRequestDone(s.config.Name)
+	}
+
+	// 限制请求的最大处理时长：截止时间从接收请求开始计算，到期后取消请求上下文，中断当前所处的阶段
+	if s.maxRequestDuration > 0 {
+		ctx, cancel := context.WithDeadline(c.Request.Context(), startTime.Add(s.maxRequestDuration))
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+	}
+
+	// 幂等键去重：重放已缓存的响应，或等待进行中的同幂等键请求完成
+	if s.idempotencyStore != nil {
+		if key := idempotency.RequestKey(c.Request); key != "" {
+			if s.acquireIdempotency(c, key, requestID, startTime) {
+				return
+			}
+			defer s.completeIdempotency(c, key)
+		}
 	}
 
 	// 处理请求，如果有错误，直接返回错误响应
-	if err := s.processRequest(c, startTime, requestID); err != nil {
+	if err := s.processRequest(c, startTime, requestID, accessLog); err != nil {
+		// 客户端请求被拒绝时响应和日志已由 processRequest 写出，不计为处理错误
+		if isClientRejection(err) {
+			return
+		}
+
 		s.logger.Error(err, "Request processing failed",
 			"request_id", requestID,
 			"method", c.Request.Method,
@@ -375,43 +685,246 @@ func (s *ForwardService) handleForward(c *gin.Context) {
 			s.metricsCollector.RecordError(s.config.Name, constants.ErrorTypeProcessing)
 		}
 
-		s.sendErrorResponse(c, http.StatusInternalServerError, "Internal server error")
+		// processRequest 可能已经写出了具体的错误响应，避免重复写入
+		if !c.Writer.Written() {
+			if requestDurationExceeded(c.Request) {
+				s.sendErrorResponse(c, http.StatusGatewayTimeout, "Request exceeded maximum duration")
+			} else {
+				s.sendErrorResponse(c, http.StatusInternalServerError, "Internal server error")
+			}
+		}
 		return
 	}
 
 	// 记录请求完成
-	s.logger.Info("Request completed successfully",
+	accessLog.Info("Request completed successfully",
 		"request_id", requestID,
 		"method", c.Request.Method,
 		"path", c.Request.URL.Path,
 		"duration_ms", time.Since(startTime).Milliseconds())
 }
 
+// logSlowRequest 请求耗时超过慢请求阈值时记录慢请求日志
+// latency: 收到上游响应头的耗时
+// duration: 包含响应转发的请求总耗时
+func (s *ForwardService) logSlowRequest(req *http.Request, requestID, upstreamName string, latency int64, duration time.Duration) {
+	if s.slowRequestThreshold <= 0 || duration <= s.slowRequestThreshold {
+		return
+	}
+
+	// 慢请求使用名为 slow 的日志记录器以 info 级别记录，与访问日志区分，便于单独筛选和告警
+	s.logger.WithName("slow").Info("Slow request",
+		"request_id", requestID,
+		"method", req.Method,
+		"path", req.URL.Path,
+		"upstream", upstreamName,
+		"latency_ms", latency,
+		"duration_ms", duration.Milliseconds(),
+		"threshold_ms", s.slowRequestThreshold.Milliseconds())
+}
+
+// recordOutcome 记录未经上游转发即完成的请求的响应指标，如限流拒绝、熔断拒绝和缓存重放
+// 响应已由调用方写出，状态码和响应大小从响应写入器获取
+func (s *ForwardService) recordOutcome(c *gin.Context, startTime time.Time, outcome string) {
+	if s.metricsCollector == nil {
+		return
+	}
+
+	s.metricsCollector.RecordResponse(
+		s.config.Name,
+		c.Request.Method,
+		c.Request.URL.Path,
+		c.Writer.Status(),
+		false,
+		outcome,
+		time.Since(startTime),
+		c.Request.ContentLength,
+		int64(c.Writer.Size()),
+	)
+}
+
+// accessLogger 获取本次请求的访问日志记录器
+// 未被采样的请求将访问日志降级到 V(1) 调试级别输出，Error 日志不受 V 级别影响，始终记录；
+// 采样仅影响日志，指标照常记录
+func (s *ForwardService) accessLogger() logr.Logger {
+	if s.accessLogSampleRate >= 1 || rand.Float64() < s.accessLogSampleRate {
+		return *s.logger
+	}
+	return s.logger.V(1)
+}
+
+// acquireIdempotency 获取幂等键，返回请求是否已处理完毕（已重放缓存响应或客户端已断开）
+// 未处理完毕时当前请求负责执行，响应由记录器捕获并在 completeIdempotency 中提交
+func (s *ForwardService) acquireIdempotency(c *gin.Context, key, requestID string, startTime time.Time) bool {
+	result, err := s.idempotencyStore.Acquire(c.Request.Context(), key)
+	if result.Waited && s.metricsCollector != nil {
+		s.metricsCollector.RecordIdempotencyWait(s.config.Name)
+	}
+	if err != nil {
+		if requestDurationExceeded(c.Request) {
+			s.logger.Info("Request exceeded maximum duration while waiting for in-flight idempotent request",
+				"request_id", requestID,
+				"duration_ms", time.Since(startTime).Milliseconds())
+			s.sendErrorResponse(c, http.StatusGatewayTimeout, "Request exceeded maximum duration")
+			return true
+		}
+		s.logger.Info("Client canceled while waiting for in-flight idempotent request",
+			"request_id", requestID,
+			"error", err.Error())
+		return true
+	}
+
+	if result.Response != nil {
+		if s.metricsCollector != nil {
+			s.metricsCollector.RecordIdempotencyHit(s.config.Name)
+		}
+		s.logger.Info("Replaying idempotent response",
+			"request_id", requestID,
+			"status", result.Response.StatusCode,
+			"waited", result.Waited)
+		s.replayIdempotentResponse(c, result.Response)
+		s.recordOutcome(c, startTime, constants.OutcomeCacheHit)
+		return true
+	}
+
+	c.Set(idempotencyRecorderKey, idempotency.NewRecorder(s.config.Idempotency.MaxBodySize))
+	return false
+}
+
+// completeIdempotency 提交幂等键的执行结果，不可缓存的响应会释放幂等键供等待者重新执行
+func (s *ForwardService) completeIdempotency(c *gin.Context, key string) {
+	var response *idempotency.Response
+	if value, ok := c.Get(idempotencyRecorderKey); ok {
+		response = value.(*idempotency.Recorder).Response()
+	}
+	s.idempotencyStore.Complete(key, response)
+}
+
+// replayIdempotentResponse 重放已缓存的幂等响应
+func (s *ForwardService) replayIdempotentResponse(c *gin.Context, resp *idempotency.Response) {
+	for name, values := range resp.Header {
+		for _, value := range values {
+			c.Header(name, value)
+		}
+	}
+	c.Header(constants.HeaderIdempotentReplayed, "true")
+	c.Status(resp.StatusCode)
+	if _, err := c.Writer.Write(resp.Body); err != nil {
+		s.logger.Error(err, "Failed to write idempotent response")
+	}
+}
+
 // processRequest 处理请求的核心逻辑
-func (s *ForwardService) processRequest(c *gin.Context, startTime time.Time, requestID string) error {
+// accessLog: 本次请求的访问日志记录器，仅用于成功路径的信息日志
+func (s *ForwardService) processRequest(c *gin.Context, startTime time.Time, requestID string, accessLog logr.Logger) error {
 	req := c.Request
-	ctx := req.Context()
+	// 负载均衡使用的客户端 IP，经过可信代理时取转发头部中的真实客户端地址，供 iphash 等基于客户端的策略使用
+	ctx := balance.WithClientIP(req.Context(), s.getClientIP(req))
+
+	// 拒绝 Content-Type 不在允许列表中的请求，在选择上游之前执行
+	if !s.isContentTypeAllowed(req) {
+		s.logger.Info("Unsupported request content type",
+			"request_id", requestID,
+			"content_type", req.Header.Get(constants.HeaderContentType))
+
+		s.sendErrorResponse(c, http.StatusUnsupportedMediaType, "Unsupported request content type")
+		return ErrUnsupportedContentType
+	}
 
-	// 1. 选择上游服务
-	s.logger.Info("Selecting upstream server", "request_id", requestID)
-	upstream, err := s.loadBalancer.Select(ctx, s.upstreams)
+	// 1. 按权重选择上游组，再在组内选择上游服务
+	group := s.selectGroup(ctx)
+
+	// 客户端指定上游时绕过负载均衡，使用该上游所属的上游组，指定的上游必须属于转发服务的某个上游组
+	group, override, overridden, err := s.upstreamOverride(req, group)
 	if err != nil {
-		s.logger.Error(err, "Failed to select upstream", "request_id", requestID)
+		s.logger.Info("Invalid upstream override",
+			"request_id", requestID,
+			"group", group.name,
+			"upstream", req.Header.Get(constants.HeaderLLMProxyUpstream))
+
+		message := "Unknown upstream"
+		switch {
+		case errors.Is(err, ErrUpstreamOverrideNotInGroup):
+			message = "Upstream not in forward groups"
+		case errors.Is(err, ErrUpstreamOverrideDisabled):
+			message = "Upstream disabled"
+		}
+		s.sendErrorResponse(c, http.StatusBadRequest, message)
+		return err
+	}
+
+	accessLog.Info("Selecting upstream server", "request_id", requestID, "group", group.name)
+	upstream := override
+	pinned := false
+	if !overridden {
+		// 会话存在有效的响应亲和记录时绕过负载均衡，使用记录的上游组和上游
+		var pinnedGroup *upstreamGroup
+		if pinnedGroup, upstream, pinned = s.responseAffinityUpstream(req); pinned {
+			group = pinnedGroup
+			accessLog.Info("Upstream pinned by response affinity", "request_id", requestID, "group", group.name, "upstream", upstream.Name)
+		}
+	}
+	if !overridden && !pinned {
+		upstream, err = group.loadBalancer.Select(ctx, group.selectableUpstreams())
+
+		// 跟踪进行中请求的负载均衡器在请求完成（包括流式响应转发完成）后释放选中的上游
+		if tracker, ok := group.loadBalancer.(balance.LoadBalancerWithRequestTracking); ok && err == nil {
+			defer tracker.Release(upstream.Name)
+		}
+	}
+	if err != nil {
+		s.logger.Error(err, "Failed to select upstream", "request_id", requestID, "group", group.name)
+
+		errorType := constants.ErrorTypeSelection
+		switch {
+		case errors.Is(err, balance.ErrEmptyUpstreams) && len(group.upstreams) > 0:
+			errorType = constants.ErrorTypeAllUpstreamsDisabled
+		case errors.Is(err, balance.ErrNilUpstreams), errors.Is(err, balance.ErrEmptyUpstreams):
+			errorType = constants.ErrorTypeNoUpstreams
+		case errors.Is(err, balance.ErrAllUpstreamsUnhealthy):
+			errorType = constants.ErrorTypeNoHealthyUpstream
+		}
 
 		// 记录上游错误
 		if s.metricsCollector != nil {
-			s.metricsCollector.RecordUpstreamError(s.config.DefaultGroup, constants.ErrorTypeUnknown, constants.ErrorTypeSelection)
+			s.metricsCollector.RecordUpstreamError(group.name, constants.ErrorTypeUnknown, errorType)
 		}
 
-		s.sendErrorResponse(c, http.StatusServiceUnavailable, "No available upstream")
-		return fmt.Errorf("failed to select upstream: %w", err)
+		// 配置了无可用上游处理方式时返回静态响应或转发到备用地址
+		switch {
+		case s.noUpstreamBehavior != nil && s.noUpstreamBehavior.staticResponse != nil:
+			accessLog.Info("No upstream available, sending static response", "request_id", requestID, "group", group.name)
+			sendStaticResponse(c, s.noUpstreamBehavior.staticResponse)
+			return nil
+		case s.noUpstreamBehavior != nil && s.noUpstreamBehavior.fallback != nil:
+			accessLog.Info("No upstream available, forwarding to fallback URL",
+				"request_id", requestID,
+				"group", group.name,
+				"fallback_url", s.noUpstreamBehavior.fallback.URL)
+			upstream, err = *s.noUpstreamBehavior.fallback, nil
+		default:
+			// 上游组为空属于配置错误返回 500，所有上游熔断返回 503 并携带 Retry-After，其余选择失败（包括所有上游均已停用）返回 503
+			switch errorType {
+			case constants.ErrorTypeNoUpstreams:
+				s.sendErrorResponse(c, http.StatusInternalServerError, "No upstreams configured")
+			case constants.ErrorTypeNoHealthyUpstream:
+				s.sendNoHealthyUpstreamResponse(c, group)
+				s.recordOutcome(c, startTime, constants.OutcomeBreakerOpen)
+			default:
+				s.sendErrorResponse(c, http.StatusServiceUnavailable, "No available upstream")
+			}
+			return fmt.Errorf("failed to select upstream: %w", err)
+		}
 	}
 
-	s.logger.Info("Upstream server selected",
+	s.recordUpstreamSelection(group, upstream.Name)
+
+	accessLog.Info("Upstream server selected",
 		"request_id", requestID,
 		"upstream_name", upstream.Name,
 		"upstream_url", upstream.URL,
-		"load_balancer_type", s.loadBalancer.Type())
+		"group", group.name,
+		"load_balancer_type", group.loadBalancer.Type())
 
 	// 2. 检查上游级别的限流
 	if !upstream.CheckRateLimit() {
@@ -421,50 +934,158 @@ func (s *ForwardService) processRequest(c *gin.Context, startTime time.Time, req
 
 		// 记录限流拒绝
 		if s.metricsCollector != nil {
-			s.metricsCollector.RecordRateLimitRejection(s.config.Name, "upstream")
+			s.metricsCollector.RecordRateLimitRejection(s.config.Name, "upstream", upstream.Name)
 		}
 
 		s.sendErrorResponse(c, http.StatusTooManyRequests, "Too many requests to upstream service")
+		s.recordOutcome(c, startTime, constants.OutcomeRateLimited)
 		return fmt.Errorf("rate limit exceeded for upstream: %s", upstream.Name)
 	}
 
 	// 3. 创建请求副本
-	s.logger.Info("Creating proxy request", "request_id", requestID, "upstream", upstream.Name)
+	accessLog.Info("Creating proxy request", "request_id", requestID, "upstream", upstream.Name)
 	proxyReq, err := s.createProxyRequest(req)
 	if err != nil {
+		// 请求体超过大小限制属于客户端错误，返回 413 而不是 500
+		if errors.Is(err, ErrRequestBodyTooLarge) {
+			if s.metricsCollector != nil {
+				s.metricsCollector.RecordRequestBodyTooLarge(s.config.Name)
+			}
+
+			s.sendErrorResponse(c, http.StatusRequestEntityTooLarge, "Request body too large")
+			return fmt.Errorf("failed to create proxy request: %w", err)
+		}
+
 		s.logger.Error(err, "Failed to create proxy request", "request_id", requestID)
 		s.sendErrorResponse(c, http.StatusInternalServerError, "Failed to create proxy request")
 		return fmt.Errorf("failed to create proxy request: %w", err)
 	}
+	if s.requestIDPolicy != nil {
+		proxyReq.Header.Set(s.requestIDPolicy.outboundHeader, requestID)
+	}
+	// 上游指定头部仅用于代理路由，不转发给上游
+	if s.config.AllowUpstreamOverride {
+		proxyReq.Header.Del(constants.HeaderLLMProxyUpstream)
+	}
+	if group.preserveClientHeaders {
+		proxyReq.Header.Del(constants.HeaderXForwardedHost)
+		for _, value := range req.Header.Values(constants.HeaderXForwardedHost) {
+			proxyReq.Header.Add(constants.HeaderXForwardedHost, value)
+		}
+	}
+
+	// 按客户端指定的超时时间限制上游请求，超时后返回 504
+	if timeout, ok := s.clientTimeout(req, requestID, group.requestTimeout); ok {
+		timeoutCtx, cancel := context.WithTimeout(proxyReq.Context(), timeout)
+		defer cancel()
+		proxyReq = proxyReq.WithContext(timeoutCtx)
+	}
+
+	// 执行注册的拦截器，拦截器可以修改发往上游的请求或拒绝请求
+	if err := runBeforeRequest(proxyReq); err != nil {
+		s.logger.Info("Request rejected by interceptor", "request_id", requestID, "upstream", upstream.Name, "error", err.Error())
+		s.sendErrorResponse(c, http.StatusForbidden, "Request rejected")
+		return err
+	}
 
 	// 4. 执行请求（通过Upstream封装的熔断器保护）
-	s.logger.Info("Executing upstream request",
+	accessLog.Info("Executing upstream request",
 		"request_id", requestID,
 		"upstream", upstream.Name,
 		"target_url", proxyReq.URL.String())
 
+	// 流式请求体在传输过程中超过大小限制属于客户端错误，不计入熔断器失败
+	execute := func() (*http.Response, error) {
+		var bodyErr error
+		resp, err := upstream.ExecuteWithBreaker(func() (*http.Response, error) {
+			resp, err := group.httpClient.Do(proxyReq, &upstream)
+			if errors.Is(err, ErrRequestBodyTooLarge) {
+				bodyErr = err
+				return nil, nil
+			}
+			return resp, err
+		})
+		if bodyErr != nil {
+			return nil, bodyErr
+		}
+		return resp, err
+	}
+
+	// 启用请求合并时，相同的并发请求只访问一次上游，共享结果的请求改用实际处理请求的上游，且不重复记录上游指标和健康状态
+	coalesceKey, err := s.coalesceKey(req, proxyReq, group)
+	if err != nil {
+		return err
+	}
+
 	requestStartTime := time.Now()
-	resp, err := upstream.ExecuteWithBreaker(func() (*http.Response, error) {
-		return s.httpClient.Do(proxyReq, &upstream)
-	})
+	var resp *http.Response
+	var coalesced bool
+	if coalesceKey != "" {
+		resp, upstream, coalesced, err = s.executeCoalesced(proxyReq.Context(), coalesceKey, upstream, execute)
+	} else {
+		resp, err = execute()
+	}
 	requestDuration := time.Since(requestStartTime)
 
 	if err != nil {
+		// 流式请求体超过大小限制，返回 413 且不影响上游的健康状态和失败惩罚
+		if errors.Is(err, ErrRequestBodyTooLarge) {
+			s.logger.V(1).Info("Request body too large", "request_id", requestID, "upstream", upstream.Name, "limit", MaxRequestBodySize)
+			if s.metricsCollector != nil {
+				s.metricsCollector.RecordRequestBodyTooLarge(s.config.Name)
+			}
+
+			s.sendErrorResponse(c, http.StatusRequestEntityTooLarge, "Request body too large")
+			return fmt.Errorf("request body exceeded limit for upstream %s: %w", upstream.Name, err)
+		}
+
 		s.logger.Error(err, "Request execution failed",
 			"request_id", requestID,
 			"upstream", upstream.Name,
 			"request_duration_ms", requestDuration.Milliseconds())
 
+		// 熔断器拒绝请求时返回独立的熔断响应，便于客户端区分熔断与上游故障
+		if isBreakerOpenError(err) {
+			if s.metricsCollector != nil && !coalesced {
+				s.metricsCollector.RecordUpstreamError(group.name, upstream.Name, constants.ErrorTypeCircuitBreakerOpen)
+			}
+
+			s.sendBreakerOpenResponse(c, &upstream)
+			s.recordOutcome(c, startTime, constants.OutcomeBreakerOpen)
+			return fmt.Errorf("circuit breaker rejected request to upstream %s: %w", upstream.Name, err)
+		}
+
+		// 超时和执行错误计为失败，用于健康状态指标
+		if !coalesced {
+			s.recordUpstreamOutcome(group, upstream.Name, false)
+			upstream.PenalizeFailure()
+		}
+
+		// 请求超时返回 504，其余执行错误返回 502
+		if isTimeoutError(err) {
+			if s.metricsCollector != nil && !coalesced {
+				s.metricsCollector.RecordUpstreamError(group.name, upstream.Name, constants.ErrorTypeUpstreamTimeout)
+			}
+
+			// 请求最大处理时长先于上游超时到期时，使用独立的错误信息
+			message := "Upstream request timed out"
+			if requestDurationExceeded(req) {
+				message = "Request exceeded maximum duration"
+			}
+			s.sendErrorResponse(c, http.StatusGatewayTimeout, message)
+			return fmt.Errorf("request to upstream %s timed out: %w", upstream.Name, err)
+		}
+
 		// 记录上游错误
-		if s.metricsCollector != nil {
-			s.metricsCollector.RecordUpstreamError(s.config.DefaultGroup, upstream.Name, constants.ErrorTypeExecution)
+		if s.metricsCollector != nil && !coalesced {
+			s.metricsCollector.RecordUpstreamError(group.name, upstream.Name, constants.ErrorTypeExecution)
 		}
 
-		s.sendErrorResponse(c, http.StatusServiceUnavailable, "Upstream service unavailable")
+		s.sendErrorResponse(c, http.StatusBadGateway, "Upstream request failed")
 		return fmt.Errorf("request execution failed for upstream %s: %w", upstream.Name, err)
 	}
 
-	s.logger.Info("Upstream request completed",
+	accessLog.Info("Upstream request completed",
 		"request_id", requestID,
 		"upstream", upstream.Name,
 		"status_code", resp.StatusCode,
@@ -472,50 +1093,164 @@ func (s *ForwardService) processRequest(c *gin.Context, startTime time.Time, req
 
 	defer resp.Body.Close()
 
+	// 5xx 响应计为失败，用于健康状态指标
+	if !coalesced {
+		s.recordUpstreamOutcome(group, upstream.Name, resp.StatusCode < http.StatusInternalServerError)
+		if resp.StatusCode >= http.StatusInternalServerError {
+			upstream.PenalizeFailure()
+		}
+	}
+
+	// 上游响应携带亲和头部时将会话绑定到处理请求的上游
+	s.rememberResponseAffinity(req, resp, group, upstream.Name)
+
 	// 6. 计算响应时间并更新负载均衡器
 	duration := time.Since(startTime)
 	latency := duration.Milliseconds()
-	s.loadBalancer.UpdateLatency(upstream.Name, latency)
+	if !coalesced {
+		group.loadBalancer.UpdateLatency(upstream.Name, latency)
+	}
 
-	// 7. 转发响应
-	s.forwardResponse(c, resp)
+	// 执行注册的拦截器，拦截器可以在转发给客户端之前修改上游响应
+	if err := runAfterResponse(proxyReq.Context(), resp); err != nil {
+		s.logger.Error(err, "Upstream response rejected by interceptor", "request_id", requestID, "upstream", upstream.Name)
+		s.sendErrorResponse(c, http.StatusBadGateway, "Upstream response rejected")
+		return err
+	}
+
+	// 调试头部：标识处理请求的上游和负载均衡策略，覆盖上游返回的同名头部
+	if s.config.DebugHeaders {
+		resp.Header.Set(constants.HeaderLLMProxyUpstream, upstream.Name)
+		resp.Header.Set(constants.HeaderLLMProxyBalancer, group.loadBalancer.Type())
+	}
+
+	// 收到上游响应头后才能确定是否为流式响应，用于区分流式和非流式请求的指标
+	stream := s.isStreamingResponse(resp)
+
+	// 按上游配置映射状态码，转发响应和幂等记录均使用映射后的状态码
+	upstreamStatusCode := resp.StatusCode
+	if mappedStatusCode, ok := mapUpstreamStatus(&upstream, upstreamStatusCode); ok {
+		resp.StatusCode = mappedStatusCode
+		if s.metricsCollector != nil && !coalesced {
+			s.metricsCollector.RecordUpstreamStatusRemap(group.name, upstream.Name, upstreamStatusCode, mappedStatusCode)
+		}
+	}
+
+	// 对非流式 JSON 响应体执行上游配置的转换操作
+	if !stream {
+		if err := s.transformResponseBody(resp, &upstream); err != nil {
+			s.logger.Error(err, "Failed to transform upstream response", "request_id", requestID, "upstream", upstream.Name)
+
+			if s.metricsCollector != nil {
+				s.metricsCollector.RecordUpstreamError(group.name, upstream.Name, constants.ErrorTypeExecution)
+			}
+
+			s.sendErrorResponse(c, http.StatusBadGateway, "Failed to read upstream response")
+			return fmt.Errorf("failed to transform response from upstream %s: %w", upstream.Name, err)
+		}
+	}
+
+	// 流式响应记录读取到第一个响应体字节的时间，用于首字节时间指标
+	var firstByte *firstByteReader
+	if stream {
+		firstByte = &firstByteReader{ReadCloser: resp.Body}
+		resp.Body = firstByte
+
+		// 按上游配置的 streamFormat 逐个转换 SSE 事件
+		if transformer := streamTransformer(resp, &upstream); transformer != nil {
+			c.Set(streamTransformerKey, transformer)
+		}
+	}
+
+	// 7. 转发响应，记录实际写入客户端的字节数
+	written, streamErr := s.forwardResponse(c, resp)
+	statusCode := resp.StatusCode
+	if stream && isClientCanceled(c) {
+		s.logger.Info("Client disconnected during streaming response",
+			"request_id", requestID,
+			"upstream", upstream.Name,
+			"bytes_written", written)
+
+		if s.metricsCollector != nil {
+			s.metricsCollector.RecordUpstreamError(group.name, upstream.Name, constants.ErrorTypeClientDisconnected)
+		}
+	}
+
+	if streamErr != nil {
+		s.logger.Error(streamErr, "Upstream closed streaming response prematurely",
+			"request_id", requestID,
+			"upstream", upstream.Name,
+			"bytes_written", written)
+
+		// 未写出任何数据时已改为返回 502
+		if written == 0 {
+			statusCode = http.StatusBadGateway
+		}
+		if s.metricsCollector != nil {
+			s.metricsCollector.RecordUpstreamError(group.name, upstream.Name, constants.ErrorTypeStreamTruncated)
+		}
+	}
 
 	// 8. 记录指标
 	if s.metricsCollector != nil {
-		// 获取请求和响应大小
-		requestSize := s.getRequestSize(req)
-		responseSize := s.getResponseSize(resp)
+		// 获取请求和响应大小（流式响应没有 Content-Length，使用实际写入字节数）
+		requestSize := s.getRequestSize(proxyReq)
+		responseSize := s.getResponseSize(resp, written)
 
 		// 记录 HTTP 响应指标
+		outcome := constants.OutcomeDirect
+		if coalesced {
+			outcome = constants.OutcomeCoalesced
+		}
 		s.metricsCollector.RecordResponse(
 			s.config.Name,
 			req.Method,
 			req.URL.Path,
-			resp.StatusCode,
+			statusCode,
+			stream,
+			outcome,
 			duration,
 			requestSize,
 			responseSize,
 		)
+	}
 
+	// 共享响应的请求未访问上游，不记录上游指标
+	if s.metricsCollector != nil && !coalesced {
 		// 记录上游响应指标
 		s.metricsCollector.RecordUpstreamResponse(
-			s.config.DefaultGroup,
+			group.name,
 			upstream.Name,
 			req.Method,
-			resp.StatusCode,
+			upstreamStatusCode,
+			stream,
 			duration,
 		)
 
+		// 记录首字节时间，非流式响应收到响应即视为首字节到达，流式响应未读取到数据时不记录
+		if !stream {
+			s.metricsCollector.RecordUpstreamTimeToFirstByte(group.name, upstream.Name, requestDuration)
+		} else if !firstByte.at.IsZero() {
+			s.metricsCollector.RecordUpstreamTimeToFirstByte(group.name, upstream.Name, firstByte.at.Sub(requestStartTime))
+		}
+
 		// 记录负载均衡器选择
 		s.metricsCollector.RecordLoadBalancerSelection(
-			s.config.DefaultGroup,
+			group.name,
 			upstream.Name,
-			s.loadBalancer.Type(),
+			group.loadBalancer.Type(),
 		)
 	}
 
+	// 慢请求日志不受访问日志采样影响，包含流式响应的完整传输时间
+	s.logSlowRequest(req, requestID, upstream.Name, latency, time.Since(startTime))
+
+	if streamErr != nil {
+		return fmt.Errorf("streaming response from upstream %s truncated: %w", upstream.Name, streamErr)
+	}
+
 	// 9. 记录访问日志
-	s.logger.Info("Request forwarded successfully",
+	accessLog.Info("Request forwarded successfully",
 		"method", req.Method,
 		"path", req.URL.Path,
 		"upstream", upstream.Name,
@@ -528,9 +1263,19 @@ func (s *ForwardService) processRequest(c *gin.Context, startTime time.Time, req
 // createProxyRequest 创建代理请求
 func (s *ForwardService) createProxyRequest(originalReq *http.Request) (*http.Request, error) {
 	var proxyBody io.Reader
+	var decompress bool
+	stream := s.shouldStreamRequestBody(originalReq)
 
 	// 处理请求体
-	if originalReq.Body != nil {
+	if stream {
+		// 流式模式直接使用客户端请求体，原始请求体由 HTTP 服务器在请求结束后关闭
+		decompress = s.shouldDecompressRequestBody(originalReq)
+		body, err := s.newStreamingRequestBody(originalReq, decompress)
+		if err != nil {
+			return nil, err
+		}
+		proxyBody = body
+	} else if originalReq.Body != nil {
 		// 确保原始请求体在函数结束时被关闭
 		defer func() {
 			if closeErr := originalReq.Body.Close(); closeErr != nil {
@@ -538,8 +1283,21 @@ func (s *ForwardService) createProxyRequest(originalReq *http.Request) (*http.Re
 			}
 		}()
 
+		// 启用请求体解压时，gzip 编码的请求体解压后再转发，大小限制作用于解压后的内容
+		var bodyReader io.Reader = originalReq.Body
+		decompress = s.shouldDecompressRequestBody(originalReq)
+		if decompress {
+			gzipReader, err := gzip.NewReader(originalReq.Body)
+			if err != nil {
+				s.logger.Error(err, "Failed to decompress request body")
+				return nil, fmt.Errorf("failed to decompress request body: %w", err)
+			}
+			defer gzipReader.Close()
+			bodyReader = gzipReader
+		}
+
 		// 使用 LimitReader 限制读取大小，防止内存耗尽
-		limitedReader := io.LimitReader(originalReq.Body, MaxRequestBodySize+1)
+		limitedReader := io.LimitReader(bodyReader, MaxRequestBodySize+1)
 
 		// 读取请求体内容到内存
 		bodyBytes, err := io.ReadAll(limitedReader)
@@ -550,8 +1308,8 @@ func (s *ForwardService) createProxyRequest(originalReq *http.Request) (*http.Re
 
 		// 检查是否超过大小限制
 		if len(bodyBytes) > MaxRequestBodySize {
-			s.logger.Info("Request body too large", "size", len(bodyBytes), "limit", MaxRequestBodySize)
-			return nil, fmt.Errorf("request body too large: %d bytes (limit: %d bytes)", len(bodyBytes), MaxRequestBodySize)
+			s.logger.V(1).Info("Request body too large", "size", len(bodyBytes), "limit", MaxRequestBodySize)
+			return nil, fmt.Errorf("%w: %d bytes (limit: %d bytes)", ErrRequestBodyTooLarge, len(bodyBytes), MaxRequestBodySize)
 		}
 
 		// 创建新的可读取的请求体
@@ -572,38 +1330,190 @@ func (s *ForwardService) createProxyRequest(originalReq *http.Request) (*http.Re
 		return nil, fmt.Errorf("failed to create proxy request: %w", err)
 	}
 
-	// 复制原始请求的头部
-	for name, values := range originalReq.Header {
-		for _, value := range values {
-			proxyReq.Header.Add(name, value)
-		}
+	// 流式请求体未解压时保留原始长度，避免改为分块传输
+	if stream && !decompress && originalReq.ContentLength > 0 {
+		proxyReq.ContentLength = originalReq.ContentLength
+	}
+
+	// 复制原始请求的头部，逐跳头部只对客户端到代理的连接有效，不转发给上游
+	// Clone 预分配头部映射并将所有值复制到同一个切片中，避免逐个 Add 引起的重复分配
+	if header := originalReq.Header.Clone(); header != nil {
+		proxyReq.Header = header
+	}
+	removeHopByHopHeaders(proxyReq.Header)
+
+	// 请求体已解压，移除编码头部，由请求体长度重新生成 Content-Length
+	if decompress {
+		proxyReq.Header.Del(constants.HeaderContentEncoding)
+		proxyReq.Header.Del(constants.HeaderContentLength)
 	}
 
 	// 设置代理相关头部
-	proxyReq.Header.Set(constants.HeaderXForwardedFor, s.getClientIP(originalReq))
+	proxyReq.Header.Set(constants.HeaderXForwardedFor, s.forwardedFor(originalReq))
 	proxyReq.Header.Set(constants.HeaderXForwardedProto, s.getScheme(originalReq))
-	proxyReq.Header.Set(constants.HeaderXForwardedHost, originalReq.Host)
+	proxyReq.Header.Set(constants.HeaderXForwardedHost, s.getHost(originalReq))
 
 	return proxyReq, nil
 }
 
-// forwardResponse 转发响应
-func (s *ForwardService) forwardResponse(c *gin.Context, resp *http.Response) {
-	// 复制响应头部
+// hopByHopHeaders 代表 RFC 7230 第 6.1 节定义的逐跳头部
+var hopByHopHeaders = []string{
+	constants.HeaderConnection,
+	constants.HeaderKeepAlive,
+	constants.HeaderProxyAuthenticate,
+	constants.HeaderProxyAuthorization,
+	constants.HeaderProxyConnection,
+	constants.HeaderTE,
+	constants.HeaderTrailer,
+	constants.HeaderTransferEncoding,
+	constants.HeaderUpgrade,
+}
+
+// removeHopByHopHeaders 移除逐跳头部，包括 Connection 头部中列出的头部
+func removeHopByHopHeaders(header http.Header) {
+	for _, value := range header.Values(constants.HeaderConnection) {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				header.Del(name)
+			}
+		}
+	}
+	for _, name := range hopByHopHeaders {
+		header.Del(name)
+	}
+}
+
+// shouldDecompressRequestBody 判断是否需要解压请求体：启用 decompressRequestBody 且请求体为 gzip 编码
+func (s *ForwardService) shouldDecompressRequestBody(req *http.Request) bool {
+	if s.config == nil || !s.config.DecompressRequestBody {
+		return false
+	}
+	return strings.EqualFold(strings.TrimSpace(req.Header.Get(constants.HeaderContentEncoding)), constants.ContentEncodingGzip)
+}
+
+// mapUpstreamStatus 按上游配置的 statusMap 映射上游状态码，未配置对应映射时返回 false
+func mapUpstreamStatus(upstream *balance.Upstream, statusCode int) (int, bool) {
+	if upstream.Config == nil {
+		return statusCode, false
+	}
+	mapped, ok := upstream.Config.StatusMap[statusCode]
+	return mapped, ok
+}
+
+// forwardResponse 转发响应，返回实际写入客户端的响应体字节数
+// 上游在流式响应完成前断开连接时返回读取错误
+func (s *ForwardService) forwardResponse(c *gin.Context, resp *http.Response) (int64, error) {
+	// 按过滤策略复制响应头部，同时作用于幂等键缓存的响应
+	s.filterResponseHeaders(resp.Header)
 	for name, values := range resp.Header {
 		for _, value := range values {
 			c.Header(name, value)
 		}
 	}
+	forwardTrailers := s.config != nil && s.config.ForwardTrailers && len(resp.Trailer) > 0
+	if forwardTrailers {
+		announceTrailers(c.Writer.Header(), resp.Trailer)
+	}
 
 	// 设置状态码
 	c.Status(resp.StatusCode)
 
+	// 包装响应写入器，统计实际写出的字节数
+	writer := &countingWriter{writer: c.Writer}
+
+	// 启用幂等键去重时同时记录响应，供相同幂等键的后续请求重放
+	if value, ok := c.Get(idempotencyRecorderKey); ok {
+		recorder := value.(*idempotency.Recorder)
+		recorder.WriteHeader(resp.StatusCode, resp.Header)
+		writer.writer = io.MultiWriter(c.Writer, recorder)
+	}
+
 	// 判断是否为流式响应
 	if s.isStreamingResponse(resp) {
-		s.forwardStreamingResponse(c, resp)
+		s.applyStreamWriteDeadline(c)
+		ctx := context.Background()
+		if c.Request != nil {
+			ctx = c.Request.Context()
+		}
+		var transformer transform.StreamTransformer
+		if value, ok := c.Get(streamTransformerKey); ok {
+			transformer = value.(transform.StreamTransformer)
+		}
+		err := s.forwardStreamingResponse(ctx, writer, c.Writer, resp, transformer)
+
+		// 客户端取消请求导致的读取错误不属于上游断开，不完整的响应不可作为幂等响应缓存
+		if isClientCanceled(c) {
+			if value, ok := c.Get(idempotencyRecorderKey); ok {
+				value.(*idempotency.Recorder).Discard()
+			}
+			return writer.Count(), nil
+		}
+		if err != nil {
+			s.handleTruncatedStream(c, writer, resp)
+			return writer.Count(), err
+		}
 	} else {
-		s.forwardRegularResponse(c, resp)
+		s.forwardRegularResponse(writer, resp)
+	}
+
+	// 上游 trailer 在响应体读取完毕后才可用
+	if forwardTrailers {
+		copyTrailers(c.Writer.Header(), resp.Trailer)
+	}
+
+	return writer.Count(), nil
+}
+
+// handleTruncatedStream 处理上游提前断开的流式响应
+// 尚未写出数据时改为返回 502；已写出数据时按配置追加 SSE 错误事件，告知客户端流已中断
+func (s *ForwardService) handleTruncatedStream(c *gin.Context, writer *countingWriter, resp *http.Response) {
+	// 截断的响应不可作为幂等响应缓存
+	if value, ok := c.Get(idempotencyRecorderKey); ok {
+		value.(*idempotency.Recorder).Discard()
+	}
+
+	if writer.Count() == 0 && !c.Writer.Written() {
+		for name := range resp.Header {
+			c.Writer.Header().Del(name)
+		}
+		s.sendErrorResponse(c, http.StatusBadGateway, "Upstream closed connection before sending response")
+		return
+	}
+
+	if s.config != nil && s.config.StreamErrorEvent {
+		if _, err := writer.Write([]byte(constants.StreamErrorEventUpstreamDisconnected)); err != nil {
+			s.logger.Error(err, "Failed to write stream error event")
+		}
+	}
+}
+
+// isClientCanceled 判断客户端是否已取消请求
+func isClientCanceled(c *gin.Context) bool {
+	return c.Request != nil && c.Request.Context().Err() != nil
+}
+
+// applyStreamWriteDeadline 使用 Timeout.StreamWrite 替换服务器级写入超时，避免长时间的流式响应被截断
+func (s *ForwardService) applyStreamWriteDeadline(c *gin.Context) {
+	var deadline time.Time // 零值表示取消写入截止时间
+	if s.config != nil && s.config.Timeout != nil && s.config.Timeout.StreamWrite > 0 {
+		deadline = time.Now().Add(time.Duration(s.config.Timeout.StreamWrite) * time.Millisecond)
+	}
+
+	controller := http.NewResponseController(unwrapResponseWriter(c.Writer))
+	if err := controller.SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		s.logger.Error(err, "Failed to set stream write deadline")
+	}
+}
+
+// unwrapResponseWriter 剥离 orbit 缓冲中间件包装的响应写入器
+// 包装器未实现 Unwrap，http.ResponseController 无法穿透到底层连接
+func unwrapResponseWriter(w http.ResponseWriter) http.ResponseWriter {
+	for {
+		wrapper, ok := w.(interface{ GetResponseWriter() gin.ResponseWriter })
+		if !ok {
+			return w
+		}
+		w = wrapper.GetResponseWriter()
 	}
 }
 
@@ -615,41 +1525,70 @@ func (s *ForwardService) isStreamingResponse(resp *http.Response) bool {
 		resp.Header.Get(constants.HeaderTransferEncoding) == constants.TransferEncodingChunked
 }
 
-// forwardStreamingResponse 转发流式响应
-func (s *ForwardService) forwardStreamingResponse(c *gin.Context, resp *http.Response) {
+// forwardStreamingResponse 转发流式响应，返回上游响应体的读取错误，写入客户端失败时返回 nil
+// 客户端断开（请求上下文取消）时关闭上游响应体并停止复制，返回上下文错误，避免继续消耗上游资源
+// 请求上下文在连接关闭时由 net/http 取消，与 CloseNotify 检测的是同一事件，因此无需单独监听 CloseNotify
+// flusher 为 nil 时不主动刷新，由 HTTP 服务器在缓冲区写满或请求结束时写出
+// transformer 不为 nil 时逐个转换 SSE 事件，否则直接复制响应体
+func (s *ForwardService) forwardStreamingResponse(ctx context.Context, w io.Writer, flusher http.Flusher, resp *http.Response, transformer transform.StreamTransformer) error {
+	// 按配置的刷新间隔合并刷新，在延迟与系统调用开销之间取舍
+	if flusher != nil {
+		var interval time.Duration
+		if s.config != nil {
+			interval = time.Duration(s.config.StreamFlushInterval) * time.Millisecond
+		}
+		flushWriter := newStreamFlushWriter(w, flusher, interval)
+		defer flushWriter.Stop()
+		w = flushWriter
+	}
+
+	// 客户端断开时关闭上游响应体，中断阻塞中的读取
+	stop := context.AfterFunc(ctx, func() {
+		_ = resp.Body.Close()
+	})
+	defer stop()
+
+	if transformer != nil {
+		return s.forwardTransformedStream(ctx, w, resp.Body, transformer)
+	}
+
 	// 从对象池获取缓冲区
-	buffer := streamingBufferPool.Get()
-	defer streamingBufferPool.Put(buffer)
+	buffer := s.streamingBufferPool.Get()
+	defer s.streamingBufferPool.Put(buffer)
 	bufSlice := buffer.([]byte) // 使用完整的缓冲区，不截断为0长度
 
 	// 流式复制响应体
 	for {
 		n, err := resp.Body.Read(bufSlice)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
 		if n > 0 {
-			if _, writeErr := c.Writer.Write(bufSlice[:n]); writeErr != nil {
+			if _, writeErr := w.Write(bufSlice[:n]); writeErr != nil {
 				s.logger.Error(writeErr, "Failed to write streaming response")
-				break
+				return nil
 			}
-			// 移除 Flush() 调用以避免 orbit 框架的双写问题
+			// 数据写出后再刷新，避免在写出响应头部前调用 Flush() 导致 orbit 框架的双写问题
 		}
 		if err != nil {
 			if err != io.EOF {
 				s.logger.Error(err, "Error reading streaming response")
+				return err
 			}
-			break
+			return nil
 		}
 	}
 }
 
 // forwardRegularResponse 转发常规响应
-func (s *ForwardService) forwardRegularResponse(c *gin.Context, resp *http.Response) {
+func (s *ForwardService) forwardRegularResponse(w io.Writer, resp *http.Response) {
 	// 从对象池获取缓冲区
-	buffer := nonStreamingBufferPool.Get()
-	defer nonStreamingBufferPool.Put(buffer)
+	buffer := s.nonStreamingBufferPool.Get()
+	defer s.nonStreamingBufferPool.Put(buffer)
 	bufSlice := buffer.([]byte) // 使用完整的缓冲区，不截断为0长度
 
 	// 直接复制响应体
-	if _, err := io.CopyBuffer(c.Writer, resp.Body, bufSlice); err != nil {
+	if _, err := io.CopyBuffer(w, resp.Body, bufSlice); err != nil {
 		s.logger.Error(err, "Failed to copy response body")
 	}
 }
@@ -666,7 +1605,7 @@ func (s *ForwardService) sendErrorResponse(c *gin.Context, statusCode int, messa
 		code = response.CodeBadGateway
 	case http.StatusGatewayTimeout:
 		code = response.CodeGatewayTimeout
-	case http.StatusBadRequest:
+	case http.StatusBadRequest, http.StatusUnsupportedMediaType, http.StatusRequestEntityTooLarge:
 		code = response.CodeBadRequest
 	case http.StatusUnauthorized:
 		code = response.CodeUnauthorized
@@ -683,40 +1622,153 @@ func (s *ForwardService) sendErrorResponse(c *gin.Context, statusCode int, messa
 		"timestamp": time.Now().Unix(),
 	}
 
+	s.writeErrorResponse(c, statusCode, code, message, detail)
+}
+
+// writeErrorResponse 按转发服务配置的错误格式输出错误响应
+// 配置了该状态码的自定义错误响应时直接输出配置的响应体
+// openai 格式不携带 detail，内部错误代码映射到 error.code 字段
+func (s *ForwardService) writeErrorResponse(c *gin.Context, statusCode int, code int64, message string, detail interface{}) {
+	if s.config == nil {
+		response.Error(code, message).WithDetail(detail).JSON(c, statusCode)
+		return
+	}
+
+	if errorResponse, ok := s.config.ErrorResponses[statusCode]; ok {
+		contentType := errorResponse.ContentType
+		if contentType == "" {
+			contentType = constants.ContentTypeJSON
+		}
+		c.Data(statusCode, contentType, errorResponse.Body)
+		return
+	}
+
+	if s.config.ErrorFormat == constants.ErrorFormatOpenAI {
+		response.OpenAIError(c, statusCode, code, message)
+		return
+	}
+
 	response.Error(code, message).WithDetail(detail).JSON(c, statusCode)
 }
 
+// sendBreakerOpenResponse 发送熔断器开启的错误响应，携带上游名称和建议的重试等待时间
+func (s *ForwardService) sendBreakerOpenResponse(c *gin.Context, upstream *balance.Upstream) {
+	retryAfter := breakerRetryAfterSeconds(upstream)
+	c.Header(constants.HeaderRetryAfter, strconv.Itoa(retryAfter))
+
+	detail := map[string]interface{}{
+		"error":      http.StatusText(http.StatusServiceUnavailable),
+		"upstream":   upstream.Name,
+		"retryAfter": retryAfter,
+		"timestamp":  time.Now().Unix(),
+	}
+
+	message := fmt.Sprintf("Circuit breaker is open for upstream %s, retry after %d seconds", upstream.Name, retryAfter)
+	s.writeErrorResponse(c, http.StatusServiceUnavailable, response.CodeCircuitBreaker, message, detail)
+}
+
+// sendNoHealthyUpstreamResponse 上游组内所有上游的熔断器均处于开启状态时发送 503 响应
+// Retry-After 取组内上游熔断冷却时间的最小值，最早恢复的上游结束冷却后即可重试
+func (s *ForwardService) sendNoHealthyUpstreamResponse(c *gin.Context, group *upstreamGroup) {
+	retryAfter := 0
+	upstreams := group.selectableUpstreams()
+	for i := range upstreams {
+		if seconds := breakerRetryAfterSeconds(&upstreams[i]); retryAfter == 0 || seconds < retryAfter {
+			retryAfter = seconds
+		}
+	}
+	c.Header(constants.HeaderRetryAfter, strconv.Itoa(retryAfter))
+
+	detail := map[string]interface{}{
+		"error":      http.StatusText(http.StatusServiceUnavailable),
+		"group":      group.name,
+		"retryAfter": retryAfter,
+		"timestamp":  time.Now().Unix(),
+	}
+
+	message := fmt.Sprintf("No healthy upstream in group %s, retry after %d seconds", group.name, retryAfter)
+	s.writeErrorResponse(c, http.StatusServiceUnavailable, response.CodeCircuitBreaker, message, detail)
+}
+
+// breakerRetryAfterSeconds 根据熔断器冷却时间计算重试等待秒数（向上取整，至少 1 秒）
+func breakerRetryAfterSeconds(upstream *balance.Upstream) int {
+	cooldown := constants.DefaultBreakerCooldown
+	if upstream.Config != nil && upstream.Config.Breaker != nil && upstream.Config.Breaker.Cooldown > 0 {
+		cooldown = upstream.Config.Breaker.Cooldown
+	}
+
+	seconds := (cooldown + 999) / 1000
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// isBreakerOpenError 判断错误是否由熔断器拒绝请求引起（开启状态或半开状态请求数超限）
+func isBreakerOpenError(err error) bool {
+	return errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests)
+}
+
+// requestDurationExceeded 判断请求上下文是否因超过最大处理时长而取消
+// 客户端断开连接时上下文错误为 context.Canceled，不会被误判
+func requestDurationExceeded(req *http.Request) bool {
+	return errors.Is(req.Context().Err(), context.DeadlineExceeded)
+}
+
+// isTimeoutError 判断错误是否由请求超时引起（上下文截止或客户端超时）
+func isTimeoutError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 // getClientIP 获取客户端IP
+// 仅采信可信代理设置的 X-Forwarded-For 和 X-Real-IP；X-Forwarded-For 从右向左跳过可信代理，
+// 返回第一个不可信的地址，全部可信时返回最左侧地址
 func (s *ForwardService) getClientIP(req *http.Request) string {
+	peer := remoteIP(req)
+	if !s.isTrustedProxy(peer) {
+		return peer
+	}
+
 	if xff := req.Header.Get(constants.HeaderXForwardedFor); xff != "" {
-		if idx := strings.Index(xff, ","); idx >= 0 {
-			return strings.TrimSpace(xff[:idx])
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i > 0; i-- {
+			if hop := strings.TrimSpace(hops[i]); !s.isTrustedProxy(hop) {
+				return hop
+			}
 		}
-		return strings.TrimSpace(xff)
+		return strings.TrimSpace(hops[0])
 	}
 
 	if xri := req.Header.Get(constants.HeaderXRealIP); xri != "" {
 		return strings.TrimSpace(xri)
 	}
 
-	if idx := strings.LastIndex(req.RemoteAddr, ":"); idx >= 0 {
-		return req.RemoteAddr[:idx]
-	}
-
-	return req.RemoteAddr
+	return peer
 }
 
-// getScheme 获取请求协议
+// getScheme 获取请求协议，仅采信可信代理设置的 X-Forwarded-Proto
 func (s *ForwardService) getScheme(req *http.Request) string {
 	if req.TLS != nil {
 		return constants.ProtocolHTTPS
 	}
-	if scheme := req.Header.Get(constants.HeaderXForwardedProto); scheme != "" {
+	if scheme := firstHeaderValue(req.Header.Get(constants.HeaderXForwardedProto)); scheme != "" && s.isFromTrustedProxy(req) {
 		return scheme
 	}
 	return constants.ProtocolHTTP
 }
 
+// getHost 获取请求的原始主机，仅采信可信代理设置的 X-Forwarded-Host
+func (s *ForwardService) getHost(req *http.Request) string {
+	if host := firstHeaderValue(req.Header.Get(constants.HeaderXForwardedHost)); host != "" && s.isFromTrustedProxy(req) {
+		return host
+	}
+	return req.Host
+}
+
 // Run 启动转发服务
 func (s *ForwardService) Run() {
 	s.mu.Lock()
@@ -727,9 +1779,52 @@ func (s *ForwardService) Run() {
 	}
 
 	s.running = true
+
+	// 周期性上报上游健康状态指标
+	if s.healthStatusInterval > 0 && len(s.groups) > 0 {
+		go s.runHealthStatusReporter(s.healthStatusInterval, s.stopCh)
+	}
+
+	// 异步预热上游连接，不阻塞启动
+	for _, g := range s.groups {
+		if g.warmupConnections > 0 && g.httpClient != nil {
+			go s.warmupUpstreams(g, s.stopCh)
+		}
+	}
+
 	s.logger.Info("Forward service started")
 }
 
+// warmupUpstreams 并发预热上游组内所有上游的连接，预热失败仅记录日志
+// 服务停止或超过预热超时时间后取消未完成的预热请求
+func (s *ForwardService) warmupUpstreams(g *upstreamGroup, stopCh <-chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(constants.DefaultWarmupTimeout)*time.Millisecond)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := range g.upstreams {
+		wg.Add(1)
+		go func(upstream *balance.Upstream) {
+			defer wg.Done()
+			if err := g.httpClient.Warmup(ctx, upstream, g.warmupConnections); err != nil {
+				s.logger.Info("Upstream connection warmup failed, continuing without warm connections",
+					"group", g.name,
+					"upstream", upstream.Name,
+					"error", err.Error())
+			}
+		}(&g.upstreams[i])
+	}
+	wg.Wait()
+}
+
 // Stop 停止转发服务
 func (s *ForwardService) Stop() {
 	s.mu.Lock()
@@ -750,13 +1845,52 @@ func (s *ForwardService) Stop() {
 	}
 
 	// 清理资源
-	if s.httpClient != nil {
-		s.httpClient.Close()
+	for _, g := range s.groups {
+		if g.httpClient != nil {
+			g.httpClient.Close()
+		}
 	}
 
 	s.logger.Info("Forward service stopped")
 }
 
+// BeginDrain 开始排空，之后到达的请求直接返回 503，进行中的请求不受影响
+func (s *ForwardService) BeginDrain() {
+	s.draining.Store(true)
+}
+
+// EndDrain 结束排空，恢复接收新请求
+func (s *ForwardService) EndDrain() {
+	s.draining.Store(false)
+}
+
+// IsDraining 检查服务是否正在排空
+func (s *ForwardService) IsDraining() bool {
+	return s.draining.Load()
+}
+
+// InFlightRequests 获取进行中的请求数
+func (s *ForwardService) InFlightRequests() int64 {
+	return s.inFlight.Load()
+}
+
+// MaxConcurrentRequests 获取最大并发请求数，0 表示不限制
+func (s *ForwardService) MaxConcurrentRequests() int {
+	return cap(s.concurrencySem)
+}
+
+// rejectConcurrency 拒绝超出最大并发请求数的请求，返回 503 并提示客户端稍后重试
+func (s *ForwardService) rejectConcurrency(c *gin.Context, requestID string) {
+	s.logger.V(1).Info("Request rejected by concurrency limit",
+		"request_id", requestID,
+		"max_concurrent_requests", cap(s.concurrencySem))
+	if s.metricsCollector != nil {
+		s.metricsCollector.RecordConcurrencyRejection(s.config.Name)
+	}
+	c.Header(constants.HeaderRetryAfter, strconv.Itoa(constants.DefaultConcurrencyRetryAfter))
+	s.sendErrorResponse(c, http.StatusServiceUnavailable, "Too many concurrent requests")
+}
+
 // IsRunning 检查服务是否运行中
 func (s *ForwardService) IsRunning() bool {
 	s.mu.RLock()
@@ -786,6 +1920,10 @@ func (s *ForwardService) initializeMetricsCollector() error {
 		Namespace: constants.MetricsNamespace,
 		Subsystem: "",
 	}
+	if metricsConfig := s.globalConfig.HTTPServer.Metrics; metricsConfig != nil {
+		config.DurationBuckets = metricsConfig.DurationBuckets
+		config.UpstreamLabels = metricsConfig.UpstreamLabels
+	}
 
 	// 创建新的全局共享收集器
 	collector, err := globalRegistry.CreateSharedCollector(globalCollectorName, config)
@@ -803,13 +1941,54 @@ func (s *ForwardService) getRequestSize(req *http.Request) int64 {
 	if req.ContentLength > 0 {
 		return req.ContentLength
 	}
+	// 分块传输的流式请求体没有 Content-Length，使用实际读取的字节数
+	if body, ok := req.Body.(*limitedRequestBody); ok {
+		return body.Size()
+	}
 	return 0
 }
 
 // getResponseSize 获取响应体大小
-func (s *ForwardService) getResponseSize(resp *http.Response) int64 {
+// written: 实际写入客户端的字节数，优先于 Content-Length 使用
+func (s *ForwardService) getResponseSize(resp *http.Response, written int64) int64 {
+	if written > 0 {
+		return written
+	}
 	if resp.ContentLength > 0 {
 		return resp.ContentLength
 	}
 	return 0
 }
+
+// countingWriter 统计实际写入的字节数，只统计成功写出的数据，不包含缓冲区容量
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+// Write 写入数据并累加写入字节数
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.count += int64(n)
+	return n, err
+}
+
+// Count 获取已写入的字节数
+func (w *countingWriter) Count() int64 {
+	return w.count
+}
+
+// firstByteReader 记录第一次成功读取到数据的时间
+type firstByteReader struct {
+	io.ReadCloser
+	at time.Time
+}
+
+// Read 读取数据，首次读取到数据时记录当前时间
+func (r *firstByteReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 && r.at.IsZero() {
+		r.at = time.Now()
+	}
+	return n, err
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+)
+
+// certificateStore 保存转发服务的 TLS 证书，重新加载时原子替换，新建立的连接使用新证书
+type certificateStore struct {
+	certificate atomic.Pointer[tls.Certificate]
+}
+
+// Load 从文件加载证书和私钥，加载失败时保留当前证书
+// cfg: 转发服务的 TLS 证书配置
+func (s *certificateStore) Load(cfg *config.ForwardTLSConfig) error {
+	certificate, err := loadCertificate(cfg)
+	if err != nil {
+		return err
+	}
+	s.certificate.Store(certificate)
+	return nil
+}
+
+// loadCertificate 从文件加载证书和私钥
+// cfg: 转发服务的 TLS 证书配置
+func loadCertificate(cfg *config.ForwardTLSConfig) (*tls.Certificate, error) {
+	certificate, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tls certificate: %w", err)
+	}
+	return &certificate, nil
+}
+
+// GetCertificate 获取当前证书，用于 tls.Config.GetCertificate
+func (s *certificateStore) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	certificate := s.certificate.Load()
+	if certificate == nil {
+		return nil, errors.New("no tls certificate loaded")
+	}
+	return certificate, nil
+}
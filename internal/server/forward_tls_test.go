@@ -0,0 +1,147 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/shengyanli1982/llmproxy-go/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCertificate 生成 127.0.0.1 的自签名证书并写入指定文件，返回证书
+func writeTestCertificate(t *testing.T, certFile, keyFile string, serial int64) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "llmproxy-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certificate
+}
+
+// TestForwardServer_TLS 测试转发服务直接提供 HTTPS，转发请求携带 X-Forwarded-Proto: https，并支持重新加载证书
+func TestForwardServer_TLS(t *testing.T) {
+	logger := logr.Discard()
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Received-Proto", r.Header.Get(constants.HeaderXForwardedProto))
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer upstreamServer.Close()
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	firstCertificate := writeTestCertificate(t, certFile, keyFile, 1)
+
+	// orbit 会将端口 0 替换为默认端口，HTTPS 引擎与其保持一致，这里预先分配一个空闲端口
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	forwardConfig := &config.ForwardConfig{
+		Name:         "tls-forward",
+		Address:      "127.0.0.1",
+		Port:         port,
+		DefaultGroup: "tls-group",
+		Timeout:      &config.TimeoutConfig{Idle: 30000, Read: 15000, Write: 15000},
+		TLS:          &config.ForwardTLSConfig{CertFile: certFile, KeyFile: keyFile},
+	}
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{{Name: "tls-upstream", URL: upstreamServer.URL}},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{Name: "tls-group", Upstreams: []config.UpstreamRefConfig{{Name: "tls-upstream"}}},
+		},
+	}
+
+	forwardServer := NewForwardServer(false, &logger, forwardConfig, globalConfig)
+	forwardServer.Start()
+	defer forwardServer.Stop()
+
+	// get 使用只信任指定证书的新连接发送请求，返回响应和服务端证书
+	get := func(trusted *x509.Certificate) (*http.Response, []byte, error) {
+		roots := x509.NewCertPool()
+		roots.AddCert(trusted)
+		client := &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}},
+		}
+		defer client.CloseIdleConnections()
+
+		resp, err := client.Get("https://" + forwardServer.GetEndpoint() + "/v1/models")
+		if err != nil {
+			return nil, nil, err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return resp, body, err
+	}
+
+	var resp *http.Response
+	var body []byte
+	require.Eventually(t, func() bool {
+		resp, body, err = get(firstCertificate)
+		return err == nil
+	}, 5*time.Second, 20*time.Millisecond)
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"ok":true}`, string(body))
+	assert.Equal(t, constants.ProtocolHTTPS, resp.Header.Get("X-Received-Proto"))
+	assert.Equal(t, firstCertificate.SerialNumber, resp.TLS.PeerCertificates[0].SerialNumber)
+
+	// 证书轮换后重新加载，新连接使用新证书
+	secondCertificate := writeTestCertificate(t, certFile, keyFile, 2)
+	require.NoError(t, forwardServer.ReloadCertificate(forwardConfig.TLS))
+
+	resp, _, err = get(secondCertificate)
+	require.NoError(t, err)
+	assert.Equal(t, secondCertificate.SerialNumber, resp.TLS.PeerCertificates[0].SerialNumber)
+
+	// 加载失败时继续使用当前证书
+	require.NoError(t, os.WriteFile(certFile, []byte("invalid"), 0o600))
+	assert.Error(t, forwardServer.ReloadCertificate(forwardConfig.TLS))
+
+	resp, _, err = get(secondCertificate)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/sony/gobreaker"
+)
+
+// upstreamHealthStats 代表单个上游在一个上报周期内的请求统计
+type upstreamHealthStats struct {
+	total    atomic.Int64 // 周期内完成的请求数
+	failures atomic.Int64 // 周期内失败的请求数（执行错误或 5xx 响应）
+	healthy  atomic.Bool  // 最近一次上报的健康状态
+
+	selections atomic.Int64 // 累计被负载均衡器选中的次数，不随上报周期清零
+}
+
+// newUpstreamHealthStats 创建上游请求统计，初始状态为健康
+func newUpstreamHealthStats() *upstreamHealthStats {
+	stats := &upstreamHealthStats{}
+	stats.healthy.Store(true)
+	return stats
+}
+
+// recordUpstreamOutcome 记录上游请求结果，用于按近期成功率上报健康状态
+func (s *ForwardService) recordUpstreamOutcome(g *upstreamGroup, upstreamName string, success bool) {
+	stats, ok := g.upstreamHealth[upstreamName]
+	if !ok {
+		return
+	}
+	stats.total.Add(1)
+	if !success {
+		stats.failures.Add(1)
+	}
+}
+
+// reportUpstreamHealth 根据上报周期内的请求成功率和熔断器状态上报上游健康状态指标
+// 熔断器打开时始终视为不健康；周期内没有请求时保持上一次的状态
+func (s *ForwardService) reportUpstreamHealth() {
+	if s.metricsCollector == nil {
+		return
+	}
+
+	for _, g := range s.groups {
+		s.reportGroupHealth(g)
+	}
+}
+
+// reportGroupHealth 上报单个上游组内所有上游的健康状态指标
+func (s *ForwardService) reportGroupHealth(g *upstreamGroup) {
+	for _, upstream := range g.upstreams {
+		stats, ok := g.upstreamHealth[upstream.Name]
+		if !ok {
+			continue
+		}
+
+		total := stats.total.Swap(0)
+		failures := stats.failures.Swap(0)
+
+		healthy := stats.healthy.Load()
+		if total > 0 {
+			healthy = float64(failures)/float64(total) < constants.DefaultHealthStatusFailureRatio
+		}
+		if upstream.Breaker != nil && upstream.Breaker.State() == gobreaker.StateOpen {
+			healthy = false
+		}
+
+		stats.healthy.Store(healthy)
+		s.metricsCollector.RecordUpstreamHealthStatus(g.name, upstream.Name, healthy)
+	}
+}
+
+// runHealthStatusReporter 周期性上报上游健康状态和连接池连接数指标，直到服务停止
+func (s *ForwardService) runHealthStatusReporter(interval time.Duration, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// 启动时立即上报一次，避免指标在首个周期内缺失
+	s.reportUpstreamHealth()
+	s.reportUpstreamConnections()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.reportUpstreamHealth()
+			s.reportUpstreamConnections()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// onBreakerStateChange 熔断器状态变化时立即更新上游健康状态指标，仅闭合状态视为健康
+func (s *ForwardService) onBreakerStateChange(g *upstreamGroup, upstreamName string, from, to gobreaker.State) {
+	s.logger.Info("Circuit breaker state changed",
+		"group", g.name,
+		"upstream", upstreamName,
+		"from", from.String(),
+		"to", to.String())
+
+	healthy := to == gobreaker.StateClosed
+	if stats, ok := g.upstreamHealth[upstreamName]; ok {
+		stats.healthy.Store(healthy)
+	}
+	if s.metricsCollector != nil {
+		s.metricsCollector.RecordCircuitBreakerState(g.name, upstreamName, int(to))
+		s.metricsCollector.RecordCircuitBreakerStateChange(g.name, upstreamName, from.String(), to.String())
+		s.metricsCollector.RecordUpstreamHealthStatus(g.name, upstreamName, healthy)
+	}
+}
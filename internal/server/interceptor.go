@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Interceptor 代表编译进程序的请求拦截器，用于在转发前后执行自定义逻辑，如敏感信息脱敏和审计
+// 拦截器在负载均衡选择上游之后、访问上游之前和收到上游响应头之后执行，可直接修改请求和响应
+type Interceptor interface {
+	// BeforeRequest 在请求发送到上游之前执行，返回错误时拒绝请求并返回 403
+	BeforeRequest(ctx context.Context, req *http.Request) error
+
+	// AfterResponse 在收到上游响应之后、转发给客户端之前执行，返回错误时返回 502
+	AfterResponse(ctx context.Context, resp *http.Response) error
+}
+
+var (
+	interceptorsMu sync.RWMutex  // 读写锁，保护拦截器列表
+	interceptors   []Interceptor // 已注册的拦截器，按注册顺序执行
+)
+
+// RegisterInterceptor 注册请求拦截器，作用于所有转发服务
+// 应在启动服务之前调用，如在 init 函数中注册；默认不注册任何拦截器
+// interceptor: 请求拦截器
+func RegisterInterceptor(interceptor Interceptor) {
+	interceptorsMu.Lock()
+	defer interceptorsMu.Unlock()
+
+	interceptors = append(interceptors, interceptor)
+}
+
+// registeredInterceptors 获取已注册的拦截器，注册只会追加，返回的列表不会被修改
+func registeredInterceptors() []Interceptor {
+	interceptorsMu.RLock()
+	defer interceptorsMu.RUnlock()
+
+	return interceptors
+}
+
+// runBeforeRequest 按注册顺序执行拦截器的 BeforeRequest，任一拦截器返回错误时停止执行
+func runBeforeRequest(req *http.Request) error {
+	for _, interceptor := range registeredInterceptors() {
+		if err := interceptor.BeforeRequest(req.Context(), req); err != nil {
+			return fmt.Errorf("%w: %w", ErrInterceptorRejectedRequest, err)
+		}
+	}
+	return nil
+}
+
+// runAfterResponse 按注册顺序执行拦截器的 AfterResponse，任一拦截器返回错误时停止执行
+func runAfterResponse(ctx context.Context, resp *http.Response) error {
+	for _, interceptor := range registeredInterceptors() {
+		if err := interceptor.AfterResponse(ctx, resp); err != nil {
+			return fmt.Errorf("interceptor rejected response: %w", err)
+		}
+	}
+	return nil
+}
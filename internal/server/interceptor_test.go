@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testInterceptor 测试用拦截器，在请求中添加头部并可按需拒绝请求或响应
+type testInterceptor struct {
+	rejectRequest  bool
+	rejectResponse bool
+}
+
+func (i *testInterceptor) BeforeRequest(ctx context.Context, req *http.Request) error {
+	if i.rejectRequest {
+		return errors.New("request contains sensitive data")
+	}
+	req.Header.Set("X-Audit-Id", "audit-1")
+	req.Header.Del("X-Secret")
+	return nil
+}
+
+func (i *testInterceptor) AfterResponse(ctx context.Context, resp *http.Response) error {
+	if i.rejectResponse {
+		return errors.New("response contains sensitive data")
+	}
+	resp.Header.Set("X-Audited", "true")
+	return nil
+}
+
+// withInterceptors 在测试期间替换已注册的拦截器
+func withInterceptors(t *testing.T, registered ...Interceptor) {
+	interceptorsMu.Lock()
+	saved := interceptors
+	interceptors = nil
+	interceptorsMu.Unlock()
+
+	for _, interceptor := range registered {
+		RegisterInterceptor(interceptor)
+	}
+
+	t.Cleanup(func() {
+		interceptorsMu.Lock()
+		interceptors = saved
+		interceptorsMu.Unlock()
+	})
+}
+
+func TestForwardService_Interceptor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	var upstreamHeaders http.Header
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHeaders = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer upstreamServer.Close()
+
+	globalConfig := &config.Config{
+		Upstreams:      []config.UpstreamConfig{{Name: "upstream", URL: upstreamServer.URL}},
+		UpstreamGroups: []config.UpstreamGroupConfig{{Name: "group", Upstreams: []config.UpstreamRefConfig{{Name: "upstream"}}}},
+	}
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(&config.ForwardConfig{Name: "interceptor-forward", DefaultGroup: "group"}, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+
+	send := func() *httptest.ResponseRecorder {
+		upstreamHeaders = nil
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`))
+		req.Header.Set("X-Secret", "sk-123")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("no interceptors", func(t *testing.T) {
+		withInterceptors(t)
+
+		w := send()
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.NotNil(t, upstreamHeaders)
+		assert.Equal(t, "sk-123", upstreamHeaders.Get("X-Secret"))
+		assert.Empty(t, upstreamHeaders.Get("X-Audit-Id"))
+	})
+
+	t.Run("mutates request and response", func(t *testing.T) {
+		withInterceptors(t, &testInterceptor{})
+
+		w := send()
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.NotNil(t, upstreamHeaders)
+		assert.Equal(t, "audit-1", upstreamHeaders.Get("X-Audit-Id"))
+		assert.Empty(t, upstreamHeaders.Get("X-Secret"))
+		assert.Equal(t, "true", w.Header().Get("X-Audited"))
+	})
+
+	t.Run("rejects request", func(t *testing.T) {
+		withInterceptors(t, &testInterceptor{}, &testInterceptor{rejectRequest: true})
+
+		w := send()
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Nil(t, upstreamHeaders)
+
+		// 拦截器拒绝请求不计为处理错误
+		assert.Zero(t, processingErrorCount(t, service))
+	})
+
+	t.Run("rejects response", func(t *testing.T) {
+		withInterceptors(t, &testInterceptor{rejectResponse: true})
+
+		w := send()
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.NotNil(t, upstreamHeaders)
+		assert.NotContains(t, w.Body.String(), `"ok"`)
+	})
+}
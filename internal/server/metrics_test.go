@@ -4,13 +4,19 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-logr/logr"
 	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
 	"github.com/shengyanli1982/llmproxy-go/internal/metrics"
+	"github.com/sony/gobreaker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestMetricsEndpointWithData 测试 /metrics 端点输出实际的指标数据
@@ -32,7 +38,7 @@ func TestMetricsEndpointWithData(t *testing.T) {
 			{
 				Name: "test-group",
 				Upstreams: []config.UpstreamRefConfig{
-					{Name: "test-upstream", Weight: 1},
+					{Name: "test-upstream"},
 				},
 			},
 		},
@@ -71,12 +77,12 @@ func TestMetricsEndpointWithData(t *testing.T) {
 	if forwardService.metricsCollector != nil {
 		// 记录 HTTP 请求和响应
 		forwardService.metricsCollector.RecordRequest("test-forward", "GET", "/api/test")
-		forwardService.metricsCollector.RecordResponse("test-forward", "GET", "/api/test", 200, time.Millisecond*150, 1024, 2048)
-		forwardService.metricsCollector.RecordResponse("test-forward", "POST", "/api/data", 201, time.Millisecond*300, 4096, 8192)
+		forwardService.metricsCollector.RecordResponse("test-forward", "GET", "/api/test", 200, false, "direct", time.Millisecond*150, 1024, 2048)
+		forwardService.metricsCollector.RecordResponse("test-forward", "POST", "/api/data", 201, false, "direct", time.Millisecond*300, 4096, 8192)
 
 		// 记录上游请求
-		forwardService.metricsCollector.RecordUpstreamResponse("test-group", "test-upstream", "GET", 200, time.Millisecond*100)
-		forwardService.metricsCollector.RecordUpstreamResponse("test-group", "test-upstream", "POST", 201, time.Millisecond*250)
+		forwardService.metricsCollector.RecordUpstreamResponse("test-group", "test-upstream", "GET", 200, false, time.Millisecond*100)
+		forwardService.metricsCollector.RecordUpstreamResponse("test-group", "test-upstream", "POST", 201, false, time.Millisecond*250)
 
 		// 记录错误
 		forwardService.metricsCollector.RecordError("test-forward", "timeout_error")
@@ -93,7 +99,7 @@ func TestMetricsEndpointWithData(t *testing.T) {
 		forwardService.metricsCollector.RecordActiveConnections("test-forward", 5)
 
 		// 记录限流拒绝
-		forwardService.metricsCollector.RecordRateLimitRejection("test-forward", "ip")
+		forwardService.metricsCollector.RecordRateLimitRejection("test-forward", "ip", "default")
 	}
 
 	// 设置 HTTP 路由
@@ -250,3 +256,254 @@ func TestMetricsEndpointEmpty(t *testing.T) {
 	// 清理
 	globalRegistry.Clear()
 }
+
+// TestForwardService_RequestsInFlight 测试并发请求时进行中请求数指标的增减
+func TestForwardService_RequestsInFlight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 清理全局注册器
+	globalRegistry := metrics.GetGlobalRegistry()
+	globalRegistry.Clear()
+	defer globalRegistry.Clear()
+
+	var upstreamCalls atomic.Int64
+	release := make(chan struct{})
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls.Add(1)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstreamServer.Close()
+
+	forwardConfig := &config.ForwardConfig{
+		Name:         "inflight-forward",
+		DefaultGroup: "test-group",
+	}
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "inflight-upstream", URL: upstreamServer.URL},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name:      "test-group",
+				Upstreams: []config.UpstreamRefConfig{{Name: "inflight-upstream"}},
+			},
+		},
+	}
+
+	logger := logr.Discard()
+	forwardService := NewForwardServices()
+	require.NoError(t, forwardService.Initialize(forwardConfig, globalConfig, &logger))
+	defer forwardService.Stop()
+
+	router := gin.New()
+	forwardService.RegisterGroup(router.Group("/"))
+
+	// inFlight 获取转发服务当前进行中的请求数
+	inFlight := func() float64 {
+		metricFamilies, err := forwardService.metricsCollector.GetRegistry().Gather()
+		require.NoError(t, err)
+		for _, mf := range metricFamilies {
+			if !strings.HasSuffix(mf.GetName(), "_http_requests_in_flight") {
+				continue
+			}
+			for _, m := range mf.GetMetric() {
+				for _, label := range m.GetLabel() {
+					if label.GetName() == metrics.LabelForwardName && label.GetValue() == forwardConfig.Name {
+						return m.GetGauge().GetValue()
+					}
+				}
+			}
+		}
+		return 0
+	}
+
+	const concurrency = 4
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model": "test"}`))
+			router.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+	}
+
+	// 所有请求阻塞在上游时，进行中的请求数等于并发数
+	require.Eventually(t, func() bool { return upstreamCalls.Load() == concurrency }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, float64(concurrency), inFlight())
+
+	close(release)
+	wg.Wait()
+
+	// 请求结束后进行中的请求数归零
+	assert.Equal(t, float64(0), inFlight())
+}
+
+// TestForwardService_UpstreamHealthStatus 测试按近期请求成功率和熔断器状态上报上游健康状态指标
+func TestForwardService_UpstreamHealthStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 清理全局注册器
+	globalRegistry := metrics.GetGlobalRegistry()
+	globalRegistry.Clear()
+	defer globalRegistry.Clear()
+
+	var statusCode atomic.Int64
+	statusCode.Store(http.StatusInternalServerError)
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(statusCode.Load()))
+	}))
+	defer upstreamServer.Close()
+
+	forwardConfig := &config.ForwardConfig{
+		Name:         "health-forward",
+		DefaultGroup: "health-group",
+	}
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "health-upstream", URL: upstreamServer.URL},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name:      "health-group",
+				Upstreams: []config.UpstreamRefConfig{{Name: "health-upstream"}},
+			},
+		},
+	}
+
+	logger := logr.Discard()
+	forwardService := NewForwardServices()
+	require.NoError(t, forwardService.Initialize(forwardConfig, globalConfig, &logger))
+	defer forwardService.Stop()
+	assert.Equal(t, time.Duration(constants.DefaultHealthStatusInterval)*time.Millisecond, forwardService.healthStatusInterval)
+
+	router := gin.New()
+	forwardService.RegisterGroup(router.Group("/"))
+
+	sendRequest := func() {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model": "test"}`))
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// healthStatus 获取上游健康状态指标值，未上报时返回 -1
+	healthStatus := func() float64 {
+		metricFamilies, err := forwardService.metricsCollector.GetRegistry().Gather()
+		require.NoError(t, err)
+		for _, mf := range metricFamilies {
+			if !strings.HasSuffix(mf.GetName(), "_upstream_health_status") {
+				continue
+			}
+			for _, m := range mf.GetMetric() {
+				labels := make(map[string]string)
+				for _, label := range m.GetLabel() {
+					labels[label.GetName()] = label.GetValue()
+				}
+				if labels[metrics.LabelUpstreamGroup] == "health-group" && labels[metrics.LabelUpstreamName] == "health-upstream" {
+					return m.GetGauge().GetValue()
+				}
+			}
+		}
+		return -1
+	}
+
+	// 没有请求时上报初始健康状态
+	forwardService.reportUpstreamHealth()
+	assert.Equal(t, float64(1), healthStatus())
+
+	// 5xx 响应计为失败
+	sendRequest()
+	sendRequest()
+	forwardService.reportUpstreamHealth()
+	assert.Equal(t, float64(0), healthStatus())
+
+	// 周期内没有请求时保持上一次的状态
+	forwardService.reportUpstreamHealth()
+	assert.Equal(t, float64(0), healthStatus())
+
+	statusCode.Store(http.StatusOK)
+	sendRequest()
+	forwardService.reportUpstreamHealth()
+	assert.Equal(t, float64(1), healthStatus())
+
+	// 熔断器状态变化立即更新指标
+	forwardService.onBreakerStateChange(forwardService.groups[0], "health-upstream", gobreaker.StateClosed, gobreaker.StateOpen)
+	assert.Equal(t, float64(0), healthStatus())
+	forwardService.onBreakerStateChange(forwardService.groups[0], "health-upstream", gobreaker.StateHalfOpen, gobreaker.StateClosed)
+	assert.Equal(t, float64(1), healthStatus())
+}
+
+// TestForwardService_ExposeMetrics 测试转发端口的 /_metrics 端点仅输出当前转发服务的指标
+func TestForwardService_ExposeMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 清理全局注册器
+	globalRegistry := metrics.GetGlobalRegistry()
+	globalRegistry.Clear()
+	defer globalRegistry.Clear()
+
+	var upstreamCalls atomic.Int64
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstreamServer.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "metrics-upstream", URL: upstreamServer.URL},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name:      "metrics-group",
+				Upstreams: []config.UpstreamRefConfig{{Name: "metrics-upstream"}},
+			},
+		},
+	}
+
+	logger := logr.Discard()
+	newRouter := func(name string, exposeMetrics bool) *gin.Engine {
+		forwardService := NewForwardServices()
+		require.NoError(t, forwardService.Initialize(&config.ForwardConfig{
+			Name:          name,
+			DefaultGroup:  "metrics-group",
+			ExposeMetrics: exposeMetrics,
+		}, globalConfig, &logger))
+		t.Cleanup(forwardService.Stop)
+
+		router := gin.New()
+		forwardService.RegisterGroup(router.Group("/"))
+		return router
+	}
+
+	exposed := newRouter("exposed-forward", true)
+	hidden := newRouter("hidden-forward", false)
+
+	for _, router := range []*gin.Engine{exposed, hidden} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model": "test"}`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	t.Run("filtered to forward", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		exposed.ServeHTTP(w, httptest.NewRequest(http.MethodGet, constants.ForwardMetricsPath, nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.Contains(t, body, `llmproxy_http_requests_total{forward_name="exposed-forward"`)
+		assert.NotContains(t, body, "hidden-forward")
+		assert.NotContains(t, body, "llmproxy_upstream_requests_total")
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		callsBefore := upstreamCalls.Load()
+		w := httptest.NewRecorder()
+		hidden.ServeHTTP(w, httptest.NewRequest(http.MethodGet, constants.ForwardMetricsPath, nil))
+
+		// 未启用时该路径按普通请求转发到上游
+		assert.Equal(t, callsBefore+1, upstreamCalls.Load())
+		assert.NotContains(t, w.Body.String(), "llmproxy_http_requests_total")
+	})
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shengyanli1982/llmproxy-go/internal/balance"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// noUpstreamBehavior 代表上游组没有可选择的上游时的处理方式
+type noUpstreamBehavior struct {
+	staticResponse *config.StaticResponseConfig // static 模式返回的静态响应
+	fallback       *balance.Upstream            // fallback 模式转发请求的备用上游
+}
+
+// newNoUpstreamBehavior 根据配置创建无可用上游处理方式
+// 未配置或模式为 error 时返回 nil，表示返回错误响应
+func newNoUpstreamBehavior(cfg *config.NoUpstreamBehaviorConfig) *noUpstreamBehavior {
+	if cfg == nil {
+		return nil
+	}
+
+	switch cfg.Mode {
+	case constants.NoUpstreamModeStatic:
+		return &noUpstreamBehavior{staticResponse: cfg.StaticResponse}
+	case constants.NoUpstreamModeFallback:
+		// 备用上游不经过负载均衡，没有熔断器、限流器和认证器，请求按原样转发
+		return &noUpstreamBehavior{fallback: &balance.Upstream{
+			Name: constants.NoUpstreamFallbackName,
+			URL:  cfg.FallbackURL,
+		}}
+	default:
+		return nil
+	}
+}
+
+// sendStaticResponse 输出配置的静态响应
+func sendStaticResponse(c *gin.Context, response *config.StaticResponseConfig) {
+	statusCode := response.StatusCode
+	if statusCode == 0 {
+		statusCode = constants.DefaultNoUpstreamStatusCode
+	}
+	contentType := response.ContentType
+	if contentType == "" {
+		contentType = constants.ContentTypeJSON
+	}
+	c.Data(statusCode, contentType, response.Body)
+}
@@ -7,8 +7,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"k8s.io/klog/v2"
 
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
 	"github.com/shengyanli1982/llmproxy-go/internal/ratelimit"
 	"github.com/shengyanli1982/llmproxy-go/internal/response"
 )
@@ -45,10 +47,8 @@ func TestGinRateLimitMiddleware(t *testing.T) {
 	t.Run("enabled rate limit - allow request", func(t *testing.T) {
 		// 创建限流中间件
 		rateLimitMW := ratelimit.NewRateLimitMiddleware(
-			10.0,  // IP每秒10个请求
-			20,    // IP突发20个请求
-			100.0, // 上游每秒100个请求
-			200,   // 上游突发200个请求
+			10.0, // IP每秒10个请求
+			20,   // IP突发20个请求
 		)
 
 		// 创建服务
@@ -78,10 +78,8 @@ func TestGinRateLimitMiddleware(t *testing.T) {
 	t.Run("enabled rate limit - exceed limit", func(t *testing.T) {
 		// 创建严格的限流中间件
 		rateLimitMW := ratelimit.NewRateLimitMiddleware(
-			1.0,   // IP每秒1个请求
-			1,     // IP突发1个请求
-			100.0, // 上游每秒100个请求
-			200,   // 上游突发200个请求
+			1.0, // IP每秒1个请求
+			1,   // IP突发1个请求
 		)
 
 		// 创建服务
@@ -118,4 +116,106 @@ func TestGinRateLimitMiddleware(t *testing.T) {
 		assert.Contains(t, responseBody, "1004")
 		assert.Contains(t, responseBody, "too many requests from this IP")
 	})
+
+	t.Run("token rate limit - multiple keys behind one IP", func(t *testing.T) {
+		logger := klog.NewKlogr()
+		service := &ForwardService{
+			logger:      &logger,
+			rateLimitMW: ratelimit.NewRateLimitMiddlewareWithKey(1.0, 1, "token", ""),
+		}
+
+		router := gin.New()
+		router.Use(service.ginRateLimitMiddleware())
+		router.GET("/test", func(c *gin.Context) {
+			response.OK(c, map[string]interface{}{"message": "success"})
+		})
+
+		send := func(token string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.RemoteAddr = "127.0.0.1:12345"
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		// 同一 IP 下不同 Token 独立限流
+		assert.Equal(t, http.StatusOK, send("sk-client-a").Code)
+		assert.Equal(t, http.StatusOK, send("sk-client-b").Code)
+
+		w := send("sk-client-a")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Contains(t, w.Body.String(), "too many requests for this API key")
+		assert.NotContains(t, w.Body.String(), "sk-client-a")
+	})
+	t.Run("route rule overrides forward rate limit", func(t *testing.T) {
+		logger := klog.NewKlogr()
+		service := &ForwardService{logger: &logger}
+		service.initializeRateLimit(&config.ForwardConfig{
+			Name:      "test-forward",
+			RateLimit: &config.RateLimitConfig{PerSecond: 100, Burst: 100, KeyBy: "ip"},
+			RateLimitRules: []config.RateLimitRuleConfig{
+				{PathPrefix: "/v1/chat/completions", PerSecond: 1, Burst: 1},
+			},
+		})
+
+		router := gin.New()
+		router.Use(service.ginRateLimitMiddleware())
+		router.POST("/*path", func(c *gin.Context) {
+			response.OK(c, map[string]interface{}{"message": "success"})
+		})
+
+		send := func(path string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", path, nil)
+			req.RemoteAddr = "127.0.0.1:12345"
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		assert.Equal(t, http.StatusOK, send("/v1/chat/completions").Code)
+		w := send("/v1/chat/completions")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Contains(t, w.Body.String(), "/v1/chat/completions")
+
+		// 其他路径使用转发服务级别限流
+		assert.Equal(t, http.StatusOK, send("/v1/models").Code)
+		assert.Equal(t, http.StatusOK, send("/v1/models").Code)
+	})
+
+	t.Run("spoofed forwarded headers from untrusted peer", func(t *testing.T) {
+		logger := klog.NewKlogr()
+		trustedProxies, err := parseTrustedProxies([]string{"10.0.0.0/8"})
+		require.NoError(t, err)
+		service := &ForwardService{logger: &logger, trustedProxies: trustedProxies}
+		service.initializeRateLimit(&config.ForwardConfig{
+			Name:      "test-forward",
+			RateLimit: &config.RateLimitConfig{PerSecond: 1, Burst: 1, KeyBy: "ip"},
+		})
+
+		router := gin.New()
+		router.Use(service.ginRateLimitMiddleware())
+		router.GET("/test", func(c *gin.Context) {
+			response.OK(c, map[string]interface{}{"message": "success"})
+		})
+
+		send := func(remoteAddr, xff string) int {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.RemoteAddr = remoteAddr
+			req.Header.Set("X-Forwarded-For", xff)
+			req.Header.Set("X-Real-IP", xff)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w.Code
+		}
+
+		// 不可信的客户端轮换伪造的转发头部仍按连接地址限流
+		assert.Equal(t, http.StatusOK, send("203.0.113.7:12345", "198.51.100.1"))
+		assert.Equal(t, http.StatusTooManyRequests, send("203.0.113.7:12345", "198.51.100.2"))
+
+		// 可信代理转发的请求按转发头部中的客户端地址限流
+		assert.Equal(t, http.StatusOK, send("10.0.0.1:12345", "198.51.100.3"))
+		assert.Equal(t, http.StatusOK, send("10.0.0.1:12345", "198.51.100.4"))
+		assert.Equal(t, http.StatusTooManyRequests, send("10.0.0.1:12345", "198.51.100.4"))
+	})
 }
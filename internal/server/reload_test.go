@@ -0,0 +1,159 @@
+package server
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/shengyanli1982/llmproxy-go/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testReloadConfigYAML = `
+upstreams:
+  - name: openai
+    url: "https://api.openai.com/v1"
+upstreamGroups:
+  - name: openai
+    upstreams:
+      - name: openai
+httpServer:
+  forwards:
+    - name: to_openai
+      port: 3000
+      defaultGroup: openai
+  admin:
+    port: 9000
+`
+
+// reloadCounts 读取配置重新加载计数，按 result 标签分组
+func reloadCounts(t *testing.T) map[string]float64 {
+	t.Helper()
+	metricFamilies, err := metrics.GetGlobalRegistry().GetRegistry().Gather()
+	require.NoError(t, err)
+
+	counts := make(map[string]float64)
+	for _, mf := range metricFamilies {
+		if mf.GetName() != constants.MetricsNamespace+"_config_reloads_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			counts[m.GetLabel()[0].GetValue()] = m.GetCounter().GetValue()
+		}
+	}
+	return counts
+}
+
+// TestServer_Reload 测试配置重新加载失败或包含需要重启的变更时记录失败指标且不替换当前配置
+func TestServer_Reload(t *testing.T) {
+	globalRegistry := metrics.GetGlobalRegistry()
+	globalRegistry.Clear()
+	defer globalRegistry.Clear()
+	_, err := globalRegistry.CreateSharedCollector(constants.MetricsCollectorGlobal, &metrics.Config{
+		Type:      constants.MetricsTypePrometheus,
+		Enabled:   true,
+		Namespace: constants.MetricsNamespace,
+	})
+	require.NoError(t, err)
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(testReloadConfigYAML), 0o600))
+
+	manager, err := config.NewManager()
+	require.NoError(t, err)
+	require.NoError(t, manager.LoadFromFile(configPath))
+	loaded := manager.GetConfig()
+
+	logger := logr.Discard()
+	srv := &Server{forwardServers: map[string]*ForwardServer{}, logger: &logger}
+
+	// 引用不存在的上游组，验证失败
+	invalid := strings.Replace(testReloadConfigYAML, "defaultGroup: openai", "defaultGroup: missing", 1)
+	require.NoError(t, os.WriteFile(configPath, []byte(invalid), 0o600))
+	require.Error(t, srv.Reload(manager))
+	assert.Same(t, loaded, manager.GetConfig())
+	assert.True(t, srv.GetConfigInfo().LastReloadAt.IsZero())
+	assert.Equal(t, map[string]float64{metrics.ReloadResultFailure: 1}, reloadCounts(t))
+
+	// 修改端口无法在运行时应用，不能报告重新加载成功
+	changed := strings.Replace(testReloadConfigYAML, "port: 3000", "port: 3001", 1)
+	require.NoError(t, os.WriteFile(configPath, []byte(changed), 0o600))
+	assert.ErrorIs(t, srv.Reload(manager), ErrReloadRequiresRestart)
+	assert.Same(t, loaded, manager.GetConfig())
+	assert.True(t, srv.GetConfigInfo().LastReloadAt.IsZero())
+	assert.Equal(t, map[string]float64{metrics.ReloadResultFailure: 2}, reloadCounts(t))
+
+	require.NoError(t, os.WriteFile(configPath, []byte(testReloadConfigYAML), 0o600))
+	require.NoError(t, srv.Reload(manager))
+	assert.NotSame(t, loaded, manager.GetConfig())
+	assert.False(t, srv.GetConfigInfo().LastReloadAt.IsZero())
+	assert.Equal(t, map[string]float64{metrics.ReloadResultSuccess: 1, metrics.ReloadResultFailure: 2}, reloadCounts(t))
+}
+
+// TestServer_Reload_DefaultConfig 测试重新加载未修改的默认配置文件时判定为没有需要重启的变更
+func TestServer_Reload_DefaultConfig(t *testing.T) {
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	manager, err := config.NewManager()
+	require.NoError(t, err)
+	require.NoError(t, manager.LoadFromFile(filepath.Join("..", "..", "config.default.yaml")))
+
+	logger := logr.Discard()
+	srv := &Server{forwardServers: map[string]*ForwardServer{}, logger: &logger}
+	assert.NoError(t, srv.Reload(manager))
+}
+
+// TestServer_ApplyConfig_Certificates 测试重新加载时替换转发服务证书，任一证书加载失败时所有转发服务保留当前证书
+func TestServer_ApplyConfig_Certificates(t *testing.T) {
+	dir := t.TempDir()
+	forwards := []config.ForwardConfig{
+		{Name: "forward-a", TLS: &config.ForwardTLSConfig{CertFile: filepath.Join(dir, "a.crt"), KeyFile: filepath.Join(dir, "a.key")}},
+		{Name: "forward-b", TLS: &config.ForwardTLSConfig{CertFile: filepath.Join(dir, "b.crt"), KeyFile: filepath.Join(dir, "b.key")}},
+	}
+	writeTestCertificate(t, forwards[0].TLS.CertFile, forwards[0].TLS.KeyFile, 1)
+	writeTestCertificate(t, forwards[1].TLS.CertFile, forwards[1].TLS.KeyFile, 1)
+
+	logger := logr.Discard()
+	srv := &Server{forwardServers: map[string]*ForwardServer{}, logger: &logger}
+	for i := range forwards {
+		store := &certificateStore{}
+		require.NoError(t, store.Load(forwards[i].TLS))
+		srv.forwardServers[forwards[i].Name] = &ForwardServer{name: forwards[i].Name, certificates: store}
+	}
+	current := &config.Config{HTTPServer: config.HTTPServerConfig{Forwards: forwards}}
+	serial := func(name string) int64 {
+		certificate, err := srv.forwardServers[name].certificates.GetCertificate(nil)
+		require.NoError(t, err)
+		leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+		require.NoError(t, err)
+		return leaf.SerialNumber.Int64()
+	}
+
+	// forward-b 的证书无效时 forward-a 也不替换证书
+	writeTestCertificate(t, forwards[0].TLS.CertFile, forwards[0].TLS.KeyFile, 2)
+	require.NoError(t, os.WriteFile(forwards[1].TLS.CertFile, []byte("invalid"), 0o600))
+	assert.Error(t, srv.applyConfig(current, current))
+	assert.Equal(t, int64(1), serial("forward-a"))
+	assert.Equal(t, int64(1), serial("forward-b"))
+
+	writeTestCertificate(t, forwards[1].TLS.CertFile, forwards[1].TLS.KeyFile, 2)
+	require.NoError(t, srv.applyConfig(current, current))
+	assert.Equal(t, int64(2), serial("forward-a"))
+	assert.Equal(t, int64(2), serial("forward-b"))
+
+	// 证书路径变更可以在运行时应用，启用或关闭 TLS 需要重启
+	next := &config.Config{HTTPServer: config.HTTPServerConfig{Forwards: []config.ForwardConfig{
+		{Name: "forward-a", TLS: forwards[1].TLS},
+		{Name: "forward-b", TLS: forwards[0].TLS},
+	}}}
+	assert.NoError(t, srv.applyConfig(current, next))
+	next.HTTPServer.Forwards[1].TLS = nil
+	assert.ErrorIs(t, srv.applyConfig(current, next), ErrReloadRequiresRestart)
+}
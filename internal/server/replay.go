@@ -0,0 +1,40 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/balance"
+	"github.com/shengyanli1982/llmproxy-go/internal/client"
+)
+
+// Replay 使用上游所在上游组的 HTTP 客户端将请求直接发送到指定上游，不经过负载均衡、熔断和限流
+// 请求仍会应用上游的认证、头部操作和请求体转换；上游不属于该转发服务时返回 false
+// upstreamName: 上游名称
+// req: 待发送的请求，URL 只需包含路径和查询参数
+func (s *ForwardService) Replay(upstreamName string, req *http.Request) (*http.Response, bool, error) {
+	httpClient, upstream, ok := s.findUpstream(upstreamName)
+	if !ok {
+		return nil, false, nil
+	}
+
+	resp, err := httpClient.Do(req, &upstream)
+	return resp, true, err
+}
+
+// findUpstream 按名称查找上游及其所在上游组的 HTTP 客户端，上游被多个上游组引用时使用第一个上游组
+func (s *ForwardService) findUpstream(upstreamName string) (client.HTTPClient, balance.Upstream, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, g := range s.groups {
+		if g.httpClient == nil {
+			continue
+		}
+		for _, upstream := range g.upstreams {
+			if upstream.Name == upstreamName {
+				return g.httpClient, upstream, true
+			}
+		}
+	}
+	return nil, balance.Upstream{}, false
+}
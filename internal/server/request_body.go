@@ -0,0 +1,78 @@
+package server
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// shouldStreamRequestBody 判断是否直接流式转发客户端请求体，不预先读入内存
+// 开启 StreamLargeUploads 时，multipart/form-data 文件上传同样流式转发
+func (s *ForwardService) shouldStreamRequestBody(req *http.Request) bool {
+	if s.config == nil || req.Body == nil || req.Body == http.NoBody {
+		return false
+	}
+	if s.config.StreamRequestBody {
+		return true
+	}
+	return s.config.StreamLargeUploads && isMultipartFormData(req)
+}
+
+// isMultipartFormData 判断请求是否为 multipart/form-data 文件上传
+func isMultipartFormData(req *http.Request) bool {
+	return normalizeMediaType(req.Header.Get(constants.HeaderContentType)) == constants.ContentTypeMultipartFormData
+}
+
+// newStreamingRequestBody 创建流式转发的请求体，读取时才从客户端连接读取数据
+// 客户端发送 Expect: 100-continue 时，上游可在请求体上传前拒绝请求（如认证失败）
+// 请求体不可重放，传输层无法在连接失效时自动重试请求
+// req: 原始请求
+// decompress: 是否解压 gzip 编码的请求体
+func (s *ForwardService) newStreamingRequestBody(req *http.Request, decompress bool) (io.Reader, error) {
+	if req.ContentLength > MaxRequestBodySize {
+		s.logger.V(1).Info("Request body too large", "size", req.ContentLength, "limit", MaxRequestBodySize)
+		return nil, fmt.Errorf("%w: %d bytes (limit: %d bytes)", ErrRequestBodyTooLarge, req.ContentLength, MaxRequestBodySize)
+	}
+
+	var bodyReader io.Reader = req.Body
+	if decompress {
+		gzipReader, err := gzip.NewReader(req.Body)
+		if err != nil {
+			s.logger.Error(err, "Failed to decompress request body")
+			return nil, fmt.Errorf("failed to decompress request body: %w", err)
+		}
+		bodyReader = gzipReader
+	}
+
+	return &limitedRequestBody{reader: io.LimitReader(bodyReader, MaxRequestBodySize+1)}, nil
+}
+
+// limitedRequestBody 限制流式请求体大小，超过限制时返回错误而不是静默截断
+// 同时统计实际读取的字节数，用于分块传输等未声明 Content-Length 的请求的大小指标
+type limitedRequestBody struct {
+	reader io.Reader
+	read   atomic.Int64 // 传输层在独立的 goroutine 中读取请求体
+}
+
+// Read 读取请求体，累计读取量超过 MaxRequestBodySize 时返回错误
+func (b *limitedRequestBody) Read(p []byte) (int, error) {
+	n, err := b.reader.Read(p)
+	if b.read.Add(int64(n)) > MaxRequestBodySize {
+		return 0, fmt.Errorf("%w: exceeds %d bytes", ErrRequestBodyTooLarge, MaxRequestBodySize)
+	}
+	return n, err
+}
+
+// Close 实现 io.Closer，使代理请求直接持有该请求体，原始请求体由 HTTP 服务器在请求结束后关闭
+func (b *limitedRequestBody) Close() error {
+	return nil
+}
+
+// Size 获取已读取的请求体字节数，不超过 MaxRequestBodySize
+func (b *limitedRequestBody) Size() int64 {
+	return min(b.read.Load(), MaxRequestBodySize)
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+const (
+	// ksuidEpoch KSUID 时间戳的起始时间（2014-05-13 16:53:20 UTC）
+	ksuidEpoch = 1400000000
+
+	// ksuidLength KSUID 字符串长度
+	ksuidLength = 27
+
+	// ksuidAlphabet KSUID 使用的 base62 字符表
+	ksuidAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// requestIDPolicy 代表请求 ID 的读取、生成和传递策略
+type requestIDPolicy struct {
+	inboundHeaders []string      // 读取客户端请求 ID 的头部名称，按顺序使用第一个存在的头部
+	outboundHeader string        // 转发到上游和返回客户端时设置的头部名称
+	generate       func() string // 客户端未提供请求 ID 时的生成函数
+}
+
+// newRequestIDPolicy 根据配置创建请求 ID 策略，未配置时返回 nil
+func newRequestIDPolicy(cfg *config.RequestIDConfig) *requestIDPolicy {
+	if cfg == nil {
+		return nil
+	}
+
+	policy := &requestIDPolicy{
+		inboundHeaders: cfg.InboundHeaders,
+		outboundHeader: cfg.OutboundHeader,
+	}
+	if len(policy.inboundHeaders) == 0 {
+		policy.inboundHeaders = []string{constants.HeaderXRequestID}
+	}
+	if policy.outboundHeader == "" {
+		policy.outboundHeader = constants.HeaderXRequestID
+	}
+
+	switch cfg.Format {
+	case constants.RequestIDFormatKSUID:
+		policy.generate = newKSUID
+	case constants.RequestIDFormatShort:
+		policy.generate = newShortID
+	default:
+		policy.generate = newUUID4
+	}
+	return policy
+}
+
+// resolveRequestID 获取请求 ID，客户端未提供时生成新的请求 ID
+// 未配置请求 ID 策略时仅读取 X-Request-ID 头部，生成的 ID 只用于日志
+func (s *ForwardService) resolveRequestID(req *http.Request) string {
+	if s.requestIDPolicy == nil {
+		if requestID := req.Header.Get(constants.HeaderXRequestID); requestID != "" {
+			return requestID
+		}
+		return fmt.Sprintf("req-%s", xid.New().String())
+	}
+
+	for _, name := range s.requestIDPolicy.inboundHeaders {
+		if requestID := req.Header.Get(name); requestID != "" {
+			return requestID
+		}
+	}
+	return s.requestIDPolicy.generate()
+}
+
+// newUUID4 生成随机 UUID（版本 4）
+func newUUID4() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40 // 版本 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 变体
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// newKSUID 生成 KSUID：4 字节秒级时间戳和 16 字节随机数，编码为定长 base62 字符串
+func newKSUID() string {
+	var b [20]byte
+	binary.BigEndian.PutUint32(b[:4], uint32(time.Now().Unix()-ksuidEpoch))
+	_, _ = rand.Read(b[4:])
+
+	encoded := make([]byte, ksuidLength)
+	value := new(big.Int).SetBytes(b[:])
+	base := big.NewInt(int64(len(ksuidAlphabet)))
+	remainder := new(big.Int)
+	for i := ksuidLength - 1; i >= 0; i-- {
+		value.DivMod(value, base, remainder)
+		encoded[i] = ksuidAlphabet[remainder.Int64()]
+	}
+	return string(encoded)
+}
+
+// newShortID 生成较短的 xid
+func newShortID() string {
+	return xid.New().String()
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/shengyanli1982/llmproxy-go/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRequestIDPolicy_Formats 测试各请求 ID 格式的生成结果
+func TestRequestIDPolicy_Formats(t *testing.T) {
+	tests := []struct {
+		format  string
+		pattern *regexp.Regexp
+	}{
+		{format: constants.RequestIDFormatUUID4, pattern: regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)},
+		{format: constants.RequestIDFormatKSUID, pattern: regexp.MustCompile(`^[0-9A-Za-z]{27}$`)},
+		{format: constants.RequestIDFormatShort, pattern: regexp.MustCompile(`^[0-9a-v]{20}$`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			policy := newRequestIDPolicy(&config.RequestIDConfig{Format: tt.format})
+			require.NotNil(t, policy)
+
+			first := policy.generate()
+			second := policy.generate()
+			assert.Regexp(t, tt.pattern, first)
+			assert.Regexp(t, tt.pattern, second)
+			assert.NotEqual(t, first, second)
+		})
+	}
+}
+
+// TestForwardService_RequestIDPropagation 测试按配置读取、生成并传递请求 ID
+func TestForwardService_RequestIDPropagation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	var upstreamRequestID string
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamRequestID = r.Header.Get("X-Correlation-ID")
+		w.Header().Set("X-Correlation-ID", "upstream-generated")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstreamServer.Close()
+
+	newRouter := func(t *testing.T, requestID *config.RequestIDConfig) *gin.Engine {
+		metrics.GetGlobalRegistry().Clear()
+		t.Cleanup(func() { _ = metrics.GetGlobalRegistry().Clear() })
+
+		globalConfig := &config.Config{
+			HTTPServer: config.HTTPServerConfig{RequestID: requestID},
+			Upstreams:  []config.UpstreamConfig{{Name: "id-upstream", URL: upstreamServer.URL}},
+			UpstreamGroups: []config.UpstreamGroupConfig{
+				{Name: "id-group", Upstreams: []config.UpstreamRefConfig{{Name: "id-upstream"}}},
+			},
+		}
+		service := NewForwardServices()
+		require.NoError(t, service.Initialize(&config.ForwardConfig{
+			Name:         "id-forward",
+			DefaultGroup: "id-group",
+		}, globalConfig, &logger))
+		t.Cleanup(service.Stop)
+
+		router := gin.New()
+		service.RegisterGroup(router.Group("/"))
+		return router
+	}
+
+	router := newRouter(t, &config.RequestIDConfig{
+		InboundHeaders: []string{"X-Correlation-ID", "traceparent"},
+		OutboundHeader: "X-Correlation-ID",
+		Format:         constants.RequestIDFormatKSUID,
+	})
+
+	t.Run("first present inbound header wins", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+		req.Header.Set("traceparent", "00-trace-parent-01")
+		req.Header.Set("X-Correlation-ID", "client-correlation")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "client-correlation", upstreamRequestID)
+		assert.Equal(t, "client-correlation", w.Header().Get("X-Correlation-ID"))
+	})
+
+	t.Run("later inbound header used when earlier is absent", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+		req.Header.Set("traceparent", "00-trace-parent-01")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "00-trace-parent-01", upstreamRequestID)
+		assert.Equal(t, "00-trace-parent-01", w.Header().Get("X-Correlation-ID"))
+	})
+
+	t.Run("generated when no inbound header present", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Regexp(t, `^[0-9A-Za-z]{27}$`, upstreamRequestID)
+		assert.Equal(t, upstreamRequestID, w.Header().Get("X-Correlation-ID"))
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		router := newRouter(t, nil)
+		req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+		req.Header.Set("X-Correlation-ID", "client-correlation")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// 未配置时不设置请求 ID 头部，客户端头部原样转发，上游响应头部原样返回
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "client-correlation", upstreamRequestID)
+		assert.Equal(t, "upstream-generated", w.Header().Get("X-Correlation-ID"))
+	})
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/balance"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/sony/gobreaker"
+)
+
+// responseAffinity 代表由上游响应驱动的会话亲和
+// 上游响应携带指定头部时记录会话与上游的绑定关系，有效期内该会话的后续请求绕过负载均衡发送到同一上游
+type responseAffinity struct {
+	sessionHeader  string        // 标识客户端会话的请求头部名称
+	upstreamHeader string        // 触发亲和绑定的上游响应头部名称
+	ttl            time.Duration // 亲和记录有效期
+	maxEntries     int           // 最大记录数量
+
+	mu            sync.Mutex                       // 互斥锁，保护亲和记录
+	entries       map[string]responseAffinityEntry // 亲和记录，key 为会话标识
+	lastSweepTime time.Time                        // 上次清理过期记录的时间
+}
+
+// responseAffinityEntry 代表会话的亲和记录
+type responseAffinityEntry struct {
+	group     string    // 上游组名称
+	upstream  string    // 上游名称
+	expiresAt time.Time // 过期时间
+}
+
+// newResponseAffinity 根据配置创建响应亲和，未配置时返回 nil
+func newResponseAffinity(cfg *config.ResponseAffinityConfig) *responseAffinity {
+	if cfg == nil {
+		return nil
+	}
+
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = constants.DefaultResponseAffinityTTL
+	}
+	return &responseAffinity{
+		sessionHeader:  cfg.SessionHeader,
+		upstreamHeader: cfg.UpstreamHeader,
+		ttl:            time.Duration(ttl) * time.Millisecond,
+		maxEntries:     constants.DefaultResponseAffinityMaxEntries,
+		entries:        make(map[string]responseAffinityEntry),
+	}
+}
+
+// lookup 查找会话的有效亲和记录，命中时顺延有效期
+func (a *responseAffinity) lookup(session string) (responseAffinityEntry, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	entry, exists := a.entries[session]
+	if !exists {
+		return responseAffinityEntry{}, false
+	}
+	if !now.Before(entry.expiresAt) {
+		delete(a.entries, session)
+		return responseAffinityEntry{}, false
+	}
+
+	entry.expiresAt = now.Add(a.ttl)
+	a.entries[session] = entry
+	return entry, true
+}
+
+// remember 记录会话绑定的上游组和上游，记录数量达到上限且没有过期记录可清理时不再记录新的会话
+func (a *responseAffinity) remember(session, group, upstream string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	if _, exists := a.entries[session]; !exists && len(a.entries) >= a.maxEntries {
+		a.sweep(now)
+		if len(a.entries) >= a.maxEntries {
+			return
+		}
+	}
+	a.entries[session] = responseAffinityEntry{
+		group:     group,
+		upstream:  upstream,
+		expiresAt: now.Add(a.ttl),
+	}
+}
+
+// forget 删除会话的亲和记录
+func (a *responseAffinity) forget(session string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.entries, session)
+}
+
+// sweep 清理过期的亲和记录，每个 TTL 周期最多执行一次，调用方必须持有锁
+func (a *responseAffinity) sweep(now time.Time) {
+	if now.Sub(a.lastSweepTime) < a.ttl {
+		return
+	}
+	a.lastSweepTime = now
+
+	for session, entry := range a.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(a.entries, session)
+		}
+	}
+}
+
+// responseAffinityUpstream 获取请求会话通过响应亲和绑定的上游组和上游
+// 上游组或上游已不存在、上游已停用或熔断器处于开启状态时删除记录并返回 false，由负载均衡重新选择
+func (s *ForwardService) responseAffinityUpstream(req *http.Request) (*upstreamGroup, balance.Upstream, bool) {
+	if s.responseAffinity == nil {
+		return nil, balance.Upstream{}, false
+	}
+	session := req.Header.Get(s.responseAffinity.sessionHeader)
+	if session == "" {
+		return nil, balance.Upstream{}, false
+	}
+
+	entry, ok := s.responseAffinity.lookup(session)
+	if !ok {
+		return nil, balance.Upstream{}, false
+	}
+
+	for _, g := range s.groups {
+		if g.name != entry.group {
+			continue
+		}
+		for _, upstream := range g.selectableUpstreams() {
+			if upstream.Name == entry.upstream {
+				if upstream.Breaker != nil && upstream.Breaker.State() == gobreaker.StateOpen {
+					break
+				}
+				return g, upstream, true
+			}
+		}
+		break
+	}
+
+	s.responseAffinity.forget(session)
+	return nil, balance.Upstream{}, false
+}
+
+// rememberResponseAffinity 上游响应携带亲和头部时，将请求会话绑定到处理该请求的上游组和上游
+// 未携带会话头部的请求和 5xx 响应不建立亲和
+func (s *ForwardService) rememberResponseAffinity(req *http.Request, resp *http.Response, group *upstreamGroup, upstreamName string) {
+	if s.responseAffinity == nil || resp.StatusCode >= http.StatusInternalServerError {
+		return
+	}
+	session := req.Header.Get(s.responseAffinity.sessionHeader)
+	if session == "" || resp.Header.Get(s.responseAffinity.upstreamHeader) == "" {
+		return
+	}
+	s.responseAffinity.remember(session, group.name, upstreamName)
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestForwardService_ResponseAffinity 测试上游响应携带亲和头部后，同一会话的后续请求发送到同一上游
+func TestForwardService_ResponseAffinity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	// 上游按 sendRegion 决定是否返回 x-region 头部，响应体为上游名称
+	var sendRegion atomic.Bool
+	sendRegion.Store(true)
+	newUpstream := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if sendRegion.Load() {
+				w.Header().Set("X-Region", "region-"+name)
+			}
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(name))
+		}))
+	}
+	upstreamA, upstreamB := newUpstream("a"), newUpstream("b")
+	defer upstreamA.Close()
+	defer upstreamB.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "upstream-a", URL: upstreamA.URL},
+			{Name: "upstream-b", URL: upstreamB.URL},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{{
+			Name:      "rr-group",
+			Balance:   &config.BalanceConfig{Strategy: "roundrobin"},
+			Upstreams: []config.UpstreamRefConfig{{Name: "upstream-a"}, {Name: "upstream-b"}},
+		}},
+	}
+	forwardConfig := &config.ForwardConfig{
+		Name:             "affinity-forward",
+		DefaultGroup:     "rr-group",
+		ResponseAffinity: &config.ResponseAffinityConfig{SessionHeader: "X-Session-ID", UpstreamHeader: "x-region", TTL: 60000},
+	}
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(forwardConfig, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+
+	send := func(session string) string {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`))
+		if session != "" {
+			req.Header.Set("X-Session-ID", session)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	t.Run("affinity forms after first response", func(t *testing.T) {
+		// 轮询策略下连续请求会交替选择上游，亲和建立后始终发送到同一上游
+		first := send("session-1")
+		for i := 0; i < 6; i++ {
+			assert.Equal(t, first, send("session-1"))
+		}
+
+		// 不同会话独立建立亲和
+		second := send("session-2")
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, second, send("session-2"))
+		}
+	})
+
+	t.Run("requests without session header use load balancing", func(t *testing.T) {
+		seen := map[string]int{}
+		for i := 0; i < 4; i++ {
+			seen[send("")]++
+		}
+		assert.Equal(t, map[string]int{"a": 2, "b": 2}, seen)
+	})
+
+	t.Run("responses without affinity header do not pin", func(t *testing.T) {
+		sendRegion.Store(false)
+		defer sendRegion.Store(true)
+
+		seen := map[string]int{}
+		for i := 0; i < 4; i++ {
+			seen[send("session-3")]++
+		}
+		assert.Equal(t, map[string]int{"a": 2, "b": 2}, seen)
+	})
+
+	t.Run("disabled upstream breaks affinity", func(t *testing.T) {
+		pinned := send("session-4")
+		pinnedName := "upstream-" + pinned
+		require.True(t, service.SetUpstreamDisabled(pinnedName, true))
+		defer service.SetUpstreamDisabled(pinnedName, false)
+
+		other := send("session-4")
+		assert.NotEqual(t, pinned, other)
+		// 会话重新绑定到新的上游
+		require.True(t, service.SetUpstreamDisabled(pinnedName, false))
+		assert.Equal(t, other, send("session-4"))
+	})
+
+	t.Run("expired affinity falls back to load balancing", func(t *testing.T) {
+		service.responseAffinity.ttl = 50 * time.Millisecond
+		defer func() { service.responseAffinity.ttl = time.Minute }()
+
+		pinned := send("session-5")
+		time.Sleep(100 * time.Millisecond)
+
+		_, ok := service.responseAffinity.lookup("session-5")
+		assert.False(t, ok)
+		assert.NotEmpty(t, pinned)
+	})
+}
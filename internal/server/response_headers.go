@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// responseHeaderPolicy 代表上游响应头部过滤策略
+type responseHeaderPolicy struct {
+	allow   bool                // true 表示仅转发列出的头部，false 表示移除列出的头部
+	headers map[string]struct{} // 规范化后的头部名称集合
+}
+
+// newResponseHeaderPolicy 根据配置创建响应头部过滤策略
+// 未配置或模式为 all 时返回 nil，表示转发全部头部
+func newResponseHeaderPolicy(cfg *config.ResponseHeaderPolicyConfig) *responseHeaderPolicy {
+	if cfg == nil || cfg.Mode == "" || cfg.Mode == constants.ResponseHeaderPolicyAll {
+		return nil
+	}
+
+	headers := make(map[string]struct{}, len(cfg.Headers))
+	for _, name := range cfg.Headers {
+		headers[http.CanonicalHeaderKey(name)] = struct{}{}
+	}
+	return &responseHeaderPolicy{
+		allow:   cfg.Mode == constants.ResponseHeaderPolicyAllow,
+		headers: headers,
+	}
+}
+
+// filterResponseHeaders 移除不应转发给客户端的上游响应头部
+// 逐跳头部始终移除，其余头部按转发服务配置的过滤策略处理
+func (s *ForwardService) filterResponseHeaders(header http.Header) {
+	removeHopByHopHeaders(header)
+
+	// 请求 ID 头部由代理设置，不使用上游返回的同名头部覆盖
+	if s.requestIDPolicy != nil {
+		header.Del(s.requestIDPolicy.outboundHeader)
+	}
+	if s.responseHeaderPolicy == nil {
+		return
+	}
+
+	for name := range header {
+		if _, listed := s.responseHeaderPolicy.headers[http.CanonicalHeaderKey(name)]; listed != s.responseHeaderPolicy.allow {
+			delete(header, name)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/balance"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/shengyanli1982/llmproxy-go/internal/transform"
+)
+
+// transformResponseBody 对非流式 JSON 响应体执行上游配置的转换操作
+// 已编码或非 JSON 的响应体保持不变，转换失败时原样返回上游响应体
+func (s *ForwardService) transformResponseBody(resp *http.Response, upstream *balance.Upstream) error {
+	if upstream.Config == nil || len(upstream.Config.ResponseTransform) == 0 {
+		return nil
+	}
+	if resp.Header.Get(constants.HeaderContentEncoding) != "" || !transform.IsJSONContentType(resp.Header.Get(constants.HeaderContentType)) {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read upstream response body: %w", err)
+	}
+
+	transformed, err := transform.Apply(body, upstream.Config.ResponseTransform)
+	if err != nil {
+		s.logger.Error(err, "Failed to transform response body, returning unchanged", "upstream", upstream.Name)
+		transformed = body
+	}
+
+	// 原始响应体由调用方关闭，这里只替换为转换后的内容
+	resp.Body = io.NopCloser(bytes.NewReader(transformed))
+	resp.ContentLength = int64(len(transformed))
+	resp.Header.Set(constants.HeaderContentLength, strconv.Itoa(len(transformed)))
+	return nil
+}
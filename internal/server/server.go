@@ -1,18 +1,42 @@
 package server
 
 import (
+	"crypto/tls"
+	"fmt"
+	"reflect"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/shengyanli1982/llmproxy-go/internal/metrics"
+	"go.uber.org/zap"
 )
 
+// BuildInfo 代表构建信息，由 main 包通过 ldflags 在编译时注入
+type BuildInfo struct {
+	Version   string // 版本号
+	GitCommit string // Git 提交哈希
+	BuildTime string // 构建时间
+}
+
+// ConfigInfo 代表配置加载信息
+type ConfigInfo struct {
+	Path         string    // 解析后的配置文件路径，多个文件以逗号分隔
+	LoadedAt     time.Time // 配置首次加载时间
+	LastReloadAt time.Time // 最近一次重新加载配置的时间，未重新加载时为零值
+}
+
 // Server 代表主服务器，管理转发服务器和管理服务器
 type Server struct {
 	lock           sync.RWMutex              // 读写锁，保护并发访问
 	forwardServers map[string]*ForwardServer // 转发服务器映射
 	adminServer    *AdminServer              // 管理服务器实例
 	logger         *logr.Logger              // 日志记录器
+	buildInfo      BuildInfo                 // 构建信息
+	configInfo     ConfigInfo                // 配置加载信息
+	logLevel       *zap.AtomicLevel          // 运行时日志级别，未设置时不支持运行时调整
 }
 
 // NewServer 创建新的服务器实例
@@ -23,6 +47,11 @@ func NewServer(debug bool, logger *logr.Logger, config *config.HTTPServerConfig,
 	srv := &Server{
 		forwardServers: make(map[string]*ForwardServer),
 		logger:         logger,
+		buildInfo: BuildInfo{
+			Version:   constants.DefaultVersion,
+			GitCommit: constants.DefaultBuildValue,
+			BuildTime: constants.DefaultBuildValue,
+		},
 	}
 
 	// 创建转发服务器实例
@@ -31,8 +60,12 @@ func NewServer(debug bool, logger *logr.Logger, config *config.HTTPServerConfig,
 		srv.forwardServers[forward.Name] = forwardServer
 	}
 
-	// 创建管理服务器实例
-	srv.adminServer = NewAdminServer(debug, logger, &config.Admin, globalConfig, srv)
+	// 创建管理服务器实例，禁用时不监听管理端口
+	if config.Admin.IsEnabled() {
+		srv.adminServer = NewAdminServer(debug, logger, &config.Admin, globalConfig, srv)
+	} else {
+		logger.Info("Admin server disabled, admin endpoints (including /metrics) are unavailable")
+	}
 
 	return srv
 }
@@ -47,8 +80,10 @@ func (s *Server) Start() {
 	}
 
 	// 启动管理服务器
-	s.logger.Info("Starting admin server")
-	s.adminServer.Start()
+	if s.adminServer != nil {
+		s.logger.Info("Starting admin server")
+		s.adminServer.Start()
+	}
 }
 
 // Stop 停止所有服务器（转发服务器和管理服务器）
@@ -61,8 +96,10 @@ func (s *Server) Stop() {
 	}
 
 	// 停止管理服务器
-	s.logger.Info("Stopping admin server")
-	s.adminServer.Stop()
+	if s.adminServer != nil {
+		s.logger.Info("Stopping admin server")
+		s.adminServer.Stop()
+	}
 }
 
 // AddForwardServer 添加新的转发服务器
@@ -106,6 +143,23 @@ func (s *Server) RemoveForwardServer(forwardServer *ForwardServer) {
 	}
 }
 
+// GetAdminServer 获取管理服务器实例，管理服务禁用时返回 nil
+func (s *Server) GetAdminServer() *AdminServer {
+	return s.adminServer
+}
+
+// GetForwardServers 获取所有转发服务器实例
+func (s *Server) GetForwardServers() []*ForwardServer {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	forwardServers := make([]*ForwardServer, 0, len(s.forwardServers))
+	for _, forwardServer := range s.forwardServers {
+		forwardServers = append(forwardServers, forwardServer)
+	}
+	return forwardServers
+}
+
 // GetForwardServer 根据名称获取转发服务器实例
 // name: 转发服务器名称
 func (s *Server) GetForwardServer(name string) *ForwardServer {
@@ -117,3 +171,123 @@ func (s *Server) GetForwardServer(name string) *ForwardServer {
 	}
 	return nil
 }
+
+// SetBuildInfo 设置构建信息，供管理接口 /admin/info 展示
+func (s *Server) SetBuildInfo(info BuildInfo) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.buildInfo = info
+}
+
+// GetBuildInfo 获取构建信息
+func (s *Server) GetBuildInfo() BuildInfo {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.buildInfo
+}
+
+// SetConfigInfo 设置配置加载信息，供管理接口 /admin/info 展示
+func (s *Server) SetConfigInfo(info ConfigInfo) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.configInfo = info
+}
+
+// Reload 通过配置管理器重新加载配置并应用到运行中的服务，记录重新加载次数和结果指标
+// 运行时只能应用转发服务 TLS 证书的变更，包含其他变更、验证或证书加载失败时返回错误，配置管理器继续持有当前运行的配置
+// manager: 配置管理器
+func (s *Server) Reload(manager *config.Manager) error {
+	current := manager.GetConfig()
+	err := manager.Reload(func(next *config.Config) error {
+		return s.applyConfig(current, next)
+	})
+
+	if collector, exists := metrics.GetGlobalRegistry().GetCollector(constants.MetricsCollectorGlobal); exists {
+		collector.RecordConfigReload(err == nil)
+	}
+
+	if err != nil {
+		s.logger.Error(err, "Failed to reload configuration, keeping current configuration")
+		return err
+	}
+
+	s.lock.Lock()
+	s.configInfo.LastReloadAt = time.Now()
+	s.lock.Unlock()
+
+	s.logger.Info("Configuration reloaded", "path", manager.GetConfigPath())
+	return nil
+}
+
+// applyConfig 将重新加载的配置应用到运行中的服务，目前只支持重新加载转发服务的 TLS 证书
+// 先加载全部证书，全部成功后再替换，任一证书加载失败时所有转发服务继续使用当前证书
+// current: 当前运行的配置
+// next: 重新加载的配置
+func (s *Server) applyConfig(current, next *config.Config) error {
+	if !reflect.DeepEqual(withoutCertificatePaths(current), withoutCertificatePaths(next)) {
+		return ErrReloadRequiresRestart
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	certificates := make(map[*certificateStore]*tls.Certificate)
+	for i := range next.HTTPServer.Forwards {
+		forward := &next.HTTPServer.Forwards[i]
+		forwardServer, exists := s.forwardServers[forward.Name]
+		if !exists || forward.TLS == nil || forwardServer.certificates == nil {
+			continue
+		}
+		certificate, err := loadCertificate(forward.TLS)
+		if err != nil {
+			return fmt.Errorf("forward service '%s': %w", forward.Name, err)
+		}
+		certificates[forwardServer.certificates] = certificate
+	}
+
+	for store, certificate := range certificates {
+		store.certificate.Store(certificate)
+	}
+	if len(certificates) > 0 {
+		s.logger.Info("Forward tls certificates reloaded", "count", len(certificates))
+	}
+	return nil
+}
+
+// withoutCertificatePaths 返回清除转发服务证书文件路径的配置副本，用于判断配置变更是否只涉及证书
+// cfg: 全局配置
+func withoutCertificatePaths(cfg *config.Config) *config.Config {
+	clone := *cfg
+	clone.HTTPServer.Forwards = make([]config.ForwardConfig, len(cfg.HTTPServer.Forwards))
+	for i, forward := range cfg.HTTPServer.Forwards {
+		if forward.TLS != nil {
+			forward.TLS = &config.ForwardTLSConfig{}
+		}
+		clone.HTTPServer.Forwards[i] = forward
+	}
+	return &clone
+}
+
+// GetConfigInfo 获取配置加载信息
+func (s *Server) GetConfigInfo() ConfigInfo {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.configInfo
+}
+
+// SetLogLevel 设置运行时日志级别，供管理接口 /admin/loglevel 查询和调整
+func (s *Server) SetLogLevel(level zap.AtomicLevel) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.logLevel = &level
+}
+
+// GetLogLevel 获取运行时日志级别，未设置时返回 false
+func (s *Server) GetLogLevel() (zap.AtomicLevel, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.logLevel == nil {
+		return zap.AtomicLevel{}, false
+	}
+	return *s.logLevel, true
+}
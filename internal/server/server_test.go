@@ -1,20 +1,40 @@
 package server
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
 	"github.com/shengyanli1982/llmproxy-go/internal/balance"
+	"github.com/shengyanli1982/llmproxy-go/internal/breaker"
 	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/shengyanli1982/llmproxy-go/internal/metrics"
+	"github.com/shengyanli1982/llmproxy-go/internal/response"
+	"github.com/shengyanli1982/toolkit/pkg/httptool"
+	"github.com/sony/gobreaker"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -47,8 +67,8 @@ func TestForwardService_Initialize(t *testing.T) {
 					Strategy: "roundrobin",
 				},
 				Upstreams: []config.UpstreamRefConfig{
-					{Name: "upstream1", Weight: 1},
-					{Name: "upstream2", Weight: 2},
+					{Name: "upstream1"},
+					{Name: "upstream2"},
 				},
 			},
 		},
@@ -75,10 +95,10 @@ func TestForwardService_Initialize(t *testing.T) {
 	err := service.Initialize(forwardConfig, globalConfig, &logger)
 
 	require.NoError(t, err)
-	assert.NotNil(t, service.loadBalancer)
-	assert.NotNil(t, service.httpClient)
+	assert.NotNil(t, service.groups[0].loadBalancer)
+	assert.NotNil(t, service.groups[0].httpClient)
 	assert.NotNil(t, service.rateLimitMW)
-	assert.Equal(t, 2, len(service.upstreams))
+	assert.Equal(t, 2, len(service.groups[0].upstreams))
 	assert.Equal(t, 2, len(service.upstreamMap))
 }
 
@@ -107,6 +127,83 @@ func TestForwardService_Initialize_MissingDefaultGroup(t *testing.T) {
 	assert.Contains(t, err.Error(), "default upstream group 'nonexistent-group' not found")
 }
 
+func TestForwardService_WeightedGroups(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	globalRegistry := metrics.GetGlobalRegistry()
+	globalRegistry.Clear()
+	defer globalRegistry.Clear()
+
+	newUpstream := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Upstream", name)
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+	upstreamA1, upstreamA2, upstreamB := newUpstream("a1"), newUpstream("a2"), newUpstream("b")
+	defer upstreamA1.Close()
+	defer upstreamA2.Close()
+	defer upstreamB.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "a1", URL: upstreamA1.URL},
+			{Name: "a2", URL: upstreamA2.URL},
+			{Name: "b", URL: upstreamB.URL},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name:      "account-a",
+				Upstreams: []config.UpstreamRefConfig{{Name: "a1"}, {Name: "a2"}},
+				Balance:   &config.BalanceConfig{Strategy: constants.BalanceRoundRobin},
+			},
+			{
+				Name:      "account-b",
+				Upstreams: []config.UpstreamRefConfig{{Name: "b"}},
+			},
+		},
+	}
+
+	logger := logr.Discard()
+
+	t.Run("traffic split by group weight", func(t *testing.T) {
+		service := NewForwardServices()
+		require.NoError(t, service.Initialize(&config.ForwardConfig{
+			Name:   "weighted",
+			Groups: []config.GroupRefConfig{{Name: "account-a", Weight: 7}, {Name: "account-b", Weight: 3}},
+		}, globalConfig, &logger))
+		defer service.Stop()
+		require.Len(t, service.groups, 2)
+
+		router := gin.New()
+		service.RegisterGroup(router.Group("/"))
+
+		counts := make(map[string]int)
+		for i := 0; i < 100; i++ {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`)))
+			require.Equal(t, http.StatusOK, w.Code)
+			counts[w.Header().Get("X-Upstream")]++
+		}
+
+		// 组间按 70/30 分配，组内按各自的负载均衡策略轮询
+		assert.Equal(t, 35, counts["a1"])
+		assert.Equal(t, 35, counts["a2"])
+		assert.Equal(t, 30, counts["b"])
+	})
+
+	t.Run("unknown group", func(t *testing.T) {
+		service := NewForwardServices()
+		err := service.Initialize(&config.ForwardConfig{
+			Name:   "weighted",
+			Groups: []config.GroupRefConfig{{Name: "account-a", Weight: 1}, {Name: "missing", Weight: 1}},
+		}, globalConfig, &logger)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "upstream group 'missing' not found")
+	})
+}
+
 func TestForwardService_Initialize_MissingUpstream(t *testing.T) {
 	logger := logr.Discard()
 
@@ -125,7 +222,7 @@ func TestForwardService_Initialize_MissingUpstream(t *testing.T) {
 			{
 				Name: "test-group",
 				Upstreams: []config.UpstreamRefConfig{
-					{Name: "nonexistent-upstream", Weight: 1},
+					{Name: "nonexistent-upstream"},
 				},
 			},
 		},
@@ -268,7 +365,7 @@ func TestForwardServer_Integration(t *testing.T) {
 					Strategy: "roundrobin",
 				},
 				Upstreams: []config.UpstreamRefConfig{
-					{Name: "test-upstream", Weight: 1},
+					{Name: "test-upstream"},
 				},
 			},
 		},
@@ -361,6 +458,362 @@ func TestForwardService_CreateProxyRequest_EmptyBody(t *testing.T) {
 	assert.Nil(t, proxyReq.Body)
 }
 
+func TestForwardService_CreateProxyRequest_HopByHopHeaders(t *testing.T) {
+	service := NewForwardServices()
+
+	originalReq, err := http.NewRequest("POST", "http://original.com/api/test", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	originalReq.RemoteAddr = "192.168.1.100:12345"
+	originalReq.Header.Set("Connection", "keep-alive, X-Hop-Custom")
+	originalReq.Header.Set("Keep-Alive", "timeout=5")
+	originalReq.Header.Set("Proxy-Authorization", "Basic cHJveHk6c2VjcmV0")
+	originalReq.Header.Set("Proxy-Connection", "keep-alive")
+	originalReq.Header.Set("Te", "trailers")
+	originalReq.Header.Set("Upgrade", "h2c")
+	originalReq.Header.Set("X-Hop-Custom", "hop")
+	originalReq.Header.Set("Authorization", "Bearer client-token")
+	originalReq.Header.Set("Content-Type", "application/json")
+
+	proxyReq, err := service.createProxyRequest(originalReq)
+	require.NoError(t, err)
+
+	for _, name := range []string{"Connection", "Keep-Alive", "Proxy-Authorization", "Proxy-Connection", "Te", "Upgrade", "X-Hop-Custom"} {
+		assert.Empty(t, proxyReq.Header.Get(name), name)
+	}
+	assert.Equal(t, "Bearer client-token", proxyReq.Header.Get("Authorization"))
+	assert.Equal(t, "application/json", proxyReq.Header.Get("Content-Type"))
+}
+
+func TestForwardService_CreateProxyRequest_GzipBody(t *testing.T) {
+	payload := `{"model": "gpt-4", "messages": []}`
+	gzipBody := func(t *testing.T, data []byte) []byte {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		_, err := writer.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+		return buf.Bytes()
+	}
+	newRequest := func(t *testing.T, body []byte) *http.Request {
+		req, err := http.NewRequest("POST", "http://original.com/v1/chat/completions", bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Encoding", "gzip")
+		req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		req.RemoteAddr = "192.168.1.100:12345"
+		return req
+	}
+
+	t.Run("decompressed when enabled", func(t *testing.T) {
+		service := NewForwardServices()
+		service.config = &config.ForwardConfig{Name: "test", DecompressRequestBody: true}
+
+		proxyReq, err := service.createProxyRequest(newRequest(t, gzipBody(t, []byte(payload))))
+		require.NoError(t, err)
+
+		assert.Empty(t, proxyReq.Header.Get("Content-Encoding"))
+		assert.Empty(t, proxyReq.Header.Get("Content-Length"))
+		assert.Equal(t, int64(len(payload)), proxyReq.ContentLength)
+		bodyBytes, err := io.ReadAll(proxyReq.Body)
+		require.NoError(t, err)
+		assert.Equal(t, payload, string(bodyBytes))
+	})
+
+	t.Run("forwarded unchanged when disabled", func(t *testing.T) {
+		service := NewForwardServices()
+		service.config = &config.ForwardConfig{Name: "test"}
+		compressed := gzipBody(t, []byte(payload))
+
+		proxyReq, err := service.createProxyRequest(newRequest(t, compressed))
+		require.NoError(t, err)
+
+		assert.Equal(t, "gzip", proxyReq.Header.Get("Content-Encoding"))
+		bodyBytes, err := io.ReadAll(proxyReq.Body)
+		require.NoError(t, err)
+		assert.Equal(t, compressed, bodyBytes)
+	})
+
+	t.Run("limit applies to decompressed size", func(t *testing.T) {
+		service := NewForwardServices()
+		service.config = &config.ForwardConfig{Name: "test", DecompressRequestBody: true}
+		compressed := gzipBody(t, bytes.Repeat([]byte("A"), MaxRequestBodySize+1000))
+		require.Less(t, len(compressed), MaxRequestBodySize)
+
+		_, err := service.createProxyRequest(newRequest(t, compressed))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "request body too large")
+	})
+
+	t.Run("invalid gzip body", func(t *testing.T) {
+		service := NewForwardServices()
+		service.config = &config.ForwardConfig{Name: "test", DecompressRequestBody: true}
+
+		_, err := service.createProxyRequest(newRequest(t, []byte("not gzip")))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to decompress request body")
+	})
+}
+
+// trackingReader 记录是否已被读取的请求体
+type trackingReader struct {
+	reader io.Reader
+	read   bool
+}
+
+func (r *trackingReader) Read(p []byte) (int, error) {
+	r.read = true
+	return r.reader.Read(p)
+}
+
+// zeroReader 无限返回零字节的读取器
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestForwardService_CreateProxyRequest_StreamBody(t *testing.T) {
+	payload := `{"model": "test", "messages": []}`
+
+	t.Run("body streamed without buffering", func(t *testing.T) {
+		service := NewForwardServices()
+		service.config = &config.ForwardConfig{Name: "test", StreamRequestBody: true}
+		body := &trackingReader{reader: strings.NewReader(payload)}
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", body)
+		req.ContentLength = int64(len(payload))
+		req.Header.Set("Expect", "100-continue")
+
+		proxyReq, err := service.createProxyRequest(req)
+		require.NoError(t, err)
+
+		assert.False(t, body.read)
+		assert.Nil(t, proxyReq.GetBody)
+		assert.Equal(t, int64(len(payload)), proxyReq.ContentLength)
+		assert.Equal(t, "100-continue", proxyReq.Header.Get("Expect"))
+		bodyBytes, err := io.ReadAll(proxyReq.Body)
+		require.NoError(t, err)
+		assert.Equal(t, payload, string(bodyBytes))
+	})
+
+	t.Run("declared length too large", func(t *testing.T) {
+		service := NewForwardServices()
+		service.config = &config.ForwardConfig{Name: "test", StreamRequestBody: true}
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(payload))
+		req.ContentLength = MaxRequestBodySize + 1
+
+		_, err := service.createProxyRequest(req)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "request body too large")
+	})
+
+	t.Run("chunked body exceeds limit", func(t *testing.T) {
+		service := NewForwardServices()
+		service.config = &config.ForwardConfig{Name: "test", StreamRequestBody: true}
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", io.NopCloser(zeroReader{}))
+		req.ContentLength = -1
+
+		proxyReq, err := service.createProxyRequest(req)
+		require.NoError(t, err)
+
+		_, err = io.Copy(io.Discard, proxyReq.Body)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "request body too large")
+	})
+}
+
+func TestForwardService_CreateProxyRequest_StreamLargeUploads(t *testing.T) {
+	payload := "--boundary\r\nContent-Disposition: form-data; name=\"model\"\r\n\r\nwhisper-1\r\n--boundary--\r\n"
+
+	t.Run("multipart upload streamed", func(t *testing.T) {
+		service := NewForwardServices()
+		service.config = &config.ForwardConfig{Name: "test", StreamLargeUploads: true}
+		body := &trackingReader{reader: strings.NewReader(payload)}
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/audio/transcriptions", body)
+		req.ContentLength = int64(len(payload))
+		req.Header.Set("Content-Type", "multipart/form-data; boundary=boundary")
+
+		proxyReq, err := service.createProxyRequest(req)
+		require.NoError(t, err)
+
+		assert.False(t, body.read)
+		assert.Nil(t, proxyReq.GetBody)
+		bodyBytes, err := io.ReadAll(proxyReq.Body)
+		require.NoError(t, err)
+		assert.Equal(t, payload, string(bodyBytes))
+	})
+
+	t.Run("other content types buffered", func(t *testing.T) {
+		service := NewForwardServices()
+		service.config = &config.ForwardConfig{Name: "test", StreamLargeUploads: true}
+		body := &trackingReader{reader: strings.NewReader(`{"model": "test"}`)}
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", body)
+		req.Header.Set("Content-Type", "application/json")
+
+		proxyReq, err := service.createProxyRequest(req)
+		require.NoError(t, err)
+
+		assert.True(t, body.read)
+		assert.NotNil(t, proxyReq.GetBody)
+	})
+
+	t.Run("chunked upload exceeds limit", func(t *testing.T) {
+		service := NewForwardServices()
+		service.config = &config.ForwardConfig{Name: "test", StreamLargeUploads: true}
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/audio/transcriptions", io.NopCloser(zeroReader{}))
+		req.ContentLength = -1
+		req.Header.Set("Content-Type", "multipart/form-data; boundary=boundary")
+
+		proxyReq, err := service.createProxyRequest(req)
+		require.NoError(t, err)
+
+		_, err = io.Copy(io.Discard, proxyReq.Body)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "request body too large")
+	})
+}
+
+// TestForwardService_ChunkedRequestBodySize 测试未声明 Content-Length 的分块请求体按实际读取字节数记录请求大小
+func TestForwardService_ChunkedRequestBodySize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstreamServer.Close()
+
+	const payload = `{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`
+
+	for _, streamBody := range []bool{false, true} {
+		t.Run(fmt.Sprintf("streamRequestBody=%v", streamBody), func(t *testing.T) {
+			metrics.GetGlobalRegistry().Clear()
+			defer metrics.GetGlobalRegistry().Clear()
+
+			globalConfig := &config.Config{
+				Upstreams: []config.UpstreamConfig{{Name: "chunked-upstream", URL: upstreamServer.URL}},
+				UpstreamGroups: []config.UpstreamGroupConfig{
+					{Name: "chunked-group", Upstreams: []config.UpstreamRefConfig{{Name: "chunked-upstream"}}},
+				},
+			}
+			service := NewForwardServices()
+			require.NoError(t, service.Initialize(&config.ForwardConfig{
+				Name:              "chunked-forward",
+				DefaultGroup:      "chunked-group",
+				StreamRequestBody: streamBody,
+			}, globalConfig, &logger))
+			defer service.Stop()
+
+			router := gin.New()
+			service.RegisterGroup(router.Group("/"))
+
+			// 包装为未知长度的读取器，模拟 Transfer-Encoding: chunked 请求
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", io.MultiReader(strings.NewReader(payload)))
+			req.ContentLength = -1
+			req.TransferEncoding = []string{"chunked"}
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			families, err := service.metricsCollector.GetRegistry().Gather()
+			require.NoError(t, err)
+
+			var size float64
+			for _, mf := range families {
+				if !strings.HasSuffix(mf.GetName(), "_http_request_size_bytes") {
+					continue
+				}
+				for _, m := range mf.GetMetric() {
+					size += m.GetHistogram().GetSampleSum()
+				}
+			}
+			assert.Equal(t, float64(len(payload)), size)
+		})
+	}
+}
+
+// TestForwardService_StreamLargeUploads 测试大文件 multipart 上传完整流式转发到上游
+func TestForwardService_StreamLargeUploads(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	// 上游解析 multipart 表单，返回文件大小和摘要
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		hash := sha256.New()
+		size, err := io.Copy(hash, file)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("X-File-Size", strconv.FormatInt(size, 10))
+		w.Header().Set("X-File-Sha256", hex.EncodeToString(hash.Sum(nil)))
+		w.Header().Set("X-Model", r.FormValue("model"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstreamServer.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{{Name: "audio-upstream", URL: upstreamServer.URL}},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{Name: "audio-group", Upstreams: []config.UpstreamRefConfig{{Name: "audio-upstream"}}},
+		},
+	}
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(&config.ForwardConfig{
+		Name:               "upload-forward",
+		DefaultGroup:       "audio-group",
+		StreamLargeUploads: true,
+	}, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+	proxyServer := httptest.NewServer(router)
+	defer proxyServer.Close()
+
+	// 构造 16MB 的音频文件，远大于常规请求体但小于请求体大小限制
+	audio := make([]byte, 16<<20)
+	rand.New(rand.NewSource(1)).Read(audio)
+	expectedHash := sha256.Sum256(audio)
+
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	require.NoError(t, writer.WriteField("model", "whisper-1"))
+	part, err := writer.CreateFormFile("file", "audio.wav")
+	require.NoError(t, err)
+	_, err = part.Write(audio)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req, err := http.NewRequest(http.MethodPost, proxyServer.URL+"/v1/audio/transcriptions", &form)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, strconv.Itoa(len(audio)), resp.Header.Get("X-File-Size"))
+	assert.Equal(t, hex.EncodeToString(expectedHash[:]), resp.Header.Get("X-File-Sha256"))
+	assert.Equal(t, "whisper-1", resp.Header.Get("X-Model"))
+}
+
 func TestForwardService_ErrorHandling(t *testing.T) {
 	service := NewForwardServices()
 
@@ -373,7 +826,7 @@ func TestForwardService_ErrorHandling(t *testing.T) {
 			{
 				Name: "test-group",
 				Upstreams: []config.UpstreamRefConfig{
-					{Name: "test-upstream", Weight: 1},
+					{Name: "test-upstream"},
 				},
 			},
 		},
@@ -393,7 +846,7 @@ func TestForwardService_ErrorHandling(t *testing.T) {
 	ctx := context.Background()
 	emptyUpstreams := []balance.Upstream{}
 
-	_, err = service.loadBalancer.Select(ctx, emptyUpstreams)
+	_, err = service.groups[0].loadBalancer.Select(ctx, emptyUpstreams)
 	assert.Error(t, err)
 }
 
@@ -552,20 +1005,147 @@ func TestForwardService_Scheme_Detection(t *testing.T) {
 	}
 }
 
+func TestForwardService_TrustedProxies(t *testing.T) {
+	trustedProxies, err := parseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.10"})
+	require.NoError(t, err)
+
+	service := NewForwardServices()
+	service.trustedProxies = trustedProxies
+
+	newRequest := func(remoteAddr string, headers map[string]string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://llmproxy.internal/v1/chat/completions", nil)
+		req.RemoteAddr = remoteAddr
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		return req
+	}
+
+	t.Run("trusted proxy headers honored", func(t *testing.T) {
+		req := newRequest("10.0.0.1:12345", map[string]string{
+			"X-Forwarded-For":   "203.0.113.1, 10.0.0.2",
+			"X-Forwarded-Proto": "https",
+			"X-Forwarded-Host":  "api.example.com",
+		})
+
+		assert.Equal(t, "https", service.getScheme(req))
+		assert.Equal(t, "api.example.com", service.getHost(req))
+		assert.Equal(t, "203.0.113.1", service.getClientIP(req))
+
+		proxyReq, err := service.createProxyRequest(req)
+		require.NoError(t, err)
+		assert.Equal(t, "203.0.113.1, 10.0.0.2, 10.0.0.1", proxyReq.Header.Get("X-Forwarded-For"))
+		assert.Equal(t, "https", proxyReq.Header.Get("X-Forwarded-Proto"))
+		assert.Equal(t, "api.example.com", proxyReq.Header.Get("X-Forwarded-Host"))
+	})
+
+	t.Run("untrusted source headers ignored", func(t *testing.T) {
+		req := newRequest("203.0.113.9:12345", map[string]string{
+			"X-Forwarded-For":   "198.51.100.1",
+			"X-Forwarded-Proto": "https",
+			"X-Forwarded-Host":  "spoofed.example.com",
+			"X-Real-IP":         "198.51.100.2",
+		})
+
+		assert.Equal(t, "http", service.getScheme(req))
+		assert.Equal(t, "llmproxy.internal", service.getHost(req))
+		assert.Equal(t, "203.0.113.9", service.getClientIP(req))
+
+		proxyReq, err := service.createProxyRequest(req)
+		require.NoError(t, err)
+		assert.Equal(t, "203.0.113.9", proxyReq.Header.Get("X-Forwarded-For"))
+		assert.Equal(t, "http", proxyReq.Header.Get("X-Forwarded-Proto"))
+		assert.Equal(t, "llmproxy.internal", proxyReq.Header.Get("X-Forwarded-Host"))
+	})
+
+	t.Run("spoofed hop before trusted proxy", func(t *testing.T) {
+		// 客户端伪造的 198.51.100.1 位于最左侧，应返回可信代理记录的真实客户端地址
+		req := newRequest("192.168.1.10:12345", map[string]string{
+			"X-Forwarded-For": "198.51.100.1, 203.0.113.1",
+		})
+		assert.Equal(t, "203.0.113.1", service.getClientIP(req))
+	})
+
+	t.Run("invalid entries", func(t *testing.T) {
+		_, err := parseTrustedProxies([]string{"10.0.0.0/33"})
+		assert.Error(t, err)
+		_, err = parseTrustedProxies([]string{"not-an-ip"})
+		assert.Error(t, err)
+	})
+}
+
+// TestForwardService_CreateProxyRequest_Headers 测试头部复制保留多值头部的顺序，且不修改原始请求
+func TestForwardService_CreateProxyRequest_Headers(t *testing.T) {
+	service := NewForwardServices()
+
+	originalReq, err := http.NewRequest("GET", "http://original.com/api/test", nil)
+	require.NoError(t, err)
+	originalReq.Header.Add("Accept", "application/json")
+	originalReq.Header.Add("Accept", "text/event-stream")
+	originalReq.Header.Add("X-Custom", "first")
+	originalReq.Header.Add("X-Custom", "second")
+	originalReq.Header.Add("X-Custom", "third")
+	originalReq.Header.Set("Connection", "keep-alive, X-Hop")
+	originalReq.Header.Set("X-Hop", "hop-value")
+
+	proxyReq, err := service.createProxyRequest(originalReq)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"application/json", "text/event-stream"}, proxyReq.Header.Values("Accept"))
+	assert.Equal(t, []string{"first", "second", "third"}, proxyReq.Header.Values("X-Custom"))
+	assert.Empty(t, proxyReq.Header.Get("Connection"))
+	assert.Empty(t, proxyReq.Header.Get("X-Hop"))
+
+	// 修改代理请求头部不影响原始请求
+	proxyReq.Header.Add("X-Custom", "fourth")
+	assert.Equal(t, []string{"first", "second", "third"}, originalReq.Header.Values("X-Custom"))
+	assert.Equal(t, "hop-value", originalReq.Header.Get("X-Hop"))
+	assert.Empty(t, originalReq.Header.Get("X-Forwarded-For"))
+}
+
 // Benchmarks
 func BenchmarkForwardService_CreateProxyRequest(b *testing.B) {
 	service := NewForwardServices()
 
-	originalReq, _ := http.NewRequest("GET", "http://example.com/test", nil)
-	originalReq.Header.Set("User-Agent", "test-agent")
+	b.Run("single header", func(b *testing.B) {
+		originalReq, _ := http.NewRequest("GET", "http://example.com/test", nil)
+		originalReq.Header.Set("User-Agent", "test-agent")
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		_, err := service.createProxyRequest(originalReq)
-		if err != nil {
-			b.Fatal(err)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, err := service.createProxyRequest(originalReq)
+			if err != nil {
+				b.Fatal(err)
+			}
 		}
-	}
+	})
+
+	// 典型的 LLM API 请求头部，包含多值头部
+	b.Run("typical headers", func(b *testing.B) {
+		originalReq, _ := http.NewRequest("GET", "http://example.com/v1/chat/completions", nil)
+		originalReq.Header.Set("User-Agent", "openai-python/1.40.0")
+		originalReq.Header.Set("Authorization", "Bearer sk-test")
+		originalReq.Header.Set("Content-Type", "application/json")
+		originalReq.Header.Add("Accept", "application/json")
+		originalReq.Header.Add("Accept", "text/event-stream")
+		originalReq.Header.Set("Accept-Encoding", "gzip, deflate")
+		originalReq.Header.Set("X-Stainless-Lang", "python")
+		originalReq.Header.Set("X-Stainless-Os", "Linux")
+		originalReq.Header.Set("X-Stainless-Runtime", "CPython")
+		originalReq.Header.Set("X-Request-ID", "req-123")
+		originalReq.Header.Add("Cookie", "a=1")
+		originalReq.Header.Add("Cookie", "b=2")
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, err := service.createProxyRequest(originalReq)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
 }
 
 func BenchmarkForwardService_GetClientIP(b *testing.B) {
@@ -642,8 +1222,7 @@ func createTestServer(t *testing.T, upstreams []*testUpstreamServer, rateLimitCo
 			URL:  upstream.server.URL,
 		})
 		upstreamRefs = append(upstreamRefs, config.UpstreamRefConfig{
-			Name:   upstream.name,
-			Weight: 1,
+			Name: upstream.name,
 		})
 	}
 
@@ -739,7 +1318,7 @@ func TestForwardService_CircuitBreakerIntegration(t *testing.T) {
 						Strategy: "roundrobin",
 					},
 					Upstreams: []config.UpstreamRefConfig{
-						{Name: "test-upstream-with-breaker", Weight: 1},
+						{Name: "test-upstream-with-breaker"},
 					},
 				},
 			},
@@ -756,8 +1335,8 @@ func TestForwardService_CircuitBreakerIntegration(t *testing.T) {
 		// 验证熔断器已在Upstream中初始化
 		// 熔断器现在封装在Upstream对象中，不再直接暴露在ForwardService中
 		// 验证上游服务已正确构建
-		assert.NotNil(t, service.upstreams)
-		assert.Greater(t, len(service.upstreams), 0)
+		assert.NotNil(t, service.groups[0].upstreams)
+		assert.Greater(t, len(service.groups[0].upstreams), 0)
 	})
 
 	t.Run("circuit_breaker_state_check_and_rejection_logic", func(t *testing.T) {
@@ -794,7 +1373,7 @@ func TestForwardService_CircuitBreakerIntegration(t *testing.T) {
 						Strategy: "roundrobin",
 					},
 					Upstreams: []config.UpstreamRefConfig{
-						{Name: "failing-upstream", Weight: 1},
+						{Name: "failing-upstream"},
 					},
 				},
 			},
@@ -809,12 +1388,12 @@ func TestForwardService_CircuitBreakerIntegration(t *testing.T) {
 
 		// 验证上游服务已正确构建和初始化
 		// 熔断器现在封装在Upstream对象中
-		assert.NotNil(t, service.upstreams)
-		assert.Greater(t, len(service.upstreams), 0)
+		assert.NotNil(t, service.groups[0].upstreams)
+		assert.Greater(t, len(service.groups[0].upstreams), 0)
 
 		// 验证服务已正确初始化
-		assert.NotNil(t, service.loadBalancer)
-		assert.NotNil(t, service.httpClient)
+		assert.NotNil(t, service.groups[0].loadBalancer)
+		assert.NotNil(t, service.groups[0].httpClient)
 	})
 
 	t.Run("verify_circuit_breaker_load_balancer_integration", func(t *testing.T) {
@@ -844,7 +1423,7 @@ func TestForwardService_CircuitBreakerIntegration(t *testing.T) {
 						Strategy: "roundrobin", // 使用轮询负载均衡器
 					},
 					Upstreams: []config.UpstreamRefConfig{
-						{Name: "test-upstream-lb", Weight: 1},
+						{Name: "test-upstream-lb"},
 					},
 				},
 			},
@@ -858,7 +1437,7 @@ func TestForwardService_CircuitBreakerIntegration(t *testing.T) {
 		require.NoError(t, err)
 
 		// 验证负载均衡器支持熔断器功能
-		if breakerBalancer, ok := service.loadBalancer.(balance.LoadBalancerWithBreaker); ok {
+		if breakerBalancer, ok := service.groups[0].loadBalancer.(balance.LoadBalancerWithBreaker); ok {
 			// 验证负载均衡器中也有熔断器
 			cb, exists := breakerBalancer.GetBreaker("test-upstream-lb")
 			assert.True(t, exists)
@@ -867,7 +1446,3212 @@ func TestForwardService_CircuitBreakerIntegration(t *testing.T) {
 
 		// 验证上游服务已正确构建和初始化
 		// 熔断器现在封装在Upstream对象中，不再直接暴露
-		assert.NotNil(t, service.upstreams)
-		assert.Greater(t, len(service.upstreams), 0)
+		assert.NotNil(t, service.groups[0].upstreams)
+		assert.Greater(t, len(service.groups[0].upstreams), 0)
 	})
 }
+
+func TestForwardService_UpstreamTimeoutReturns504(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	// 创建一个响应缓慢的上游服务器
+	slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"message": "too late"}`))
+	}))
+	defer slowServer.Close()
+
+	forwardConfig := &config.ForwardConfig{
+		Name:         "timeout-forward",
+		DefaultGroup: "test-group",
+	}
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "slow-upstream", URL: slowServer.URL},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name: "test-group",
+				Upstreams: []config.UpstreamRefConfig{
+					{Name: "slow-upstream"},
+				},
+				HTTPClient: &config.HTTPClientConfig{
+					KeepAlive: 60000,
+					Timeout: &config.TimeoutConfig{
+						Connect: 1000,
+						Request: 100, // 请求超时远小于上游延迟
+					},
+				},
+			},
+		},
+	}
+
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(forwardConfig, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewBufferString(`{"model": "test"}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+	var body httptool.BaseHttpResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, int64(response.CodeGatewayTimeout), body.Code)
+	assert.Equal(t, "Upstream request timed out", body.ErrorMessage)
+
+	detail, ok := body.ErrorDetail.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, http.StatusText(http.StatusGatewayTimeout), detail["error"])
+}
+
+func TestForwardService_TruncatedStreamingResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// newAbruptUpstream 创建发送分块 SSE 响应后直接断开连接的上游，chunks 为断开前发送的数据块
+	newAbruptUpstream := func(chunks ...string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, buf, err := http.NewResponseController(w).Hijack()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+
+			_, _ = buf.WriteString("HTTP/1.1 200 OK\r\nContent-Type: text/event-stream\r\nTransfer-Encoding: chunked\r\n\r\n")
+			for _, chunk := range chunks {
+				_, _ = fmt.Fprintf(buf, "%x\r\n%s\r\n", len(chunk), chunk)
+			}
+			_ = buf.Flush()
+		}))
+	}
+
+	newRouter := func(t *testing.T, upstreamURL string, streamErrorEvent bool) (*ForwardService, *gin.Engine) {
+		globalConfig := &config.Config{
+			Upstreams: []config.UpstreamConfig{{Name: "sse-upstream", URL: upstreamURL}},
+			UpstreamGroups: []config.UpstreamGroupConfig{
+				{Name: "sse-group", Upstreams: []config.UpstreamRefConfig{{Name: "sse-upstream"}}},
+			},
+		}
+		logger := logr.Discard()
+		service := NewForwardServices()
+		require.NoError(t, service.Initialize(&config.ForwardConfig{
+			Name:             "sse-forward",
+			DefaultGroup:     "sse-group",
+			StreamErrorEvent: streamErrorEvent,
+		}, globalConfig, &logger))
+		t.Cleanup(service.Stop)
+
+		router := gin.New()
+		service.RegisterGroup(router.Group("/"))
+		return service, router
+	}
+
+	sendRequest := func(router *gin.Engine) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"stream": true}`)))
+		return w
+	}
+
+	// truncatedCount 获取流式响应截断的上游错误计数
+	truncatedCount := func(t *testing.T, service *ForwardService) float64 {
+		metricFamilies, err := service.metricsCollector.GetRegistry().Gather()
+		require.NoError(t, err)
+		for _, mf := range metricFamilies {
+			if !strings.HasSuffix(mf.GetName(), "_upstream_errors_total") {
+				continue
+			}
+			for _, m := range mf.GetMetric() {
+				for _, label := range m.GetLabel() {
+					if label.GetName() == metrics.LabelErrorType && label.GetValue() == constants.ErrorTypeStreamTruncated {
+						return m.GetCounter().GetValue()
+					}
+				}
+			}
+		}
+		return 0
+	}
+
+	t.Run("closed before first byte", func(t *testing.T) {
+		metrics.GetGlobalRegistry().Clear()
+		defer metrics.GetGlobalRegistry().Clear()
+
+		upstream := newAbruptUpstream()
+		defer upstream.Close()
+		service, router := newRouter(t, upstream.URL, true)
+
+		w := sendRequest(router)
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.NotContains(t, w.Header().Get("Content-Type"), "text/event-stream")
+		assert.NotContains(t, w.Body.String(), "upstream_disconnected")
+		assert.Equal(t, float64(1), truncatedCount(t, service))
+	})
+
+	t.Run("closed mid-stream with error event", func(t *testing.T) {
+		metrics.GetGlobalRegistry().Clear()
+		defer metrics.GetGlobalRegistry().Clear()
+
+		upstream := newAbruptUpstream("data: {\"id\":1}\n\n")
+		defer upstream.Close()
+		service, router := newRouter(t, upstream.URL, true)
+
+		w := sendRequest(router)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "data: {\"id\":1}\n\n"+constants.StreamErrorEventUpstreamDisconnected, w.Body.String())
+		assert.Equal(t, float64(1), truncatedCount(t, service))
+	})
+
+	t.Run("closed mid-stream without error event", func(t *testing.T) {
+		metrics.GetGlobalRegistry().Clear()
+		defer metrics.GetGlobalRegistry().Clear()
+
+		upstream := newAbruptUpstream("data: {\"id\":1}\n\n")
+		defer upstream.Close()
+		service, router := newRouter(t, upstream.URL, false)
+
+		w := sendRequest(router)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "data: {\"id\":1}\n\n", w.Body.String())
+		assert.Equal(t, float64(1), truncatedCount(t, service))
+	})
+}
+
+func TestIsTimeoutError(t *testing.T) {
+	assert.True(t, isTimeoutError(context.DeadlineExceeded))
+	assert.True(t, isTimeoutError(fmt.Errorf("wrapped: %w", context.DeadlineExceeded)))
+	assert.False(t, isTimeoutError(context.Canceled))
+	assert.False(t, isTimeoutError(errors.New("connection refused")))
+}
+
+func TestForwardService_ForwardResponseCountsWrittenBytes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := NewForwardServices()
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{
+			name:        "streaming response without content length",
+			contentType: "text/event-stream",
+			body:        strings.Repeat("data: {\"delta\": \"hello\"}\n\n", 500),
+		},
+		{
+			name:        "regular response",
+			contentType: "application/json",
+			body:        `{"message": "hello"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			resp := &http.Response{
+				StatusCode:    http.StatusOK,
+				Header:        make(http.Header),
+				Body:          io.NopCloser(strings.NewReader(tt.body)),
+				ContentLength: -1, // 模拟分块传输，长度未知
+			}
+			resp.Header.Set("Content-Type", tt.contentType)
+
+			written, err := service.forwardResponse(c, resp)
+			require.NoError(t, err)
+
+			assert.Equal(t, int64(len(tt.body)), written)
+			assert.Equal(t, tt.body, w.Body.String())
+			assert.Equal(t, written, service.getResponseSize(resp, written))
+		})
+	}
+}
+
+// TestServer_AdminDisabled 测试禁用管理服务时不创建管理服务器
+func TestServer_AdminDisabled(t *testing.T) {
+	logger := logr.Discard()
+	disabled := false
+
+	httpServerConfig := &config.HTTPServerConfig{
+		Forwards: []config.ForwardConfig{
+			{
+				Name:         "test-forward",
+				Address:      "127.0.0.1",
+				Port:         0,
+				DefaultGroup: "test-group",
+				Timeout:      &config.TimeoutConfig{Idle: 30, Read: 15, Write: 15},
+			},
+		},
+		Admin: config.AdminConfig{
+			Enabled: &disabled,
+			Address: "127.0.0.1",
+			Port:    0,
+			Timeout: &config.TimeoutConfig{Idle: 30, Read: 15, Write: 15},
+		},
+	}
+
+	globalConfig := &config.Config{
+		HTTPServer: *httpServerConfig,
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name:      "test-group",
+				Balance:   &config.BalanceConfig{Strategy: "roundrobin"},
+				Upstreams: []config.UpstreamRefConfig{{Name: "test-upstream"}},
+			},
+		},
+		Upstreams: []config.UpstreamConfig{
+			{Name: "test-upstream", URL: "http://127.0.0.1:1"},
+		},
+	}
+
+	srv := NewServer(true, &logger, httpServerConfig, globalConfig)
+	require.NotNil(t, srv)
+	assert.Nil(t, srv.GetAdminServer())
+	assert.NotNil(t, srv.GetForwardServer("test-forward"))
+
+	// 管理服务器为空时启停不应 panic
+	assert.NotPanics(t, func() {
+		srv.Start()
+		srv.Stop()
+	})
+}
+
+// TestForwardService_ErrorFormat 测试代理自身错误按配置的格式输出
+func TestForwardService_ErrorFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	// 关闭的上游，请求会因连接失败返回 502
+	closedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closedURL := closedServer.URL
+	closedServer.Close()
+
+	tests := []struct {
+		name        string
+		errorFormat string
+	}{
+		{name: "default llmproxy format", errorFormat: ""},
+		{name: "explicit llmproxy format", errorFormat: "llmproxy"},
+		{name: "openai format", errorFormat: "openai"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forwardConfig := &config.ForwardConfig{
+				Name:         "error-format-forward",
+				DefaultGroup: "test-group",
+				ErrorFormat:  tt.errorFormat,
+			}
+
+			globalConfig := &config.Config{
+				Upstreams: []config.UpstreamConfig{
+					{Name: "closed-upstream", URL: closedURL},
+				},
+				UpstreamGroups: []config.UpstreamGroupConfig{
+					{
+						Name:      "test-group",
+						Upstreams: []config.UpstreamRefConfig{{Name: "closed-upstream"}},
+						HTTPClient: &config.HTTPClientConfig{
+							KeepAlive: 60000,
+							Timeout:   &config.TimeoutConfig{Connect: 1000, Request: 1000},
+						},
+					},
+				},
+			}
+
+			service := NewForwardServices()
+			require.NoError(t, service.Initialize(forwardConfig, globalConfig, &logger))
+			defer service.Stop()
+
+			router := gin.New()
+			service.RegisterGroup(router.Group("/"))
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewBufferString(`{"model": "test"}`))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadGateway, w.Code)
+
+			if tt.errorFormat == "openai" {
+				var body response.OpenAIErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, "Upstream request failed", body.Error.Message)
+				assert.Equal(t, response.OpenAIErrorTypeServer, body.Error.Type)
+				assert.Equal(t, "2001", body.Error.Code)
+				assert.Nil(t, body.Error.Param)
+				return
+			}
+
+			var body httptool.BaseHttpResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, int64(response.CodeBadGateway), body.Code)
+			assert.Equal(t, "Upstream request failed", body.ErrorMessage)
+		})
+	}
+}
+
+// TestForwardService_ErrorResponses 测试按状态码配置的自定义错误响应体
+func TestForwardService_ErrorResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	// 关闭的上游，请求会因连接失败返回 502
+	closedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closedURL := closedServer.URL
+	closedServer.Close()
+
+	customBody := `{"error":{"type":"rate_limited","message":"Please slow down"}}`
+	forwardConfig := &config.ForwardConfig{
+		Name:         "error-responses-forward",
+		DefaultGroup: "test-group",
+		ErrorFormat:  "openai",
+		RateLimit:    &config.RateLimitConfig{PerSecond: 1, Burst: 1},
+		ErrorResponses: map[int]config.ErrorResponseConfig{
+			http.StatusTooManyRequests: {Body: []byte(customBody), ContentType: "application/json; charset=utf-8"},
+		},
+	}
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "closed-upstream", URL: closedURL},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name:      "test-group",
+				Upstreams: []config.UpstreamRefConfig{{Name: "closed-upstream"}},
+				HTTPClient: &config.HTTPClientConfig{
+					Timeout: &config.TimeoutConfig{Connect: 1000, Request: 1000},
+				},
+			},
+		},
+	}
+
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(forwardConfig, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+	sendRequest := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewBufferString(`{"model": "test"}`))
+		req.RemoteAddr = "1.2.3.4:12345"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 未配置覆盖的状态码使用默认错误格式
+	w := sendRequest()
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	var body response.OpenAIErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "Upstream request failed", body.Error.Message)
+
+	// 限流拒绝使用配置的响应体和 Content-Type
+	w = sendRequest()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get(constants.HeaderContentType))
+	assert.Equal(t, customBody, w.Body.String())
+}
+
+// TestForwardService_BreakerOpenResponse 测试熔断器开启时返回独立的熔断响应
+func TestForwardService_BreakerOpenResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	// 关闭的上游，连接失败会计入熔断器失败次数
+	closedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closedURL := closedServer.URL
+	closedServer.Close()
+
+	forwardConfig := &config.ForwardConfig{
+		Name:         "breaker-forward",
+		DefaultGroup: "test-group",
+	}
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{
+				Name: "broken-upstream",
+				URL:  closedURL,
+				Breaker: &config.BreakerConfig{
+					Threshold: 0.5,
+					Cooldown:  2500,
+				},
+			},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name:      "test-group",
+				Upstreams: []config.UpstreamRefConfig{{Name: "broken-upstream"}},
+				HTTPClient: &config.HTTPClientConfig{
+					KeepAlive: 60000,
+					Timeout:   &config.TimeoutConfig{Connect: 1000, Request: 1000},
+				},
+			},
+		},
+	}
+
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(forwardConfig, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+
+	// 持续请求直到熔断器开启
+	var w *httptest.ResponseRecorder
+	var body httptool.BaseHttpResponse
+	for i := 0; i < constants.DefaultBreakerMinRequests+5; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewBufferString(`{"model": "test"}`))
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		if w.Code == http.StatusServiceUnavailable {
+			break
+		}
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.Equal(t, int64(response.CodeBadGateway), body.Code)
+	}
+
+	// 组内唯一的上游熔断后，负载均衡器不再选择该上游，直接返回熔断响应
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Equal(t, int64(response.CodeCircuitBreaker), body.Code)
+	assert.Contains(t, body.ErrorMessage, "test-group")
+	assert.Equal(t, "3", w.Header().Get("Retry-After"))
+
+	detail, ok := body.ErrorDetail.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "test-group", detail["group"])
+	assert.Equal(t, float64(3), detail["retryAfter"])
+}
+
+// openBreaker 代表始终处于开启状态的测试熔断器
+type openBreaker struct{}
+
+func (b *openBreaker) Execute(req func() (interface{}, error)) (interface{}, error) {
+	return nil, gobreaker.ErrOpenState
+}
+func (b *openBreaker) Name() string           { return "open" }
+func (b *openBreaker) State() gobreaker.State { return gobreaker.StateOpen }
+
+// TestForwardService_UpstreamSelectionErrors 测试上游组为空、所有上游熔断和请求失败分别返回不同的状态码和错误类型
+func TestForwardService_UpstreamSelectionErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	closedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closedURL := closedServer.URL
+	closedServer.Close()
+
+	tests := []struct {
+		name       string
+		prepare    func(g *upstreamGroup)
+		statusCode int
+		errorType  string
+		retryAfter string
+	}{
+		{
+			name:       "no upstreams",
+			prepare:    func(g *upstreamGroup) { g.upstreams = []balance.Upstream{} },
+			statusCode: http.StatusInternalServerError,
+			errorType:  constants.ErrorTypeNoUpstreams,
+		},
+		{
+			name: "all upstreams unhealthy",
+			prepare: func(g *upstreamGroup) {
+				for i := range g.upstreams {
+					g.upstreams[i].Breaker = &openBreaker{}
+				}
+				g.upstreams[1].Config.Breaker = &config.BreakerConfig{Cooldown: 2500}
+			},
+			statusCode: http.StatusServiceUnavailable,
+			errorType:  constants.ErrorTypeNoHealthyUpstream,
+			retryAfter: "3",
+		},
+		{
+			name:       "request failed",
+			prepare:    func(g *upstreamGroup) {},
+			statusCode: http.StatusBadGateway,
+			errorType:  constants.ErrorTypeExecution,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics.GetGlobalRegistry().Clear()
+			defer metrics.GetGlobalRegistry().Clear()
+
+			globalConfig := &config.Config{
+				Upstreams: []config.UpstreamConfig{
+					{Name: "closed-a", URL: closedURL, Breaker: &config.BreakerConfig{Cooldown: 30000}},
+					{Name: "closed-b", URL: closedURL},
+				},
+				UpstreamGroups: []config.UpstreamGroupConfig{
+					{Name: "selection-group", Upstreams: []config.UpstreamRefConfig{{Name: "closed-a"}, {Name: "closed-b"}}},
+				},
+			}
+			service := NewForwardServices()
+			require.NoError(t, service.Initialize(&config.ForwardConfig{
+				Name:         "selection-forward",
+				DefaultGroup: "selection-group",
+			}, globalConfig, &logger))
+			defer service.Stop()
+			tt.prepare(service.groups[0])
+
+			router := gin.New()
+			service.RegisterGroup(router.Group("/"))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+			assert.Equal(t, tt.statusCode, w.Code)
+			assert.Equal(t, tt.retryAfter, w.Header().Get(constants.HeaderRetryAfter))
+
+			families, err := service.metricsCollector.GetRegistry().Gather()
+			require.NoError(t, err)
+			var errorTypes []string
+			for _, mf := range families {
+				if !strings.HasSuffix(mf.GetName(), "_upstream_errors_total") {
+					continue
+				}
+				for _, m := range mf.GetMetric() {
+					for _, label := range m.GetLabel() {
+						if label.GetName() == metrics.LabelErrorType {
+							errorTypes = append(errorTypes, label.GetValue())
+						}
+					}
+				}
+			}
+			assert.Equal(t, []string{tt.errorType}, errorTypes)
+		})
+	}
+}
+
+func TestBreakerRetryAfterSeconds(t *testing.T) {
+	assert.Equal(t, constants.DefaultBreakerCooldown/1000, breakerRetryAfterSeconds(&balance.Upstream{}))
+	assert.Equal(t, 1, breakerRetryAfterSeconds(&balance.Upstream{
+		Config: &config.UpstreamConfig{Breaker: &config.BreakerConfig{Cooldown: 20}},
+	}))
+	assert.Equal(t, 3, breakerRetryAfterSeconds(&balance.Upstream{
+		Config: &config.UpstreamConfig{Breaker: &config.BreakerConfig{Cooldown: 2500}},
+	}))
+	assert.True(t, isBreakerOpenError(fmt.Errorf("wrapped: %w", gobreaker.ErrOpenState)))
+	assert.True(t, isBreakerOpenError(gobreaker.ErrTooManyRequests))
+	assert.False(t, isBreakerOpenError(errors.New("connection refused")))
+}
+
+// TestForwardService_BufferPoolSizes 测试响应复制缓冲区大小按配置生效
+func TestForwardService_BufferPoolSizes(t *testing.T) {
+	logger := logr.Discard()
+
+	tests := []struct {
+		name             string
+		streamBufferSize int
+		copyBufferSize   int
+		wantStream       int
+		wantCopy         int
+	}{
+		{name: "defaults", wantStream: constants.DefaultStreamBufferSize, wantCopy: constants.DefaultCopyBufferSize},
+		{name: "configured", streamBufferSize: 512, copyBufferSize: 256 * 1024, wantStream: 512, wantCopy: 256 * 1024},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			globalConfig := &config.Config{
+				HTTPServer: config.HTTPServerConfig{
+					StreamBufferSize: tt.streamBufferSize,
+					CopyBufferSize:   tt.copyBufferSize,
+				},
+				Upstreams: []config.UpstreamConfig{
+					{Name: "test-upstream", URL: "http://127.0.0.1:1"},
+				},
+				UpstreamGroups: []config.UpstreamGroupConfig{
+					{
+						Name:      "test-group",
+						Upstreams: []config.UpstreamRefConfig{{Name: "test-upstream"}},
+					},
+				},
+			}
+
+			service := NewForwardServices()
+			require.NoError(t, service.Initialize(&config.ForwardConfig{Name: "buffer-forward", DefaultGroup: "test-group"}, globalConfig, &logger))
+			defer service.Stop()
+
+			assert.Len(t, service.streamingBufferPool.Get().([]byte), tt.wantStream)
+			assert.Len(t, service.nonStreamingBufferPool.Get().([]byte), tt.wantCopy)
+		})
+	}
+}
+
+// TestForwardServer_StreamWriteTimeout 测试流式响应不受服务器写入超时限制
+func TestForwardServer_StreamWriteTimeout(t *testing.T) {
+	logger := logr.Discard()
+
+	// 持续输出约 1.6 秒的 SSE 上游，超过转发服务的写入超时
+	sseServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		flusher, _ := w.(http.Flusher)
+		for i := 0; i < 4; i++ {
+			_, _ = fmt.Fprintf(w, "data: chunk-%d\n\n", i)
+			if flusher != nil {
+				flusher.Flush()
+			}
+			time.Sleep(400 * time.Millisecond)
+		}
+	}))
+	defer sseServer.Close()
+
+	// orbit 会将端口 0 替换为默认端口，这里预先分配一个空闲端口
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	forwardConfig := &config.ForwardConfig{
+		Name:         "stream-forward",
+		Address:      "127.0.0.1",
+		Port:         port,
+		DefaultGroup: "test-group",
+		Timeout: &config.TimeoutConfig{
+			Idle:        30000,
+			Read:        15000,
+			Write:       1000, // 常规响应写入超时
+			StreamWrite: 0,    // 流式响应不限制写入超时
+		},
+	}
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "sse-upstream", URL: sseServer.URL},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name:      "test-group",
+				Upstreams: []config.UpstreamRefConfig{{Name: "sse-upstream"}},
+			},
+		},
+	}
+
+	forwardServer := NewForwardServer(false, &logger, forwardConfig, globalConfig)
+	forwardServer.Start()
+	defer forwardServer.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	resp, err := http.Post("http://"+forwardServer.GetEndpoint()+"/v1/chat/completions", "application/json", strings.NewReader(`{"stream": true}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "data: chunk-3")
+}
+
+// TestForwardServer_MaxHeaderBytes 测试转发服务器应用配置的最大请求头部大小
+func TestForwardServer_MaxHeaderBytes(t *testing.T) {
+	logger := logr.Discard()
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstreamServer.Close()
+
+	// orbit 会将端口 0 替换为默认端口，这里预先分配一个空闲端口
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	forwardConfig := &config.ForwardConfig{
+		Name:         "header-forward",
+		Address:      "127.0.0.1",
+		Port:         port,
+		DefaultGroup: "test-group",
+		Timeout:      &config.TimeoutConfig{Idle: 30000, Read: 15000, Write: 15000},
+	}
+
+	globalConfig := &config.Config{
+		HTTPServer: config.HTTPServerConfig{MaxHeaderBytes: 4096},
+		Upstreams: []config.UpstreamConfig{
+			{Name: "header-upstream", URL: upstreamServer.URL},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name:      "test-group",
+				Upstreams: []config.UpstreamRefConfig{{Name: "header-upstream"}},
+			},
+		},
+	}
+
+	forwardServer := NewForwardServer(false, &logger, forwardConfig, globalConfig)
+	forwardServer.Start()
+	defer forwardServer.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	sendWithHeader := func(size int) int {
+		req, err := http.NewRequest(http.MethodPost, "http://"+forwardServer.GetEndpoint()+"/v1/chat/completions", strings.NewReader(`{}`))
+		require.NoError(t, err)
+		req.Header.Set("X-Large", strings.Repeat("a", size))
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, sendWithHeader(1024))
+	// 超过配置的限制（net/http 额外预留 4096 字节）时返回 431
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, sendWithHeader(16*1024))
+}
+
+// TestForwardServer_MultipleListeners 测试转发服务器在多个监听地址上提供同一处理器
+func TestForwardServer_MultipleListeners(t *testing.T) {
+	logger := logr.Discard()
+
+	var upstreamCalls atomic.Int32
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls.Add(1)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"message": "ok"}`))
+	}))
+	defer upstreamServer.Close()
+
+	// orbit 会将端口 0 替换为默认端口，这里预先分配两个空闲端口
+	freePort := func() int {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		port := listener.Addr().(*net.TCPAddr).Port
+		require.NoError(t, listener.Close())
+		return port
+	}
+
+	forwardConfig := &config.ForwardConfig{
+		Name: "multi-listener-forward",
+		Listeners: []config.ListenerConfig{
+			{Address: "127.0.0.1", Port: freePort()},
+			{Address: "127.0.0.1", Port: freePort()},
+		},
+		DefaultGroup: "test-group",
+		Timeout:      &config.TimeoutConfig{Idle: 30000, Read: 15000, Write: 15000},
+	}
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "test-upstream", URL: upstreamServer.URL},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name:      "test-group",
+				Upstreams: []config.UpstreamRefConfig{{Name: "test-upstream"}},
+			},
+		},
+	}
+
+	forwardServer := NewForwardServer(false, &logger, forwardConfig, globalConfig)
+	forwardServer.Start()
+	time.Sleep(100 * time.Millisecond)
+
+	endpoints := forwardServer.GetEndpoints()
+	require.Len(t, endpoints, 2)
+	assert.Equal(t, endpoints[0], forwardServer.GetEndpoint())
+	assert.NotEqual(t, endpoints[0], endpoints[1])
+
+	for _, endpoint := range endpoints {
+		resp, err := http.Post("http://"+endpoint+"/v1/chat/completions", "application/json", strings.NewReader(`{}`))
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.Equal(t, int32(2), upstreamCalls.Load())
+
+	// 停止后所有监听地址都不再接受连接
+	forwardServer.Stop()
+	assert.False(t, forwardServer.IsRunning())
+	for _, endpoint := range endpoints {
+		_, err := net.DialTimeout("tcp", endpoint, time.Second)
+		assert.Error(t, err)
+	}
+}
+
+// TestForwardService_DebugHeaders 测试调试头部仅在启用时输出
+func TestForwardService_DebugHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer upstreamServer.Close()
+
+	tests := []struct {
+		name         string
+		debugHeaders bool
+	}{
+		{name: "enabled", debugHeaders: true},
+		{name: "disabled", debugHeaders: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forwardConfig := &config.ForwardConfig{
+				Name:         "debug-forward",
+				DefaultGroup: "test-group",
+				DebugHeaders: tt.debugHeaders,
+			}
+
+			globalConfig := &config.Config{
+				Upstreams: []config.UpstreamConfig{
+					{Name: "debug-upstream", URL: upstreamServer.URL},
+				},
+				UpstreamGroups: []config.UpstreamGroupConfig{
+					{
+						Name:      "test-group",
+						Balance:   &config.BalanceConfig{Strategy: "random"},
+						Upstreams: []config.UpstreamRefConfig{{Name: "debug-upstream"}},
+					},
+				},
+			}
+
+			service := NewForwardServices()
+			require.NoError(t, service.Initialize(forwardConfig, globalConfig, &logger))
+			defer service.Stop()
+
+			router := gin.New()
+			service.RegisterGroup(router.Group("/"))
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewBufferString(`{"model": "test"}`))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			if tt.debugHeaders {
+				assert.Equal(t, "debug-upstream", w.Header().Get(constants.HeaderLLMProxyUpstream))
+				assert.Equal(t, "random", w.Header().Get(constants.HeaderLLMProxyBalancer))
+			} else {
+				assert.Empty(t, w.Header().Get(constants.HeaderLLMProxyUpstream))
+				assert.Empty(t, w.Header().Get(constants.HeaderLLMProxyBalancer))
+			}
+		})
+	}
+}
+
+// TestForwardService_Idempotency 测试幂等键去重：相同幂等键的顺序和并发请求只调用一次上游
+func TestForwardService_Idempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	var upstreamCalls atomic.Int64
+	release := make(chan struct{})
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := upstreamCalls.Add(1)
+		if r.URL.Path == "/v1/slow" {
+			<-release
+		}
+		if r.URL.Path == "/v1/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprintf(w, `{"call": %d}`, n)
+	}))
+	defer upstreamServer.Close()
+
+	forwardConfig := &config.ForwardConfig{
+		Name:         "idempotency-forward",
+		DefaultGroup: "test-group",
+		Idempotency:  &config.IdempotencyConfig{Enabled: true, TTL: 60000, MaxBodySize: 1024},
+	}
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "idempotency-upstream", URL: upstreamServer.URL},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name:      "test-group",
+				Upstreams: []config.UpstreamRefConfig{{Name: "idempotency-upstream"}},
+			},
+		},
+	}
+
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(forwardConfig, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+
+	send := func(path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(`{"model": "test"}`))
+		if key != "" {
+			req.Header.Set(constants.HeaderIdempotencyKey, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("sequential requests replay cached response", func(t *testing.T) {
+		upstreamCalls.Store(0)
+
+		first := send("/v1/chat/completions", "key-1")
+		require.Equal(t, http.StatusOK, first.Code)
+		assert.Empty(t, first.Header().Get(constants.HeaderIdempotentReplayed))
+
+		second := send("/v1/chat/completions", "key-1")
+		require.Equal(t, http.StatusOK, second.Code)
+		assert.Equal(t, "true", second.Header().Get(constants.HeaderIdempotentReplayed))
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, int64(1), upstreamCalls.Load())
+
+		// 不同幂等键和无幂等键的请求正常转发
+		send("/v1/chat/completions", "key-2")
+		send("/v1/chat/completions", "")
+		assert.Equal(t, int64(3), upstreamCalls.Load())
+	})
+
+	t.Run("concurrent requests are single-flight", func(t *testing.T) {
+		upstreamCalls.Store(0)
+
+		const concurrency = 5
+		results := make([]*httptest.ResponseRecorder, concurrency)
+		var wg sync.WaitGroup
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i] = send("/v1/slow", "key-concurrent")
+			}(i)
+		}
+
+		// 等待首个请求到达上游后再放行，其余请求应阻塞在幂等键上
+		require.Eventually(t, func() bool { return upstreamCalls.Load() == 1 }, time.Second, 5*time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int64(1), upstreamCalls.Load())
+		for _, w := range results {
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, results[0].Body.String(), w.Body.String())
+		}
+	})
+
+	t.Run("failed responses are not cached", func(t *testing.T) {
+		upstreamCalls.Store(0)
+
+		assert.Equal(t, http.StatusInternalServerError, send("/v1/fail", "key-fail").Code)
+		assert.Equal(t, http.StatusInternalServerError, send("/v1/fail", "key-fail").Code)
+		assert.Equal(t, int64(2), upstreamCalls.Load())
+	})
+}
+
+// TestForwardService_PanicRecovery 测试处理器 panic 时返回统一格式的 500 错误响应并记录指标
+func TestForwardService_PanicRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	globalRegistry := metrics.GetGlobalRegistry()
+	globalRegistry.Clear()
+	defer globalRegistry.Clear()
+
+	forwardConfig := &config.ForwardConfig{
+		Name:         "panic-forward",
+		DefaultGroup: "test-group",
+	}
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "panic-upstream", URL: "http://127.0.0.1:1"},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name:      "test-group",
+				Upstreams: []config.UpstreamRefConfig{{Name: "panic-upstream"}},
+			},
+		},
+	}
+
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(forwardConfig, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	group := router.Group("/")
+	service.RegisterGroup(group)
+	group.PUT("/panic", func(c *gin.Context) {
+		var upstream *balance.Upstream
+		c.String(http.StatusOK, upstream.Name)
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/panic", nil)
+	w := httptest.NewRecorder()
+	require.NotPanics(t, func() { router.ServeHTTP(w, req) })
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	var body httptool.BaseHttpResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, int64(response.CodeInternalError), body.Code)
+	assert.Equal(t, "Internal server error", body.ErrorMessage)
+
+	// 验证 panic 错误指标
+	metricFamilies, err := service.metricsCollector.GetRegistry().Gather()
+	require.NoError(t, err)
+	var panics float64
+	for _, mf := range metricFamilies {
+		if !strings.HasSuffix(mf.GetName(), "_http_errors_total") {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels[metrics.LabelForwardName] == forwardConfig.Name && labels[metrics.LabelErrorType] == constants.ErrorTypePanic {
+				panics = m.GetCounter().GetValue()
+			}
+		}
+	}
+	assert.Equal(t, float64(1), panics)
+}
+
+// processingErrorCount 获取转发服务记录的处理错误次数
+func processingErrorCount(t *testing.T, service *ForwardService) float64 {
+	metricFamilies, err := service.metricsCollector.GetRegistry().Gather()
+	require.NoError(t, err)
+	var count float64
+	for _, mf := range metricFamilies {
+		if !strings.HasSuffix(mf.GetName(), "_http_errors_total") {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == metrics.LabelErrorType && label.GetValue() == constants.ErrorTypeProcessing {
+					count += m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return count
+}
+
+// TestForwardService_AccessLogSampling 测试访问日志采样：未采样请求不输出信息日志，错误日志始终输出
+func TestForwardService_AccessLogSampling(t *testing.T) {
+	var infos, errs int
+	logger := funcr.New(func(prefix, args string) {
+		if strings.Contains(args, `"error"`) {
+			errs++
+		} else {
+			infos++
+		}
+	}, funcr.Options{})
+
+	tests := []struct {
+		name     string
+		rate     float64
+		minInfos int
+		maxInfos int
+	}{
+		{name: "log all", rate: 1, minInfos: 1000, maxInfos: 1000},
+		{name: "log ten percent", rate: 0.1, minInfos: 50, maxInfos: 150},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			infos, errs = 0, 0
+			service := &ForwardService{logger: &logger, accessLogSampleRate: tt.rate}
+
+			for i := 0; i < 1000; i++ {
+				accessLog := service.accessLogger()
+				accessLog.Info("Request completed successfully")
+				accessLog.Error(errors.New("failed"), "Request processing failed")
+			}
+
+			assert.GreaterOrEqual(t, infos, tt.minInfos)
+			assert.LessOrEqual(t, infos, tt.maxInfos)
+			assert.Equal(t, 1000, errs)
+		})
+	}
+}
+
+func TestForwardService_AllowedContentTypes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	var upstreamCalls atomic.Int32
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls.Add(1)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"message": "ok"}`))
+	}))
+	defer upstreamServer.Close()
+
+	forwardConfig := &config.ForwardConfig{
+		Name:                "content-type-forward",
+		DefaultGroup:        "test-group",
+		AllowedContentTypes: []string{"Application/JSON", "multipart/form-data"},
+	}
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "test-upstream", URL: upstreamServer.URL},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name: "test-group",
+				Upstreams: []config.UpstreamRefConfig{
+					{Name: "test-upstream"},
+				},
+			},
+		},
+	}
+
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(forwardConfig, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+
+	t.Run("disallowed content type rejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewBufferString(`<model>test</model>`))
+		req.Header.Set("Content-Type", "text/xml")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Equal(t, int32(0), upstreamCalls.Load())
+
+		var body httptool.BaseHttpResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, int64(response.CodeBadRequest), body.Code)
+		assert.Equal(t, "Unsupported request content type", body.ErrorMessage)
+
+		// 客户端请求被拒绝不计为处理错误
+		assert.Zero(t, processingErrorCount(t, service))
+	})
+
+	t.Run("charset parameter and case ignored", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewBufferString(`{"model": "test"}`))
+		req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int32(1), upstreamCalls.Load())
+	})
+}
+
+func TestForwardService_StatusMap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+	logger := logr.Discard()
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _ := strconv.Atoi(r.URL.Query().Get("status"))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"error": "overloaded"}`))
+	}))
+	defer upstreamServer.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "test-upstream", URL: upstreamServer.URL, StatusMap: map[int]int{529: http.StatusServiceUnavailable}},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{Name: "test-group", Upstreams: []config.UpstreamRefConfig{{Name: "test-upstream"}}},
+		},
+	}
+
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(&config.ForwardConfig{
+		Name:         "status-forward",
+		DefaultGroup: "test-group",
+	}, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+
+	sendRequest := func(status int) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions?status="+strconv.Itoa(status), strings.NewReader(`{}`)))
+		return w
+	}
+
+	// 映射的状态码返回映射后的值，响应体保持不变
+	w := sendRequest(529)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"error": "overloaded"}`, w.Body.String())
+
+	// 未配置映射的状态码原样透传
+	w = sendRequest(http.StatusTooManyRequests)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// 映射计数同时记录原始和映射后的状态码
+	metricFamilies, err := service.metricsCollector.GetRegistry().Gather()
+	require.NoError(t, err)
+	remaps := make(map[string]float64)
+	for _, mf := range metricFamilies {
+		if !strings.HasSuffix(mf.GetName(), "_upstream_status_remapped_total") {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			var original, mapped string
+			for _, label := range m.GetLabel() {
+				switch label.GetName() {
+				case metrics.LabelOriginalStatus:
+					original = label.GetValue()
+				case metrics.LabelMappedStatus:
+					mapped = label.GetValue()
+				}
+			}
+			remaps[original+"->"+mapped] = m.GetCounter().GetValue()
+		}
+	}
+	assert.Equal(t, map[string]float64{"529->503": 1}, remaps)
+}
+
+func TestForwardService_ResponseTransform(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.URL.Query().Get("type"))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"result": {"content": "hello"}, "internal": "x"}`))
+	}))
+	defer upstreamServer.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{
+				Name: "test-upstream",
+				URL:  upstreamServer.URL,
+				ResponseTransform: []config.BodyTransformConfig{
+					{Op: "delete", Field: "internal"},
+					{Op: "unwrap", Field: "result"},
+					{Op: "rename", Field: "content", To: "choices.text"},
+				},
+			},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{Name: "test-group", Upstreams: []config.UpstreamRefConfig{{Name: "test-upstream"}}},
+		},
+	}
+
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(&config.ForwardConfig{
+		Name:         "transform-forward",
+		DefaultGroup: "test-group",
+	}, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+
+	sendRequest := func(contentType string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions?type="+contentType, strings.NewReader(`{}`)))
+		return w
+	}
+
+	// JSON 响应体按配置转换，Content-Length 与转换后的内容一致
+	w := sendRequest("application/json")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"choices": {"text": "hello"}}`, w.Body.String())
+	assert.Equal(t, strconv.Itoa(w.Body.Len()), w.Header().Get("Content-Length"))
+
+	// 流式响应不做转换
+	w = sendRequest("text/event-stream")
+	assert.Equal(t, `{"result": {"content": "hello"}, "internal": "x"}`, w.Body.String())
+}
+
+func TestForwardService_ClientTimeoutHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(500 * time.Millisecond):
+			w.WriteHeader(http.StatusOK)
+		case <-r.Context().Done():
+		}
+	}))
+	defer upstreamServer.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{{Name: "slow-upstream", URL: upstreamServer.URL}},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{Name: "fast-group", Upstreams: []config.UpstreamRefConfig{{Name: "slow-upstream"}}},
+			{
+				Name:       "capped-group",
+				Upstreams:  []config.UpstreamRefConfig{{Name: "slow-upstream"}},
+				HTTPClient: &config.HTTPClientConfig{Timeout: &config.TimeoutConfig{Request: 100}},
+			},
+		},
+	}
+
+	newRouter := func(group string) *gin.Engine {
+		service := NewForwardServices()
+		require.NoError(t, service.Initialize(&config.ForwardConfig{
+			Name:                "timeout-forward",
+			DefaultGroup:        group,
+			ClientTimeoutHeader: "X-Request-Timeout",
+		}, globalConfig, &logger))
+		t.Cleanup(service.Stop)
+
+		router := gin.New()
+		service.RegisterGroup(router.Group("/"))
+		return router
+	}
+
+	sendRequest := func(router *gin.Engine, timeout string) (*httptest.ResponseRecorder, time.Duration) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`))
+		if timeout != "" {
+			req.Header.Set("X-Request-Timeout", timeout)
+		}
+		w := httptest.NewRecorder()
+		start := time.Now()
+		router.ServeHTTP(w, req)
+		return w, time.Since(start)
+	}
+
+	router := newRouter("fast-group")
+
+	// 客户端超时时间短于上游响应时间时返回 504
+	w, elapsed := sendRequest(router, "50ms")
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Less(t, elapsed, 400*time.Millisecond)
+
+	// 客户端超时时间足够时正常返回
+	w, _ = sendRequest(router, "5s")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 无效的头部值被忽略
+	w, _ = sendRequest(router, "soon")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 客户端超时时间不超过上游组的请求超时时间
+	w, elapsed = sendRequest(newRouter("capped-group"), "10s")
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Less(t, elapsed, 400*time.Millisecond)
+}
+
+func TestForwardService_ClientDisconnectDuringStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+	logger := logr.Discard()
+
+	// 上游发送第一个数据块后保持连接，直到请求被取消
+	upstreamCanceled := make(chan struct{})
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		// 数据块超过响应写入缓冲区大小，确保代理将其发送给客户端
+		_, _ = w.Write([]byte("data: " + strings.Repeat("x", 16*1024) + "\n\n"))
+		w.(http.Flusher).Flush()
+
+		<-r.Context().Done()
+		close(upstreamCanceled)
+	}))
+	defer upstreamServer.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{{Name: "sse-upstream", URL: upstreamServer.URL}},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{Name: "sse-group", Upstreams: []config.UpstreamRefConfig{{Name: "sse-upstream"}}},
+		},
+	}
+
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(&config.ForwardConfig{
+		Name:         "sse-forward",
+		DefaultGroup: "sse-group",
+	}, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+	proxyServer := httptest.NewServer(router)
+	defer proxyServer.Close()
+
+	// 客户端收到第一个数据块后断开连接
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, proxyServer.URL+"/v1/chat/completions", strings.NewReader(`{"stream": true}`))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	prefix := make([]byte, len("data: "))
+	_, err = io.ReadFull(resp.Body, prefix)
+	require.NoError(t, err)
+	assert.Equal(t, "data: ", string(prefix))
+	cancel()
+	_ = resp.Body.Close()
+
+	select {
+	case <-upstreamCanceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream request was not canceled after client disconnected")
+	}
+
+	// 等待代理完成请求处理后再检查指标
+	require.Eventually(t, func() bool { return service.InFlightRequests() == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	// 记录客户端断开的上游错误，不计为上游截断
+	metricFamilies, err := service.metricsCollector.GetRegistry().Gather()
+	require.NoError(t, err)
+	errorTypes := make(map[string]float64)
+	for _, mf := range metricFamilies {
+		if !strings.HasSuffix(mf.GetName(), "_upstream_errors_total") {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == metrics.LabelErrorType {
+					errorTypes[label.GetValue()] = m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	assert.Equal(t, map[string]float64{constants.ErrorTypeClientDisconnected: 1}, errorTypes)
+}
+
+// TestForwardService_MaxConcurrentRequests 测试达到最大并发请求数后超出的请求被立即拒绝
+func TestForwardService_MaxConcurrentRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	// 上游在收到释放信号前一直阻塞，占满并发名额
+	release := make(chan struct{})
+	var upstreamHits atomic.Int32
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHits.Add(1)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstreamServer.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{{Name: "slow-upstream", URL: upstreamServer.URL}},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{Name: "slow-group", Upstreams: []config.UpstreamRefConfig{{Name: "slow-upstream"}}},
+		},
+	}
+	const maxConcurrent = 2
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(&config.ForwardConfig{
+		Name:                  "limited-forward",
+		DefaultGroup:          "slow-group",
+		MaxConcurrentRequests: maxConcurrent,
+	}, globalConfig, &logger))
+	defer service.Stop()
+	assert.Equal(t, maxConcurrent, service.MaxConcurrentRequests())
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+	sendRequest := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 占满并发名额
+	var admitted sync.WaitGroup
+	admittedCodes := make([]int, maxConcurrent)
+	for i := 0; i < maxConcurrent; i++ {
+		admitted.Add(1)
+		go func(i int) {
+			defer admitted.Done()
+			admittedCodes[i] = sendRequest().Code
+		}(i)
+	}
+	require.Eventually(t, func() bool {
+		return upstreamHits.Load() == maxConcurrent
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// 超出的并发请求立即返回 503，不访问上游
+	const excess = 20
+	var rejected sync.WaitGroup
+	var rejectedCount atomic.Int32
+	start := time.Now()
+	for i := 0; i < excess; i++ {
+		rejected.Add(1)
+		go func() {
+			defer rejected.Done()
+			w := sendRequest()
+			if w.Code == http.StatusServiceUnavailable && w.Header().Get(constants.HeaderRetryAfter) == "1" {
+				rejectedCount.Add(1)
+			}
+		}()
+	}
+	rejected.Wait()
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, int32(excess), rejectedCount.Load())
+	assert.Equal(t, int32(maxConcurrent), upstreamHits.Load())
+	assert.Equal(t, int64(maxConcurrent), service.InFlightRequests())
+
+	// 释放后已准入的请求正常完成，名额归还
+	close(release)
+	admitted.Wait()
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK}, admittedCodes)
+	assert.Equal(t, http.StatusOK, sendRequest().Code)
+
+	// 指标记录并发上限和拒绝次数
+	metricFamilies, err := service.metricsCollector.GetRegistry().Gather()
+	require.NoError(t, err)
+	values := make(map[string]float64)
+	for _, mf := range metricFamilies {
+		for _, m := range mf.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == metrics.LabelForwardName && label.GetValue() == "limited-forward" {
+					switch {
+					case strings.HasSuffix(mf.GetName(), "_concurrency_limit"):
+						values["limit"] = m.GetGauge().GetValue()
+					case strings.HasSuffix(mf.GetName(), "_concurrency_rejections_total"):
+						values["rejections"] = m.GetCounter().GetValue()
+					}
+				}
+			}
+		}
+	}
+	assert.Equal(t, map[string]float64{"limit": maxConcurrent, "rejections": excess}, values)
+}
+
+// TestForwardService_PreserveClientHeaders 测试保留客户端头部时不注入代理接收到的主机名
+func TestForwardService_PreserveClientHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Echo-X-Forwarded-Host", strings.Join(r.Header.Values("X-Forwarded-Host"), ","))
+		w.Header().Set("Echo-User-Agent", r.Header.Get("User-Agent"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstreamServer.Close()
+
+	send := func(preserve bool, forwardedHost string) http.Header {
+		globalConfig := &config.Config{
+			Upstreams: []config.UpstreamConfig{{Name: "echo-upstream", URL: upstreamServer.URL}},
+			UpstreamGroups: []config.UpstreamGroupConfig{{
+				Name:       "echo-group",
+				Upstreams:  []config.UpstreamRefConfig{{Name: "echo-upstream"}},
+				HTTPClient: &config.HTTPClientConfig{KeepAlive: 60000, PreserveClientHeaders: preserve},
+			}},
+		}
+		service := NewForwardServices()
+		require.NoError(t, service.Initialize(&config.ForwardConfig{
+			Name:         "preserve-forward",
+			DefaultGroup: "echo-group",
+		}, globalConfig, &logger))
+		defer service.Stop()
+
+		router := gin.New()
+		service.RegisterGroup(router.Group("/"))
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+		req.Host = "llmproxy.internal"
+		req.Header.Set("User-Agent", "client-agent/1.0")
+		if forwardedHost != "" {
+			req.Header.Set("X-Forwarded-Host", forwardedHost)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Header()
+	}
+
+	// 默认模式：注入代理接收到的主机名
+	echoed := send(false, "")
+	assert.Equal(t, "llmproxy.internal", echoed.Get("Echo-X-Forwarded-Host"))
+	assert.Equal(t, "client-agent/1.0", echoed.Get("Echo-User-Agent"))
+
+	// 保留模式：不注入 X-Forwarded-Host，客户端提供的值原样转发
+	echoed = send(true, "")
+	assert.Empty(t, echoed.Get("Echo-X-Forwarded-Host"))
+	echoed = send(true, "api.example.com")
+	assert.Equal(t, "api.example.com", echoed.Get("Echo-X-Forwarded-Host"))
+	assert.Equal(t, "client-agent/1.0", echoed.Get("Echo-User-Agent"))
+}
+
+// TestForwardService_GroupDefaultAuth 测试上游组默认认证应用于未配置认证的上游，上游自身认证优先
+func TestForwardService_GroupDefaultAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Echo-Authorization", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstreamServer.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "shared-1", URL: upstreamServer.URL},
+			{Name: "shared-2", URL: upstreamServer.URL},
+			{Name: "own", URL: upstreamServer.URL, Auth: &config.AuthConfig{Type: constants.AuthTypeBearer, Token: "own-token"}},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{{
+			Name:        "openai",
+			Upstreams:   []config.UpstreamRefConfig{{Name: "shared-1"}, {Name: "shared-2"}, {Name: "own"}},
+			Balance:     &config.BalanceConfig{Strategy: constants.BalanceRoundRobin},
+			HTTPClient:  &config.HTTPClientConfig{KeepAlive: 60000},
+			DefaultAuth: &config.AuthConfig{Type: constants.AuthTypeBearer, Token: "group-token"},
+		}},
+	}
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(&config.ForwardConfig{
+		Name:         "default-auth-forward",
+		DefaultGroup: "openai",
+	}, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+
+	authorizations := make(map[string]int)
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		authorizations[w.Header().Get("Echo-Authorization")]++
+	}
+
+	assert.Equal(t, map[string]int{"Bearer group-token": 2, "Bearer own-token": 1}, authorizations)
+}
+
+// TestForwardService_SlowRequestLog 测试请求耗时超过阈值时记录慢请求日志，且不受访问日志采样影响
+func TestForwardService_SlowRequestLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstreamServer.Close()
+
+	var mu sync.Mutex
+	var slowLogs []string
+	var slowPrefixes []string
+	logger := funcr.New(func(prefix, args string) {
+		if strings.Contains(args, `"msg"="Slow request"`) {
+			mu.Lock()
+			slowLogs = append(slowLogs, args)
+			slowPrefixes = append(slowPrefixes, prefix)
+			mu.Unlock()
+		}
+	}, funcr.Options{})
+
+	globalConfig := &config.Config{
+		HTTPServer: config.HTTPServerConfig{AccessLogSampleRate: 0.000001},
+		Upstreams:  []config.UpstreamConfig{{Name: "slow-upstream", URL: upstreamServer.URL}},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{Name: "slow-group", Upstreams: []config.UpstreamRefConfig{{Name: "slow-upstream"}}},
+		},
+	}
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(&config.ForwardConfig{
+		Name:                 "slow-forward",
+		DefaultGroup:         "slow-group",
+		SlowRequestThreshold: 50,
+	}, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+	send := func(path string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// 快速请求不记录
+	send("/fast")
+	mu.Lock()
+	assert.Empty(t, slowLogs)
+	mu.Unlock()
+
+	// 慢请求记录方法、路径、上游和耗时
+	send("/slow")
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, slowLogs, 1)
+	assert.Contains(t, slowLogs[0], `"method"="GET"`)
+	assert.Contains(t, slowLogs[0], `"path"="/slow"`)
+	assert.Contains(t, slowLogs[0], `"upstream"="slow-upstream"`)
+	assert.Contains(t, slowLogs[0], `"threshold_ms"=50`)
+	assert.Contains(t, slowLogs[0], `"duration_ms"=`)
+	// 慢请求以 info 级别记录，使用名为 slow 的日志记录器与访问日志区分
+	assert.Contains(t, slowLogs[0], `"level"=0`)
+	assert.NotContains(t, slowLogs[0], `"error"=`)
+	assert.Equal(t, "slow", slowPrefixes[0])
+}
+
+// TestForwardService_OutcomeMetrics 测试请求指标按处理结果记录 outcome 标签
+func TestForwardService_OutcomeMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer upstreamServer.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{{Name: "ok-upstream", URL: upstreamServer.URL}},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{Name: "ok-group", Upstreams: []config.UpstreamRefConfig{{Name: "ok-upstream"}}},
+		},
+	}
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(&config.ForwardConfig{
+		Name:         "outcome-forward",
+		DefaultGroup: "ok-group",
+		RateLimit:    &config.RateLimitConfig{PerSecond: 1, Burst: 2},
+		Idempotency:  &config.IdempotencyConfig{Enabled: true, TTL: 60000, MaxBodySize: 1024},
+	}, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+	send := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`))
+		req.RemoteAddr = "1.2.3.4:12345"
+		req.Header.Set(constants.HeaderIdempotencyKey, "outcome-key")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 首个请求转发到上游，第二个请求重放缓存响应，第三个请求被限流
+	assert.Equal(t, http.StatusOK, send())
+	assert.Equal(t, http.StatusOK, send())
+	assert.Equal(t, http.StatusTooManyRequests, send())
+
+	metricFamilies, err := service.metricsCollector.GetRegistry().Gather()
+	require.NoError(t, err)
+	outcomes := make(map[string]string)
+	for _, mf := range metricFamilies {
+		if !strings.HasSuffix(mf.GetName(), "_http_requests_total") {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels[metrics.LabelForwardName] == "outcome-forward" {
+				outcomes[labels[metrics.LabelOutcome]] = labels[metrics.LabelStatusCode]
+				assert.Equal(t, float64(1), m.GetCounter().GetValue())
+			}
+		}
+	}
+	assert.Equal(t, map[string]string{
+		constants.OutcomeDirect:      "200",
+		constants.OutcomeCacheHit:    "200",
+		constants.OutcomeRateLimited: "429",
+	}, outcomes)
+}
+
+// TestForwardService_FailurePenalty 测试上游失败一次后在惩罚期内被跳过，惩罚期结束后恢复选择
+func TestForwardService_FailurePenalty(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	var failingCalls, healthyCalls atomic.Int32
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failingCalls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failingServer.Close()
+	healthyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthyCalls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer healthyServer.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "failing", URL: failingServer.URL, FailurePenalty: 200},
+			{Name: "healthy", URL: healthyServer.URL, FailurePenalty: 200},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{{
+			Name:      "penalty-group",
+			Upstreams: []config.UpstreamRefConfig{{Name: "failing"}, {Name: "healthy"}},
+			Balance:   &config.BalanceConfig{Strategy: constants.BalanceRoundRobin},
+		}},
+	}
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(&config.ForwardConfig{
+		Name:         "penalty-forward",
+		DefaultGroup: "penalty-group",
+	}, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+
+	sendRequest := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+		return w.Code
+	}
+
+	// 轮询最多两次即命中失败上游
+	for i := 0; i < 2 && failingCalls.Load() == 0; i++ {
+		sendRequest()
+	}
+	require.Equal(t, int32(1), failingCalls.Load())
+
+	// 惩罚期内所有请求都转发到健康上游
+	for i := 0; i < 6; i++ {
+		assert.Equal(t, http.StatusOK, sendRequest())
+	}
+	assert.Equal(t, int32(1), failingCalls.Load())
+
+	// 惩罚期结束后失败上游重新参与选择
+	time.Sleep(250 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		sendRequest()
+	}
+	assert.Equal(t, int32(2), failingCalls.Load())
+}
+
+// TestForwardService_RequestBodyTooLarge 测试请求体超过大小限制时返回 413 并记录指标
+func TestForwardService_RequestBodyTooLarge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	var upstreamCalls atomic.Int32
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstreamServer.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{{Name: "body-upstream", URL: upstreamServer.URL}},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{Name: "body-group", Upstreams: []config.UpstreamRefConfig{{Name: "body-upstream"}}},
+		},
+	}
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(&config.ForwardConfig{
+		Name:         "body-forward",
+		DefaultGroup: "body-group",
+	}, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(make([]byte, MaxRequestBodySize+1)))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Zero(t, upstreamCalls.Load())
+
+	var body httptool.BaseHttpResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, int64(response.CodeBadRequest), body.Code)
+	assert.Equal(t, "Request body too large", body.ErrorMessage)
+
+	metricFamilies, err := service.metricsCollector.GetRegistry().Gather()
+	require.NoError(t, err)
+	var rejections float64
+	for _, mf := range metricFamilies {
+		if strings.HasSuffix(mf.GetName(), "_request_body_too_large_total") {
+			for _, m := range mf.GetMetric() {
+				for _, label := range m.GetLabel() {
+					if label.GetName() == metrics.LabelForwardName && label.GetValue() == "body-forward" {
+						rejections = m.GetCounter().GetValue()
+					}
+				}
+			}
+		}
+	}
+	assert.Equal(t, float64(1), rejections)
+}
+
+// TestForwardService_RequestBodyTooLarge_Streaming 测试流式转发的分块请求体超过大小限制时返回 413，且不计为上游失败
+func TestForwardService_RequestBodyTooLarge_Streaming(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstreamServer.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{{Name: "body-upstream", URL: upstreamServer.URL}},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{Name: "body-group", Upstreams: []config.UpstreamRefConfig{{Name: "body-upstream"}}},
+		},
+	}
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(&config.ForwardConfig{
+		Name:              "body-forward",
+		DefaultGroup:      "body-group",
+		StreamRequestBody: true,
+	}, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+
+	// 未声明 Content-Length 的请求体在转发过程中才超过限制
+	body := io.MultiReader(bytes.NewReader(make([]byte, MaxRequestBodySize+1)))
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", body)
+	require.Equal(t, int64(-1), req.ContentLength)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	var resp httptool.BaseHttpResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "Request body too large", resp.ErrorMessage)
+
+	metricFamilies, err := service.metricsCollector.GetRegistry().Gather()
+	require.NoError(t, err)
+	var rejections, upstreamErrors float64
+	for _, mf := range metricFamilies {
+		for _, m := range mf.GetMetric() {
+			switch {
+			case strings.HasSuffix(mf.GetName(), "_request_body_too_large_total"):
+				rejections += m.GetCounter().GetValue()
+			case strings.HasSuffix(mf.GetName(), "_upstream_errors_total"):
+				upstreamErrors += m.GetCounter().GetValue()
+			}
+		}
+	}
+	assert.Equal(t, float64(1), rejections)
+	assert.Zero(t, upstreamErrors)
+
+	// 上游仍可正常处理后续请求
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewBufferString(`{}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestForwardService_ResponseHeaderPolicy 测试按过滤策略转发上游响应头部，逐跳头部始终移除
+func TestForwardService_ResponseHeaderPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "provider-request-id")
+		w.Header().Set("X-Ratelimit-Remaining-Requests", "99")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("Connection", "X-Internal-Hop")
+		w.Header().Set("X-Internal-Hop", "1")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer upstreamServer.Close()
+
+	tests := []struct {
+		name     string
+		policy   *config.ResponseHeaderPolicyConfig
+		expected []string
+		removed  []string
+	}{
+		{
+			name:     "all",
+			policy:   nil,
+			expected: []string{"Content-Type", "X-Request-Id", "X-Ratelimit-Remaining-Requests", "Set-Cookie"},
+			removed:  []string{"X-Internal-Hop"},
+		},
+		{
+			name: "allow",
+			policy: &config.ResponseHeaderPolicyConfig{
+				Mode:    constants.ResponseHeaderPolicyAllow,
+				Headers: []string{"content-type", "x-ratelimit-remaining-requests", "x-internal-hop"},
+			},
+			expected: []string{"Content-Type", "X-Ratelimit-Remaining-Requests"},
+			removed:  []string{"X-Request-Id", "Set-Cookie", "X-Internal-Hop"},
+		},
+		{
+			name: "deny",
+			policy: &config.ResponseHeaderPolicyConfig{
+				Mode:    constants.ResponseHeaderPolicyDeny,
+				Headers: []string{"set-cookie", "X-Request-Id"},
+			},
+			expected: []string{"Content-Type", "X-Ratelimit-Remaining-Requests"},
+			removed:  []string{"X-Request-Id", "Set-Cookie", "X-Internal-Hop"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics.GetGlobalRegistry().Clear()
+			defer metrics.GetGlobalRegistry().Clear()
+
+			globalConfig := &config.Config{
+				Upstreams: []config.UpstreamConfig{{Name: "header-upstream", URL: upstreamServer.URL}},
+				UpstreamGroups: []config.UpstreamGroupConfig{
+					{Name: "header-group", Upstreams: []config.UpstreamRefConfig{{Name: "header-upstream"}}},
+				},
+			}
+			service := NewForwardServices()
+			require.NoError(t, service.Initialize(&config.ForwardConfig{
+				Name:                 "header-forward",
+				DefaultGroup:         "header-group",
+				ResponseHeaderPolicy: tt.policy,
+			}, globalConfig, &logger))
+			defer service.Stop()
+
+			router := gin.New()
+			service.RegisterGroup(router.Group("/"))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.JSONEq(t, `{"ok":true}`, w.Body.String())
+
+			for _, name := range tt.expected {
+				assert.NotEmpty(t, w.Header().Get(name), name)
+			}
+			for _, name := range tt.removed {
+				assert.Empty(t, w.Header().Get(name), name)
+			}
+			assert.Empty(t, w.Header().Get("Connection"))
+		})
+	}
+}
+
+// TestForwardService_ForwardTrailers 测试启用后将上游声明的 trailer 转发给客户端
+func TestForwardService_ForwardTrailers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "X-Stream-Status")
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("data: hello\n\n"))
+		w.(http.Flusher).Flush()
+		w.Header().Set("X-Stream-Status", "complete")
+	}))
+	defer upstreamServer.Close()
+
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("enabled=%v", enabled), func(t *testing.T) {
+			metrics.GetGlobalRegistry().Clear()
+			defer metrics.GetGlobalRegistry().Clear()
+
+			globalConfig := &config.Config{
+				Upstreams: []config.UpstreamConfig{{Name: "trailer-upstream", URL: upstreamServer.URL}},
+				UpstreamGroups: []config.UpstreamGroupConfig{
+					{Name: "trailer-group", Upstreams: []config.UpstreamRefConfig{{Name: "trailer-upstream"}}},
+				},
+			}
+			service := NewForwardServices()
+			require.NoError(t, service.Initialize(&config.ForwardConfig{
+				Name:            "trailer-forward",
+				DefaultGroup:    "trailer-group",
+				ForwardTrailers: enabled,
+			}, globalConfig, &logger))
+			defer service.Stop()
+
+			router := gin.New()
+			service.RegisterGroup(router.Group("/"))
+			proxyServer := httptest.NewServer(router)
+			defer proxyServer.Close()
+
+			resp, err := http.Get(proxyServer.URL + "/v1/chat/completions")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "data: hello\n\n", string(body))
+
+			if enabled {
+				assert.Equal(t, "complete", resp.Trailer.Get("X-Stream-Status"))
+			} else {
+				assert.Empty(t, resp.Trailer.Get("X-Stream-Status"))
+			}
+		})
+	}
+}
+
+// TestForwardService_StreamFlushInterval 测试流式响应的数据在刷新间隔内送达客户端
+func TestForwardService_StreamFlushInterval(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	const upstreamPause = 500 * time.Millisecond
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for i := 0; i < 3; i++ {
+			_, _ = fmt.Fprintf(w, "data: chunk-%d\n\n", i)
+			w.(http.Flusher).Flush()
+		}
+		time.Sleep(upstreamPause)
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer upstreamServer.Close()
+
+	for _, interval := range []int{0, 100} {
+		t.Run(fmt.Sprintf("interval=%dms", interval), func(t *testing.T) {
+			metrics.GetGlobalRegistry().Clear()
+			defer metrics.GetGlobalRegistry().Clear()
+
+			globalConfig := &config.Config{
+				Upstreams: []config.UpstreamConfig{{Name: "flush-upstream", URL: upstreamServer.URL}},
+				UpstreamGroups: []config.UpstreamGroupConfig{
+					{Name: "flush-group", Upstreams: []config.UpstreamRefConfig{{Name: "flush-upstream"}}},
+				},
+			}
+			service := NewForwardServices()
+			require.NoError(t, service.Initialize(&config.ForwardConfig{
+				Name:                "flush-forward",
+				DefaultGroup:        "flush-group",
+				StreamFlushInterval: interval,
+			}, globalConfig, &logger))
+			defer service.Stop()
+
+			router := gin.New()
+			service.RegisterGroup(router.Group("/"))
+			proxyServer := httptest.NewServer(router)
+			defer proxyServer.Close()
+
+			start := time.Now()
+			resp, err := http.Get(proxyServer.URL + "/v1/chat/completions")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			// 上游暂停期间，已写出的数据应在刷新间隔内送达，而不是等到响应结束
+			reader := bufio.NewReader(resp.Body)
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			assert.Equal(t, "data: chunk-0\n", line)
+			assert.Less(t, time.Since(start), time.Duration(interval)*time.Millisecond+upstreamPause/2)
+
+			rest, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, "\ndata: chunk-1\n\ndata: chunk-2\n\ndata: [DONE]\n\n", string(rest))
+		})
+	}
+}
+
+// TestForwardService_UpstreamTimeToFirstByte 测试流式响应的首字节时间独立于上游请求总耗时记录
+func TestForwardService_UpstreamTimeToFirstByte(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	const firstBytePause = 200 * time.Millisecond
+	const generationPause = 300 * time.Millisecond
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(firstBytePause)
+		_, _ = w.Write([]byte("data: hello\n\n"))
+		w.(http.Flusher).Flush()
+		time.Sleep(generationPause)
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer upstreamServer.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{{Name: "ttfb-upstream", URL: upstreamServer.URL}},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{Name: "ttfb-group", Upstreams: []config.UpstreamRefConfig{{Name: "ttfb-upstream"}}},
+		},
+	}
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(&config.ForwardConfig{
+		Name:         "ttfb-forward",
+		DefaultGroup: "ttfb-group",
+	}, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+	proxyServer := httptest.NewServer(router)
+
+	resp, err := http.Get(proxyServer.URL + "/v1/chat/completions")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "data: hello\n\ndata: [DONE]\n\n", string(body))
+
+	// 等待处理器完成指标记录
+	proxyServer.Close()
+
+	families, err := service.metricsCollector.GetRegistry().Gather()
+	require.NoError(t, err)
+
+	var ttfb, duration float64
+	var ttfbCount uint64
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			switch {
+			case strings.HasSuffix(mf.GetName(), "_upstream_time_to_first_byte_seconds"):
+				ttfb = m.GetHistogram().GetSampleSum()
+				ttfbCount = m.GetHistogram().GetSampleCount()
+			case strings.HasSuffix(mf.GetName(), "_upstream_request_duration_seconds"):
+				duration = m.GetHistogram().GetSampleSum()
+			}
+		}
+	}
+
+	// 上游请求耗时在收到响应头时结束，首字节时间包含等待首个数据块的时间，但不包含后续生成时间
+	assert.Equal(t, uint64(1), ttfbCount)
+	assert.Less(t, duration, firstBytePause.Seconds())
+	assert.GreaterOrEqual(t, ttfb, firstBytePause.Seconds())
+	assert.Less(t, ttfb, (firstBytePause + generationPause).Seconds())
+}
+
+// TestForwardService_UpstreamOverride 测试客户端通过 X-LLMProxy-Upstream 头部指定上游
+func TestForwardService_UpstreamOverride(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	newUpstream := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// 上游指定头部不应转发给上游
+			w.Header().Set("X-Received-Override", r.Header.Get(constants.HeaderLLMProxyUpstream))
+			_, _ = w.Write([]byte(name))
+		}))
+	}
+	alphaServer := newUpstream("alpha")
+	defer alphaServer.Close()
+	betaServer := newUpstream("beta")
+	defer betaServer.Close()
+	gammaServer := newUpstream("gamma")
+	defer gammaServer.Close()
+
+	var current *ForwardService
+	newService := func(t *testing.T, allowOverride bool, groups ...config.GroupRefConfig) *gin.Engine {
+		metrics.GetGlobalRegistry().Clear()
+		t.Cleanup(func() { _ = metrics.GetGlobalRegistry().Clear() })
+
+		globalConfig := &config.Config{
+			Upstreams: []config.UpstreamConfig{
+				{Name: "alpha", URL: alphaServer.URL},
+				{Name: "beta", URL: betaServer.URL},
+				{Name: "gamma", URL: gammaServer.URL},
+			},
+			UpstreamGroups: []config.UpstreamGroupConfig{
+				{Name: "main-group", Upstreams: []config.UpstreamRefConfig{{Name: "alpha"}, {Name: "beta"}}},
+				{Name: "other-group", Upstreams: []config.UpstreamRefConfig{{Name: "gamma"}}},
+			},
+		}
+		service := NewForwardServices()
+		require.NoError(t, service.Initialize(&config.ForwardConfig{
+			Name:                  "override-forward",
+			DefaultGroup:          "main-group",
+			Groups:                groups,
+			AllowUpstreamOverride: allowOverride,
+		}, globalConfig, &logger))
+		t.Cleanup(service.Stop)
+		current = service
+
+		router := gin.New()
+		service.RegisterGroup(router.Group("/"))
+		return router
+	}
+
+	send := func(router *gin.Engine, upstream string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+		req.Header.Set(constants.HeaderLLMProxyUpstream, upstream)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("valid override bypasses balancer", func(t *testing.T) {
+		router := newService(t, true)
+		for i := 0; i < 4; i++ {
+			w := send(router, "beta")
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "beta", w.Body.String())
+			assert.Empty(t, w.Header().Get("X-Received-Override"))
+		}
+	})
+
+	t.Run("invalid override rejected", func(t *testing.T) {
+		router := newService(t, true)
+		tests := []struct {
+			upstream string
+			message  string
+		}{
+			{upstream: "unknown", message: "Unknown upstream"},
+			{upstream: "gamma", message: "Upstream not in forward groups"},
+		}
+		for _, tt := range tests {
+			w := send(router, tt.upstream)
+			require.Equal(t, http.StatusBadRequest, w.Code, tt.upstream)
+
+			var body httptool.BaseHttpResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.message, body.ErrorMessage)
+		}
+
+		// 客户端指定无效的上游不计为处理错误
+		assert.Zero(t, processingErrorCount(t, current))
+	})
+
+	t.Run("override uses the group containing the upstream", func(t *testing.T) {
+		router := newService(t, true,
+			config.GroupRefConfig{Name: "main-group", Weight: 1},
+			config.GroupRefConfig{Name: "other-group", Weight: 1})
+		// 按权重选中的上游组不包含指定的上游时，使用包含该上游的上游组
+		for _, upstream := range []string{"alpha", "beta", "gamma"} {
+			for i := 0; i < 10; i++ {
+				w := send(router, upstream)
+				require.Equal(t, http.StatusOK, w.Code, upstream)
+				assert.Equal(t, upstream, w.Body.String())
+			}
+		}
+		assert.Equal(t, http.StatusBadRequest, send(router, "unknown").Code)
+	})
+
+	t.Run("override disabled by default", func(t *testing.T) {
+		router := newService(t, false)
+		seen := make(map[string]bool)
+		for i := 0; i < 4; i++ {
+			w := send(router, "gamma")
+			require.Equal(t, http.StatusOK, w.Code)
+			seen[w.Body.String()] = true
+			assert.Equal(t, "gamma", w.Header().Get("X-Received-Override"))
+		}
+		assert.Equal(t, map[string]bool{"alpha": true, "beta": true}, seen)
+	})
+}
+
+// TestForwardService_BreakerProbe 测试配置探测路径时半开状态使用合成探测请求，探测成功后闭合，失败后重新开启
+func TestForwardService_BreakerProbe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	tests := []struct {
+		name        string
+		probeStatus int
+		expected    gobreaker.State
+	}{
+		{name: "probe success closes breaker", probeStatus: http.StatusOK, expected: gobreaker.StateClosed},
+		{name: "probe failure reopens breaker", probeStatus: http.StatusServiceUnavailable, expected: gobreaker.StateOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics.GetGlobalRegistry().Clear()
+			defer metrics.GetGlobalRegistry().Clear()
+
+			var failing atomic.Bool
+			var userCalls, probeCalls atomic.Int32
+			failing.Store(true)
+			upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/health" {
+					probeCalls.Add(1)
+					w.WriteHeader(tt.probeStatus)
+					return
+				}
+				if failing.Load() {
+					// 中断连接，使请求执行失败并计入熔断器失败次数
+					panic(http.ErrAbortHandler)
+				}
+				userCalls.Add(1)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer upstreamServer.Close()
+
+			globalConfig := &config.Config{
+				Upstreams: []config.UpstreamConfig{{
+					Name: "probe-upstream",
+					URL:  upstreamServer.URL,
+					Breaker: &config.BreakerConfig{
+						Threshold:   0.5,
+						Cooldown:    100,
+						MaxRequests: 2,
+						ProbePath:   "/health",
+					},
+				}},
+				UpstreamGroups: []config.UpstreamGroupConfig{{
+					Name:      "probe-group",
+					Upstreams: []config.UpstreamRefConfig{{Name: "probe-upstream"}},
+				}},
+			}
+			service := NewForwardServices()
+			require.NoError(t, service.Initialize(&config.ForwardConfig{
+				Name:         "probe-forward",
+				DefaultGroup: "probe-group",
+			}, globalConfig, &logger))
+			defer service.Stop()
+
+			router := gin.New()
+			service.RegisterGroup(router.Group("/"))
+			sendRequest := func() int {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+				return w.Code
+			}
+
+			// 连续失败直到熔断器开启
+			probeBreaker, ok := service.groups[0].upstreams[0].Breaker.(*breaker.ProbeBreaker)
+			require.True(t, ok)
+			for i := 0; i < constants.DefaultBreakerMinRequests; i++ {
+				sendRequest()
+			}
+			require.Equal(t, gobreaker.StateOpen, probeBreaker.CircuitBreaker.State())
+
+			// 冷却结束后用户请求触发探测但不会被转发到上游
+			failing.Store(false)
+			time.Sleep(150 * time.Millisecond)
+			assert.Equal(t, http.StatusServiceUnavailable, sendRequest())
+			assert.Zero(t, userCalls.Load())
+
+			require.Eventually(t, func() bool {
+				return probeBreaker.CircuitBreaker.State() != gobreaker.StateHalfOpen
+			}, time.Second, 10*time.Millisecond)
+			assert.Equal(t, tt.expected, probeBreaker.CircuitBreaker.State())
+
+			if tt.expected == gobreaker.StateClosed {
+				// 探测成功次数达到半开状态最大请求数后闭合，用户请求恢复转发
+				assert.Equal(t, int32(2), probeCalls.Load())
+				assert.Equal(t, http.StatusOK, sendRequest())
+				assert.Equal(t, int32(1), userCalls.Load())
+			} else {
+				// 探测失败后重新开启，用户请求仍不会被转发
+				assert.Equal(t, int32(1), probeCalls.Load())
+				assert.Equal(t, http.StatusServiceUnavailable, sendRequest())
+				assert.Zero(t, userCalls.Load())
+			}
+		})
+	}
+}
+
+// TestForwardService_MaxRequestDuration 测试请求最大处理时长覆盖等待和上游请求等全部阶段，到期后中断请求并返回 504
+func TestForwardService_MaxRequestDuration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	// 上游响应时间在上游组的请求超时时间之内，但超过请求最大处理时长
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(800 * time.Millisecond):
+			w.WriteHeader(http.StatusOK)
+		case <-r.Context().Done():
+		}
+	}))
+	defer upstreamServer.Close()
+
+	const maxDuration = 200 * time.Millisecond
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{{Name: "slow-upstream", URL: upstreamServer.URL}},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name:       "slow-group",
+				Upstreams:  []config.UpstreamRefConfig{{Name: "slow-upstream"}},
+				HTTPClient: &config.HTTPClientConfig{Timeout: &config.TimeoutConfig{Request: 5000}},
+			},
+		},
+	}
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(&config.ForwardConfig{
+		Name:               "capped-forward",
+		DefaultGroup:       "slow-group",
+		MaxRequestDuration: int(maxDuration.Milliseconds()),
+		Idempotency:        &config.IdempotencyConfig{Enabled: true, TTL: 60000, MaxBodySize: 1024},
+	}, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+
+	send := func(key string) (*httptest.ResponseRecorder, time.Duration) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`))
+		if key != "" {
+			req.Header.Set(constants.HeaderIdempotencyKey, key)
+		}
+		w := httptest.NewRecorder()
+		start := time.Now()
+		router.ServeHTTP(w, req)
+		return w, time.Since(start)
+	}
+
+	assertAborted := func(w *httptest.ResponseRecorder, elapsed time.Duration) {
+		t.Helper()
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.GreaterOrEqual(t, elapsed, maxDuration)
+		assert.Less(t, elapsed, maxDuration+300*time.Millisecond)
+
+		var response httptool.BaseHttpResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "Request exceeded maximum duration", response.ErrorMessage)
+	}
+
+	t.Run("aborts upstream request at the cap", func(t *testing.T) {
+		w, elapsed := send("")
+		assertAborted(w, elapsed)
+	})
+
+	t.Run("cap includes time spent waiting for in-flight request", func(t *testing.T) {
+		// 第一个请求持有幂等键，第二个请求先等待再执行上游请求，累计耗时在截止时间被中断
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			send("capped-key")
+		}()
+		time.Sleep(100 * time.Millisecond)
+
+		w, elapsed := send("capped-key")
+		assertAborted(w, elapsed)
+		<-done
+	})
+}
+
+// TestForwardService_Coalesce 测试相同的并发请求合并为一次上游请求并共享响应，流式响应和不同请求体不合并
+func TestForwardService_Coalesce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	var upstreamCalls atomic.Int64
+	release := make(chan struct{})
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := upstreamCalls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		<-release
+
+		if r.URL.Path == "/v1/stream" {
+			w.Header().Set(constants.HeaderContentType, constants.ContentTypeEventStream)
+			_, _ = fmt.Fprintf(w, "data: %d\n\n", n)
+			return
+		}
+		w.Header().Set(constants.HeaderContentType, "application/json")
+		_, _ = fmt.Fprintf(w, `{"call": %d, "input": %s}`, n, body)
+	}))
+	defer upstreamServer.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "coalesce-upstream-1", URL: upstreamServer.URL},
+			{Name: "coalesce-upstream-2", URL: upstreamServer.URL},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name:      "coalesce-group",
+				Upstreams: []config.UpstreamRefConfig{{Name: "coalesce-upstream-1"}, {Name: "coalesce-upstream-2"}},
+			},
+		},
+	}
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(&config.ForwardConfig{
+		Name:         "coalesce-forward",
+		DefaultGroup: "coalesce-group",
+		Coalesce:     true,
+	}, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+
+	// sendConcurrently 并发发送请求，等待请求到达上游后放行上游响应，apiKeys 非空时为每个请求设置对应的 X-Api-Key
+	sendConcurrently := func(path string, bodies []string, apiKeys ...string) []*httptest.ResponseRecorder {
+		upstreamCalls.Store(0)
+		release = make(chan struct{})
+
+		recorders := make([]*httptest.ResponseRecorder, len(bodies))
+		var wg sync.WaitGroup
+		for i, body := range bodies {
+			wg.Add(1)
+			go func(i int, body string) {
+				defer wg.Done()
+				req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+				req.Header.Set(constants.HeaderContentType, "application/json")
+				if len(apiKeys) > 0 {
+					req.Header.Set("x-api-key", apiKeys[i])
+				}
+				recorders[i] = httptest.NewRecorder()
+				router.ServeHTTP(recorders[i], req)
+			}(i, body)
+		}
+
+		time.Sleep(200 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		return recorders
+	}
+
+	const requests = 10
+
+	t.Run("identical requests share one upstream call", func(t *testing.T) {
+		bodies := make([]string, requests)
+		for i := range bodies {
+			bodies[i] = `{"input": "hello"}`
+		}
+
+		recorders := sendConcurrently("/v1/embeddings", bodies)
+		assert.Equal(t, int64(1), upstreamCalls.Load())
+		for _, w := range recorders {
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.JSONEq(t, `{"call": 1, "input": {"input": "hello"}}`, w.Body.String())
+		}
+
+		// 共享响应的请求使用 coalesced 结果标签，上游指标只记录一次
+		var coalesced, upstreamResponses float64
+		families, err := service.metricsCollector.GetRegistry().Gather()
+		require.NoError(t, err)
+		for _, mf := range families {
+			for _, m := range mf.GetMetric() {
+				switch {
+				case strings.HasSuffix(mf.GetName(), "_http_requests_total"):
+					for _, label := range m.GetLabel() {
+						if label.GetName() == "outcome" && label.GetValue() == constants.OutcomeCoalesced {
+							coalesced += m.GetCounter().GetValue()
+						}
+					}
+				case strings.HasSuffix(mf.GetName(), "_upstream_requests_total"):
+					upstreamResponses += m.GetCounter().GetValue()
+				}
+			}
+		}
+		assert.Equal(t, float64(requests-1), coalesced)
+		assert.Equal(t, float64(1), upstreamResponses)
+	})
+
+	t.Run("different bodies are not coalesced", func(t *testing.T) {
+		bodies := make([]string, requests)
+		for i := range bodies {
+			bodies[i] = fmt.Sprintf(`{"input": %d}`, i)
+		}
+
+		recorders := sendConcurrently("/v1/embeddings", bodies)
+		assert.Equal(t, int64(requests), upstreamCalls.Load())
+		for i, w := range recorders {
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), fmt.Sprintf(`"input": {"input": %d}`, i))
+		}
+	})
+
+	t.Run("different api keys are not coalesced", func(t *testing.T) {
+		bodies := make([]string, requests)
+		apiKeys := make([]string, requests)
+		for i := range bodies {
+			bodies[i] = `{"input": "hello"}`
+			apiKeys[i] = fmt.Sprintf("tenant-%d", i%2)
+		}
+
+		// 两个租户各自合并为一次上游请求，不共享对方的响应
+		sendConcurrently("/v1/embeddings", bodies, apiKeys...)
+		assert.Equal(t, int64(2), upstreamCalls.Load())
+	})
+
+	t.Run("streaming responses are not shared", func(t *testing.T) {
+		bodies := make([]string, requests)
+		for i := range bodies {
+			bodies[i] = `{"stream": true}`
+		}
+
+		recorders := sendConcurrently("/v1/stream", bodies)
+		assert.Equal(t, int64(requests), upstreamCalls.Load())
+		for _, w := range recorders {
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), "data: ")
+		}
+	})
+}
+
+// TestNewCoalesceKeyHeaders 测试参与合并键计算的凭据头部默认值、自定义配置和限流键头部
+func TestNewCoalesceKeyHeaders(t *testing.T) {
+	assert.Equal(t, []string{"Authorization", "X-Api-Key", "Api-Key"}, newCoalesceKeyHeaders(&config.ForwardConfig{}))
+
+	assert.Equal(t, []string{"X-Tenant-Token"}, newCoalesceKeyHeaders(&config.ForwardConfig{
+		CoalesceKeyHeaders: []string{"x-tenant-token", "X-Tenant-Token"},
+	}))
+
+	// 按头部限流时追加限流键头部，不修改默认头部列表
+	assert.Equal(t, []string{"Authorization", "X-Api-Key", "Api-Key", "X-User-Id"}, newCoalesceKeyHeaders(&config.ForwardConfig{
+		RateLimit: &config.RateLimitConfig{KeyBy: constants.RateLimitKeyByHeader, Header: "x-user-id"},
+	}))
+	assert.Len(t, defaultCoalesceKeyHeaders, 3)
+}
+
+// TestForwardService_UpstreamConnections 测试并发请求时上游连接池活跃连接数指标随之增加
+func TestForwardService_UpstreamConnections(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	var received atomic.Int64
+	release := make(chan struct{})
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		<-release
+		w.Header().Set(constants.HeaderContentType, "application/json")
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer upstreamServer.Close()
+
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "conns-upstream", URL: upstreamServer.URL},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name:      "conns-group",
+				Upstreams: []config.UpstreamRefConfig{{Name: "conns-upstream"}},
+			},
+		},
+	}
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(&config.ForwardConfig{
+		Name:         "conns-forward",
+		DefaultGroup: "conns-group",
+	}, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+
+	// connectionGauges 上报并读取上游连接数指标
+	connectionGauges := func() (active, idle float64) {
+		service.reportUpstreamConnections()
+		families, err := service.metricsCollector.GetRegistry().Gather()
+		require.NoError(t, err)
+		for _, mf := range families {
+			for _, m := range mf.GetMetric() {
+				switch mf.GetName() {
+				case "llmproxy_upstream_connections_active":
+					active = m.GetGauge().GetValue()
+				case "llmproxy_upstream_connections_idle":
+					idle = m.GetGauge().GetValue()
+				}
+			}
+		}
+		return active, idle
+	}
+
+	const requests = 3
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`)))
+			assert.Equal(t, http.StatusOK, w.Code)
+		}()
+	}
+
+	// 请求阻塞在上游期间，每个请求占用一个活跃连接
+	require.Eventually(t, func() bool { return received.Load() == requests }, 5*time.Second, 10*time.Millisecond)
+	active, idle := connectionGauges()
+	assert.Equal(t, float64(requests), active)
+	assert.Equal(t, float64(0), idle)
+
+	stats := service.UpstreamConnections()["conns-upstream"]
+	assert.Equal(t, requests, stats.Active)
+
+	// 响应完成后连接归还连接池，超出空闲连接上限的连接被关闭
+	close(release)
+	wg.Wait()
+	active, idle = connectionGauges()
+	assert.Equal(t, float64(0), active)
+	assert.Positive(t, idle)
+	assert.LessOrEqual(t, idle, float64(requests))
+}
+
+// TestForwardService_StreamFormat 测试按上游 streamFormat 转换流式响应事件，未配置时原样转发
+func TestForwardService_StreamFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	upstreamBody := "event: message_start\n" +
+		"data: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"model\":\"claude\",\"usage\":{\"input_tokens\":3}}}\n\n" +
+		": keep-alive\n\n" +
+		"event: content_block_delta\n" +
+		"data: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hi\"}}\n\n" +
+		"data: {broken\n\n" +
+		"event: message_stop\n" +
+		"data: {\"type\":\"message_stop\"}\n\n"
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(constants.HeaderContentType, constants.ContentTypeEventStream)
+		_, _ = io.WriteString(w, upstreamBody)
+	}))
+	defer upstreamServer.Close()
+
+	tests := []struct {
+		name         string
+		streamFormat string
+		check        func(t *testing.T, body string)
+	}{
+		{
+			name: "passthrough by default",
+			check: func(t *testing.T, body string) {
+				assert.Equal(t, upstreamBody, body)
+			},
+		},
+		{
+			name:         "anthropic events to openai",
+			streamFormat: constants.StreamFormatOpenAI,
+			check: func(t *testing.T, body string) {
+				events := strings.Split(strings.TrimSuffix(body, "\n\n"), "\n\n")
+				require.Len(t, events, 5)
+				assert.Contains(t, events[0], `"object":"chat.completion.chunk"`)
+				assert.Contains(t, events[0], `"role":"assistant"`)
+				assert.Equal(t, ": keep-alive", events[1])
+				assert.Contains(t, events[2], `"content":"Hi"`)
+				// 无法解析的事件原样转发
+				assert.Equal(t, "data: {broken", events[3])
+				assert.Equal(t, "data: [DONE]", events[4])
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics.GetGlobalRegistry().Clear()
+			globalConfig := &config.Config{
+				Upstreams: []config.UpstreamConfig{
+					{Name: "stream-format-upstream", URL: upstreamServer.URL, StreamFormat: tt.streamFormat},
+				},
+				UpstreamGroups: []config.UpstreamGroupConfig{
+					{
+						Name:      "stream-format-group",
+						Upstreams: []config.UpstreamRefConfig{{Name: "stream-format-upstream"}},
+					},
+				},
+			}
+			service := NewForwardServices()
+			require.NoError(t, service.Initialize(&config.ForwardConfig{
+				Name:         "stream-format-forward",
+				DefaultGroup: "stream-format-group",
+			}, globalConfig, &logger))
+			defer service.Stop()
+
+			router := gin.New()
+			service.RegisterGroup(router.Group("/"))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(`{"stream": true}`)))
+			assert.Equal(t, http.StatusOK, w.Code)
+			tt.check(t, w.Body.String())
+		})
+	}
+}
+
+// TestForwardService_WeightedLeastRequest 测试加权最少请求策略在请求完成后释放进行中的请求
+func TestForwardService_WeightedLeastRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer upstreamServer.Close()
+
+	forwardConfig := &config.ForwardConfig{
+		Name:         "least-request-forward",
+		DefaultGroup: "test-group",
+	}
+	heavy, light := 3, 1
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "upstream-a", URL: upstreamServer.URL},
+			{Name: "upstream-b", URL: upstreamServer.URL},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name:    "test-group",
+				Balance: &config.BalanceConfig{Strategy: constants.BalanceWeightedLeastRequest},
+				Upstreams: []config.UpstreamRefConfig{
+					{Name: "upstream-a", Weight: &heavy},
+					{Name: "upstream-b", Weight: &light},
+				},
+			},
+		},
+	}
+
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(forwardConfig, globalConfig, &logger))
+	defer service.Stop()
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+
+	balancer, ok := service.groups[0].loadBalancer.(*balance.WeightedLeastRequestBalancer)
+	require.True(t, ok)
+
+	for i := 0; i < 10; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewBufferString(`{"model": "test"}`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// 请求完成后进行中的请求全部释放，从空闲状态开始权重 3:1 的上游依次被选中 a、a、a、b
+	var selected []string
+	for i := 0; i < 4; i++ {
+		upstream, err := balancer.Select(context.Background(), service.groups[0].upstreams)
+		require.NoError(t, err)
+		selected = append(selected, upstream.Name)
+	}
+	assert.Equal(t, []string{"upstream-a", "upstream-a", "upstream-a", "upstream-b"}, selected)
+	for _, name := range selected {
+		balancer.Release(name)
+	}
+}
+
+// newIPHashTestService 创建使用 iphash 策略的转发服务，响应通过调试头部标识处理请求的上游
+func newIPHashTestService(t *testing.T, name string, seed int64, upstreamURL string) *gin.Engine {
+	t.Helper()
+	logger := logr.Discard()
+
+	forwardConfig := &config.ForwardConfig{
+		Name:         name,
+		DefaultGroup: "iphash-group",
+		DebugHeaders: true,
+	}
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "upstream-a", URL: upstreamURL},
+			{Name: "upstream-b", URL: upstreamURL},
+			{Name: "upstream-c", URL: upstreamURL},
+			{Name: "upstream-d", URL: upstreamURL},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name:    "iphash-group",
+				Balance: &config.BalanceConfig{Strategy: constants.BalanceIPHash, Seed: seed},
+				Upstreams: []config.UpstreamRefConfig{
+					{Name: "upstream-a"}, {Name: "upstream-b"}, {Name: "upstream-c"}, {Name: "upstream-d"},
+				},
+			},
+		},
+	}
+
+	service := NewForwardServices()
+	require.NoError(t, service.Initialize(forwardConfig, globalConfig, &logger))
+	t.Cleanup(service.Stop)
+
+	router := gin.New()
+	service.RegisterGroup(router.Group("/"))
+	return router
+}
+
+// sendFromClient 以指定客户端 IP 发送请求，返回处理请求的上游名称
+func sendFromClient(t *testing.T, router *gin.Engine, clientIP string) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewBufferString(`{"model": "test"}`))
+	req.RemoteAddr = clientIP + ":12345"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	return w.Header().Get(constants.HeaderLLMProxyUpstream)
+}
+
+// TestForwardService_IPHashClientIP 测试 iphash 策略通过请求处理流程获取客户端 IP，同一客户端的请求发送到同一上游
+func TestForwardService_IPHashClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer upstreamServer.Close()
+
+	router := newIPHashTestService(t, "iphash-forward", 0, upstreamServer.URL)
+
+	seen := make(map[string]struct{})
+	for i := 1; i <= 16; i++ {
+		clientIP := fmt.Sprintf("10.0.0.%d", i)
+		first := sendFromClient(t, router, clientIP)
+		require.NotEmpty(t, first)
+		seen[first] = struct{}{}
+
+		// 随机降级选择下同一客户端的连续请求几乎不可能全部落在同一上游
+		for j := 0; j < 5; j++ {
+			assert.Equal(t, first, sendFromClient(t, router, clientIP), clientIP)
+		}
+	}
+	assert.Greater(t, len(seen), 1)
+}
+
+// TestForwardService_IPHashSeed 测试使用相同种子的两个实例对同一客户端选择相同的上游
+func TestForwardService_IPHashSeed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	metrics.GetGlobalRegistry().Clear()
+	defer metrics.GetGlobalRegistry().Clear()
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer upstreamServer.Close()
+
+	// 模拟两个副本
+	replicaA := newIPHashTestService(t, "iphash-replica-a", 42, upstreamServer.URL)
+	replicaB := newIPHashTestService(t, "iphash-replica-b", 42, upstreamServer.URL)
+
+	for i := 1; i <= 16; i++ {
+		clientIP := fmt.Sprintf("192.168.1.%d", i)
+		assert.Equal(t, sendFromClient(t, replicaA, clientIP), sendFromClient(t, replicaB, clientIP), clientIP)
+	}
+}
+
+// TestForwardService_NoUpstreamBehavior 测试上游组没有可选择的上游时按配置返回错误响应、静态响应或转发到备用地址
+func TestForwardService_NoUpstreamBehavior(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logr.Discard()
+
+	var fallbackPath string
+	fallbackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallbackPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"from": "fallback"}`))
+	}))
+	defer fallbackServer.Close()
+
+	tests := []struct {
+		name        string
+		behavior    *config.NoUpstreamBehaviorConfig
+		prepare     func(g *upstreamGroup)
+		statusCode  int
+		contentType string
+		body        string
+	}{
+		{
+			name:       "error",
+			behavior:   &config.NoUpstreamBehaviorConfig{Mode: constants.NoUpstreamModeError},
+			prepare:    func(g *upstreamGroup) { g.upstreams = []balance.Upstream{} },
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "static",
+			behavior: &config.NoUpstreamBehaviorConfig{
+				Mode: constants.NoUpstreamModeStatic,
+				StaticResponse: &config.StaticResponseConfig{
+					StatusCode:  http.StatusOK,
+					Body:        []byte(`{"message": "service paused"}`),
+					ContentType: "application/problem+json",
+				},
+			},
+			prepare:     func(g *upstreamGroup) { g.upstreams = []balance.Upstream{} },
+			statusCode:  http.StatusOK,
+			contentType: "application/problem+json",
+			body:        `{"message": "service paused"}`,
+		},
+		{
+			name: "static with default status code",
+			behavior: &config.NoUpstreamBehaviorConfig{
+				Mode:           constants.NoUpstreamModeStatic,
+				StaticResponse: &config.StaticResponseConfig{Body: []byte(`{"message": "unavailable"}`)},
+			},
+			prepare: func(g *upstreamGroup) {
+				for i := range g.upstreams {
+					g.upstreams[i].Breaker = &openBreaker{}
+				}
+			},
+			statusCode:  http.StatusServiceUnavailable,
+			contentType: "application/json",
+			body:        `{"message": "unavailable"}`,
+		},
+		{
+			name:        "fallback",
+			behavior:    &config.NoUpstreamBehaviorConfig{Mode: constants.NoUpstreamModeFallback, FallbackURL: fallbackServer.URL},
+			prepare:     func(g *upstreamGroup) { g.upstreams = []balance.Upstream{} },
+			statusCode:  http.StatusOK,
+			contentType: "application/json",
+			body:        `{"from": "fallback"}`,
+		},
+		{
+			name:     "fallback when all upstreams unhealthy",
+			behavior: &config.NoUpstreamBehaviorConfig{Mode: constants.NoUpstreamModeFallback, FallbackURL: fallbackServer.URL},
+			prepare: func(g *upstreamGroup) {
+				for i := range g.upstreams {
+					g.upstreams[i].Breaker = &openBreaker{}
+				}
+			},
+			statusCode:  http.StatusOK,
+			contentType: "application/json",
+			body:        `{"from": "fallback"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fallbackPath = ""
+			globalConfig := &config.Config{
+				Upstreams: []config.UpstreamConfig{
+					{Name: "primary", URL: "http://127.0.0.1:1"},
+				},
+				UpstreamGroups: []config.UpstreamGroupConfig{
+					{Name: "test-group", Upstreams: []config.UpstreamRefConfig{{Name: "primary"}}},
+				},
+			}
+			service := NewForwardServices()
+			require.NoError(t, service.Initialize(&config.ForwardConfig{
+				Name:               "no-upstream-forward",
+				DefaultGroup:       "test-group",
+				NoUpstreamBehavior: tt.behavior,
+			}, globalConfig, &logger))
+			defer service.Stop()
+			tt.prepare(service.groups[0])
+
+			router := gin.New()
+			service.RegisterGroup(router.Group("/"))
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewBufferString(`{"model": "test"}`))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.statusCode, w.Code)
+			if tt.body != "" {
+				assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+				assert.JSONEq(t, tt.body, w.Body.String())
+			}
+			if tt.behavior.Mode == constants.NoUpstreamModeFallback {
+				assert.Equal(t, "/v1/chat/completions", fallbackPath)
+			} else {
+				assert.Empty(t, fallbackPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// InFlightRequests 获取所有转发服务进行中的请求总数
+func (s *Server) InFlightRequests() int64 {
+	var total int64
+	for _, forwardServer := range s.GetForwardServers() {
+		total += forwardServer.GetService().InFlightRequests()
+	}
+	return total
+}
+
+// Drain 停止接收新的转发请求并等待进行中的请求完成，期间周期性输出剩余请求数
+// ctx: 控制最长等待时间，取消后立即返回
+// 返回进行中的请求是否已全部完成
+func (s *Server) Drain(ctx context.Context) bool {
+	for _, forwardServer := range s.GetForwardServers() {
+		forwardServer.GetService().BeginDrain()
+	}
+
+	pollTicker := time.NewTicker(time.Duration(constants.DefaultDrainPollInterval) * time.Millisecond)
+	defer pollTicker.Stop()
+	progressTicker := time.NewTicker(time.Duration(constants.DefaultDrainProgressInterval) * time.Millisecond)
+	defer progressTicker.Stop()
+
+	startTime := time.Now()
+	s.logger.Info("Draining in-flight requests", "in_flight", s.InFlightRequests())
+
+	for {
+		remaining := s.InFlightRequests()
+		if remaining == 0 {
+			s.logger.Info("All in-flight requests drained", "duration_ms", time.Since(startTime).Milliseconds())
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Stopped waiting for in-flight requests",
+				"in_flight", remaining,
+				"duration_ms", time.Since(startTime).Milliseconds(),
+				"reason", ctx.Err().Error())
+			return false
+		case <-progressTicker.C:
+			s.logger.Info("Waiting for in-flight requests", "in_flight", remaining)
+		case <-pollTicker.C:
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestServer_Drain 测试排空期间拒绝新请求并等待进行中的请求完成
+func TestServer_Drain(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 清理全局注册器
+	globalRegistry := metrics.GetGlobalRegistry()
+	globalRegistry.Clear()
+	defer globalRegistry.Clear()
+
+	var upstreamCalls atomic.Int64
+	release := make(chan struct{})
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls.Add(1)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstreamServer.Close()
+
+	forwardConfig := &config.ForwardConfig{
+		Name:         "drain-forward",
+		DefaultGroup: "drain-group",
+	}
+	globalConfig := &config.Config{
+		Upstreams: []config.UpstreamConfig{
+			{Name: "drain-upstream", URL: upstreamServer.URL},
+		},
+		UpstreamGroups: []config.UpstreamGroupConfig{
+			{
+				Name:      "drain-group",
+				Upstreams: []config.UpstreamRefConfig{{Name: "drain-upstream"}},
+			},
+		},
+	}
+
+	logger := logr.Discard()
+	forwardService := NewForwardServices()
+	require.NoError(t, forwardService.Initialize(forwardConfig, globalConfig, &logger))
+	defer forwardService.Stop()
+
+	router := gin.New()
+	forwardService.RegisterGroup(router.Group("/"))
+
+	srv := &Server{
+		forwardServers: map[string]*ForwardServer{forwardConfig.Name: {name: forwardConfig.Name, service: forwardService}},
+		logger:         &logger,
+	}
+
+	sendRequest := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model": "test"}`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("timeout with requests in flight", func(t *testing.T) {
+		const concurrency = 2
+		var wg sync.WaitGroup
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.Equal(t, http.StatusOK, sendRequest().Code)
+			}()
+		}
+		require.Eventually(t, func() bool { return upstreamCalls.Load() == concurrency }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, int64(concurrency), srv.InFlightRequests())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		assert.False(t, srv.Drain(ctx))
+
+		// 排空期间的新请求直接返回 503，不转发到上游
+		w := sendRequest()
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "close", w.Header().Get("Connection"))
+		assert.Equal(t, int64(concurrency), upstreamCalls.Load())
+
+		close(release)
+		wg.Wait()
+	})
+
+	t.Run("drained", func(t *testing.T) {
+		assert.Equal(t, int64(0), srv.InFlightRequests())
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		assert.True(t, srv.Drain(ctx))
+	})
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// streamFlushJitterDivisor 刷新间隔的抖动比例（1/10），避免大量流在同一时刻刷新
+const streamFlushJitterDivisor = 10
+
+// streamFlushWriter 按间隔合并刷新的流式响应写入器
+// 间隔为 0 时每次写入后立即刷新；否则首次写入后启动定时器，在间隔内合并后续写入，到期后统一刷新
+// 写入和定时刷新通过互斥锁串行执行，避免并发访问底层响应写入器
+type streamFlushWriter struct {
+	mu       sync.Mutex
+	writer   io.Writer
+	flusher  http.Flusher
+	interval time.Duration
+	timer    *time.Timer
+	pending  bool // 是否有尚未刷新的数据
+	stopped  bool // 是否已停止，停止后定时器不再刷新
+}
+
+// newStreamFlushWriter 创建按间隔合并刷新的流式响应写入器
+// writer: 实际写入的目标
+// flusher: 刷新响应的写入器
+// interval: 最大刷新间隔，为 0 时每次写入后立即刷新
+func newStreamFlushWriter(writer io.Writer, flusher http.Flusher, interval time.Duration) *streamFlushWriter {
+	return &streamFlushWriter{
+		writer:   writer,
+		flusher:  flusher,
+		interval: interval,
+	}
+}
+
+// Write 写入数据并按刷新间隔安排刷新
+func (w *streamFlushWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n, err := w.writer.Write(p)
+	if err != nil || w.stopped {
+		return n, err
+	}
+
+	if w.interval <= 0 {
+		w.flusher.Flush()
+		return n, nil
+	}
+	if !w.pending {
+		w.pending = true
+		w.timer = time.AfterFunc(w.jitteredInterval(), w.flushPending)
+	}
+	return n, nil
+}
+
+// jitteredInterval 获取带抖动的刷新间隔，抖动只缩短间隔，保证刷新延迟不超过配置值
+func (w *streamFlushWriter) jitteredInterval() time.Duration {
+	jitter := int64(w.interval) / streamFlushJitterDivisor
+	if jitter <= 0 {
+		return w.interval
+	}
+	return w.interval - time.Duration(rand.Int64N(jitter))
+}
+
+// flushPending 定时器到期时刷新合并的数据
+func (w *streamFlushWriter) flushPending() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped || !w.pending {
+		return
+	}
+	w.pending = false
+	w.flusher.Flush()
+}
+
+// Stop 停止定时刷新，必须在流式响应结束、处理器返回之前调用
+// 剩余的数据由 HTTP 服务器在请求结束时写出
+func (w *streamFlushWriter) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.stopped = true
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+}
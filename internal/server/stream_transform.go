@@ -0,0 +1,89 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/balance"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/shengyanli1982/llmproxy-go/internal/transform"
+)
+
+// streamTransformer 根据上游配置的 streamFormat 创建流式响应的 SSE 事件转换器
+// 未配置、passthrough、非 SSE 或已编码的响应返回 nil，保持原样转发
+// 转换后的响应长度会变化，因此移除上游的 Content-Length
+func streamTransformer(resp *http.Response, upstream *balance.Upstream) transform.StreamTransformer {
+	if upstream.Config == nil {
+		return nil
+	}
+	if resp.Header.Get(constants.HeaderContentEncoding) != "" ||
+		!strings.Contains(resp.Header.Get(constants.HeaderContentType), constants.ContentTypeEventStream) {
+		return nil
+	}
+
+	transformer := transform.NewStreamTransformer(upstream.Config.StreamFormat)
+	if transformer != nil {
+		resp.Header.Del(constants.HeaderContentLength)
+		resp.ContentLength = -1
+	}
+	return transformer
+}
+
+// forwardTransformedStream 逐个读取上游 SSE 事件，转换后写出
+// 无法解析或转换器不处理的事件原样写出，返回值与 forwardStreamingResponse 一致
+func (s *ForwardService) forwardTransformedStream(ctx context.Context, w io.Writer, body io.Reader, transformer transform.StreamTransformer) error {
+	reader := bufio.NewReader(body)
+	var event bytes.Buffer
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		event.Write(line)
+
+		// 空行结束一个事件，上游结束时输出剩余的不完整事件
+		if isBlankLine(line) || (err != nil && event.Len() > 0) {
+			if writeErr := s.writeStreamEvent(w, event.Bytes(), transformer); writeErr != nil {
+				s.logger.Error(writeErr, "Failed to write streaming response")
+				return nil
+			}
+			event.Reset()
+		}
+
+		if err != nil {
+			if err != io.EOF {
+				s.logger.Error(err, "Error reading streaming response")
+				return err
+			}
+			return nil
+		}
+	}
+}
+
+// writeStreamEvent 写出转换后的事件，转换失败时原样写出该事件
+func (s *ForwardService) writeStreamEvent(w io.Writer, raw []byte, transformer transform.StreamTransformer) error {
+	transformed, err := transform.TransformStreamEvent(transformer, raw)
+	if err != nil {
+		if !errors.Is(err, transform.ErrUnsupportedEvent) {
+			s.logger.V(1).Info("Failed to transform stream event, forwarding unchanged", "error", err.Error())
+		}
+		transformed = raw
+	}
+	if len(transformed) == 0 {
+		return nil
+	}
+
+	_, err = w.Write(transformed)
+	return err
+}
+
+// isBlankLine 判断是否为 SSE 事件之间的空行
+func isBlankLine(line []byte) bool {
+	return len(line) > 0 && len(bytes.TrimRight(line, "\r\n")) == 0
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// groupTopology 代表转发服务引用的一个上游组及其上游
+type groupTopology struct {
+	Name      string             // 上游组名称
+	Weight    int                // 上游组在转发服务内的流量权重
+	Balancer  string             // 组内负载均衡策略
+	Upstreams []upstreamTopology // 组内上游，按配置顺序
+}
+
+// upstreamTopology 代表上游组内的一个上游
+type upstreamTopology struct {
+	Name     string // 上游名称
+	URL      string // 隐藏凭据后的上游 URL
+	Weight   int    // 组内权重
+	Standby  bool   // 是否为备用上游
+	Disabled bool   // 是否已停用
+}
+
+// String 格式化为 "名称 URL weight=权重"，备用上游追加 standby，停用的上游追加 disabled，便于在文本和 JSON 日志中阅读
+func (u upstreamTopology) String() string {
+	s := fmt.Sprintf("%s %s weight=%d", u.Name, u.URL, u.Weight)
+	if u.Standby {
+		s += " standby"
+	}
+	if u.Disabled {
+		s += " disabled"
+	}
+	return s
+}
+
+// Topology 获取转发服务引用的上游组及组内上游，顺序与配置一致
+func (s *ForwardService) Topology() []groupTopology {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	groups := make([]groupTopology, 0, len(s.groups))
+	for i, g := range s.groups {
+		group := groupTopology{
+			Name:      g.name,
+			Upstreams: make([]upstreamTopology, 0, len(g.upstreams)),
+		}
+		if i < len(s.groupRefs) {
+			group.Weight = s.groupRefs[i].Weight
+		}
+		if g.loadBalancer != nil {
+			group.Balancer = g.loadBalancer.Type()
+		}
+		for _, upstream := range g.upstreams {
+			group.Upstreams = append(group.Upstreams, upstreamTopology{
+				Name:     upstream.Name,
+				URL:      sanitizeURL(upstream.URL),
+				Weight:   upstream.Weight,
+				Standby:  upstream.Standby,
+				Disabled: g.isUpstreamDisabled(upstream.Name),
+			})
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// LogTopology 输出生效的路由拓扑：每个转发服务的监听地址、引用的上游组，以及组内上游的名称、URL 和权重
+// 应在 Start 之后调用，以输出运行时分配的实际监听地址；上游 URL 中的用户信息和查询参数值会被隐藏
+func (s *Server) LogTopology() {
+	forwardServers := s.GetForwardServers()
+	sort.Slice(forwardServers, func(i, j int) bool {
+		return forwardServers[i].name < forwardServers[j].name
+	})
+
+	if s.adminServer != nil {
+		s.logger.Info("Admin server topology", "endpoint", s.adminServer.GetEndpoint())
+	}
+
+	for _, forwardServer := range forwardServers {
+		if forwardServer.service == nil {
+			continue
+		}
+		groups := forwardServer.service.Topology()
+
+		groupNames := make([]string, 0, len(groups))
+		for _, group := range groups {
+			groupNames = append(groupNames, fmt.Sprintf("%s weight=%d", group.Name, group.Weight))
+		}
+		s.logger.Info("Forward server topology",
+			"forward", forwardServer.name,
+			"endpoints", forwardServer.GetEndpoints(),
+			"groups", groupNames)
+
+		for _, group := range groups {
+			upstreams := make([]string, 0, len(group.Upstreams))
+			for _, upstream := range group.Upstreams {
+				upstreams = append(upstreams, upstream.String())
+			}
+			s.logger.Info("Upstream group topology",
+				"forward", forwardServer.name,
+				"group", group.Name,
+				"balancer", group.Balancer,
+				"upstreams", upstreams)
+		}
+	}
+}
+
+// sanitizeURL 隐藏 URL 中的凭据，移除用户信息并替换查询参数值，无法解析时只保留提示文本
+func sanitizeURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return constants.RedactedValue
+	}
+
+	u.User = nil
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			query[key] = []string{constants.RedactedValue}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
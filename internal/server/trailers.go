@@ -0,0 +1,26 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// announceTrailers 在写出响应头部前通过 Trailer 头部声明上游的 trailer 名称
+// 声明后在响应体之后写入的同名头部会作为 trailer 发送给客户端
+func announceTrailers(header http.Header, trailer http.Header) {
+	for name := range trailer {
+		header.Add(constants.HeaderTrailer, name)
+	}
+}
+
+// copyTrailers 在响应体写出后复制上游 trailer 的值
+// trailer 必须已通过 announceTrailers 声明，未声明的值会被 HTTP 服务器忽略
+func copyTrailers(header http.Header, trailer http.Header) {
+	for name, values := range trailer {
+		header.Del(name)
+		for _, value := range values {
+			header.Add(name, value)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// parseTrustedProxies 解析可信代理列表，支持单个 IP 和 CIDR 网段
+// 未配置时返回 nil，表示信任所有来源的 X-Forwarded-* 头部
+func parseTrustedProxies(entries []string) ([]*net.IPNet, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	networks := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy '%s': %w", entry, err)
+			}
+			networks = append(networks, network)
+			continue
+		}
+
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid trusted proxy '%s'", entry)
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return networks, nil
+}
+
+// isTrustedProxy 判断地址是否为可信代理，未配置可信代理时信任所有地址
+func (s *ForwardService) isTrustedProxy(addr string) bool {
+	if s.trustedProxies == nil {
+		return true
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(addr))
+	if ip == nil {
+		return false
+	}
+	for _, network := range s.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP 获取直接连接的对端 IP
+func remoteIP(req *http.Request) string {
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return host
+	}
+	return req.RemoteAddr
+}
+
+// isFromTrustedProxy 判断请求是否来自可信代理，只有可信代理设置的 X-Forwarded-* 头部才会被采信
+func (s *ForwardService) isFromTrustedProxy(req *http.Request) bool {
+	return s.isTrustedProxy(remoteIP(req))
+}
+
+// forwardedFor 生成转发给上游的 X-Forwarded-For 头部
+// 来自可信代理的请求在已有链路后追加对端 IP，否则丢弃客户端提供的值，仅保留对端 IP
+func (s *ForwardService) forwardedFor(req *http.Request) string {
+	peer := remoteIP(req)
+	if xff := req.Header.Get(constants.HeaderXForwardedFor); xff != "" && s.isFromTrustedProxy(req) {
+		return xff + ", " + peer
+	}
+	return peer
+}
+
+// firstHeaderValue 获取逗号分隔头部的第一个值
+func firstHeaderValue(value string) string {
+	if idx := strings.Index(value, ","); idx >= 0 {
+		value = value[:idx]
+	}
+	return strings.TrimSpace(value)
+}
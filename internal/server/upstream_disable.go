@@ -0,0 +1,129 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/balance"
+)
+
+// selectableUpstreams 获取参与负载均衡的上游列表，排除停用的上游
+// 没有停用的上游时直接返回完整的上游列表
+func (g *upstreamGroup) selectableUpstreams() []balance.Upstream {
+	if selectable := g.selectable.Load(); selectable != nil {
+		return *selectable
+	}
+	return g.upstreams
+}
+
+// isUpstreamDisabled 判断上游是否已停用
+func (g *upstreamGroup) isUpstreamDisabled(name string) bool {
+	g.disabledMu.Lock()
+	defer g.disabledMu.Unlock()
+
+	_, disabled := g.disabled[name]
+	return disabled
+}
+
+// lastEnabledUpstream 判断上游是否为组内唯一未停用的上游，上游不属于该组时返回 false
+func (g *upstreamGroup) lastEnabledUpstream(name string) bool {
+	g.disabledMu.Lock()
+	defer g.disabledMu.Unlock()
+
+	found := false
+	for _, upstream := range g.upstreams {
+		if _, disabled := g.disabled[upstream.Name]; disabled {
+			continue
+		}
+		if upstream.Name != name {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// setUpstreamDisabled 设置上游的停用状态并重建参与负载均衡的上游列表，返回上游是否属于该组
+func (g *upstreamGroup) setUpstreamDisabled(name string, disabled bool) bool {
+	g.disabledMu.Lock()
+	defer g.disabledMu.Unlock()
+
+	found := false
+	for _, upstream := range g.upstreams {
+		if upstream.Name == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false
+	}
+
+	if g.disabled == nil {
+		g.disabled = make(map[string]struct{})
+	}
+	if disabled {
+		g.disabled[name] = struct{}{}
+	} else {
+		delete(g.disabled, name)
+	}
+	g.rebuildSelectable()
+	return true
+}
+
+// rebuildSelectable 按停用状态重建参与负载均衡的上游列表，调用方必须持有 disabledMu
+// 请求处理过程中读取的列表不会被修改，变更时整体替换
+func (g *upstreamGroup) rebuildSelectable() {
+	if len(g.disabled) == 0 {
+		g.selectable.Store(nil)
+		return
+	}
+
+	selectable := make([]balance.Upstream, 0, len(g.upstreams))
+	for _, upstream := range g.upstreams {
+		if _, disabled := g.disabled[upstream.Name]; !disabled {
+			selectable = append(selectable, upstream)
+		}
+	}
+	g.selectable.Store(&selectable)
+}
+
+// CheckDisableUpstream 检查停用上游是否会使引用它的某个上游组没有可用的上游
+func (s *ForwardService) CheckDisableUpstream(name string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, g := range s.groups {
+		if g.lastEnabledUpstream(name) {
+			return fmt.Errorf("upstream %s in group %s: %w", name, g.name, ErrLastEnabledUpstream)
+		}
+	}
+	return nil
+}
+
+// SetUpstreamDisabled 在引用该上游的所有上游组中设置上游的停用状态，返回是否有上游组引用该上游
+// 停用的上游不参与负载均衡，也不能通过 X-LLMProxy-Upstream 头部指定，进行中的请求不受影响
+func (s *ForwardService) SetUpstreamDisabled(name string, disabled bool) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	found := false
+	for _, g := range s.groups {
+		if g.setUpstreamDisabled(name, disabled) {
+			found = true
+		}
+	}
+	return found
+}
+
+// IsUpstreamDisabled 判断上游是否在引用它的任一上游组中被停用
+func (s *ForwardService) IsUpstreamDisabled(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, g := range s.groups {
+		if g.isUpstreamDisabled(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/balance"
+	"github.com/shengyanli1982/llmproxy-go/internal/client"
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// upstreamGroup 代表转发服务引用的上游组运行时数据，每个上游组独立负载均衡
+type upstreamGroup struct {
+	name              string                          // 上游组名称
+	loadBalancer      balance.LoadBalancer            // 组内负载均衡器
+	httpClient        client.HTTPClient               // 组内共享的HTTP客户端
+	upstreams         []balance.Upstream              // 上游服务列表
+	upstreamHealth    map[string]*upstreamHealthStats // 按上游名称索引的近期请求统计
+	warmupConnections int                             // 每个上游预热的连接数，0 表示不预热
+	requestTimeout    time.Duration                   // 组内HTTP客户端的请求超时时间，客户端指定的超时时间不超过该值
+
+	preserveClientHeaders bool // 是否保留客户端原始的 X-Forwarded-Host，不注入代理接收到的主机名
+
+	disabledMu sync.Mutex                         // 保护 disabled，串行化停用状态变更
+	disabled   map[string]struct{}                // 停用的上游名称
+	selectable atomic.Pointer[[]balance.Upstream] // 参与负载均衡的上游列表，没有停用的上游时为 nil，表示使用完整的上游列表
+}
+
+// initializeUpstreamGroups 初始化转发服务引用的所有上游组
+// 配置了 groups 时按权重在多个上游组之间分配流量，否则只使用 defaultGroup
+func (s *ForwardService) initializeUpstreamGroups(cfg *config.ForwardConfig, globalConfig *config.Config) error {
+	groupRefs := cfg.Groups
+	if len(groupRefs) == 0 {
+		groupRefs = []config.GroupRefConfig{{Name: cfg.DefaultGroup, Weight: constants.DefaultWeight}}
+	}
+
+	groupConfigs := make(map[string]*config.UpstreamGroupConfig, len(globalConfig.UpstreamGroups))
+	for i := range globalConfig.UpstreamGroups {
+		groupConfigs[globalConfig.UpstreamGroups[i].Name] = &globalConfig.UpstreamGroups[i]
+	}
+
+	s.groups = make([]*upstreamGroup, 0, len(groupRefs))
+	s.groupRefs = make([]balance.Upstream, 0, len(groupRefs))
+
+	for _, groupRef := range groupRefs {
+		groupConfig, exists := groupConfigs[groupRef.Name]
+		if !exists {
+			if len(cfg.Groups) == 0 {
+				s.logger.Error(nil, "Default upstream group not found", "group_name", groupRef.Name)
+				return fmt.Errorf("default upstream group '%s' not found", groupRef.Name)
+			}
+			s.logger.Error(nil, "Upstream group not found", "group_name", groupRef.Name)
+			return fmt.Errorf("upstream group '%s' not found", groupRef.Name)
+		}
+
+		g, err := s.initializeUpstreamGroup(groupConfig, globalConfig)
+		if err != nil {
+			return err
+		}
+
+		weight := groupRef.Weight
+		if weight <= 0 {
+			weight = constants.DefaultWeight
+		}
+
+		s.groups = append(s.groups, g)
+		s.groupRefs = append(s.groupRefs, balance.Upstream{Name: g.name, Weight: weight})
+
+		s.logger.Info("Upstream group initialized",
+			"group_name", g.name,
+			"weight", weight,
+			"upstream_count", len(g.upstreams),
+			"load_balancer_type", g.loadBalancer.Type())
+	}
+
+	s.groupBalancer = balance.NewWeightedRRBalancer()
+	return nil
+}
+
+// initializeUpstreamGroup 构建单个上游组的上游列表、负载均衡器、HTTP客户端和熔断器
+func (s *ForwardService) initializeUpstreamGroup(groupConfig *config.UpstreamGroupConfig, globalConfig *config.Config) (*upstreamGroup, error) {
+	g := &upstreamGroup{name: groupConfig.Name}
+
+	// 构建上游服务列表
+	if err := s.buildUpstreams(g, groupConfig, globalConfig); err != nil {
+		s.logger.Error(err, "Failed to build upstreams", "group_name", g.name)
+		return nil, fmt.Errorf("failed to build upstreams: %w", err)
+	}
+
+	// 创建负载均衡器
+	if err := s.createLoadBalancer(g, groupConfig); err != nil {
+		s.logger.Error(err, "Failed to create load balancer", "group_name", g.name)
+		return nil, fmt.Errorf("failed to create load balancer: %w", err)
+	}
+
+	// 创建HTTP客户端
+	if err := s.createHttpClient(g, groupConfig); err != nil {
+		s.logger.Error(err, "Failed to create HTTP client", "group_name", g.name)
+		return nil, fmt.Errorf("failed to create http client: %w", err)
+	}
+
+	// 初始化负载均衡器熔断器
+	if err := s.initializeCircuitBreakers(g); err != nil {
+		s.logger.Error(err, "Failed to initialize circuit breakers", "group_name", g.name)
+		return nil, fmt.Errorf("failed to initialize circuit breakers: %w", err)
+	}
+
+	return g, nil
+}
+
+// selectGroup 按权重选择处理请求的上游组，只引用一个上游组时直接返回
+func (s *ForwardService) selectGroup(ctx context.Context) *upstreamGroup {
+	if len(s.groups) == 1 {
+		return s.groups[0]
+	}
+
+	selected, err := s.groupBalancer.Select(ctx, s.groupRefs)
+	if err == nil {
+		for _, g := range s.groups {
+			if g.name == selected.Name {
+				return g
+			}
+		}
+	}
+	return s.groups[0]
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/balance"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// upstreamOverride 获取客户端通过 X-LLMProxy-Upstream 头部指定的上游及其所属的上游组
+// 优先使用按权重选中的上游组，指定的上游不在其中时在转发服务的其他上游组中查找
+// 未启用或未携带该头部时返回 false，指定的上游不存在、不属于转发服务的任何上游组或已停用时返回错误
+func (s *ForwardService) upstreamOverride(req *http.Request, selected *upstreamGroup) (*upstreamGroup, balance.Upstream, bool, error) {
+	if s.config == nil || !s.config.AllowUpstreamOverride {
+		return selected, balance.Upstream{}, false, nil
+	}
+
+	name := req.Header.Get(constants.HeaderLLMProxyUpstream)
+	if name == "" {
+		return selected, balance.Upstream{}, false, nil
+	}
+
+	disabled := false
+	groups := append([]*upstreamGroup{selected}, s.groups...)
+	for _, group := range groups {
+		for _, upstream := range group.upstreams {
+			if upstream.Name != name {
+				continue
+			}
+			// 停用的上游不能通过头部指定
+			if group.isUpstreamDisabled(name) {
+				disabled = true
+				break
+			}
+			return group, upstream, true, nil
+		}
+	}
+	if disabled {
+		return selected, balance.Upstream{}, false, fmt.Errorf("upstream %s: %w", name, ErrUpstreamOverrideDisabled)
+	}
+
+	if s.globalConfig != nil {
+		for i := range s.globalConfig.Upstreams {
+			if s.globalConfig.Upstreams[i].Name == name {
+				return selected, balance.Upstream{}, false, fmt.Errorf("upstream %s is not in any group of forward %s: %w", name, s.config.Name, ErrUpstreamOverrideNotInGroup)
+			}
+		}
+	}
+	return selected, balance.Upstream{}, false, fmt.Errorf("upstream %s: %w", name, ErrUpstreamOverrideNotFound)
+}
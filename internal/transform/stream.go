@@ -0,0 +1,126 @@
+package transform
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// 流式事件转换相关错误定义
+var (
+	// ErrUnsupportedEvent 转换器不处理该事件，调用方应原样转发
+	ErrUnsupportedEvent = errors.New("unsupported stream event")
+)
+
+// StreamEvent 代表一个 SSE 事件
+type StreamEvent struct {
+	Name string // event 字段，为空时不输出
+	Data []byte // data 字段，多个 data 行以换行符连接
+}
+
+// StreamTransformer 代表流式响应的 SSE 事件转换器，每个流式响应使用独立的实例，可在事件之间保存状态
+type StreamTransformer interface {
+	// Transform 转换单个事件，返回需要输出的事件，可以为空
+	// 返回错误时调用方原样转发该事件
+	Transform(event *StreamEvent) ([]StreamEvent, error)
+}
+
+// StreamTransformerFactory 创建流式事件转换器
+type StreamTransformerFactory func() StreamTransformer
+
+var (
+	streamTransformersMu sync.RWMutex
+	streamTransformers   = map[string]StreamTransformerFactory{
+		constants.StreamFormatOpenAI:    func() StreamTransformer { return newOpenAIStreamTransformer() },
+		constants.StreamFormatAnthropic: func() StreamTransformer { return newAnthropicStreamTransformer() },
+	}
+)
+
+// RegisterStreamTransformer 注册流式响应格式对应的事件转换器，已存在时覆盖
+// format: 流式响应格式，与 upstreams[].streamFormat 配置一致
+// factory: 为每个流式响应创建转换器
+func RegisterStreamTransformer(format string, factory StreamTransformerFactory) {
+	streamTransformersMu.Lock()
+	defer streamTransformersMu.Unlock()
+	streamTransformers[format] = factory
+}
+
+// NewStreamTransformer 创建指定流式响应格式的事件转换器，passthrough 或未注册的格式返回 nil
+func NewStreamTransformer(format string) StreamTransformer {
+	if format == "" || format == constants.StreamFormatPassthrough {
+		return nil
+	}
+
+	streamTransformersMu.RLock()
+	factory, ok := streamTransformers[format]
+	streamTransformersMu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return factory()
+}
+
+// TransformStreamEvent 解析原始 SSE 事件并使用转换器转换，返回编码后的事件
+// 没有 data 字段的事件（如注释）、转换器不处理的事件和解析失败的事件返回错误，调用方应原样转发
+// raw: 包含结尾空行的原始事件
+func TransformStreamEvent(transformer StreamTransformer, raw []byte) ([]byte, error) {
+	event, ok := ParseStreamEvent(raw)
+	if !ok {
+		return nil, ErrUnsupportedEvent
+	}
+
+	events, err := transformer.Transform(&event)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for i := range events {
+		events[i].encode(&buf)
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseStreamEvent 解析原始 SSE 事件的 event 和 data 字段，忽略 id、retry 和注释行
+// 没有 data 字段时返回 false
+func ParseStreamEvent(raw []byte) (StreamEvent, bool) {
+	var event StreamEvent
+	var data [][]byte
+	for _, line := range bytes.Split(raw, []byte("\n")) {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		field, value, found := bytes.Cut(line, []byte(":"))
+		if !found || len(field) == 0 {
+			continue
+		}
+		value = bytes.TrimPrefix(value, []byte(" "))
+
+		switch string(field) {
+		case "event":
+			event.Name = string(value)
+		case "data":
+			data = append(data, value)
+		}
+	}
+
+	if data == nil {
+		return event, false
+	}
+	event.Data = bytes.Join(data, []byte("\n"))
+	return event, true
+}
+
+// encode 按 SSE 格式编码事件，包含结尾空行
+func (e *StreamEvent) encode(buf *bytes.Buffer) {
+	if e.Name != "" {
+		fmt.Fprintf(buf, "event: %s\n", e.Name)
+	}
+	for _, line := range bytes.Split(e.Data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+}
@@ -0,0 +1,126 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// anthropicStreamTransformer 将 OpenAI chat.completion.chunk 事件转换为 Anthropic Messages 流式事件
+// 只转换文本内容，工具调用增量被丢弃；[DONE] 转换为 message_stop
+// 不是 chat.completion.chunk 的事件（如已是 Anthropic 格式）返回 ErrUnsupportedEvent，由调用方原样转发
+type anthropicStreamTransformer struct {
+	started   bool // 是否已输出 message_start
+	blockOpen bool // 是否已输出文本内容块的 content_block_start
+	finished  bool // 是否已输出 message_delta
+}
+
+// newAnthropicStreamTransformer 创建 OpenAI 到 Anthropic 的流式事件转换器
+func newAnthropicStreamTransformer() *anthropicStreamTransformer {
+	return &anthropicStreamTransformer{}
+}
+
+// Transform 转换单个 OpenAI 事件
+func (t *anthropicStreamTransformer) Transform(event *StreamEvent) ([]StreamEvent, error) {
+	if string(bytes.TrimSpace(event.Data)) == openAIDoneData {
+		var events []StreamEvent
+		if t.started {
+			events = t.finish("end_turn", 0)
+		}
+		return append(events, anthropicStreamEvent("message_stop", map[string]any{"type": "message_stop"})), nil
+	}
+
+	var chunk openAIChunk
+	if err := json.Unmarshal(event.Data, &chunk); err != nil {
+		return nil, fmt.Errorf("failed to decode openai stream event: %w", err)
+	}
+	if chunk.Object != "chat.completion.chunk" {
+		return nil, ErrUnsupportedEvent
+	}
+
+	var events []StreamEvent
+	if !t.started {
+		t.started = true
+		inputTokens := 0
+		if chunk.Usage != nil {
+			inputTokens = chunk.Usage.PromptTokens
+		}
+		events = append(events, anthropicStreamEvent("message_start", map[string]any{
+			"type": "message_start",
+			"message": map[string]any{
+				"id":            chunk.ID,
+				"type":          "message",
+				"role":          "assistant",
+				"model":         chunk.Model,
+				"content":       []any{},
+				"stop_reason":   nil,
+				"stop_sequence": nil,
+				"usage":         map[string]int{"input_tokens": inputTokens, "output_tokens": 0},
+			},
+		}))
+	}
+
+	for _, choice := range chunk.Choices {
+		if choice.Delta.Content != nil && *choice.Delta.Content != "" && !t.finished {
+			if !t.blockOpen {
+				t.blockOpen = true
+				events = append(events, anthropicStreamEvent("content_block_start", map[string]any{
+					"type":          "content_block_start",
+					"index":         0,
+					"content_block": map[string]string{"type": "text", "text": ""},
+				}))
+			}
+			events = append(events, anthropicStreamEvent("content_block_delta", map[string]any{
+				"type":  "content_block_delta",
+				"index": 0,
+				"delta": map[string]string{"type": "text_delta", "text": *choice.Delta.Content},
+			}))
+		}
+		if choice.FinishReason != nil {
+			outputTokens := 0
+			if chunk.Usage != nil {
+				outputTokens = chunk.Usage.CompletionTokens
+			}
+			events = append(events, t.finish(anthropicStopReason(*choice.FinishReason), outputTokens)...)
+		}
+	}
+	return events, nil
+}
+
+// finish 输出未关闭内容块的 content_block_stop 和 message_delta，只输出一次
+func (t *anthropicStreamTransformer) finish(stopReason string, outputTokens int) []StreamEvent {
+	if t.finished {
+		return nil
+	}
+	t.finished = true
+
+	var events []StreamEvent
+	if t.blockOpen {
+		t.blockOpen = false
+		events = append(events, anthropicStreamEvent("content_block_stop", map[string]any{"type": "content_block_stop", "index": 0}))
+	}
+	return append(events, anthropicStreamEvent("message_delta", map[string]any{
+		"type":  "message_delta",
+		"delta": map[string]any{"stop_reason": stopReason, "stop_sequence": nil},
+		"usage": map[string]int{"output_tokens": outputTokens},
+	}))
+}
+
+// anthropicStreamEvent 创建 Anthropic 事件，事件名称与 data 中的 type 一致
+func anthropicStreamEvent(name string, data map[string]any) StreamEvent {
+	// 事件数据只包含字符串、数字和嵌套映射，编码不会失败
+	encoded, _ := json.Marshal(data)
+	return StreamEvent{Name: name, Data: encoded}
+}
+
+// anthropicStopReason 将 OpenAI 的 finish_reason 映射为 Anthropic 的 stop_reason
+func anthropicStopReason(finishReason string) string {
+	switch finishReason {
+	case "length":
+		return "max_tokens"
+	case "tool_calls", "function_call":
+		return "tool_use"
+	default:
+		return "end_turn"
+	}
+}
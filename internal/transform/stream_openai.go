@@ -0,0 +1,224 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// openAIDoneData OpenAI 流式响应的结束标记
+const openAIDoneData = "[DONE]"
+
+// openAIChunk 代表 OpenAI chat.completion.chunk 事件
+type openAIChunk struct {
+	ID      string              `json:"id"`
+	Object  string              `json:"object"`
+	Created int64               `json:"created"`
+	Model   string              `json:"model"`
+	Choices []openAIChunkChoice `json:"choices"`
+	Usage   *openAIUsage        `json:"usage,omitempty"`
+}
+
+// openAIChunkChoice 代表 chat.completion.chunk 事件中的增量结果
+type openAIChunkChoice struct {
+	Index        int         `json:"index"`
+	Delta        openAIDelta `json:"delta"`
+	FinishReason *string     `json:"finish_reason"`
+}
+
+// openAIDelta 代表增量消息内容
+type openAIDelta struct {
+	Role      string           `json:"role,omitempty"`
+	Content   *string          `json:"content,omitempty"`
+	ToolCalls []openAIToolCall `json:"tool_calls,omitempty"`
+}
+
+// openAIToolCall 代表增量工具调用，arguments 分片输出
+type openAIToolCall struct {
+	Index    int            `json:"index"`
+	ID       string         `json:"id,omitempty"`
+	Type     string         `json:"type,omitempty"`
+	Function openAIFunction `json:"function"`
+}
+
+// openAIFunction 代表工具调用的函数名和参数分片
+type openAIFunction struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+// openAIUsage 代表 token 用量
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// anthropicEvent 代表 Anthropic Messages 流式事件，不同类型的事件使用不同字段
+type anthropicEvent struct {
+	Type         string                 `json:"type"`
+	Index        int                    `json:"index"`
+	Message      *anthropicMessage      `json:"message"`
+	ContentBlock *anthropicContentBlock `json:"content_block"`
+	Delta        *anthropicDelta        `json:"delta"`
+	Usage        *anthropicUsage        `json:"usage"`
+	Error        *anthropicError        `json:"error"`
+}
+
+// anthropicMessage 代表 message_start 事件中的消息
+type anthropicMessage struct {
+	ID    string         `json:"id"`
+	Model string         `json:"model"`
+	Usage anthropicUsage `json:"usage"`
+}
+
+// anthropicContentBlock 代表 content_block_start 事件中的内容块
+type anthropicContentBlock struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// anthropicDelta 代表 content_block_delta 和 message_delta 事件中的增量
+type anthropicDelta struct {
+	Type        string `json:"type"`
+	Text        string `json:"text"`
+	PartialJSON string `json:"partial_json"`
+	StopReason  string `json:"stop_reason"`
+}
+
+// anthropicUsage 代表 token 用量
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// anthropicError 代表 error 事件中的错误
+type anthropicError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// openAIStreamTransformer 将 Anthropic Messages 流式事件转换为 OpenAI chat.completion.chunk 事件
+// 文本和工具调用转换为增量内容，message_stop 转换为 [DONE]；ping、thinking 等没有对应格式的事件被丢弃
+// 不含 type 字段的事件（如已是 OpenAI 格式）返回 ErrUnsupportedEvent，由调用方原样转发
+type openAIStreamTransformer struct {
+	id          string
+	model       string
+	created     int64
+	inputTokens int
+	toolCalls   map[int]int // Anthropic 内容块索引到 OpenAI 工具调用索引的映射
+}
+
+// newOpenAIStreamTransformer 创建 Anthropic 到 OpenAI 的流式事件转换器
+func newOpenAIStreamTransformer() *openAIStreamTransformer {
+	return &openAIStreamTransformer{toolCalls: make(map[int]int)}
+}
+
+// Transform 转换单个 Anthropic 事件
+func (t *openAIStreamTransformer) Transform(event *StreamEvent) ([]StreamEvent, error) {
+	var src anthropicEvent
+	if err := json.Unmarshal(event.Data, &src); err != nil {
+		return nil, fmt.Errorf("failed to decode anthropic stream event: %w", err)
+	}
+
+	switch src.Type {
+	case "message_start":
+		if src.Message != nil {
+			t.id, t.model, t.inputTokens = src.Message.ID, src.Message.Model, src.Message.Usage.InputTokens
+		}
+		t.created = time.Now().Unix()
+		empty := ""
+		return t.chunk(openAIDelta{Role: "assistant", Content: &empty}, nil, nil)
+	case "content_block_start":
+		if src.ContentBlock == nil || src.ContentBlock.Type != "tool_use" {
+			return nil, nil
+		}
+		index := len(t.toolCalls)
+		t.toolCalls[src.Index] = index
+		return t.chunk(openAIDelta{ToolCalls: []openAIToolCall{{
+			Index:    index,
+			ID:       src.ContentBlock.ID,
+			Type:     "function",
+			Function: openAIFunction{Name: src.ContentBlock.Name},
+		}}}, nil, nil)
+	case "content_block_delta":
+		if src.Delta == nil {
+			return nil, nil
+		}
+		switch src.Delta.Type {
+		case "text_delta":
+			text := src.Delta.Text
+			return t.chunk(openAIDelta{Content: &text}, nil, nil)
+		case "input_json_delta":
+			index, ok := t.toolCalls[src.Index]
+			if !ok {
+				return nil, nil
+			}
+			return t.chunk(openAIDelta{ToolCalls: []openAIToolCall{{
+				Index:    index,
+				Function: openAIFunction{Arguments: src.Delta.PartialJSON},
+			}}}, nil, nil)
+		}
+		return nil, nil
+	case "message_delta":
+		if src.Delta == nil || src.Delta.StopReason == "" {
+			return nil, nil
+		}
+		finishReason := openAIFinishReason(src.Delta.StopReason)
+		var usage *openAIUsage
+		if src.Usage != nil {
+			usage = &openAIUsage{
+				PromptTokens:     t.inputTokens,
+				CompletionTokens: src.Usage.OutputTokens,
+				TotalTokens:      t.inputTokens + src.Usage.OutputTokens,
+			}
+		}
+		return t.chunk(openAIDelta{}, &finishReason, usage)
+	case "message_stop":
+		return []StreamEvent{{Data: []byte(openAIDoneData)}}, nil
+	case "error":
+		if src.Error == nil {
+			return nil, ErrUnsupportedEvent
+		}
+		data, err := json.Marshal(map[string]any{
+			"error": map[string]string{"type": src.Error.Type, "message": src.Error.Message},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []StreamEvent{{Data: data}}, nil
+	case "ping", "content_block_stop":
+		return nil, nil
+	default:
+		return nil, ErrUnsupportedEvent
+	}
+}
+
+// chunk 编码单个 chat.completion.chunk 事件
+func (t *openAIStreamTransformer) chunk(delta openAIDelta, finishReason *string, usage *openAIUsage) ([]StreamEvent, error) {
+	data, err := json.Marshal(openAIChunk{
+		ID:      t.id,
+		Object:  "chat.completion.chunk",
+		Created: t.created,
+		Model:   t.model,
+		Choices: []openAIChunkChoice{{Delta: delta, FinishReason: finishReason}},
+		Usage:   usage,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []StreamEvent{{Data: data}}, nil
+}
+
+// openAIFinishReason 将 Anthropic 的 stop_reason 映射为 OpenAI 的 finish_reason
+func openAIFinishReason(stopReason string) string {
+	switch stopReason {
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	default:
+		return "stop"
+	}
+}
@@ -0,0 +1,142 @@
+package transform
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// transformEvents 依次转换原始事件，返回输出的每个事件的 data 字段，转换失败的事件按原样输出
+func transformEvents(t *testing.T, transformer StreamTransformer, raws ...string) []string {
+	t.Helper()
+	var out []string
+	for _, raw := range raws {
+		transformed, err := TransformStreamEvent(transformer, []byte(raw))
+		if err != nil {
+			transformed = []byte(raw)
+		}
+		for len(transformed) > 0 {
+			end := indexEventEnd(transformed)
+			event, ok := ParseStreamEvent(transformed[:end])
+			require.True(t, ok)
+			out = append(out, string(event.Data))
+			transformed = transformed[end:]
+		}
+	}
+	return out
+}
+
+// indexEventEnd 返回第一个事件结尾空行之后的位置
+func indexEventEnd(b []byte) int {
+	for i := 0; i+1 < len(b); i++ {
+		if b[i] == '\n' && b[i+1] == '\n' {
+			return i + 2
+		}
+	}
+	return len(b)
+}
+
+// TestParseStreamEvent 测试 SSE 事件解析
+func TestParseStreamEvent(t *testing.T) {
+	event, ok := ParseStreamEvent([]byte("event: message_start\r\nid: 1\r\ndata: {\"a\":1}\r\ndata:{\"b\":2}\r\n\r\n"))
+	require.True(t, ok)
+	assert.Equal(t, "message_start", event.Name)
+	assert.Equal(t, "{\"a\":1}\n{\"b\":2}", string(event.Data))
+
+	_, ok = ParseStreamEvent([]byte(": keep-alive\n\n"))
+	assert.False(t, ok)
+}
+
+// TestNewStreamTransformer 测试按流式响应格式创建转换器
+func TestNewStreamTransformer(t *testing.T) {
+	assert.Nil(t, NewStreamTransformer(""))
+	assert.Nil(t, NewStreamTransformer(constants.StreamFormatPassthrough))
+	assert.Nil(t, NewStreamTransformer("unknown"))
+	assert.NotNil(t, NewStreamTransformer(constants.StreamFormatOpenAI))
+	assert.NotNil(t, NewStreamTransformer(constants.StreamFormatAnthropic))
+
+	RegisterStreamTransformer("custom", func() StreamTransformer { return newOpenAIStreamTransformer() })
+	defer func() {
+		streamTransformersMu.Lock()
+		delete(streamTransformers, "custom")
+		streamTransformersMu.Unlock()
+	}()
+	assert.NotNil(t, NewStreamTransformer("custom"))
+}
+
+// TestOpenAIStreamTransformer 测试 Anthropic 事件转换为 OpenAI 事件
+func TestOpenAIStreamTransformer(t *testing.T) {
+	out := transformEvents(t, NewStreamTransformer(constants.StreamFormatOpenAI),
+		"event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"model\":\"claude\",\"usage\":{\"input_tokens\":10}}}\n\n",
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n",
+		"event: ping\ndata: {\"type\":\"ping\"}\n\n",
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hello\"}}\n\n",
+		"event: content_block_delta\ndata: {not json}\n\n",
+		"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n",
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"tool_use\",\"id\":\"toolu_1\",\"name\":\"get_weather\"}}\n\n",
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{\\\"city\\\":\"}}\n\n",
+		"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"tool_use\"},\"usage\":{\"output_tokens\":5}}\n\n",
+		"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n",
+	)
+	require.Len(t, out, 7)
+
+	var chunks []openAIChunk
+	for _, data := range out[:2] {
+		var chunk openAIChunk
+		require.NoError(t, json.Unmarshal([]byte(data), &chunk))
+		chunks = append(chunks, chunk)
+	}
+	assert.Equal(t, "msg_1", chunks[0].ID)
+	assert.Equal(t, "claude", chunks[0].Model)
+	assert.Equal(t, "chat.completion.chunk", chunks[0].Object)
+	assert.Equal(t, "assistant", chunks[0].Choices[0].Delta.Role)
+	assert.Equal(t, "Hello", *chunks[1].Choices[0].Delta.Content)
+
+	// 解析失败的事件原样转发
+	assert.Equal(t, "{not json}", out[2])
+
+	for _, data := range out[3:6] {
+		var chunk openAIChunk
+		require.NoError(t, json.Unmarshal([]byte(data), &chunk))
+		chunks = append(chunks, chunk)
+	}
+	assert.Equal(t, "toolu_1", chunks[2].Choices[0].Delta.ToolCalls[0].ID)
+	assert.Equal(t, "get_weather", chunks[2].Choices[0].Delta.ToolCalls[0].Function.Name)
+	assert.Equal(t, `{"city":`, chunks[3].Choices[0].Delta.ToolCalls[0].Function.Arguments)
+	assert.Equal(t, "tool_calls", *chunks[4].Choices[0].FinishReason)
+	assert.Equal(t, &openAIUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, chunks[4].Usage)
+
+	assert.Equal(t, "[DONE]", out[6])
+}
+
+// TestAnthropicStreamTransformer 测试 OpenAI 事件转换为 Anthropic 事件
+func TestAnthropicStreamTransformer(t *testing.T) {
+	out := transformEvents(t, NewStreamTransformer(constants.StreamFormatAnthropic),
+		"data: {\"id\":\"chatcmpl-1\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":\"\"},\"finish_reason\":null}]}\n\n",
+		"data: {\"id\":\"chatcmpl-1\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"},\"finish_reason\":null}]}\n\n",
+		"data: {\"type\":\"ping\"}\n\n",
+		"data: {\"id\":\"chatcmpl-1\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"length\"}]}\n\n",
+		"data: [DONE]\n\n",
+	)
+
+	var types []string
+	for _, data := range out {
+		var event map[string]any
+		require.NoError(t, json.Unmarshal([]byte(data), &event))
+		types = append(types, event["type"].(string))
+	}
+	assert.Equal(t, []string{
+		"message_start",
+		"content_block_start",
+		"content_block_delta",
+		"ping",
+		"content_block_stop",
+		"message_delta",
+		"message_stop",
+	}, types)
+	assert.Contains(t, out[2], `"text":"Hi"`)
+	assert.Contains(t, out[5], `"stop_reason":"max_tokens"`)
+}
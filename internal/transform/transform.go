@@ -0,0 +1,155 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/shengyanli1982/llmproxy-go/internal/constants"
+)
+
+// 请求体/响应体转换相关错误定义
+var (
+	ErrInvalidOperation = errors.New("invalid body transform operation")
+	ErrNotObject        = errors.New("transform target is not a JSON object")
+)
+
+// IsJSONContentType 判断 Content-Type 是否为 JSON 类型，包括 application/json 和 +json 后缀的媒体类型
+func IsJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// Apply 按配置顺序对 JSON 文档执行转换操作，返回转换后的 JSON
+// 字段不存在时 rename、delete、unwrap 操作不做任何修改
+// body: 原始 JSON 文档
+// ops: 转换操作配置列表
+func Apply(body []byte, ops []config.BodyTransformConfig) ([]byte, error) {
+	// 使用 json.Number 保留数字的原始精度
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON body: %w", err)
+	}
+
+	for i, op := range ops {
+		var err error
+		if doc, err = applySingle(doc, op); err != nil {
+			return nil, fmt.Errorf("transform %d (%s %s) failed: %w", i, op.Op, op.Field, err)
+		}
+	}
+
+	// 不转义 HTML 字符，避免改变未转换字段的内容
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode JSON body: %w", err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// applySingle 对 JSON 文档执行单个转换操作，返回转换后的文档
+func applySingle(doc interface{}, op config.BodyTransformConfig) (interface{}, error) {
+	path := splitPath(op.Field)
+
+	switch strings.ToLower(op.Op) {
+	case constants.BodyTransformRename:
+		value, found := removeField(doc, path)
+		if !found {
+			return doc, nil
+		}
+		return doc, setField(doc, splitPath(op.To), value)
+	case constants.BodyTransformDefault:
+		if _, found := lookupField(doc, path); found {
+			return doc, nil
+		}
+		return doc, setField(doc, path, op.Value)
+	case constants.BodyTransformDelete:
+		removeField(doc, path)
+		return doc, nil
+	case constants.BodyTransformWrap:
+		wrapped := make(map[string]interface{})
+		if err := setField(wrapped, path, doc); err != nil {
+			return nil, err
+		}
+		return wrapped, nil
+	case constants.BodyTransformUnwrap:
+		if value, found := lookupField(doc, path); found {
+			return value, nil
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidOperation, op.Op)
+	}
+}
+
+// splitPath 将点号分隔的字段路径拆分为路径段
+func splitPath(field string) []string {
+	return strings.Split(field, ".")
+}
+
+// lookupField 查找字段路径对应的值
+func lookupField(doc interface{}, path []string) (interface{}, bool) {
+	current := doc
+	for _, key := range path {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// removeField 删除字段路径对应的字段，返回被删除的值
+func removeField(doc interface{}, path []string) (interface{}, bool) {
+	parent, found := lookupField(doc, path[:len(path)-1])
+	if !found {
+		return nil, false
+	}
+	object, ok := parent.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	key := path[len(path)-1]
+	value, found := object[key]
+	if found {
+		delete(object, key)
+	}
+	return value, found
+}
+
+// setField 设置字段路径对应的值，自动创建不存在的中间对象
+func setField(doc interface{}, path []string, value interface{}) error {
+	object, ok := doc.(map[string]interface{})
+	if !ok {
+		return ErrNotObject
+	}
+
+	for _, key := range path[:len(path)-1] {
+		child, exists := object[key]
+		if !exists {
+			child = make(map[string]interface{})
+			object[key] = child
+		}
+		if object, ok = child.(map[string]interface{}); !ok {
+			return ErrNotObject
+		}
+	}
+
+	object[path[len(path)-1]] = value
+	return nil
+}
@@ -0,0 +1,120 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/shengyanli1982/llmproxy-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestApply 测试单个转换操作
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		ops      []config.BodyTransformConfig
+		expected string
+	}{
+		{
+			name:     "rename field",
+			body:     `{"messages": [{"role": "user"}], "model": "m"}`,
+			ops:      []config.BodyTransformConfig{{Op: "rename", Field: "messages", To: "input.messages"}},
+			expected: `{"input": {"messages": [{"role": "user"}]}, "model": "m"}`,
+		},
+		{
+			name:     "rename missing field",
+			body:     `{"model": "m"}`,
+			ops:      []config.BodyTransformConfig{{Op: "rename", Field: "messages", To: "input"}},
+			expected: `{"model": "m"}`,
+		},
+		{
+			name:     "default sets missing field",
+			body:     `{"model": "m"}`,
+			ops:      []config.BodyTransformConfig{{Op: "default", Field: "params.max_tokens", Value: 1024}},
+			expected: `{"model": "m", "params": {"max_tokens": 1024}}`,
+		},
+		{
+			name:     "default keeps existing field",
+			body:     `{"max_tokens": 16}`,
+			ops:      []config.BodyTransformConfig{{Op: "default", Field: "max_tokens", Value: 1024}},
+			expected: `{"max_tokens": 16}`,
+		},
+		{
+			name:     "delete nested field",
+			body:     `{"model": "m", "metadata": {"user": "alice", "tag": "x"}}`,
+			ops:      []config.BodyTransformConfig{{Op: "delete", Field: "metadata.user"}},
+			expected: `{"model": "m", "metadata": {"tag": "x"}}`,
+		},
+		{
+			name:     "wrap document",
+			body:     `{"model": "m"}`,
+			ops:      []config.BodyTransformConfig{{Op: "wrap", Field: "request.body"}},
+			expected: `{"request": {"body": {"model": "m"}}}`,
+		},
+		{
+			name:     "unwrap document",
+			body:     `{"data": {"id": "1"}, "status": "ok"}`,
+			ops:      []config.BodyTransformConfig{{Op: "unwrap", Field: "data"}},
+			expected: `{"id": "1"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Apply([]byte(tt.body), tt.ops)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(result))
+		})
+	}
+}
+
+// TestApply_RoundTrip 测试请求转换与对应的反向转换组合后还原原始文档
+func TestApply_RoundTrip(t *testing.T) {
+	original := `{"messages": [{"role": "user", "content": "<b>hi</b>"}], "temperature": 0.70000000000000001, "seed": 12345678901234567890}`
+
+	request := []config.BodyTransformConfig{
+		{Op: "rename", Field: "messages", To: "contents"},
+		{Op: "wrap", Field: "payload"},
+	}
+	response := []config.BodyTransformConfig{
+		{Op: "unwrap", Field: "payload"},
+		{Op: "rename", Field: "contents", To: "messages"},
+	}
+
+	transformed, err := Apply([]byte(original), request)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"payload": {"contents": [{"role": "user", "content": "<b>hi</b>"}], "temperature": 0.70000000000000001, "seed": 12345678901234567890}}`, string(transformed))
+
+	restored, err := Apply(transformed, response)
+	require.NoError(t, err)
+	assert.JSONEq(t, original, string(restored))
+
+	// 数字保留原始精度，HTML 字符不被转义
+	assert.Contains(t, string(restored), "12345678901234567890")
+	assert.Contains(t, string(restored), "<b>hi</b>")
+}
+
+// TestApply_Errors 测试无效输入和无法执行的转换
+func TestApply_Errors(t *testing.T) {
+	_, err := Apply([]byte(`not json`), []config.BodyTransformConfig{{Op: "delete", Field: "a"}})
+	assert.Error(t, err)
+
+	_, err = Apply([]byte(`{"a": 1}`), []config.BodyTransformConfig{{Op: "default", Field: "a.b", Value: 1}})
+	assert.ErrorIs(t, err, ErrNotObject)
+
+	_, err = Apply([]byte(`[1, 2]`), []config.BodyTransformConfig{{Op: "default", Field: "a", Value: 1}})
+	assert.ErrorIs(t, err, ErrNotObject)
+
+	_, err = Apply([]byte(`{"a": 1}`), []config.BodyTransformConfig{{Op: "upsert", Field: "a"}})
+	assert.ErrorIs(t, err, ErrInvalidOperation)
+}
+
+// TestIsJSONContentType 测试 JSON 内容类型判断
+func TestIsJSONContentType(t *testing.T) {
+	assert.True(t, IsJSONContentType("application/json"))
+	assert.True(t, IsJSONContentType("Application/JSON; charset=utf-8"))
+	assert.True(t, IsJSONContentType("application/problem+json"))
+	assert.False(t, IsJSONContentType("text/event-stream"))
+	assert.False(t, IsJSONContentType(""))
+}